@@ -0,0 +1,325 @@
+package flame
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+// diff subcommand, compares the call stacks of two collections
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"perfspect/internal/common"
+	"perfspect/internal/report"
+)
+
+const diffCmdName = "diff"
+
+var diffExamples = []string{
+	fmt.Sprintf("  Compare two collections:             $ %s %s %s before_flame.raw after_flame.raw", common.AppName, cmdName, diffCmdName),
+	fmt.Sprintf("  Compare collections of many targets: $ %s %s %s before_dir after_dir", common.AppName, cmdName, diffCmdName),
+	fmt.Sprintf("  Collect and compare on local host:   $ %s %s %s --duration 60", common.AppName, cmdName, diffCmdName),
+	fmt.Sprintf("  Collect and compare on remote host:  $ %s %s %s --target 192.168.1.1 --user fred --key fred_key", common.AppName, cmdName, diffCmdName),
+}
+
+var diffCmd = &cobra.Command{
+	Use:   diffCmdName,
+	Short: "Compare the call stacks of two collections",
+	Long: `Compares the call stacks of two collections, e.g., before and after a change, and renders differential flamegraphs. Frame widths are the samples of a collection and frame colors are the change in the frame's share of the samples, red where the share grew and blue where it shrank. A table lists the functions whose inclusive and exclusive shares changed most.
+
+The collections are two ".raw" files written by the flame command, or two directories of them that are paired by target name. Without arguments, the call stacks are collected from the target(s) twice, before and after the Enter key is pressed.`,
+	Example:       strings.Join(diffExamples, "\n"),
+	RunE:          runDiffCmd,
+	PreRunE:       validateDiffFlags,
+	Args:          cobra.MatchAll(cobra.MaximumNArgs(2), diffArgs),
+	SilenceErrors: true,
+}
+
+var flagDiffTop int
+
+const flagDiffTopName = "top"
+
+func init() {
+	diffCmd.Flags().StringSliceVar(&common.FlagFormat, common.FlagFormatName, []string{report.FormatAll}, "")
+	diffCmd.Flags().IntVar(&flagDiffTop, flagDiffTopName, 20, "")
+	// these flags set the same options as the flame command's flags of the same name
+	diffCmd.Flags().IntVar(&flagDuration, flagDurationName, 30, "")
+	diffCmd.Flags().IntVar(&flagFrequency, flagFrequencyName, 11, "")
+	diffCmd.Flags().IntSliceVar(&flagPids, flagPidsName, nil, "")
+	diffCmd.Flags().IntVar(&flagMaxDepth, flagMaxDepthName, 0, "")
+	diffCmd.Flags().StringVar(&flagMode, flagModeName, report.CallStackModeCPU, "")
+
+	common.AddTargetFlags(diffCmd)
+
+	diffCmd.SetUsageFunc(diffUsageFunc)
+	Cmd.AddCommand(diffCmd)
+}
+
+// diffArgs requires both collections or neither
+func diffArgs(cmd *cobra.Command, args []string) error {
+	if len(args) == 1 {
+		return fmt.Errorf("requires the before and after collections, or neither to collect them")
+	}
+	return nil
+}
+
+func diffUsageFunc(cmd *cobra.Command) error {
+	cmd.Printf("Usage: %s [before after] [flags]\n\n", cmd.CommandPath())
+	cmd.Printf("Examples:\n%s\n\n", cmd.Example)
+	cmd.Println("Arguments:")
+	cmd.Printf("  before, after (optional): \".raw\" files written by the %s command, or directories containing them\n\n", cmdName)
+	cmd.Println("Flags:")
+	for _, group := range getDiffFlagGroups() {
+		cmd.Printf("  %s:\n", group.GroupName)
+		for _, flag := range group.Flags {
+			flagDefault := ""
+			if cmd.Flags().Lookup(flag.Name).DefValue != "" {
+				flagDefault = fmt.Sprintf(" (default: %s)", cmd.Flags().Lookup(flag.Name).DefValue)
+			}
+			cmd.Printf("    --%-20s %s%s\n", flag.Name, flag.Help, flagDefault)
+		}
+	}
+	cmd.Println("\nGlobal Flags:")
+	cmd.Root().PersistentFlags().VisitAll(func(pf *pflag.Flag) {
+		flagDefault := ""
+		if cmd.Root().PersistentFlags().Lookup(pf.Name).DefValue != "" {
+			flagDefault = fmt.Sprintf(" (default: %s)", cmd.Root().PersistentFlags().Lookup(pf.Name).DefValue)
+		}
+		cmd.Printf("  --%-20s %s%s\n", pf.Name, pf.Usage, flagDefault)
+	})
+	return nil
+}
+
+func getDiffFlagGroups() []common.FlagGroup {
+	var groups []common.FlagGroup
+	flags := []common.Flag{
+		{
+			Name: flagDiffTopName,
+			Help: "number of functions in the table of changes (0 = all)",
+		},
+		{
+			Name: common.FlagFormatName,
+			Help: fmt.Sprintf("choose output format(s) from: %s", strings.Join(append([]string{report.FormatAll}, report.FormatHtml, report.FormatTxt, report.FormatJson), ", ")),
+		},
+	}
+	groups = append(groups, common.FlagGroup{
+		GroupName: "Options",
+		Flags:     flags,
+	})
+	flags = []common.Flag{
+		{
+			Name: flagDurationName,
+			Help: "number of seconds to run each collection",
+		},
+		{
+			Name: flagModeName,
+			Help: "call stack collection mode: cpu (on-CPU samples), offcpu (blocked time of native stacks), wall, alloc or lock (wall-clock, allocation or lock contention samples of java stacks)",
+		},
+		{
+			Name: flagFrequencyName,
+			Help: "number of samples taken per second",
+		},
+		{
+			Name: flagPidsName,
+			Help: "comma separated list of PIDs. If not specified, all PIDs will be collected",
+		},
+		{
+			Name: flagMaxDepthName,
+			Help: "maximum render depth of call stack in flamegraph (0 = no limit)",
+		},
+	}
+	groups = append(groups, common.FlagGroup{
+		GroupName: "Collection Options",
+		Flags:     flags,
+	})
+	groups = append(groups, common.GetTargetFlagGroup())
+	return groups
+}
+
+func validateDiffFlags(cmd *cobra.Command, args []string) error {
+	// validate format options
+	for _, format := range common.FlagFormat {
+		formatOptions := append([]string{report.FormatAll}, report.FormatHtml, report.FormatTxt, report.FormatJson)
+		if !slices.Contains(formatOptions, format) {
+			return common.FlagValidationError(cmd, fmt.Sprintf("format options are: %s", strings.Join(formatOptions, ", ")))
+		}
+	}
+	// validate the collections
+	for _, path := range args {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return common.FlagValidationError(cmd, fmt.Sprintf("input file %s does not exist", path))
+		}
+	}
+	if len(args) > 0 {
+		for _, flagName := range []string{flagDurationName, flagFrequencyName, flagPidsName, flagMaxDepthName, flagModeName, "target", "targets"} {
+			if cmd.Flags().Lookup(flagName).Changed {
+				return common.FlagValidationError(cmd, fmt.Sprintf("--%s applies only when the collections are collected, not when they are provided", flagName))
+			}
+		}
+	}
+	if flagDiffTop < 0 {
+		return common.FlagValidationError(cmd, "top must be greater than or equal to 0")
+	}
+	if flagDuration <= 0 {
+		return common.FlagValidationError(cmd, "duration must be greater than 0")
+	}
+	if flagFrequency <= 0 {
+		return common.FlagValidationError(cmd, "frequency must be greater than 0")
+	}
+	for _, pid := range flagPids {
+		if pid < 0 {
+			return common.FlagValidationError(cmd, "PID must be greater than or equal to 0")
+		}
+	}
+	if flagMaxDepth < 0 {
+		return common.FlagValidationError(cmd, "max depth must be greater than or equal to 0")
+	}
+	if !slices.Contains(report.CallStackModes, flagMode) {
+		return common.FlagValidationError(cmd, fmt.Sprintf("mode options are: %s", strings.Join(report.CallStackModes, ", ")))
+	}
+	// common target flags
+	if err := common.ValidateTargetFlags(cmd); err != nil {
+		return common.FlagValidationError(cmd, err.Error())
+	}
+	return nil
+}
+
+func runDiffCmd(cmd *cobra.Command, args []string) error {
+	// appContext is the application context that holds common data and resources.
+	appContext := cmd.Parent().Context().Value(common.AppContext{}).(common.AppContext)
+	outputDir := appContext.OutputDir
+	var beforePaths, afterPaths []string
+	if len(args) == 2 {
+		beforePaths, afterPaths = []string{args[0]}, []string{args[1]}
+	} else {
+		var err error
+		if beforePaths, err = collectCallStacks(cmd, outputDir, "flame_before"); err != nil {
+			return err
+		}
+		fmt.Print("\nPress Enter to collect the call stacks after the change...")
+		_, _ = bufio.NewReader(os.Stdin).ReadString('\n')
+		fmt.Println()
+		if afterPaths, err = collectCallStacks(cmd, outputDir, "flame_after"); err != nil {
+			return err
+		}
+		fmt.Println()
+	}
+	err := diffCallStacks(appContext, beforePaths, afterPaths)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		slog.Error(err.Error())
+		cmd.SilenceUsage = true
+	}
+	return err
+}
+
+// collectCallStacks collects the call stacks from the target(s), the paths to the raw files of the
+// collection are returned
+func collectCallStacks(cmd *cobra.Command, outputDir string, reportNamePost string) ([]string, error) {
+	reportingCommand := common.ReportingCommand{
+		Cmd:            cmd,
+		ReportNamePost: reportNamePost,
+		ScriptParams:   scriptParams(false),
+		TableNames:     []string{report.CallStackFrequencyTableName},
+	}
+	if err := reportingCommand.Run(); err != nil {
+		return nil, err
+	}
+	return filepath.Glob(filepath.Join(outputDir, "*_"+reportNamePost+".raw"))
+}
+
+// diffCallStacks compares the call stacks of the before and after collections and writes the
+// reports. Collections of a single target are compared regardless of their target names, others
+// are paired by target name.
+func diffCallStacks(appContext common.AppContext, beforePaths, afterPaths []string) error {
+	before, err := readRawReports(beforePaths)
+	if err != nil {
+		return err
+	}
+	after, err := readRawReports(afterPaths)
+	if err != nil {
+		return err
+	}
+	var targetNames []string
+	var allTargetsTableValues [][]report.TableValues
+	for _, beforeReport := range before {
+		var afterReport *report.RawReport
+		targetName := beforeReport.TargetName
+		if len(before) == 1 && len(after) == 1 {
+			afterReport = &after[0]
+			if after[0].TargetName != targetName {
+				targetName += "_vs_" + after[0].TargetName
+			}
+		} else {
+			for i := range after {
+				if after[i].TargetName == targetName {
+					afterReport = &after[i]
+					break
+				}
+			}
+		}
+		if afterReport == nil {
+			fmt.Fprintf(os.Stderr, "Warning: target %s is not in the after collection\n", targetName)
+			slog.Warn("target not in after collection", slog.String("target", targetName))
+			continue
+		}
+		tableValues, err := report.DiffCallStacks(
+			report.GetValuesForTable(report.CallStackFrequencyTableName, beforeReport.ScriptOutputs),
+			report.GetValuesForTable(report.CallStackFrequencyTableName, afterReport.ScriptOutputs),
+			flagDiffTop)
+		if err != nil {
+			return fmt.Errorf("failed to compare the call stacks of %s: %w", targetName, err)
+		}
+		targetNames = append(targetNames, targetName)
+		allTargetsTableValues = append(allTargetsTableValues, []report.TableValues{tableValues, common.GetPerfspectTableValues(appContext)})
+	}
+	if len(targetNames) == 0 {
+		return fmt.Errorf("no targets are in both collections")
+	}
+	err = common.CreateOutputDir(appContext.OutputDir)
+	if err != nil {
+		return err
+	}
+	formats := common.FlagFormat
+	if slices.Contains(formats, report.FormatAll) {
+		formats = []string{report.FormatHtml, report.FormatTxt, report.FormatJson}
+	}
+	reportFilePaths, err := common.WriteReports(appContext.OutputDir, "flame_diff", targetNames, allTargetsTableValues, formats)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Report files:")
+	for _, reportFilePath := range reportFilePaths {
+		fmt.Printf("  %s\n", reportFilePath)
+	}
+	return nil
+}
+
+// readRawReports reads the raw reports at the paths, each must have call stacks
+func readRawReports(paths []string) ([]report.RawReport, error) {
+	var rawReports []report.RawReport
+	for _, path := range paths {
+		reports, err := report.ReadRawReports(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, rawReport := range reports {
+			if !slices.Contains(rawReport.TableNames, report.CallStackFrequencyTableName) {
+				return nil, fmt.Errorf("%s has no call stacks, it isn't from the %s command", path, cmdName)
+			}
+		}
+		rawReports = append(rawReports, reports...)
+	}
+	if len(rawReports) == 0 {
+		return nil, fmt.Errorf("no raw files found in %s", strings.Join(paths, ", "))
+	}
+	return rawReports, nil
+}
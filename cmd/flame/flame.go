@@ -24,8 +24,14 @@ var examples = []string{
 	fmt.Sprintf("  Flamegraph from local host:       $ %s %s", common.AppName, cmdName),
 	fmt.Sprintf("  Flamegraph from remote target:    $ %s %s --target 192.168.1.1 --user fred --key fred_key", common.AppName, cmdName),
 	fmt.Sprintf("  Flamegraph from multiple targets: $ %s %s --targets targets.yaml", common.AppName, cmdName),
+	fmt.Sprintf("  Off-CPU flamegraph of a process:  $ %s %s --mode offcpu --pids 1234", common.AppName, cmdName),
+	fmt.Sprintf("  Flamegraph broken down by CPU:    $ %s %s --per-cpu", common.AppName, cmdName),
+	fmt.Sprintf("  Compare two flamegraphs:          $ %s %s %s before_flame.raw after_flame.raw", common.AppName, cmdName, diffCmdName),
 }
 
+// formatOptions are the report formats of the flame command
+var formatOptions = append([]string{report.FormatAll, report.FormatHtml, report.FormatTxt, report.FormatJson}, report.ProfileFormatOptions...)
+
 var Cmd = &cobra.Command{
 	Use:           cmdName,
 	Short:         "Generate flamegraphs from target(s)",
@@ -44,6 +50,8 @@ var (
 	flagPids            []int
 	flagNoSystemSummary bool
 	flagMaxDepth        int
+	flagMode            string
+	flagPerCPU          bool
 )
 
 const (
@@ -52,6 +60,8 @@ const (
 	flagPidsName            = "pids"
 	flagNoSystemSummaryName = "no-summary"
 	flagMaxDepthName        = "max-depth"
+	flagModeName            = "mode"
+	flagPerCPUName          = "per-cpu"
 )
 
 func init() {
@@ -62,6 +72,8 @@ func init() {
 	Cmd.Flags().IntSliceVar(&flagPids, flagPidsName, nil, "")
 	Cmd.Flags().BoolVar(&flagNoSystemSummary, flagNoSystemSummaryName, false, "")
 	Cmd.Flags().IntVar(&flagMaxDepth, flagMaxDepthName, 0, "")
+	Cmd.Flags().StringVar(&flagMode, flagModeName, report.CallStackModeCPU, "")
+	Cmd.Flags().BoolVar(&flagPerCPU, flagPerCPUName, false, "")
 
 	common.AddTargetFlags(Cmd)
 
@@ -71,6 +83,13 @@ func init() {
 func usageFunc(cmd *cobra.Command) error {
 	cmd.Printf("Usage: %s [flags]\n\n", cmd.CommandPath())
 	cmd.Printf("Examples:\n%s\n\n", cmd.Example)
+	cmd.Println("Commands:")
+	for _, subCmd := range cmd.Commands() {
+		if subCmd.IsAvailableCommand() {
+			cmd.Printf("  %-22s %s\n", subCmd.Name(), subCmd.Short)
+		}
+	}
+	cmd.Println()
 	cmd.Println("Flags:")
 	for _, group := range getFlagGroups() {
 		cmd.Printf("  %s:\n", group.GroupName)
@@ -100,6 +119,10 @@ func getFlagGroups() []common.FlagGroup {
 			Name: flagDurationName,
 			Help: "number of seconds to run the collection",
 		},
+		{
+			Name: flagModeName,
+			Help: "call stack collection mode: cpu (on-CPU samples), offcpu (blocked time of native stacks), wall, alloc or lock (wall-clock, allocation or lock contention samples of java stacks)",
+		},
 		{
 			Name: flagFrequencyName,
 			Help: "number of samples taken per second",
@@ -110,12 +133,16 @@ func getFlagGroups() []common.FlagGroup {
 		},
 		{
 			Name: common.FlagFormatName,
-			Help: fmt.Sprintf("choose output format(s) from: %s", strings.Join(append([]string{report.FormatAll}, report.FormatHtml, report.FormatTxt, report.FormatJson), ", ")),
+			Help: fmt.Sprintf("choose output format(s) from: %s. The profile formats, %s, aren't included in %s, %s writes a file per process", strings.Join(formatOptions, ", "), strings.Join(report.ProfileFormatOptions, ", "), report.FormatAll, report.FormatFolded),
 		},
 		{
 			Name: flagMaxDepthName,
 			Help: "maximum render depth of call stack in flamegraph (0 = no limit)",
 		},
+		{
+			Name: flagPerCPUName,
+			Help: "break the native flamegraph down by CPU (cpu mode only). The per-CPU flamegraph is built from the frame pointer stacks only, so stacks that need DWARF unwinding are truncated",
+		},
 		{
 			Name: flagNoSystemSummaryName,
 			Help: "do not include system summary table in report",
@@ -142,7 +169,6 @@ func getFlagGroups() []common.FlagGroup {
 func validateFlags(cmd *cobra.Command, args []string) error {
 	// validate format options
 	for _, format := range common.FlagFormat {
-		formatOptions := append([]string{report.FormatAll}, report.FormatHtml, report.FormatTxt, report.FormatJson)
 		if !slices.Contains(formatOptions, format) {
 			return common.FlagValidationError(cmd, fmt.Sprintf("format options are: %s", strings.Join(formatOptions, ", ")))
 		}
@@ -167,6 +193,12 @@ func validateFlags(cmd *cobra.Command, args []string) error {
 	if flagMaxDepth < 0 {
 		return common.FlagValidationError(cmd, "max depth must be greater than or equal to 0")
 	}
+	if !slices.Contains(report.CallStackModes, flagMode) {
+		return common.FlagValidationError(cmd, fmt.Sprintf("mode options are: %s", strings.Join(report.CallStackModes, ", ")))
+	}
+	if flagPerCPU && flagMode != report.CallStackModeCPU {
+		return common.FlagValidationError(cmd, fmt.Sprintf("--%s requires --%s %s", flagPerCPUName, flagModeName, report.CallStackModeCPU))
+	}
 	// common target flags
 	if err := common.ValidateTargetFlags(cmd); err != nil {
 		return common.FlagValidationError(cmd, err.Error())
@@ -180,16 +212,37 @@ func runCmd(cmd *cobra.Command, args []string) error {
 		tableNames = append(tableNames, report.BriefSysSummaryTableName)
 	}
 	tableNames = append(tableNames, report.CallStackFrequencyTableName)
+	if isTimelineNeeded() {
+		tableNames = append(tableNames, report.CallStackTimelineTableName)
+	}
 	reportingCommand := common.ReportingCommand{
 		Cmd:            cmd,
 		ReportNamePost: "flame",
-		ScriptParams: map[string]string{
-			"Frequency": strconv.Itoa(flagFrequency),
-			"Duration":  strconv.Itoa(flagDuration),
-			"PIDs":      strings.Join(util.IntSliceToStringSlice(flagPids), ","),
-			"MaxDepth":  strconv.Itoa(flagMaxDepth),
-		},
-		TableNames: tableNames,
+		ScriptParams:   scriptParams(isTimelineNeeded()),
+		TableNames:     tableNames,
 	}
 	return reportingCommand.Run()
 }
+
+// isTimelineNeeded returns true when the timeline of the native samples is collected, i.e., in cpu
+// mode when the native stacks are broken down by CPU or the timeline is rendered in the HTML report
+func isTimelineNeeded() bool {
+	if flagMode != report.CallStackModeCPU {
+		return false
+	}
+	return flagPerCPU || slices.Contains(common.FlagFormat, report.FormatAll) || slices.Contains(common.FlagFormat, report.FormatHtml)
+}
+
+// scriptParams are the parameters of the call stack collection script, the timeline of the native
+// samples is collected when timeline is true
+func scriptParams(timeline bool) map[string]string {
+	return map[string]string{
+		"Frequency": strconv.Itoa(flagFrequency),
+		"Duration":  strconv.Itoa(flagDuration),
+		"PIDs":      strings.Join(util.IntSliceToStringSlice(flagPids), ","),
+		"MaxDepth":  strconv.Itoa(flagMaxDepth),
+		"Mode":      flagMode,
+		"PerCPU":    strconv.FormatBool(flagPerCPU),
+		"Timeline":  strconv.FormatBool(timeline),
+	}
+}
@@ -0,0 +1,133 @@
+package metrics
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+// analysis of which metrics can be computed on a target, done before collection
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	capabilityComputed     = "computed"
+	capabilityApproximated = "approximated"
+	capabilityImpossible   = "impossible"
+	capabilitySkipped      = "skipped"
+)
+
+// metricCapability describes whether a metric can be computed on the target and why not
+type metricCapability struct {
+	Metric string
+	Status string // computed, approximated, impossible, or skipped
+	Reason string // why the metric is approximated, impossible, or skipped
+}
+
+// getCollectionBlocker returns the reason that no metrics can be computed on the target, or an
+// empty string if metrics can be computed. pmuBusy describes the PMU counters that are in use by
+// another tool, if any.
+func getCollectionBlocker(metadata Metadata, pmuBusy string) string {
+	if pmuBusy != "" {
+		return "PMU in use by another tool: " + pmuBusy
+	}
+	if !metadata.SupportsInstructions {
+		reason := "instructions event not supported"
+		if flagNoRoot && metadata.PerfEventParanoid > 0 {
+			reason += fmt.Sprintf(", perf_event_paranoid is %d, set it to 0 or run without --%s", metadata.PerfEventParanoid, flagNoRootName)
+		} else if metadata.Virtualized {
+			reason += ", PMU not exposed by hypervisor"
+		}
+		return reason
+	}
+	return ""
+}
+
+// analyzeMetricCapabilities determines, for each loaded metric, if it will be computed, approximated,
+// or if it is impossible to compute on the target, with the reason for the latter two. Opt-in
+// metrics that weren't requested, i.e., the transaction rate metrics, are skipped.
+func analyzeMetricCapabilities(loadedMetrics []MetricDefinition, uncollectableEvents map[string]string, metadata Metadata, blocker string) (capabilities []metricCapability) {
+	approximateTMA := flagMetricFilePath == "" && usesNoFixedTMADefinitions(metadata)
+	for _, metric := range loadedMetrics {
+		capability := metricCapability{Metric: metric.Name, Status: capabilityComputed}
+		if blocker != "" {
+			capability.Status = capabilityImpossible
+			capability.Reason = blocker
+		} else if isTransactionRateMissing(metric.Expression) {
+			capability.Status = capabilitySkipped
+			capability.Reason = fmt.Sprintf("transaction rate not provided, see --%s", flagTransactionRateName)
+		} else if event, reason := findUncollectableEvent(abbreviateEventName(metric.Expression), uncollectableEvents); event != "" {
+			capability.Status = capabilityImpossible
+			capability.Reason = fmt.Sprintf("%s: %s", event, reason)
+		} else if approximateTMA && strings.HasPrefix(metric.Name, "TMA_") {
+			capability.Status = capabilityApproximated
+			capability.Reason = "fixed-counter TMA not supported, derived from general-purpose counters"
+		}
+		capabilities = append(capabilities, capability)
+	}
+	// attributed power metrics are added to the loaded metrics during collection
+	if energy := newEnergySampler(metadata); energy != nil && blocker == "" {
+		reason := "share of system-wide power, in proportion to cycles"
+		capabilities = append(capabilities, metricCapability{Metric: metricPackagePowerAttributed, Status: capabilityApproximated, Reason: reason})
+		if energy.dram {
+			capabilities = append(capabilities, metricCapability{Metric: metricDRAMPowerAttributed, Status: capabilityApproximated, Reason: reason})
+		}
+	}
+	return
+}
+
+// getImpossibleMetrics returns the names of the metrics that can't be computed, skipped metrics
+// aren't included
+func getImpossibleMetrics(capabilities []metricCapability) (names []string) {
+	for _, capability := range capabilities {
+		if capability.Status == capabilityImpossible {
+			names = append(names, capability.Metric)
+		}
+	}
+	return
+}
+
+// getCapabilitiesTxt formats the capability analysis as a table preceded by a count of the metrics
+// in each status
+func getCapabilitiesTxt(targetName string, capabilities []metricCapability) string {
+	counts := make(map[string]int)
+	for _, capability := range capabilities {
+		counts[capability.Status]++
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Metric capabilities on %s: %d %s, %d %s, %d %s, %d %s\n\n", targetName,
+		counts[capabilityComputed], capabilityComputed,
+		counts[capabilityApproximated], capabilityApproximated,
+		counts[capabilityImpossible], capabilityImpossible,
+		counts[capabilitySkipped], capabilitySkipped)
+	fmt.Fprintf(&sb, "%-70s %-13s %s\n", "metric", "status", "reason")
+	fmt.Fprintf(&sb, "%-70s %-13s %s\n", "------------------------", "------------", "------------------------")
+	for _, capability := range capabilities {
+		fmt.Fprintf(&sb, "%-70s %-13s %s\n", capability.Metric, capability.Status, capability.Reason)
+	}
+	return sb.String()
+}
+
+// writeCapabilities writes the capability analysis to text and JSON files in the output directory
+func writeCapabilities(localOutputDir string, targetName string, capabilities []metricCapability) (filesWritten []string, err error) {
+	jsonBytes, err := json.MarshalIndent(capabilities, "", "  ")
+	if err != nil {
+		return
+	}
+	outputs := map[string][]byte{
+		"txt":  []byte(getCapabilitiesTxt(targetName, capabilities)),
+		"json": jsonBytes,
+	}
+	for _, extension := range []string{"txt", "json"} {
+		filename := filepath.Join(localOutputDir, targetName+"_metrics_capabilities."+extension)
+		if err = os.WriteFile(filename, outputs[extension], 0644); err != nil { // #nosec G306
+			err = fmt.Errorf("failed to write capability analysis to file: %w", err)
+			return
+		}
+		filesWritten = append(filesWritten, filename)
+	}
+	return
+}
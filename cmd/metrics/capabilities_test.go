@@ -0,0 +1,72 @@
+package metrics
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeMetricCapabilities(t *testing.T) {
+	metadata := Metadata{Microarchitecture: "SPR", SupportsInstructions: true, Virtualized: true}
+	metrics := []MetricDefinition{
+		{Name: "CPI", Expression: "[cpu-cycles] / [instructions]"},
+		{Name: "memory bandwidth", Expression: "[UNC_M_CAS_COUNT_SCH0.RD] * 64"},
+		{Name: "cycles per txn", Expression: "[cpu-cycles] / [TXN]"},
+		{Name: "TMA_Frontend_Bound(%)", Expression: "100 * [IDQ_BUBBLES.CORE] / [cpu-cycles]"},
+	}
+	uncollectable := map[string]string{"UNCMCC0.RD": "no uncore PMU found, restricted by hypervisor"}
+
+	capabilities := analyzeMetricCapabilities(metrics, uncollectable, metadata, "")
+	require.Len(t, capabilities, 4)
+	assert.Equal(t, metricCapability{Metric: "CPI", Status: capabilityComputed}, capabilities[0])
+	// event names in expressions are abbreviated to match the uncollectable event names
+	assert.Equal(t, capabilityImpossible, capabilities[1].Status)
+	assert.Equal(t, "UNCMCC0.RD: no uncore PMU found, restricted by hypervisor", capabilities[1].Reason)
+	// the transaction rate metrics are opt-in, so they are skipped rather than impossible
+	assert.Equal(t, capabilitySkipped, capabilities[2].Status)
+	assert.Contains(t, capabilities[2].Reason, "--txnrate")
+	// SPR without fixed-counter TMA uses the alternate TMA definitions
+	assert.Equal(t, capabilityApproximated, capabilities[3].Status)
+	assert.Equal(t, []string{"memory bandwidth"}, getImpossibleMetrics(capabilities))
+
+	flagTransactionRate = 100
+	capabilities = analyzeMetricCapabilities(metrics, uncollectable, metadata, "")
+	flagTransactionRate = 0
+	assert.Equal(t, capabilityComputed, capabilities[2].Status)
+
+	// nothing can be computed while the PMU is busy
+	blocker := getCollectionBlocker(metadata, "active counter(s) 0xc1")
+	assert.Equal(t, "PMU in use by another tool: active counter(s) 0xc1", blocker)
+	capabilities = analyzeMetricCapabilities(metrics, uncollectable, metadata, blocker)
+	assert.Len(t, getImpossibleMetrics(capabilities), 4)
+	assert.Equal(t, blocker, capabilities[0].Reason)
+
+	flagNoRoot = true
+	defer func() { flagNoRoot = false }()
+	assert.Contains(t, getCollectionBlocker(Metadata{PerfEventParanoid: 2}, ""), "perf_event_paranoid is 2")
+	assert.Empty(t, getCollectionBlocker(Metadata{SupportsInstructions: true}, ""))
+}
+
+func TestWriteCapabilities(t *testing.T) {
+	dir := t.TempDir()
+	capabilities := []metricCapability{
+		{Metric: "CPI", Status: capabilityComputed},
+		{Metric: "memory bandwidth", Status: capabilityImpossible, Reason: "UNCMCC0.RD: no uncore PMU found"},
+	}
+	files, err := writeCapabilities(dir, "host", capabilities)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	txt, err := os.ReadFile(filepath.Join(dir, "host_metrics_capabilities.txt"))
+	require.NoError(t, err)
+	assert.Contains(t, string(txt), "Metric capabilities on host: 1 computed, 0 approximated, 1 impossible, 0 skipped\n")
+	assert.Contains(t, string(txt), "impossible    UNCMCC0.RD: no uncore PMU found\n")
+	jsonBytes, err := os.ReadFile(filepath.Join(dir, "host_metrics_capabilities.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(jsonBytes), `"Status": "impossible"`)
+}
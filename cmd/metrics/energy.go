@@ -0,0 +1,145 @@
+package metrics
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+// attribution of system energy to processes and threads
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	energyPackageEvent = "power/energy-pkg/"
+	energyDRAMEvent    = "power/energy-ram/"
+	energyCyclesEvent  = "cpu-cycles"
+)
+
+const (
+	metricPackagePowerAttributed = "package power attributed (watts)"
+	metricDRAMPowerAttributed    = "DRAM power attributed (watts)"
+)
+
+// attributedPowerDefinitions describe the attributed power metrics, which are added to the metrics
+// during collection rather than being defined in the metric definition files
+var attributedPowerDefinitions = []MetricDefinition{
+	{Name: metricPackagePowerAttributed, Unit: "W", Category: "Power", Description: "Package power attributed in proportion to the share of the system's cycles. In process scope, this is the power of all collected processes combined. In thread scope, it is the power of each thread."},
+	{Name: metricDRAMPowerAttributed, Unit: "W", Category: "Power", Description: "DRAM power attributed in proportion to the share of the system's cycles. In process scope, this is the power of all collected processes combined. In thread scope, it is the power of each thread."},
+}
+
+// energySampler tracks system-wide package power, DRAM power, and cycles measured by a companion
+// perf process while collecting at process or thread scope, where RAPL events can't be collected.
+// The most recent rates are used to attribute power to the collected processes, combined, or to
+// each thread based on their share of the system's cycles.
+type energySampler struct {
+	lock             sync.Mutex
+	dram             bool               // DRAM energy is measured
+	values           map[string]float64 // event values received for the current interval
+	previousInterval float64
+	packageWatts     float64
+	dramWatts        float64
+	cyclesPerSecond  float64
+	valid            bool
+}
+
+// newEnergySampler returns an energySampler if energy attribution is supported in the current scope
+// on the target, otherwise nil
+func newEnergySampler(metadata Metadata) *energySampler {
+	if flagScope != scopeProcess && flagScope != scopeThread {
+		return nil
+	}
+	if !strings.Contains(metadata.PerfSupportedEvents, energyPackageEvent) {
+		return nil
+	}
+	return &energySampler{
+		dram:   strings.Contains(metadata.PerfSupportedEvents, energyDRAMEvent),
+		values: make(map[string]float64),
+	}
+}
+
+// events returns the events collected by the companion perf process
+func (s *energySampler) events() []string {
+	events := []string{energyPackageEvent}
+	if s.dram {
+		events = append(events, energyDRAMEvent)
+	}
+	return append(events, energyCyclesEvent)
+}
+
+// getScript returns a script that runs the companion perf process alongside the given perf command
+// and stops it when the perf command exits. The companion's events are written to stdout to keep
+// them separate from the perf command's events on stderr. The script exits with the perf command's
+// exit code.
+func (s *energySampler) getScript(perfPath string, perfCommand string) string {
+	return fmt.Sprintf(`%s stat -I %d -j -a -e '%s' 2>&1 >/dev/null &
+energy_pid=$!
+%s
+status=$?
+kill -INT $energy_pid 2>/dev/null
+exit $status
+`, perfPath, flagPerfPrintInterval*1000, strings.Join(s.events(), ","), perfCommand)
+}
+
+// addLine parses a line of the companion perf's output. The rates are updated when the values of
+// all events for an interval have been received.
+func (s *energySampler) addLine(line string) {
+	var event Event
+	if err := json.Unmarshal([]byte(line), &event); err != nil || event.Event == "" {
+		return
+	}
+	value, err := strconv.ParseFloat(event.CounterValue, 64)
+	if err != nil {
+		return // e.g., "<not counted>"
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.values[event.Event] = value
+	if len(s.values) < len(s.events()) {
+		return
+	}
+	elapsed := event.Interval - s.previousInterval
+	s.previousInterval = event.Interval
+	values := s.values
+	s.values = make(map[string]float64)
+	if elapsed <= 0 {
+		return
+	}
+	s.packageWatts = values[energyPackageEvent] / elapsed
+	s.dramWatts = values[energyDRAMEvent] / elapsed
+	s.cyclesPerSecond = values[energyCyclesEvent] / elapsed
+	s.valid = true
+}
+
+// getAttributedPowerMetrics returns metrics that attribute the system's package and DRAM power to
+// the frame in proportion to its share of the system's cycles. In process scope, perf aggregates
+// the events of all collected processes into one frame, so the power is that of the group of
+// processes. In thread scope, each thread has its own frame.
+func (s *energySampler) getAttributedPowerMetrics(frame EventFrame, previousTimestamp float64) (metrics []Metric) {
+	packagePower := newMetric(attributedPowerDefinitions[0])
+	dramPower := newMetric(attributedPowerDefinitions[1])
+	s.lock.Lock()
+	packageWatts, dramWatts, cyclesPerSecond, valid := s.packageWatts, s.dramWatts, s.cyclesPerSecond, s.valid
+	s.lock.Unlock()
+	cycles := math.NaN()
+	for _, group := range frame.EventGroups {
+		if value, ok := group.EventValues[energyCyclesEvent]; ok && !math.IsNaN(value) {
+			cycles = value
+			break
+		}
+	}
+	if valid && !math.IsNaN(cycles) && cyclesPerSecond > 0 && frame.Timestamp > previousTimestamp {
+		share := min(cycles/(frame.Timestamp-previousTimestamp)/cyclesPerSecond, 1)
+		packagePower.Value = packageWatts * share
+		dramPower.Value = dramWatts * share
+	}
+	metrics = append(metrics, packagePower)
+	if s.dram {
+		metrics = append(metrics, dramPower)
+	}
+	return
+}
@@ -0,0 +1,48 @@
+package metrics
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnergySampler(t *testing.T) {
+	flagScope = scopeProcess
+	defer func() { flagScope = scopeSystem }()
+	assert.Nil(t, newEnergySampler(Metadata{PerfSupportedEvents: "cpu-cycles"}))
+	energy := newEnergySampler(Metadata{PerfSupportedEvents: "power/energy-pkg/ power/energy-ram/ cpu-cycles"})
+	require.NotNil(t, energy)
+	assert.Equal(t, []string{energyPackageEvent, energyDRAMEvent, energyCyclesEvent}, energy.events())
+
+	frame := EventFrame{
+		Timestamp: 10,
+		EventGroups: []EventGroup{
+			{GroupID: 0, EventValues: map[string]float64{"cpu-cycles": 2.5e10, "instructions": 1e10}},
+		},
+	}
+	// no system energy received yet
+	metrics := energy.getAttributedPowerMetrics(frame, 5)
+	require.Len(t, metrics, 2)
+	assert.True(t, math.IsNaN(metrics[0].Value))
+
+	energy.addLine(`{"interval" : 5.000, "counter-value" : "1000.0", "unit" : "Joules", "event" : "power/energy-pkg/"}`)
+	energy.addLine(`{"interval" : 5.000, "counter-value" : "<not counted>", "unit" : "Joules", "event" : "power/energy-ram/"}`)
+	energy.addLine(`not json`)
+	// incomplete interval
+	assert.True(t, math.IsNaN(energy.getAttributedPowerMetrics(frame, 5)[0].Value))
+	energy.addLine(`{"interval" : 5.000, "counter-value" : "250.0", "unit" : "Joules", "event" : "power/energy-ram/"}`)
+	energy.addLine(`{"interval" : 5.000, "counter-value" : "1e11", "unit" : "", "event" : "cpu-cycles"}`)
+	// 200 W package, 50 W DRAM, process has 25% of the system's cycles
+	metrics = energy.getAttributedPowerMetrics(frame, 5)
+	assert.Equal(t, Metric{Name: metricPackagePowerAttributed, Value: 50, Unit: "W", Category: "Power"}, metrics[0])
+	assert.Equal(t, Metric{Name: metricDRAMPowerAttributed, Value: 12.5, Unit: "W", Category: "Power"}, metrics[1])
+
+	script := energy.getScript("perf", "perf stat -I 5000 -j -p 1234")
+	assert.Contains(t, script, "perf stat -I 5000 -j -a -e 'power/energy-pkg/,power/energy-ram/,cpu-cycles' 2>&1 >/dev/null &")
+	assert.Contains(t, script, "\nperf stat -I 5000 -j -p 1234\nstatus=$?\n")
+}
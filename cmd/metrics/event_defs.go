@@ -10,13 +10,12 @@ import (
 	"fmt"
 	"io/fs"
 	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
 	"regexp"
 	"slices"
 	"strings"
-
-	mapset "github.com/deckarep/golang-set/v2"
 )
 
 // EventDefinition represents a single perf event
@@ -29,9 +28,33 @@ type EventDefinition struct {
 // GroupDefinition represents a group of perf events
 type GroupDefinition []EventDefinition
 
+// getDefinitionUarch returns the short microarchitecture name used to find the definition files
+func getDefinitionUarch(metadata Metadata) string {
+	uarch := strings.ToLower(strings.Split(metadata.Microarchitecture, "_")[0])
+	return strings.Split(uarch, " ")[0]
+}
+
+// usesNoFixedTMADefinitions checks if the alternate event and metric definitions, which don't use the
+// TMA fixed counters, are used on the target
+func usesNoFixedTMADefinitions(metadata Metadata) bool {
+	uarch := getDefinitionUarch(metadata)
+	return (uarch == "icx" || uarch == "spr" || uarch == "emr") && !metadata.SupportsFixedTMA // AWS VM instances
+}
+
+// getDefinitionFileBaseName returns the name, without extension, of the architecture specific event
+// and metric definition files
+func getDefinitionFileBaseName(metadata Metadata) string {
+	// use alternate events/metrics when TMA fixed counters are not supported
+	if usesNoFixedTMADefinitions(metadata) {
+		return getDefinitionUarch(metadata) + "_nofixedtma"
+	}
+	return getDefinitionUarch(metadata)
+}
+
 // LoadEventGroups reads the events defined in the architecture specific event definition file, then
-// expands them to include the per-device uncore events
-func LoadEventGroups(eventDefinitionOverridePath string, metadata Metadata) (groups []GroupDefinition, uncollectableEvents []string, err error) {
+// expands them to include the per-device uncore events. The events that can't be collected on the
+// target are returned with the reason they can't be collected.
+func LoadEventGroups(eventDefinitionOverridePath string, metadata Metadata) (groups []GroupDefinition, uncollectableEvents map[string]string, err error) {
 	var file fs.File
 	if eventDefinitionOverridePath != "" {
 		file, err = os.Open(eventDefinitionOverridePath) // #nosec G304
@@ -39,21 +62,14 @@ func LoadEventGroups(eventDefinitionOverridePath string, metadata Metadata) (gro
 			return
 		}
 	} else {
-		uarch := strings.ToLower(strings.Split(metadata.Microarchitecture, "_")[0])
-		uarch = strings.Split(uarch, " ")[0]
-		// use alternate events/metrics when TMA fixed counters are not supported
-		alternate := ""
-		if (uarch == "icx" || uarch == "spr" || uarch == "emr") && !metadata.SupportsFixedTMA { // AWS VM instances
-			alternate = "_nofixedtma"
-		}
-		eventFileName := fmt.Sprintf("%s%s.txt", uarch, alternate)
+		eventFileName := getDefinitionFileBaseName(metadata) + ".txt"
 		if file, err = resources.Open(filepath.Join("resources", "events", metadata.Architecture, metadata.Vendor, eventFileName)); err != nil {
 			return
 		}
 	}
 	defer file.Close()
 	scanner := bufio.NewScanner(file)
-	uncollectable := mapset.NewSet[string]()
+	uncollectableEvents = make(map[string]string)
 	var group GroupDefinition
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
@@ -67,10 +83,10 @@ func LoadEventGroups(eventDefinitionOverridePath string, metadata Metadata) (gro
 		// abbreviate the event name to shorten the eventual perf stat command line
 		event.Name = abbreviateEventName(event.Name)
 		event.Raw = abbreviateEventName(event.Raw)
-		if isCollectableEvent(event, metadata) {
+		if reason := getUncollectableReason(event, metadata); reason == "" {
 			group = append(group, event)
 		} else {
-			uncollectable.Add(event.Name)
+			uncollectableEvents[event.Name] = reason
 		}
 		if line[len(line)-1] == ';' {
 			// end of group detected
@@ -85,12 +101,11 @@ func LoadEventGroups(eventDefinitionOverridePath string, metadata Metadata) (gro
 	if err = scanner.Err(); err != nil {
 		return
 	}
-	uncollectableEvents = uncollectable.ToSlice()
 	// expand uncore groups for all uncore devices
 	groups, err = expandUncoreGroups(groups, metadata)
 
-	if uncollectable.Cardinality() != 0 {
-		slog.Debug("Events not collectable on target", slog.String("events", uncollectable.String()))
+	if len(uncollectableEvents) != 0 {
+		slog.Debug("Events not collectable on target", slog.Any("events", slices.Sorted(maps.Keys(uncollectableEvents))))
 	}
 	return
 }
@@ -129,44 +144,59 @@ func abbreviateEventName(event string) string {
 
 // isCollectableEvent confirms if given event can be collected on the platform
 func isCollectableEvent(event EventDefinition, metadata Metadata) bool {
+	return getUncollectableReason(event, metadata) == ""
+}
+
+// getUncollectableReason returns the reason the given event can't be collected on the platform, or an
+// empty string if it can be collected
+func getUncollectableReason(event EventDefinition, metadata Metadata) string {
+	// events that are usually missing on virtual machines are hidden by the hypervisor
+	restricted := ""
+	if metadata.Virtualized {
+		restricted = ", restricted by hypervisor"
+	}
+	processScope := flagScope == scopeProcess || flagScope == scopeCgroup || flagScope == scopeThread
 	// fixed-counter TMA
 	if !metadata.SupportsFixedTMA && (event.Name == "TOPDOWN.SLOTS" || strings.HasPrefix(event.Name, "PERF_METRICS.")) {
 		slog.Debug("Fixed counter TMA not supported on target", slog.String("event", event.Name))
-		return false
+		return "fixed-counter TMA not supported" + restricted
 	}
 	// PEBS events (not supported on GCP c4 VMs)
 	pebsEventNames := []string{"INT_MISC.UNKNOWN_BRANCH_CYCLES", "UOPS_RETIRED.MS"}
 	if !metadata.SupportsPEBS && slices.Contains(pebsEventNames, event.Name) {
 		slog.Debug("PEBS events not supported on target", slog.String("event", event.Name))
-		return false
+		return "PEBS events not supported" + restricted
 	}
 	// short-circuit for cpu events that aren't off-core response events
 	if event.Device == "cpu" && !(strings.HasPrefix(event.Name, "OCR") || strings.HasPrefix(event.Name, "OFFCORE_REQUESTS_OUTSTANDING")) {
-		return true
+		return ""
 	}
 	// off-core response events
 	if event.Device == "cpu" && (strings.HasPrefix(event.Name, "OCR") || strings.HasPrefix(event.Name, "OFFCORE_REQUESTS_OUTSTANDING")) {
-		if !(metadata.SupportsOCR && metadata.SupportsUncore) {
+		if !metadata.SupportsOCR {
+			slog.Debug("Off-core response events not supported on target", slog.String("event", event.Name))
+			return "off-core response events not supported" + restricted
+		} else if !metadata.SupportsUncore {
 			slog.Debug("Off-core response events not supported on target", slog.String("event", event.Name))
-			return false
-		} else if flagScope == scopeProcess || flagScope == scopeCgroup {
-			slog.Debug("Off-core response events not supported in process or cgroup scope", slog.String("event", event.Name))
-			return false
+			return "off-core response events require an uncore PMU, none found"
+		} else if processScope {
+			slog.Debug("Off-core response events not supported in process, cgroup, or thread scope", slog.String("event", event.Name))
+			return fmt.Sprintf("off-core response events not supported in %s scope", flagScope)
 		}
-		return true
+		return ""
 	}
 	// uncore events
 	if !metadata.SupportsUncore && strings.HasPrefix(event.Name, "UNC") {
 		slog.Debug("Uncore events not supported on target", slog.String("event", event.Name))
-		return false
+		return "no uncore PMU found" + restricted
 	}
 	// exclude uncore events when
 	// - their corresponding device is not found
 	// - not in system-wide collection scope
 	if event.Device != "cpu" && event.Device != "" {
-		if flagScope == scopeProcess || flagScope == scopeCgroup {
-			slog.Debug("Uncore events not supported in process or cgroup scope", slog.String("event", event.Name))
-			return false
+		if processScope {
+			slog.Debug("Uncore events not supported in process, cgroup, or thread scope", slog.String("event", event.Name))
+			return fmt.Sprintf("uncore events not supported in %s scope", flagScope)
 		}
 		deviceExists := false
 		for uncoreDeviceName := range metadata.UncoreDeviceIDs {
@@ -177,32 +207,31 @@ func isCollectableEvent(event EventDefinition, metadata Metadata) bool {
 		}
 		if !deviceExists {
 			slog.Debug("Uncore device not found", slog.String("device", event.Device))
-			return false
+			return fmt.Sprintf("uncore PMU device not found: %s", event.Device)
 		} else if !strings.Contains(event.Raw, "umask") && !strings.Contains(event.Raw, "event") {
 			slog.Debug("Uncore event missing umask or event", slog.String("event", event.Name))
-			return false
+			return "uncore event definition missing umask or event"
 		}
-		return true
+		return ""
 	}
 	// if we got this far, event.Device is empty
 	// is ref-cycles supported?
 	if !metadata.SupportsRefCycles && strings.Contains(event.Name, "ref-cycles") {
 		slog.Debug("ref-cycles not supported on target", slog.String("event", event.Name))
-		return false
+		return "ref-cycles not supported" + restricted
 	}
-	// no cstate and power events when collecting at process or cgroup scope
-	if (flagScope == scopeProcess || flagScope == scopeCgroup) &&
-		(strings.Contains(event.Name, "cstate_") || strings.Contains(event.Name, "power/energy")) {
-		slog.Debug("Cstate and power events not supported in process or cgroup scope", slog.String("event", event.Name))
-		return false
+	// no cstate and power events when collecting at process, cgroup, or thread scope
+	if processScope && (strings.Contains(event.Name, "cstate_") || strings.Contains(event.Name, "power/energy")) {
+		slog.Debug("Cstate and power events not supported in process, cgroup, or thread scope", slog.String("event", event.Name))
+		return fmt.Sprintf("C-state and power events not supported in %s scope", flagScope)
 	}
 	// finally, if it isn't in the perf list output, it isn't collectable
 	name := strings.Split(event.Name, ":")[0]
 	if !strings.Contains(metadata.PerfSupportedEvents, name) {
 		slog.Debug("Event not supported by perf", slog.String("event", name))
-		return false
+		return "event not supported by kernel" + restricted
 	}
-	return true
+	return ""
 }
 
 // parseEventDefinition parses one line from the event definition file into a representative structure
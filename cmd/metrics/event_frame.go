@@ -9,7 +9,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"maps"
 	"math"
+	"path"
 	"slices"
 	"strconv"
 	"strings"
@@ -23,13 +25,18 @@ type EventGroup struct {
 }
 
 // EventFrame represents the list of EventGroups collected with a specific timestamp
-// and sometimes present cgroup
+// and sometimes present cgroup or thread
 type EventFrame struct {
 	EventGroups []EventGroup
 	Timestamp   float64
 	Socket      string
 	CPU         string
 	Cgroup      string
+	PID         string // only relevant if scope is thread
+	TID         string // only relevant if scope is thread, empty for thread groups
+	Thread      string // thread name, or thread group pattern
+	// per-device uncore event values, only set when uncore detail is requested
+	UncoreDevices []UncoreDevice
 }
 
 // Event represents the structure of an event output by perf stat...with
@@ -40,6 +47,7 @@ type Event struct {
 	CounterValue string  `json:"counter-value"`
 	Unit         string  `json:"unit"`
 	Cgroup       string  `json:"cgroup"`
+	Thread       string  `json:"thread"`
 	Event        string  `json:"event"`
 	EventRuntime int     `json:"event-runtime"`
 	PcntRunning  float64 `json:"pcnt-running"`
@@ -57,12 +65,12 @@ type Event struct {
 // one process at a time.
 //
 // The frames produced will differ based on the intended metric granularity. Current options are
-// system, socket, cpu (thread/logical CPU), but only when in system scope. Process and cgroup scope
-// only support system-level granularity.
+// system, socket, cpu (thread/logical CPU), but only when in system scope. Process, cgroup, and
+// thread scope only support system-level granularity.
 func GetEventFrames(rawEvents [][]byte, eventGroupDefinitions []GroupDefinition, scope string, granularity string, metadata Metadata) (eventFrames []EventFrame, err error) {
 	// parse raw events into list of Event
 	var allEvents []Event
-	if allEvents, err = parseEvents(rawEvents, eventGroupDefinitions); err != nil {
+	if allEvents, err = parseEvents(rawEvents, eventGroupDefinitions, scope); err != nil {
 		return
 	}
 	// coalesce events to one or more lists based on scope and granularity
@@ -80,13 +88,15 @@ func GetEventFrames(rawEvents [][]byte, eventGroupDefinitions []GroupDefinition,
 			if eventIdx == 0 {
 				lastGroupID = event.Group
 				eventFrame.Timestamp = event.Interval
-				if flagGranularity == granularityCPU {
+				if granularity == granularityCPU {
 					eventFrame.CPU = event.CPU
-				} else if flagGranularity == granularitySocket {
+				} else if granularity == granularitySocket {
 					eventFrame.Socket = event.Socket
 				}
-				if flagScope == scopeCgroup {
+				if scope == scopeCgroup {
 					eventFrame.Cgroup = event.Cgroup
+				} else if scope == scopeThread {
+					eventFrame.Thread, eventFrame.TID = parseThreadID(event.Thread)
 				}
 			}
 			if event.Group != lastGroupID {
@@ -100,6 +110,10 @@ func GetEventFrames(rawEvents [][]byte, eventGroupDefinitions []GroupDefinition,
 		}
 		// add the last group
 		eventFrame.EventGroups = append(eventFrame.EventGroups, group)
+		// keep the per-device uncore values before they're collapsed
+		if flagUncoreDetail {
+			eventFrame.UncoreDevices = getUncoreDevices(eventFrame, eventGroupDefinitions, metadata)
+		}
 		// TODO: can we collapse uncore groups as we're parsing (above)?
 		if eventFrame, err = collapseUncoreGroupsInFrame(eventFrame); err != nil {
 			return
@@ -110,7 +124,7 @@ func GetEventFrames(rawEvents [][]byte, eventGroupDefinitions []GroupDefinition,
 }
 
 // parseEvents parses the raw event data into a list of Event
-func parseEvents(rawEvents [][]byte, eventGroupDefinitions []GroupDefinition) ([]Event, error) {
+func parseEvents(rawEvents [][]byte, eventGroupDefinitions []GroupDefinition, scope string) ([]Event, error) {
 	events := make([]Event, 0, len(rawEvents))
 	groupIdx := 0
 	eventIdx := -1
@@ -140,7 +154,7 @@ func parseEvents(rawEvents [][]byte, eventGroupDefinitions []GroupDefinition) ([
 			groupIdx++
 			if groupIdx == len(eventGroupDefinitions) {
 				// if in cgroup scope, we receive one set of events for each cgroup
-				if flagScope == scopeCgroup {
+				if scope == scopeCgroup {
 					groupIdx = 0
 				} else {
 					return nil, fmt.Errorf("event group definitions not aligning with raw events")
@@ -250,6 +264,20 @@ func coalesceEvents(allEvents []Event, scope string, granularity string, metadat
 			allCgroupEvents[cgroupIdx] = append(allCgroupEvents[cgroupIdx], event)
 		}
 		coalescedEvents = append(coalescedEvents, allCgroupEvents...)
+	} else if scope == scopeThread {
+		// expand events list to one list per thread
+		var allThreadEvents [][]Event
+		var threads []string
+		for _, event := range allEvents {
+			var threadIdx int
+			if threadIdx = slices.Index(threads, event.Thread); threadIdx == -1 {
+				threads = append(threads, event.Thread)
+				threadIdx = len(threads) - 1
+				allThreadEvents = append(allThreadEvents, []Event{})
+			}
+			allThreadEvents[threadIdx] = append(allThreadEvents[threadIdx], event)
+		}
+		coalescedEvents = append(coalescedEvents, allThreadEvents...)
 	} else {
 		err = fmt.Errorf("unsupported scope: %s", scope)
 		return
@@ -363,6 +391,89 @@ func collapseUncoreGroups(inGroups []EventGroup, firstIdx int, count int) (outGr
 	return
 }
 
+// parseThreadID splits the thread identifier reported by perf stat --per-thread, e.g., "java-12345",
+// into the thread name and TID
+func parseThreadID(perfThread string) (name string, tid string) {
+	idx := strings.LastIndex(perfThread, "-")
+	if idx == -1 {
+		return perfThread, ""
+	}
+	return perfThread[:idx], perfThread[idx+1:]
+}
+
+// labelThreadFrames sets the PID and thread name of each thread frame using the threads resolved
+// from /proc. Perf truncates thread names, so the /proc name is preferred when the TID is known.
+func labelThreadFrames(inFrames []EventFrame, processes []Process) (outFrames []EventFrame) {
+	threads := make(map[string]Thread)
+	for _, process := range processes {
+		for _, thread := range process.threads {
+			threads[thread.tid] = thread
+		}
+	}
+	for _, frame := range inFrames {
+		if thread, ok := threads[frame.TID]; ok {
+			frame.PID = thread.pid
+			if thread.name != "" {
+				frame.Thread = thread.name
+			}
+		}
+		outFrames = append(outFrames, frame)
+	}
+	return
+}
+
+// groupThreadFrames merges the frames of threads whose names match the same pattern into a
+// single frame by summing their event values. The merged frame is labeled with the pattern and
+// has no TID. Frames of threads that don't match any pattern are not changed. Patterns use
+// shell glob syntax, e.g., "GC*" or "worker-*". The first matching pattern wins.
+func groupThreadFrames(inFrames []EventFrame, patterns []string) (outFrames []EventFrame) {
+	if len(patterns) == 0 {
+		return inFrames
+	}
+	groupIdx := make(map[string]int) // pattern -> index in outFrames
+	for _, frame := range inFrames {
+		pattern := ""
+		for _, p := range patterns {
+			if matched, _ := path.Match(p, frame.Thread); matched {
+				pattern = p
+				break
+			}
+		}
+		if pattern == "" {
+			outFrames = append(outFrames, frame)
+			continue
+		}
+		idx, ok := groupIdx[pattern]
+		if !ok {
+			groupFrame := EventFrame{
+				Timestamp: frame.Timestamp,
+				PID:       frame.PID,
+				Thread:    pattern,
+			}
+			for _, group := range frame.EventGroups {
+				groupFrame.EventGroups = append(groupFrame.EventGroups, EventGroup{GroupID: group.GroupID, Percentage: group.Percentage, EventValues: maps.Clone(group.EventValues)})
+			}
+			outFrames = append(outFrames, groupFrame)
+			groupIdx[pattern] = len(outFrames) - 1
+			continue
+		}
+		groupFrame := &outFrames[idx]
+		if groupFrame.PID != "" && frame.PID != "" && !slices.Contains(strings.Split(groupFrame.PID, ","), frame.PID) {
+			groupFrame.PID += "," + frame.PID
+		}
+		if len(groupFrame.EventGroups) != len(frame.EventGroups) {
+			slog.Warn("thread event groups do not align, skipping thread", slog.String("thread", frame.Thread), slog.String("TID", frame.TID))
+			continue
+		}
+		for i, group := range frame.EventGroups {
+			for name, value := range group.EventValues {
+				groupFrame.EventGroups[i].EventValues[name] += value
+			}
+		}
+	}
+	return
+}
+
 // parseEventJSON parses JSON formatted event into struct
 // example: {"interval" : 5.005113019, "cpu": "0", "counter-value" : "22901873.000000", "unit" : "", "cgroup" : "...1cb2de.scope", "event" : "L1D.REPLACEMENT", "event-runtime" : 80081151765, "pcnt-running" : 6.00, "metric-value" : 0.000000, "metric-unit" : "(null)"}
 func parseEventJSON(rawEvent []byte) (Event, error) {
@@ -0,0 +1,69 @@
+package metrics
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseThreadID(t *testing.T) {
+	name, tid := parseThreadID("java-12345")
+	assert.Equal(t, "java", name)
+	assert.Equal(t, "12345", tid)
+
+	name, tid = parseThreadID("worker-pool-7-42")
+	assert.Equal(t, "worker-pool-7", name)
+	assert.Equal(t, "42", tid)
+
+	name, tid = parseThreadID("nodash")
+	assert.Equal(t, "nodash", name)
+	assert.Equal(t, "", tid)
+}
+
+func TestGroupThreadFrames(t *testing.T) {
+	newFrame := func(pid, tid, thread string, value float64) EventFrame {
+		return EventFrame{
+			PID:    pid,
+			TID:    tid,
+			Thread: thread,
+			EventGroups: []EventGroup{
+				{GroupID: 0, EventValues: map[string]float64{"instructions": value, "cycles": 2 * value}},
+			},
+		}
+	}
+	inFrames := []EventFrame{
+		newFrame("100", "100", "java", 1),
+		newFrame("100", "101", "GC Thread#0", 10),
+		newFrame("100", "102", "GC Thread#1", 20),
+		newFrame("200", "201", "worker-1", 5),
+		newFrame("100", "103", "worker-2", 7),
+	}
+	outFrames := groupThreadFrames(inFrames, []string{"GC*", "worker-*"})
+	assert.Len(t, outFrames, 3)
+	// unmatched thread is unchanged
+	assert.Equal(t, "100", outFrames[0].TID)
+	assert.Equal(t, "java", outFrames[0].Thread)
+	// matched threads are summed and labeled with the pattern
+	assert.Equal(t, "", outFrames[1].TID)
+	assert.Equal(t, "GC*", outFrames[1].Thread)
+	assert.Equal(t, 30.0, outFrames[1].EventGroups[0].EventValues["instructions"])
+	assert.Equal(t, 60.0, outFrames[1].EventGroups[0].EventValues["cycles"])
+	assert.Equal(t, "worker-*", outFrames[2].Thread)
+	assert.Equal(t, 12.0, outFrames[2].EventGroups[0].EventValues["instructions"])
+	assert.Equal(t, "200,100", outFrames[2].PID)
+	// input frames are not modified
+	assert.Equal(t, 10.0, inFrames[1].EventGroups[0].EventValues["instructions"])
+
+	// no patterns, no change
+	assert.Equal(t, inFrames, groupThreadFrames(inFrames, nil))
+}
+
+func TestParseProcessThreads(t *testing.T) {
+	threads := parseProcessThreads("100 100 java\n100 101 GC Thread#0\n200 201 a,b\n\n")
+	assert.Len(t, threads["100"], 2)
+	assert.Equal(t, Thread{pid: "100", tid: "101", name: "GC Thread#0"}, threads["100"][1])
+	assert.Equal(t, "a;b", threads["200"][0].name)
+}
@@ -0,0 +1,547 @@
+package metrics
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+// explain subcommand, shows how a metric is derived from its definition and perf events
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"perfspect/internal/common"
+	"perfspect/internal/target"
+)
+
+const explainCmdName = "explain"
+
+var explainExamples = []string{
+	fmt.Sprintf("  Explain metric on local host:            $ %s %s %s \"CPI\"", common.AppName, cmdName, explainCmdName),
+	fmt.Sprintf("  Explain metric on remote host:           $ %s %s %s \"CPI\" --target 192.168.1.1 --user fred --key fred_key", common.AppName, cmdName, explainCmdName),
+	fmt.Sprintf("  Explain metric from collected metadata:  $ %s %s %s \"CPI\" --metadata host_metadata.json", common.AppName, cmdName, explainCmdName),
+	fmt.Sprintf("  Explain metric values from raw events:   $ %s %s %s \"CPI\" --metadata host_metadata.json --events host_events.json", common.AppName, cmdName, explainCmdName),
+}
+
+var explainCmd = &cobra.Command{
+	Use:   explainCmdName,
+	Short: "Show how a metric is derived from its definition and perf events",
+	Long: fmt.Sprintf(`Shows the definition of a metric on a target: its original expression, the expression after constants are replaced with the target's values, the perf encoding of each of its events, and the event group each event's value is taken from.
+
+The target is a live target or the metadata written with the metrics command's --%s flag. When the events written with --%s are provided, the metric's variable values and result are shown for each frame of events.`, flagWriteEventsToFileName, flagWriteEventsToFileName),
+	Example:       strings.Join(explainExamples, "\n"),
+	RunE:          runExplainCmd,
+	PreRunE:       validateExplainFlags,
+	Args:          cobra.ExactArgs(1),
+	SilenceErrors: true,
+}
+
+var (
+	flagExplainMetadata string
+	flagExplainEvents   string
+)
+
+const (
+	flagExplainMetadataName = "metadata"
+	flagExplainEventsName   = "events"
+)
+
+func init() {
+	explainCmd.Flags().StringVar(&flagExplainMetadata, flagExplainMetadataName, "", "")
+	explainCmd.Flags().StringVar(&flagExplainEvents, flagExplainEventsName, "", "")
+	// these flags set the same options as the metrics command's flags of the same name
+	explainCmd.Flags().StringVar(&flagScope, flagScopeName, scopeSystem, "")
+	explainCmd.Flags().StringVar(&flagGranularity, flagGranularityName, granularitySystem, "")
+	explainCmd.Flags().Float64Var(&flagTransactionRate, flagTransactionRateName, 0, "")
+	explainCmd.Flags().StringVar(&flagEventFilePath, flagEventFilePathName, "", "")
+	explainCmd.Flags().StringVar(&flagMetricFilePath, flagMetricFilePathName, "", "")
+	explainCmd.Flags().BoolVar(&flagNoRoot, flagNoRootName, false, "")
+
+	common.AddTargetFlags(explainCmd)
+
+	explainCmd.SetUsageFunc(explainUsageFunc)
+	Cmd.AddCommand(explainCmd)
+}
+
+func explainUsageFunc(cmd *cobra.Command) error {
+	cmd.Printf("Usage: %s \"<metric name>\" [flags]\n\n", cmd.CommandPath())
+	cmd.Printf("Examples:\n%s\n\n", cmd.Example)
+	cmd.Println("Arguments:")
+	cmd.Printf("  metric name: name of the metric to explain, see '%s %s --%s'\n\n", common.AppName, cmdName, flagShowMetricNamesName)
+	cmd.Println("Flags:")
+	for _, group := range getExplainFlagGroups() {
+		cmd.Printf("  %s:\n", group.GroupName)
+		for _, flag := range group.Flags {
+			flagDefault := ""
+			if cmd.Flags().Lookup(flag.Name).DefValue != "" {
+				flagDefault = fmt.Sprintf(" (default: %s)", cmd.Flags().Lookup(flag.Name).DefValue)
+			}
+			cmd.Printf("    --%-20s %s%s\n", flag.Name, flag.Help, flagDefault)
+		}
+	}
+	cmd.Println("\nGlobal Flags:")
+	cmd.Root().PersistentFlags().VisitAll(func(pf *pflag.Flag) {
+		flagDefault := ""
+		if cmd.Root().PersistentFlags().Lookup(pf.Name).DefValue != "" {
+			flagDefault = fmt.Sprintf(" (default: %s)", cmd.Root().PersistentFlags().Lookup(pf.Name).DefValue)
+		}
+		cmd.Printf("  --%-20s %s%s\n", pf.Name, pf.Usage, flagDefault)
+	})
+	return nil
+}
+
+func getExplainFlagGroups() []common.FlagGroup {
+	var groups []common.FlagGroup
+	flags := []common.Flag{
+		{
+			Name: flagExplainMetadataName,
+			Help: fmt.Sprintf("metadata file written by the metrics command with --%s. Explains the metric on the target the metadata was collected from instead of a live target.", flagWriteEventsToFileName),
+		},
+		{
+			Name: flagExplainEventsName,
+			Help: fmt.Sprintf("events file written by the metrics command with --%s. Shows the metric's variable values and result for each frame of events. Requires --%s.", flagWriteEventsToFileName, flagExplainMetadataName),
+		},
+	}
+	groups = append(groups, common.FlagGroup{
+		GroupName: "Input Options",
+		Flags:     flags,
+	})
+	flags = []common.Flag{
+		{
+			Name: flagScopeName,
+			Help: fmt.Sprintf("scope of collection of the events. Must match the scope the events were collected with. Options: %s.", strings.Join(scopeOptions, ", ")),
+		},
+		{
+			Name: flagGranularityName,
+			Help: fmt.Sprintf("level of metric granularity of the events. Options: %s.", strings.Join(granularityOptions, ", ")),
+		},
+		{
+			Name: flagTransactionRateName,
+			Help: "number of transactions per second. Will divide relevant metrics by transactions/second.",
+		},
+		{
+			Name: flagEventFilePathName,
+			Help: "perf event definition file. Will override default event definitions.",
+		},
+		{
+			Name: flagMetricFilePathName,
+			Help: "metric definition file. Will override default metric definitions.",
+		},
+		{
+			Name: flagNoRootName,
+			Help: "do not elevate to root",
+		},
+	}
+	groups = append(groups, common.FlagGroup{
+		GroupName: "Metric Options",
+		Flags:     flags,
+	})
+	groups = append(groups, common.GetTargetFlagGroup())
+	return groups
+}
+
+func validateExplainFlags(cmd *cobra.Command, args []string) error {
+	for _, path := range []struct{ flagName, value string }{
+		{flagExplainMetadataName, flagExplainMetadata},
+		{flagExplainEventsName, flagExplainEvents},
+		{flagEventFilePathName, flagEventFilePath},
+		{flagMetricFilePathName, flagMetricFilePath},
+	} {
+		if path.value == "" {
+			continue
+		}
+		if _, err := os.Stat(path.value); err != nil {
+			if os.IsNotExist(err) {
+				return common.FlagValidationError(cmd, fmt.Sprintf("%s file path does not exist: %s", path.flagName, path.value))
+			}
+			return common.FlagValidationError(cmd, fmt.Sprintf("failed to access %s file path: %s, error: %v", path.flagName, path.value, err))
+		}
+	}
+	if flagExplainEvents != "" && flagExplainMetadata == "" {
+		return common.FlagValidationError(cmd, fmt.Sprintf("--%s requires --%s", flagExplainEventsName, flagExplainMetadataName))
+	}
+	if flagExplainMetadata != "" && (cmd.Flags().Lookup("target").Changed || cmd.Flags().Lookup("targets").Changed) {
+		return common.FlagValidationError(cmd, fmt.Sprintf("--%s is not supported with target flags", flagExplainMetadataName))
+	}
+	if !slices.Contains(scopeOptions, flagScope) {
+		return common.FlagValidationError(cmd, fmt.Sprintf("invalid scope: %s, valid options are: %s", flagScope, strings.Join(scopeOptions, ", ")))
+	}
+	if !slices.Contains(granularityOptions, flagGranularity) {
+		return common.FlagValidationError(cmd, fmt.Sprintf("invalid granularity: %s, valid options are: %s", flagGranularity, strings.Join(granularityOptions, ", ")))
+	}
+	if flagScope != scopeSystem && flagGranularity != granularitySystem {
+		return common.FlagValidationError(cmd, fmt.Sprintf("granularity must be %s when scope is not %s", granularitySystem, scopeSystem))
+	}
+	if flagTransactionRate < 0 {
+		return common.FlagValidationError(cmd, "transaction rate must be zero or greater")
+	}
+	// common target flags
+	if err := common.ValidateTargetFlags(cmd); err != nil {
+		return common.FlagValidationError(cmd, err.Error())
+	}
+	return nil
+}
+
+func runExplainCmd(cmd *cobra.Command, args []string) error {
+	metricName := args[0]
+	if flagExplainMetadata != "" {
+		metadata, err := ReadJSONFromFile(flagExplainMetadata)
+		if err != nil {
+			err = fmt.Errorf("failed to read metadata from file: %w", err)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			cmd.SilenceUsage = true
+			return err
+		}
+		var eventsFile *os.File
+		if flagExplainEvents != "" {
+			if eventsFile, err = os.Open(flagExplainEvents); err != nil { // #nosec G304
+				err = fmt.Errorf("failed to open events file: %w", err)
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				cmd.SilenceUsage = true
+				return err
+			}
+			defer eventsFile.Close()
+		}
+		if err = explainMetric(os.Stdout, metricName, metadata, eventsFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			slog.Error(err.Error())
+			cmd.SilenceUsage = true
+			return err
+		}
+		return nil
+	}
+	// appContext is the application context that holds common data and resources.
+	appContext := cmd.Parent().Context().Value(common.AppContext{}).(common.AppContext)
+	localTempDir := appContext.LocalTempDir
+	// get the targets
+	myTargets, targetErrs, err := common.GetTargets(cmd, !flagNoRoot, !flagNoRoot, localTempDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		slog.Error(err.Error())
+		cmd.SilenceUsage = true
+		return err
+	}
+	// schedule the removal of the temp directory on each target (if the debug flag is not set)
+	if cmd.Root().PersistentFlags().Lookup("debug").Value.String() != "true" {
+		for _, myTarget := range myTargets {
+			if myTarget.GetTempDirectory() != "" {
+				defer func(deferTarget target.Target) {
+					err := deferTarget.RemoveTempDirectory()
+					if err != nil {
+						slog.Error("error removing target temporary directory", slog.String("error", err.Error()))
+					}
+				}(myTarget)
+			}
+		}
+	}
+	// check for errors in target creation
+	for i := len(targetErrs) - 1; i >= 0; i-- {
+		if targetErrs[i] != nil {
+			fmt.Fprintf(os.Stderr, "Error: target: %s, %v\n", myTargets[i].GetName(), targetErrs[i])
+			slog.Error(targetErrs[i].Error())
+			myTargets = slices.Delete(myTargets, i, i+1)
+		}
+	}
+	if len(myTargets) == 0 {
+		err := fmt.Errorf("no targets remain")
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		slog.Error(err.Error())
+		cmd.SilenceUsage = true
+		return err
+	}
+	// explain the metric on each target
+	var explainErr error
+	for i, myTarget := range myTargets {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("Target: %s\n", myTarget.GetName())
+		if err := explainMetricOnTarget(myTarget, metricName, localTempDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: target: %s, %v\n", myTarget.GetName(), err)
+			slog.Error(err.Error(), slog.String("target", myTarget.GetName()))
+			explainErr = err
+		}
+	}
+	if explainErr != nil {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("failed to explain metric on one or more targets")
+	}
+	return nil
+}
+
+// explainMetricOnTarget collects the target's metadata and explains the metric on the target
+func explainMetricOnTarget(myTarget target.Target, metricName string, localTempDir string) error {
+	localPerfPath, err := extractPerf(myTarget, localTempDir)
+	if err != nil {
+		return fmt.Errorf("failed to extract perf: %w", err)
+	}
+	perfPath, err := getPerfPath(myTarget, localPerfPath)
+	if err != nil {
+		return fmt.Errorf("failed to find perf: %w", err)
+	}
+	metadata, err := LoadMetadata(myTarget, flagNoRoot, true, perfPath, localTempDir)
+	if err != nil {
+		return fmt.Errorf("failed to load metadata: %w", err)
+	}
+	return explainMetric(os.Stdout, metricName, metadata, nil)
+}
+
+// explainMetric writes the derivation of the named metric on the target described by the metadata.
+// When eventsFile is not nil, the metric is evaluated for each frame of raw events in the file.
+// Otherwise, the event groups are shown as they would be bound if all events were counted.
+func explainMetric(w io.Writer, metricName string, metadata Metadata, eventsFile *os.File) error {
+	groups, uncollectableEvents, err := LoadEventGroups(flagEventFilePath, metadata)
+	if err != nil {
+		return fmt.Errorf("failed to load event definitions: %w", err)
+	}
+	loadedMetrics, err := LoadMetricDefinitions(flagMetricFilePath, []string{metricName}, metadata)
+	if err != nil {
+		return fmt.Errorf("failed to load metric definition: %w", err)
+	}
+	definition := loadedMetrics[0]
+	metricFile, eventFile := getDefinitionFilePaths(metadata)
+	fmt.Fprintf(w, "Metric:       %s\n", definition.Name)
+	for _, field := range [][]string{
+		{"Unit", definition.Unit},
+		{"Category", definition.Category},
+		{"Description", definition.Description},
+		{"Threshold", definition.Threshold},
+	} {
+		if field[1] != "" {
+			fmt.Fprintf(w, "%-13s %s\n", field[0]+":", field[1])
+		}
+	}
+	fmt.Fprintf(w, "Metric file:  %s\n", metricFile)
+	fmt.Fprintf(w, "Event file:   %s\n", eventFile)
+	fmt.Fprintf(w, "\nExpression:\n  %s\n", definition.Expression)
+	// metrics that use uncollectable events are removed when configured
+	if event, reason := findUncollectableEvent(abbreviateEventName(definition.Expression), uncollectableEvents); event != "" {
+		fmt.Fprintf(w, "\nNot computed on %s: %s: %s\n", metadata.Hostname, event, reason)
+		return nil
+	}
+	configuredMetrics, err := ConfigureMetrics(loadedMetrics, uncollectableEvents, GetEvaluatorFunctions(), metadata)
+	if err != nil {
+		return fmt.Errorf("failed to configure metric: %w", err)
+	}
+	metric := configuredMetrics[0]
+	fmt.Fprintln(w, "\nSubstitutions:")
+	for _, substitution := range getSubstitutions(definition.Expression, metadata) {
+		fmt.Fprintf(w, "  %s\n", substitution)
+	}
+	fmt.Fprintf(w, "\nConfigured expression:\n  %s\n", metric.Expression)
+	variables := slices.Sorted(maps.Keys(metric.Variables))
+	fmt.Fprintln(w, "\nEvents:")
+	for _, variable := range variables {
+		fmt.Fprintf(w, "  %-40s %s\n", variable, getEventEncoding(variable, groups))
+	}
+	if eventsFile == nil {
+		frame, err := collapseUncoreGroupsInFrame(getAllCountedEventFrame(groups))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, "\nEvent groups (assuming all events are counted):")
+		writeEventGroupBindings(w, metric, frame, groups)
+		return nil
+	}
+	// evaluate the metric for each frame of events
+	var valueLines []string
+	var previousTimestamp float64
+	bound := false
+	for {
+		rawEvents, err := readNextEventFrame(eventsFile)
+		if err != nil {
+			return fmt.Errorf("failed to read events: %w", err)
+		}
+		if len(rawEvents) == 0 {
+			break
+		}
+		if !bound {
+			if err := checkEventsScope(rawEvents[0], flagScope); err != nil {
+				return err
+			}
+		}
+		eventFrames, err := GetEventFrames(rawEvents, groups, flagScope, flagGranularity, metadata)
+		if err != nil {
+			return fmt.Errorf("failed to put perf events into groups: %w", err)
+		}
+		for _, eventFrame := range eventFrames {
+			if !bound {
+				if err := loadMetricBestGroups(metric, eventFrame); err != nil {
+					slog.Debug("failed to bind metric variables to event groups", slog.String("error", err.Error()))
+				}
+				fmt.Fprintln(w, "\nEvent groups (bound in the first frame):")
+				writeEventGroupBindings(w, metric, eventFrame, groups)
+				bound = true
+			}
+			valueLines = append(valueLines, getFrameValues(metric, eventFrame, previousTimestamp, metadata))
+		}
+		if len(eventFrames) > 0 {
+			previousTimestamp = eventFrames[len(eventFrames)-1].Timestamp
+		}
+	}
+	fmt.Fprintln(w, "\nValues by frame (variables are per-second rates):")
+	for _, line := range valueLines {
+		fmt.Fprintf(w, "  %s\n", line)
+	}
+	return nil
+}
+
+// getDefinitionFilePaths returns the metric and event definition files used on the target
+func getDefinitionFilePaths(metadata Metadata) (metricFile string, eventFile string) {
+	metricFile = flagMetricFilePath
+	if metricFile == "" {
+		metricFile = filepath.Join("resources", "metrics", metadata.Architecture, metadata.Vendor, getDefinitionFileBaseName(metadata)+".json") + " (built-in)"
+	}
+	eventFile = flagEventFilePath
+	if eventFile == "" {
+		eventFile = filepath.Join("resources", "events", metadata.Architecture, metadata.Vendor, getDefinitionFileBaseName(metadata)+".txt") + " (built-in)"
+	}
+	return
+}
+
+// getSubstitutions describes the changes made to the metric expression when it is configured
+func getSubstitutions(expression string, metadata Metadata) (substitutions []string) {
+	abbreviated := abbreviateEventName(expression)
+	if abbreviated != expression {
+		substitutions = append(substitutions, "event names abbreviated")
+	}
+	if transformed, err := transformConditional(abbreviated); err == nil && transformed != abbreviated {
+		substitutions = append(substitutions, "if/else transformed to ?:")
+	}
+	constants, err := getMetricConstants(metadata)
+	if err != nil {
+		return
+	}
+	for _, constant := range constants {
+		if strings.Contains(expression, constant.Name) {
+			substitutions = append(substitutions, fmt.Sprintf("%s = %s", constant.Name, constant.Value))
+		}
+	}
+	if len(substitutions) == 0 {
+		substitutions = append(substitutions, "none")
+	}
+	return
+}
+
+var reUncoreDeviceEvent = regexp.MustCompile(`^(.+)\.[0-9]+$`)
+
+// getEventEncoding returns the perf encoding of the event. Uncore events are collected from each
+// uncore device, so the encoding of the first device's event is returned with the device count.
+func getEventEncoding(eventName string, groups []GroupDefinition) string {
+	var encoding string
+	devices := 0
+	for _, group := range groups {
+		for _, event := range group {
+			name := event.Name
+			if match := reUncoreDeviceEvent.FindStringSubmatch(name); match != nil && strings.HasPrefix(name, "UNC") {
+				name = match[1]
+			}
+			if name != eventName {
+				continue
+			}
+			if encoding == "" {
+				encoding = event.Raw
+			}
+			if event.Device != "" && event.Device != "cpu" {
+				devices++
+			}
+		}
+	}
+	if encoding == "" {
+		return "not in event definition file"
+	}
+	if devices > 1 {
+		encoding += fmt.Sprintf(" (summed across %d uncore devices)", devices)
+	}
+	return encoding
+}
+
+// getAllCountedEventFrame returns an event frame in which every event in the groups was counted
+func getAllCountedEventFrame(groups []GroupDefinition) (frame EventFrame) {
+	for groupIdx, group := range groups {
+		eventGroup := EventGroup{EventValues: make(map[string]float64), GroupID: groupIdx, Percentage: 100}
+		for _, event := range group {
+			eventGroup.EventValues[event.Name] = 1
+		}
+		frame.EventGroups = append(frame.EventGroups, eventGroup)
+	}
+	return
+}
+
+// writeEventGroupBindings writes the event group that each of the metric's variables is bound to
+func writeEventGroupBindings(w io.Writer, metric MetricDefinition, frame EventFrame, groups []GroupDefinition) {
+	if err := loadMetricBestGroups(metric, frame); err != nil {
+		slog.Debug("failed to bind metric variables to event groups", slog.String("error", err.Error()))
+	}
+	for _, variable := range slices.Sorted(maps.Keys(metric.Variables)) {
+		frameGroupIdx := metric.Variables[variable]
+		if frameGroupIdx < 0 || frameGroupIdx >= len(frame.EventGroups) {
+			fmt.Fprintf(w, "  %-40s not bound, no group with a counted value\n", variable)
+			continue
+		}
+		groupID := frame.EventGroups[frameGroupIdx].GroupID
+		var names []string
+		for _, event := range groups[groupID] {
+			names = append(names, event.Name)
+		}
+		fmt.Fprintf(w, "  %-40s group %d: %s\n", variable, groupID, strings.Join(names, ", "))
+	}
+}
+
+// getFrameValues formats the metric's variable values and result for one frame of events
+func getFrameValues(metric MetricDefinition, frame EventFrame, previousTimestamp float64, metadata Metadata) string {
+	label := fmt.Sprintf("%.3f", frame.Timestamp)
+	if frame.Socket != "" {
+		label += " socket " + frame.Socket
+	} else if frame.CPU != "" {
+		label += " cpu " + frame.CPU
+	}
+	variables, err := getExpressionVariableValues(metric, frame, previousTimestamp, metadata)
+	if err != nil {
+		return fmt.Sprintf("%s: %v", label, err)
+	}
+	var values []string
+	for _, name := range slices.Sorted(maps.Keys(variables)) {
+		values = append(values, fmt.Sprintf("%s=%g", name, variables[name]))
+	}
+	result := math.NaN()
+	if value, err := evaluateExpression(metric, variables); err != nil {
+		slog.Debug("failed to evaluate expression", slog.String("error", err.Error()))
+	} else if floatValue, ok := value.(float64); ok {
+		result = floatValue
+	}
+	return fmt.Sprintf("%s: %s -> %g", label, strings.Join(values, ", "), result)
+}
+
+// checkEventsScope returns an error when the perf event, as written to the events file, wasn't
+// collected in the scope. Cgroup and thread scoped events name their cgroup or thread, system scoped
+// events name their CPU when not aggregated. Otherwise, system and process scoped events can't be
+// told apart.
+func checkEventsScope(rawEvent []byte, scope string) error {
+	event, err := parseEventJSON(rawEvent)
+	if err != nil {
+		return fmt.Errorf("failed to parse event: %w", err)
+	}
+	eventsScopes := []string{scopeSystem, scopeProcess}
+	if event.Cgroup != "" {
+		eventsScopes = []string{scopeCgroup}
+	} else if event.Thread != "" {
+		eventsScopes = []string{scopeThread}
+	} else if event.CPU != "" {
+		eventsScopes = []string{scopeSystem}
+	}
+	if !slices.Contains(eventsScopes, scope) {
+		return fmt.Errorf("the events were collected in %s scope, not %s scope, see --%s", strings.Join(eventsScopes, " or "), scope, flagScopeName)
+	}
+	return nil
+}
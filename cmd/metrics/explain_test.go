@@ -0,0 +1,81 @@
+package metrics
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplainMetric(t *testing.T) {
+	dir := t.TempDir()
+	eventFile := filepath.Join(dir, "events.txt")
+	require.NoError(t, os.WriteFile(eventFile, []byte("cpu-cycles,\ninstructions;\n"), 0644))
+	metricFile := filepath.Join(dir, "metrics.json")
+	require.NoError(t, os.WriteFile(metricFile, []byte(`[{"name": "CPI", "expression": "[cpu-cycles] / [instructions] * [CONST_THREAD_COUNT]", "unit": "cycles/instruction"}]`), 0644))
+	flagEventFilePath, flagMetricFilePath = eventFile, metricFile
+	defer func() { flagEventFilePath, flagMetricFilePath = "", "" }()
+	metadata := Metadata{
+		Architecture:         "x86_64",
+		Vendor:               "GenuineIntel",
+		Microarchitecture:    "SPR",
+		SupportsInstructions: true,
+		ThreadsPerCore:       2,
+		PerfSupportedEvents:  "cpu-cycles instructions",
+	}
+
+	var sb strings.Builder
+	require.NoError(t, explainMetric(&sb, "CPI", metadata, nil))
+	out := sb.String()
+	assert.Contains(t, out, "Metric:       CPI\n")
+	assert.Contains(t, out, "Unit:         cycles/instruction\n")
+	assert.Contains(t, out, "Metric file:  "+metricFile+"\n")
+	assert.Contains(t, out, "Expression:\n  [cpu-cycles] / [instructions] * [CONST_THREAD_COUNT]\n")
+	assert.Contains(t, out, "[CONST_THREAD_COUNT] = 2.000000\n")
+	assert.Contains(t, out, "Configured expression:\n  [cpu-cycles] / [instructions] * 2.000000\n")
+	assert.Regexp(t, `cpu-cycles +cpu-cycles\n`, out)
+	assert.Regexp(t, `instructions +group 0: cpu-cycles, instructions\n`, out)
+
+	// per-frame values from raw events
+	rawEvents := `{"interval" : 1.000, "counter-value" : "300", "unit" : "", "event" : "cpu-cycles", "event-runtime" : 1000, "pcnt-running" : 100.00}
+{"interval" : 1.000, "counter-value" : "100", "unit" : "", "event" : "instructions", "event-runtime" : 1000, "pcnt-running" : 100.00}
+{"interval" : 2.000, "counter-value" : "400", "unit" : "", "event" : "cpu-cycles", "event-runtime" : 1000, "pcnt-running" : 100.00}
+{"interval" : 2.000, "counter-value" : "100", "unit" : "", "event" : "instructions", "event-runtime" : 1000, "pcnt-running" : 100.00}
+`
+	eventsPath := filepath.Join(dir, "host_events.json")
+	require.NoError(t, os.WriteFile(eventsPath, []byte(rawEvents), 0644))
+	eventsFile, err := os.Open(eventsPath)
+	require.NoError(t, err)
+	defer eventsFile.Close()
+	sb.Reset()
+	require.NoError(t, explainMetric(&sb, "CPI", metadata, eventsFile))
+	out = sb.String()
+	assert.Contains(t, out, "Event groups (bound in the first frame):\n")
+	assert.Contains(t, out, "1.000: cpu-cycles=300, instructions=100 -> 6\n")
+	assert.Contains(t, out, "2.000: cpu-cycles=400, instructions=100 -> 8\n")
+
+	// unknown metrics are reported
+	require.Error(t, explainMetric(&sb, "not a metric", metadata, nil))
+}
+
+func TestCheckEventsScope(t *testing.T) {
+	systemEvent := []byte(`{"interval" : 1.000, "counter-value" : "300", "unit" : "", "event" : "cpu-cycles", "event-runtime" : 1000, "pcnt-running" : 100.00}`)
+	cpuEvent := []byte(`{"interval" : 1.000, "cpu": "0", "counter-value" : "300", "unit" : "", "event" : "cpu-cycles", "event-runtime" : 1000, "pcnt-running" : 100.00}`)
+	cgroupEvent := []byte(`{"interval" : 1.000, "counter-value" : "300", "unit" : "", "cgroup" : "/system.slice/app.service", "event" : "cpu-cycles", "event-runtime" : 1000, "pcnt-running" : 100.00}`)
+	threadEvent := []byte(`{"interval" : 1.000, "thread" : "app-1234", "counter-value" : "300", "unit" : "", "event" : "cpu-cycles", "event-runtime" : 1000, "pcnt-running" : 100.00}`)
+	assert.NoError(t, checkEventsScope(systemEvent, scopeSystem))
+	assert.NoError(t, checkEventsScope(systemEvent, scopeProcess))
+	assert.Error(t, checkEventsScope(systemEvent, scopeCgroup))
+	assert.NoError(t, checkEventsScope(cpuEvent, scopeSystem))
+	assert.Error(t, checkEventsScope(cpuEvent, scopeProcess))
+	assert.NoError(t, checkEventsScope(cgroupEvent, scopeCgroup))
+	assert.ErrorContains(t, checkEventsScope(cgroupEvent, scopeSystem), "collected in cgroup scope")
+	assert.NoError(t, checkEventsScope(threadEvent, scopeThread))
+	assert.Error(t, checkEventsScope(threadEvent, scopeProcess))
+}
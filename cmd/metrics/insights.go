@@ -0,0 +1,224 @@
+package metrics
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+// TMA (Top-down Microarchitecture Analysis) bottleneck insights derived from the summary statistics
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Insight represents a ranked TMA bottleneck finding
+type Insight struct {
+	Rank           int
+	Group          string   `json:",omitempty"` // e.g., "SKT 0" when summarizing at socket granularity
+	Category       string   // the level 1 TMA category, e.g., Backend Bound
+	Value          float64  // mean of the level 1 category (% of pipeline slots)
+	Path           []string // the TMA nodes, from level 1 to the bottleneck, that exceed their thresholds
+	Bottleneck     string   // the deepest TMA node on the path
+	BottleneckPct  float64  // mean of the bottleneck node (% of pipeline slots)
+	Finding        string
+	Recommendation string
+}
+
+// tmaNode is one node in the TMA hierarchy as found in the summarized metrics
+type tmaNode struct {
+	definition MetricDefinition
+	name       string // display name, e.g., "Memory Bound"
+	key        string // normalized name used to find recommendations, e.g., "memory_bound"
+	mean       float64
+	children   []*tmaNode
+}
+
+// tmaRecommendations maps a TMA node to a short explanation and recommendation
+var tmaRecommendations = map[string][2]string{
+	"frontend_bound":      {"the frontend isn't supplying enough micro-ops", "consider profile guided optimization to improve code layout"},
+	"fetch_latency":       {"instruction fetch stalls", "consider profile guided optimization and reducing code footprint"},
+	"icache_misses":       {"instruction cache misses", "consider reducing code size or using profile guided optimization"},
+	"itlb_misses":         {"ITLB misses high", "consider huge pages for code"},
+	"branch_resteers":     {"frequent branch resteers", "consider reducing indirect branches"},
+	"fetch_bandwidth":     {"micro-op delivery bandwidth limits", "consider improving code alignment and loop layout"},
+	"mite":                {"legacy decode pipeline limits", "consider improving code alignment to increase DSB coverage"},
+	"dsb":                 {"decoded stream buffer limits", "consider improving code layout"},
+	"bad_speculation":     {"slots wasted on mis-speculated work", "consider reducing unpredictable branches"},
+	"branch_mispredicts":  {"branch mispredictions", "consider branchless code or profile guided optimization"},
+	"machine_clears":      {"machine clears", "check for memory ordering conflicts, self-modifying code, or false sharing"},
+	"backend_bound":       {"the backend can't accept micro-ops", "consider the memory and core bound sub-categories"},
+	"memory_bound":        {"stalls on the memory subsystem", "consider improving data locality"},
+	"l1_bound":            {"L1 data cache stalls", "consider reducing load dependencies"},
+	"dtlb_load":           {"DTLB load misses high", "consider huge pages for data"},
+	"lock_latency":        {"lock latency", "consider reducing lock contention"},
+	"l2_bound":            {"L2 cache stalls", "consider improving data locality"},
+	"l3_bound":            {"L3 cache stalls", "consider reducing the working set"},
+	"data_sharing":        {"data sharing between cores", "consider reducing cross-core sharing of modified data"},
+	"dram_bound":          {"DRAM access stalls", "consider NUMA locality and cache blocking"},
+	"mem_bandwidth":       {"DRAM bandwidth limits", "consider reducing memory traffic or spreading load across sockets"},
+	"mem_latency":         {"DRAM latency", "consider NUMA locality"},
+	"store_bound":         {"store buffer stalls", "consider reducing stores or false sharing"},
+	"false_sharing":       {"false sharing", "consider padding shared data structures to cache line size"},
+	"core_bound":          {"execution unit limits", "consider vectorization and reducing dependency chains"},
+	"ports_utilization":   {"execution port contention", "consider vectorization and reducing dependency chains"},
+	"retiring":            {"high retiring ratio", "consider vectorization or algorithmic improvements to reduce instructions"},
+	"light_operations":    {"mostly light operations", "consider vectorization to do more work per instruction"},
+	"heavy_operations":    {"heavy operations", "consider avoiding complex instructions"},
+	"microcode_sequencer": {"microcode sequencer assists", "check for denormals and other assists"},
+	"latency":             {"instruction fetch latency", "consider profile guided optimization"},
+	"bandwidth":           {"instruction fetch bandwidth limits", "consider improving code layout"},
+	"mispredicts":         {"branch mispredictions", "consider branchless code or profile guided optimization"},
+	"pipeline_restarts":   {"pipeline restarts", "check for memory ordering conflicts or self-modifying code"},
+	"memory":              {"stalls on the memory subsystem", "consider improving data locality and NUMA locality"},
+	"cpu":                 {"execution unit limits", "consider vectorization and reducing dependency chains"},
+	"smt_contention":      {"SMT contention", "consider disabling SMT or pinning threads to separate cores"},
+	"fastpath":            {"mostly fastpath operations", "consider vectorization to do more work per instruction"},
+	"microcode":           {"microcoded operations", "consider avoiding complex instructions"},
+}
+
+var (
+	reIntelTMAMetric = regexp.MustCompile(`^TMA_\.*([A-Za-z0-9_]+)\(%\)$`)
+	reAMDTMAMetric   = regexp.MustCompile(`^Pipeline Utilization - (.+) \(%\)$`)
+)
+
+// getTMANodeName returns the display name of a TMA metric, i.e., the name of its node in the TMA
+// hierarchy. Intel TMA metric names are, e.g., "TMA_..Memory_Bound(%)". AMD TMA metric names are,
+// e.g., "Pipeline Utilization - Backend Bound - Memory (%)".
+func getTMANodeName(metricName string) string {
+	if match := reIntelTMAMetric.FindStringSubmatch(metricName); match != nil {
+		return strings.ReplaceAll(match[1], "_", " ")
+	}
+	if match := reAMDTMAMetric.FindStringSubmatch(metricName); match != nil {
+		parts := strings.Split(match[1], " - ")
+		return parts[len(parts)-1]
+	}
+	return metricName
+}
+
+// buildTMAHierarchy finds the TMA metrics, i.e., those with a level in their definition, in the list
+// of metric names and arranges them into a tree using the parent in their definition. Metrics whose
+// parent isn't in the list, e.g., filtered out with --metrics, are left out.
+func buildTMAHierarchy(names []string, stats map[string]metricStats, definitions map[string]MetricDefinition) (roots []*tmaNode) {
+	nodes := make(map[string]*tmaNode)
+	for _, metricName := range names {
+		definition, ok := definitions[metricName]
+		if !ok || definition.Level == 0 {
+			continue
+		}
+		node := &tmaNode{definition: definition, name: getTMANodeName(metricName), mean: math.NaN()}
+		node.key = strings.ToLower(strings.ReplaceAll(node.name, " ", "_"))
+		if s, ok := stats[metricName]; ok {
+			node.mean = s.mean
+		}
+		if definition.Level == 1 {
+			roots = append(roots, node)
+		} else if parent, ok := nodes[definition.Parent]; ok {
+			parent.children = append(parent.children, node)
+		} else {
+			continue
+		}
+		nodes[metricName] = node
+	}
+	return
+}
+
+// getTMAInsights walks the TMA hierarchy from each level 1 category that exceeds its threshold down
+// through the largest child that also exceeds its threshold. The thresholds are those of the metric
+// definitions, so the insights agree with the metrics flagged as exceeded in the summaries. One
+// finding is produced per flagged level 1 category. Findings are ranked by the level 1 category's
+// share of pipeline slots.
+func getTMAInsights(names []string, stats map[string]metricStats, definitions map[string]MetricDefinition) (insights []Insight) {
+	means := getMeans(stats)
+	isFlagged := func(node *tmaNode) bool {
+		return isThresholdExceeded(node.definition, node.mean, means)
+	}
+	for _, root := range buildTMAHierarchy(names, stats, definitions) {
+		if !isFlagged(root) {
+			continue
+		}
+		path := []*tmaNode{root}
+		for node := root; ; {
+			var next *tmaNode
+			for _, child := range node.children {
+				if isFlagged(child) && (next == nil || child.mean > next.mean) {
+					next = child
+				}
+			}
+			if next == nil {
+				break
+			}
+			path = append(path, next)
+			node = next
+		}
+		bottleneck := path[len(path)-1]
+		insight := Insight{
+			Category:      root.name,
+			Value:         root.mean,
+			Bottleneck:    bottleneck.name,
+			BottleneckPct: bottleneck.mean,
+		}
+		for _, node := range path {
+			insight.Path = append(insight.Path, node.name)
+		}
+		explanation := tmaRecommendations[bottleneck.key]
+		insight.Finding = fmt.Sprintf("%s %.0f%%", root.name, root.mean)
+		if bottleneck != root {
+			insight.Finding += fmt.Sprintf(" dominated by %s (%.0f%%)", strings.Join(insight.Path[1:], " > "), bottleneck.mean)
+		}
+		if explanation[0] != "" {
+			insight.Finding += ": " + explanation[0]
+		}
+		insight.Recommendation = explanation[1]
+		insights = append(insights, insight)
+	}
+	slices.SortStableFunc(insights, func(a, b Insight) int {
+		if a.Value > b.Value {
+			return -1
+		} else if a.Value < b.Value {
+			return 1
+		}
+		return 0
+	})
+	for i := range insights {
+		insights[i].Rank = i + 1
+	}
+	return
+}
+
+// writeInsights writes the insights to the insights JSON and CSV files
+func writeInsights(localOutputDir string, targetName string, insights []Insight) (filesCreated []string, err error) {
+	if insights == nil {
+		insights = []Insight{}
+	}
+	jsonBytes, err := json.MarshalIndent(insights, "", "  ")
+	if err != nil {
+		return
+	}
+	jsonInsightsFile := filepath.Join(localOutputDir, targetName+"_metrics_insights.json")
+	if err = os.WriteFile(jsonInsightsFile, jsonBytes, 0644); err != nil { // #nosec G306
+		err = fmt.Errorf("failed to write insights to file: %w", err)
+		return
+	}
+	filesCreated = append(filesCreated, jsonInsightsFile)
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	_ = writer.Write([]string{"rank", "group", "category", "value", "bottleneck", "bottleneck value", "finding", "recommendation"})
+	for _, insight := range insights {
+		_ = writer.Write([]string{fmt.Sprintf("%d", insight.Rank), insight.Group, insight.Category, fmt.Sprintf("%f", insight.Value), insight.Bottleneck, fmt.Sprintf("%f", insight.BottleneckPct), insight.Finding, insight.Recommendation})
+	}
+	writer.Flush()
+	csvInsightsFile := filepath.Join(localOutputDir, targetName+"_metrics_insights.csv")
+	if err = os.WriteFile(csvInsightsFile, buf.Bytes(), 0644); err != nil { // #nosec G306
+		err = fmt.Errorf("failed to write insights to file: %w", err)
+		return
+	}
+	filesCreated = append(filesCreated, csvInsightsFile)
+	return
+}
@@ -0,0 +1,102 @@
+package metrics
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Knetic/govaluate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loadTMADefinitions loads the metric definitions of the microarchitecture, with their thresholds
+// parsed, by metric name
+func loadTMADefinitions(t *testing.T, vendor string, uarch string) map[string]MetricDefinition {
+	metricDefinitions, err := LoadMetricDefinitions("", nil, Metadata{Architecture: "x86_64", Vendor: vendor, Microarchitecture: uarch, SupportsFixedTMA: true})
+	require.NoError(t, err)
+	for i := range metricDefinitions {
+		if metricDefinitions[i].Threshold != "" {
+			metricDefinitions[i].ThresholdEvaluable, err = govaluate.NewEvaluableExpressionWithFunctions(metricDefinitions[i].Threshold, GetEvaluatorFunctions())
+			require.NoError(t, err)
+		}
+	}
+	return getMetricDefinitionsByName(metricDefinitions)
+}
+
+func TestGetTMAInsightsIntel(t *testing.T) {
+	names := []string{
+		"CPI",
+		"TMA_Frontend_Bound(%)",
+		"TMA_..Fetch_Latency(%)",
+		"TMA_....ITLB_Misses(%)",
+		"TMA_Bad_Speculation(%)",
+		"TMA_Backend_Bound(%)",
+		"TMA_..Memory_Bound(%)",
+		"TMA_....L1_Bound(%)",
+		"TMA_....DRAM_Bound(%)",
+		"TMA_......MEM_Bandwidth(%)",
+		"TMA_......MEM_Latency(%)",
+		"TMA_..Core_Bound(%)",
+		"TMA_Retiring(%)",
+		"TMA_Info_Thread_IPC",
+	}
+	means := []float64{1.5, 20, 12, 8, 5, 55, 42, 3, 30, 10, 25, 13, 20, 0.6}
+	stats := make(map[string]metricStats)
+	for i, name := range names {
+		stats[name] = metricStats{mean: means[i]}
+	}
+	insights := getTMAInsights(names, stats, loadTMADefinitions(t, "GenuineIntel", "SPR"))
+	require.Len(t, insights, 2)
+	assert.Equal(t, 1, insights[0].Rank)
+	assert.Equal(t, "Backend Bound", insights[0].Category)
+	assert.Equal(t, []string{"Backend Bound", "Memory Bound", "DRAM Bound", "MEM Latency"}, insights[0].Path)
+	assert.Equal(t, "MEM Latency", insights[0].Bottleneck)
+	assert.Contains(t, insights[0].Recommendation, "NUMA locality")
+	assert.Equal(t, 2, insights[1].Rank)
+	assert.Equal(t, "Frontend Bound", insights[1].Category)
+	assert.Equal(t, "ITLB Misses", insights[1].Bottleneck)
+	assert.Contains(t, insights[1].Finding, "ITLB misses high")
+	assert.Contains(t, insights[1].Recommendation, "huge pages for code")
+}
+
+func TestGetTMAInsightsAMD(t *testing.T) {
+	names := []string{
+		"Pipeline Utilization - Frontend Bound (%)",
+		"Pipeline Utilization - Backend Bound (%)",
+		"Pipeline Utilization - Backend Bound - Memory (%)",
+		"Pipeline Utilization - Backend Bound - CPU (%)",
+	}
+	stats := map[string]metricStats{
+		names[0]: {mean: 10},
+		names[1]: {mean: 40},
+		names[2]: {mean: 30},
+		names[3]: {mean: math.NaN()},
+	}
+	insights := getTMAInsights(names, stats, loadTMADefinitions(t, "AuthenticAMD", "Genoa"))
+	require.Len(t, insights, 1)
+	assert.Equal(t, []string{"Backend Bound", "Memory"}, insights[0].Path)
+}
+
+func TestGetTMAInsightsUseDefinitionThresholds(t *testing.T) {
+	names := []string{"TMA_Retiring(%)", "TMA_..Light_Operations(%)", "TMA_..Heavy_Operations(%)"}
+	stats := map[string]metricStats{names[0]: {mean: 65}, names[1]: {mean: 50}, names[2]: {mean: 15}}
+	newDefinition := func(name string, level int, parent string, threshold string) MetricDefinition {
+		thresholdEvaluable, err := govaluate.NewEvaluableExpression(threshold)
+		require.NoError(t, err)
+		return MetricDefinition{Name: name, Level: level, Parent: parent, Threshold: threshold, ThresholdEvaluable: thresholdEvaluable}
+	}
+	definitions := getMetricDefinitionsByName([]MetricDefinition{
+		newDefinition(names[0], 1, "", "[value] > 60"),
+		newDefinition(names[1], 2, names[0], "[value] > 60 && [TMA_Retiring(%)] > 60"),
+		newDefinition(names[2], 2, names[0], "[value] > 10 && [TMA_Retiring(%)] > 60"),
+	})
+	insights := getTMAInsights(names, stats, definitions)
+	require.Len(t, insights, 1)
+	assert.Equal(t, []string{"Retiring", "Heavy Operations"}, insights[0].Path)
+	// a level 1 metric that doesn't exceed its threshold isn't reported
+	definitions[names[0]] = newDefinition(names[0], 1, "", "[value] > 70")
+	assert.Empty(t, getTMAInsights(names, stats, definitions))
+}
@@ -13,6 +13,7 @@ import (
 	"os"
 	"os/exec"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -26,6 +27,7 @@ import (
 type Metadata struct {
 	CoresPerSocket            int
 	CPUSocketMap              map[int]int
+	CPUTopology               map[int]CPUTopology // logical CPU -> socket, die, core
 	UncoreDeviceIDs           map[string][]int
 	KernelVersion             string
 	Architecture              string
@@ -34,6 +36,7 @@ type Metadata struct {
 	Hostname                  string
 	ModelName                 string
 	PerfSupportedEvents       string
+	PerfEventParanoid         int // kernel.perf_event_paranoid
 	PMUDriverVersion          string
 	SocketCount               int
 	CollectionStartTime       time.Time
@@ -46,11 +49,19 @@ type Metadata struct {
 	SupportsPEBS              bool
 	SupportsOCR               bool
 	ThreadsPerCore            int
+	Virtualized               bool // running on a hypervisor, which may restrict access to the PMU
 	TSC                       int
 	TSCFrequencyHz            int
 	SystemSummaryFields       [][]string // slice of key-value pairs
 }
 
+// CPUTopology is the location of a logical CPU in the processor topology
+type CPUTopology struct {
+	Socket int
+	Die    int
+	Core   int
+}
+
 // LoadMetadata - populates and returns a Metadata structure containing state of the
 // system.
 func LoadMetadata(myTarget target.Target, noRoot bool, noSystemSummary bool, perfPath string, localTempDir string) (metadata Metadata, err error) {
@@ -86,6 +97,8 @@ func LoadMetadata(myTarget target.Target, noRoot bool, noSystemSummary bool, per
 	metadata.CPUSocketMap = createCPUSocketMap(metadata.CoresPerSocket, metadata.SocketCount, metadata.ThreadsPerCore == 2)
 	// Model Name (from cpuInfo)
 	metadata.ModelName = cpuInfo[0]["model name"]
+	// Virtualized (from cpuInfo)
+	metadata.Virtualized = slices.Contains(strings.Fields(cpuInfo[0]["flags"]), "hypervisor")
 	// Vendor (from cpuInfo)
 	metadata.Vendor = cpuInfo[0]["vendor_id"]
 	// CPU microarchitecture (from cpuInfo)
@@ -194,6 +207,10 @@ func LoadMetadata(myTarget target.Target, noRoot bool, noSystemSummary bool, per
 			slog.Warn("'OCR' events not supported", slog.String("output", output))
 		}
 	}
+	// perf_event_paranoid
+	if metadata.PerfEventParanoid, err = getPerfEventParanoid(scriptOutputs); err != nil {
+		slog.Warn("failed to retrieve perf_event_paranoid", slog.String("error", err.Error()))
+	}
 	// Kernel Version
 	if metadata.KernelVersion, err = getKernelVersion(scriptOutputs); err != nil {
 		err = fmt.Errorf("failed to retrieve kernel version: %v", err)
@@ -225,6 +242,10 @@ func LoadMetadata(myTarget target.Target, noRoot bool, noSystemSummary bool, per
 			slog.Warn("Uncore devices not supported")
 		}
 	}
+	// CPU topology, used to arrange per-CPU metrics in the summary
+	if metadata.CPUTopology, err = getCPUTopology(scriptOutputs); err != nil {
+		slog.Warn("failed to retrieve CPU topology", slog.String("error", err.Error()))
+	}
 	err = nil
 	return
 }
@@ -298,6 +319,20 @@ func getMetadataScripts(noRoot bool, perfPath string, uarch string, noSystemSumm
 			ScriptTemplate: "uname -r",
 			Superuser:      !noRoot,
 		},
+		{
+			Name:           "perf event paranoid",
+			ScriptTemplate: "cat /proc/sys/kernel/perf_event_paranoid",
+			Superuser:      !noRoot,
+		},
+		{
+			Name: "cpu topology",
+			ScriptTemplate: `for cpu in /sys/devices/system/cpu/cpu[0-9]*; do
+    if [ -d "$cpu/topology" ]; then
+        echo "${cpu##*/cpu} $(cat $cpu/topology/physical_package_id) $(cat $cpu/topology/die_id 2>/dev/null || echo 0) $(cat $cpu/topology/core_id)"
+    fi
+done`,
+			Superuser: !noRoot,
+		},
 	}
 	// replace script template vars
 	numGPCounters, err := getNumGPCounters(uarch)
@@ -353,6 +388,8 @@ func (md Metadata) String() string {
 		"Uncore supported: %t, "+
 		"PEBS supported: %t, "+
 		"OCR supported: %t, "+
+		"Virtualized: %t, "+
+		"perf_event_paranoid: %d, "+
 		"PMU Driver version: %s, "+
 		"Kernel version: %s, "+
 		"Collection Start Time: %s, ",
@@ -374,6 +411,8 @@ func (md Metadata) String() string {
 		md.SupportsUncore,
 		md.SupportsPEBS,
 		md.SupportsOCR,
+		md.Virtualized,
+		md.PerfEventParanoid,
 		md.PMUDriverVersion,
 		md.KernelVersion,
 		md.CollectionStartTime.Format(time.RFC3339),
@@ -703,6 +742,40 @@ func getKernelVersion(scriptOutputs map[string]script.ScriptOutput) (version str
 	return
 }
 
+// getPerfEventParanoid - parses the kernel.perf_event_paranoid setting
+func getPerfEventParanoid(scriptOutputs map[string]script.ScriptOutput) (paranoid int, err error) {
+	if scriptOutputs["perf event paranoid"].Exitcode != 0 {
+		err = fmt.Errorf("failed to read perf_event_paranoid: %s", scriptOutputs["perf event paranoid"].Stderr)
+		return
+	}
+	paranoid, err = strconv.Atoi(strings.TrimSpace(scriptOutputs["perf event paranoid"].Stdout))
+	return
+}
+
+// getCPUTopology - parses lines of "cpu socket die core" into a map of logical CPU to its topology
+func getCPUTopology(scriptOutputs map[string]script.ScriptOutput) (topology map[int]CPUTopology, err error) {
+	if scriptOutputs["cpu topology"].Exitcode != 0 {
+		err = fmt.Errorf("failed to read cpu topology: %s", scriptOutputs["cpu topology"].Stderr)
+		return
+	}
+	topology = make(map[int]CPUTopology)
+	for line := range strings.SplitSeq(scriptOutputs["cpu topology"].Stdout, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 4 {
+			continue
+		}
+		var values [4]int
+		for i, field := range fields {
+			if values[i], err = strconv.Atoi(field); err != nil {
+				err = fmt.Errorf("unexpected cpu topology format: %s", line)
+				return
+			}
+		}
+		topology[values[0]] = CPUTopology{Socket: values[1], Die: values[2], Core: values[3]}
+	}
+	return
+}
+
 // createCPUSocketMap creates a mapping of logical CPUs to their corresponding sockets.
 // The function takes the number of cores per socket, the number of sockets, and a boolean indicating whether hyperthreading is enabled.
 // It returns a map where the key is the logical CPU index and the value is the socket index.
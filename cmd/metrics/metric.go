@@ -10,6 +10,7 @@ import (
 	"log/slog"
 	"math"
 	"os"
+	"slices"
 	"strings"
 	"sync"
 
@@ -17,10 +18,28 @@ import (
 	mapset "github.com/deckarep/golang-set/v2"
 )
 
-// Metric represents a metric (name, value) derived from perf events
+// Metric represents a metric (name, value) derived from perf events, and the metadata from its
+// definition
 type Metric struct {
-	Name  string
-	Value float64
+	Name              string
+	Value             float64
+	Unit              string `json:",omitempty"`
+	Category          string `json:",omitempty"`
+	Level             int    `json:",omitempty"`
+	Parent            string `json:",omitempty"`
+	ThresholdExceeded bool   `json:",omitempty"`
+}
+
+// newMetric returns a metric, with no value yet, that carries the metadata of its definition
+func newMetric(metricDef MetricDefinition) Metric {
+	return Metric{
+		Name:     metricDef.Name,
+		Value:    math.NaN(),
+		Unit:     metricDef.Unit,
+		Category: metricDef.Category,
+		Level:    metricDef.Level,
+		Parent:   metricDef.Parent,
+	}
 }
 
 // MetricFrame represents the metrics values and associated metadata
@@ -32,15 +51,23 @@ type MetricFrame struct {
 	Cgroup    string
 	PID       string
 	Cmd       string
+	TID       string
+	Thread    string
+	// per-device uncore metrics, only set when uncore detail is requested
+	UncoreMetrics []UncoreDeviceMetric `json:",omitempty"`
 }
 
-// ProcessEvents is responsible for producing metrics from raw perf events
-func ProcessEvents(perfEvents [][]byte, eventGroupDefinitions []GroupDefinition, metricDefinitions []MetricDefinition, processes []Process, previousTimestamp float64, metadata Metadata) (metricFrames []MetricFrame, timeStamp float64, err error) {
+// ProcessEvents is responsible for producing metrics from raw perf events. When energy is not nil,
+// power attributed to the collected processes (combined) or to each thread is added to the metrics.
+func ProcessEvents(perfEvents [][]byte, eventGroupDefinitions []GroupDefinition, metricDefinitions []MetricDefinition, processes []Process, previousTimestamp float64, metadata Metadata, energy *energySampler) (metricFrames []MetricFrame, timeStamp float64, err error) {
 	var eventFrames []EventFrame
 	if eventFrames, err = GetEventFrames(perfEvents, eventGroupDefinitions, flagScope, flagGranularity, metadata); err != nil { // arrange the events into groups
 		err = fmt.Errorf("failed to put perf events into groups: %v", err)
 		return
 	}
+	if flagScope == scopeThread {
+		eventFrames = groupThreadFrames(labelThreadFrames(eventFrames, processes), flagThreadGroups)
+	}
 	metricFrames = make([]MetricFrame, 0, len(eventFrames))
 	for _, eventFrame := range eventFrames {
 		timeStamp = eventFrame.Timestamp
@@ -53,14 +80,23 @@ func ProcessEvents(perfEvents [][]byte, eventGroupDefinitions []GroupDefinition,
 		var pidList []string
 		var cmdList []string
 		for _, process := range processes {
+			// in thread scope, only include the processes that own the frame's thread(s)
+			if flagScope == scopeThread && !slices.Contains(strings.Split(eventFrame.PID, ","), process.pid) {
+				continue
+			}
 			pidList = append(pidList, process.pid)
 			cmdList = append(cmdList, process.cmd)
 		}
 		metricFrame.PID = strings.Join(pidList, ",")
 		metricFrame.Cmd = strings.Join(cmdList, ",")
+		metricFrame.TID = eventFrame.TID
+		metricFrame.Thread = eventFrame.Thread
+		if flagUncoreDetail {
+			metricFrame.UncoreMetrics = getUncoreDeviceMetrics(eventFrame.UncoreDevices, eventFrame.Timestamp-previousTimestamp)
+		}
 		// produce metrics from event groups
 		for _, metricDef := range metricDefinitions {
-			metric := Metric{Name: metricDef.Name, Value: math.NaN()}
+			metric := newMetric(metricDef)
 			var variables map[string]any
 			if variables, err = getExpressionVariableValues(metricDef, eventFrame, previousTimestamp, metadata); err != nil {
 				slog.Debug("failed to get expression variable values", slog.String("error", err.Error()))
@@ -81,6 +117,17 @@ func ProcessEvents(perfEvents [][]byte, eventGroupDefinitions []GroupDefinition,
 			}
 			slog.Debug("processed metric", slog.String("name", metricDef.Name), slog.String("expression", metricDef.Expression), slog.String("vars", strings.Join(prettyVars, ", ")))
 		}
+		// thresholds may refer to other metrics, so they are evaluated after all metrics are computed
+		metricValues := make(map[string]float64, len(metricFrame.Metrics))
+		for _, metric := range metricFrame.Metrics {
+			metricValues[metric.Name] = metric.Value
+		}
+		for i, metricDef := range metricDefinitions {
+			metricFrame.Metrics[i].ThresholdExceeded = isThresholdExceeded(metricDef, metricFrame.Metrics[i].Value, metricValues)
+		}
+		if energy != nil {
+			metricFrame.Metrics = append(metricFrame.Metrics, energy.getAttributedPowerMetrics(eventFrame, previousTimestamp)...)
+		}
 		metricFrames = append(metricFrames, metricFrame)
 	}
 	return
@@ -234,6 +281,44 @@ func evaluateExpression(metric MetricDefinition, variables map[string]any) (resu
 	return
 }
 
+// thresholdParameters provides the values of the variables in a threshold expression, where "value"
+// is the value of the metric being evaluated and other variables are the values of other metrics
+type thresholdParameters struct {
+	value        float64
+	metricValues map[string]float64
+}
+
+func (p thresholdParameters) Get(name string) (any, error) {
+	if name == "value" {
+		return p.value, nil
+	}
+	if value, ok := p.metricValues[name]; ok {
+		return value, nil
+	}
+	return nil, fmt.Errorf("no value for %s", name)
+}
+
+// isThresholdExceeded evaluates the metric's threshold expression. It returns false when the metric
+// has no threshold, has no valid value, or when the threshold can't be evaluated, e.g., because it
+// refers to a metric that isn't available.
+func isThresholdExceeded(metricDef MetricDefinition, value float64, metricValues map[string]float64) (exceeded bool) {
+	if metricDef.ThresholdEvaluable == nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return false
+	}
+	defer func() {
+		if errx := recover(); errx != nil {
+			exceeded = false
+		}
+	}()
+	result, err := metricDef.ThresholdEvaluable.Eval(thresholdParameters{value: value, metricValues: metricValues})
+	if err != nil {
+		slog.Debug("failed to evaluate threshold", slog.String("metric", metricDef.Name), slog.String("error", err.Error()))
+		return false
+	}
+	exceeded, _ = result.(bool)
+	return
+}
+
 // write json formatted events to raw file
 func writeEventsToFile(path string, events [][]byte) (err error) {
 	rawFile, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644) // #nosec G304 G302
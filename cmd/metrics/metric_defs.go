@@ -7,8 +7,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/Knetic/govaluate"
@@ -19,11 +21,22 @@ type Variable struct {
 	EventGroupIdx int // initialized to -1 to indicate that a group has not yet been identified
 }
 
+// MetricDefinition is a metric as defined in a metric definition file. Only the name and expression
+// are required. The optional threshold is an expression that evaluates to true when the metric's value
+// indicates a potential problem. In the threshold, [value] refers to the metric's value and other
+// metrics can be referenced by name, e.g., "[value] > 20 && [TMA_Backend_Bound(%)] > 20".
 type MetricDefinition struct {
-	Name       string                         `json:"name"`
-	Expression string                         `json:"expression"`
-	Variables  map[string]int                 // parsed from Expression for efficiency, int represents group index
-	Evaluable  *govaluate.EvaluableExpression // parse expression once, store here for use in metric evaluation
+	Name               string                         `json:"name"`
+	Expression         string                         `json:"expression"`
+	Unit               string                         `json:"unit,omitempty"`
+	Description        string                         `json:"description,omitempty"`
+	Category           string                         `json:"category,omitempty"`
+	Level              int                            `json:"level,omitempty"`  // depth in the TMA hierarchy, 1 is the top level
+	Parent             string                         `json:"parent,omitempty"` // name of the parent metric in the TMA hierarchy
+	Threshold          string                         `json:"threshold,omitempty"`
+	Variables          map[string]int                 // parsed from Expression for efficiency, int represents group index
+	Evaluable          *govaluate.EvaluableExpression // parse expression once, store here for use in metric evaluation
+	ThresholdEvaluable *govaluate.EvaluableExpression // parsed Threshold, nil when the metric has no threshold
 }
 
 // LoadMetricDefinitions reads and parses metric definitions from an architecture-specific metric
@@ -38,14 +51,7 @@ func LoadMetricDefinitions(metricDefinitionOverridePath string, selectedMetrics
 			return
 		}
 	} else {
-		uarch := strings.ToLower(strings.Split(metadata.Microarchitecture, "_")[0])
-		uarch = strings.Split(uarch, " ")[0]
-		// use alternate events/metrics when TMA fixed counters are not supported
-		alternate := ""
-		if (uarch == "icx" || uarch == "spr" || uarch == "emr") && !metadata.SupportsFixedTMA {
-			alternate = "_nofixedtma"
-		}
-		metricFileName := fmt.Sprintf("%s%s.json", uarch, alternate)
+		metricFileName := getDefinitionFileBaseName(metadata) + ".json"
 		if bytes, err = resources.ReadFile(filepath.Join("resources", "metrics", metadata.Architecture, metadata.Vendor, metricFileName)); err != nil {
 			return
 		}
@@ -75,12 +81,79 @@ func LoadMetricDefinitions(metricDefinitionOverridePath string, selectedMetrics
 	return
 }
 
-// ConfigureMetrics prepares metrics for use by the evaluator, by e.g., replacing
-// metric constants with known values and aligning metric variables to perf event
-// groups
-func ConfigureMetrics(loadedMetrics []MetricDefinition, uncollectableEvents []string, evaluatorFunctions map[string]govaluate.ExpressionFunction, metadata Metadata) (metrics []MetricDefinition, err error) {
-	// get constants as strings
-	tscFreq := fmt.Sprintf("%f", float64(metadata.TSCFrequencyHz))
+// getMetricDefinitionsByName returns the metric definitions, and the definitions of the metrics that
+// are added during collection, by metric name
+func getMetricDefinitionsByName(metricDefinitions []MetricDefinition) map[string]MetricDefinition {
+	definitions := make(map[string]MetricDefinition, len(metricDefinitions)+len(attributedPowerDefinitions))
+	for _, definition := range slices.Concat(metricDefinitions, attributedPowerDefinitions) {
+		definitions[definition.Name] = definition
+	}
+	return definitions
+}
+
+// getMetricCatalog formats the metric definitions as a catalog grouped by category, in the order
+// the categories first appear. Each metric's quoted name, which can be used with --metrics, is
+// followed by its unit and description, when defined.
+func getMetricCatalog(metricDefinitions []MetricDefinition) string {
+	var categories []string
+	metricsByCategory := make(map[string][]MetricDefinition)
+	for _, metric := range metricDefinitions {
+		category := metric.Category
+		if category == "" {
+			category = "Other"
+		}
+		if _, ok := metricsByCategory[category]; !ok {
+			categories = append(categories, category)
+		}
+		metricsByCategory[category] = append(metricsByCategory[category], metric)
+	}
+	var sb strings.Builder
+	for _, category := range categories {
+		fmt.Fprintf(&sb, "\n%s:\n", category)
+		for _, metric := range metricsByCategory[category] {
+			fmt.Fprintf(&sb, "  \"%s\"", metric.Name)
+			if metric.Unit != "" {
+				fmt.Fprintf(&sb, " [%s]", metric.Unit)
+			}
+			sb.WriteString("\n")
+			if metric.Description != "" {
+				fmt.Fprintf(&sb, "      %s\n", metric.Description)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// findUncollectableEvent returns the first uncollectable event, in name order, used in the metric
+// expression and the reason it can't be collected. The transaction rate is treated as an
+// uncollectable event when it isn't provided. An empty event name is returned when all of the
+// expression's events are collectable.
+func findUncollectableEvent(expression string, uncollectableEvents map[string]string) (event string, reason string) {
+	if isTransactionRateMissing(expression) {
+		return "TXN", fmt.Sprintf("transaction rate not provided, see --%s", flagTransactionRateName)
+	}
+	for _, uncollectableEvent := range slices.Sorted(maps.Keys(uncollectableEvents)) {
+		if strings.Contains(expression, uncollectableEvent) {
+			return uncollectableEvent, uncollectableEvents[uncollectableEvent]
+		}
+	}
+	return "", ""
+}
+
+// isTransactionRateMissing returns true if the metric expression uses the transaction rate and it
+// isn't provided. The transaction rate metrics are opt-in.
+func isTransactionRateMissing(expression string) bool {
+	return flagTransactionRate == 0 && strings.Contains(expression, "TXN")
+}
+
+// metricConstant is a variable in metric expressions that is replaced by a value known before collection
+type metricConstant struct {
+	Name  string // variable name in the expression, including the brackets
+	Value string
+}
+
+// getMetricConstants returns the constants that are replaced with their values in metric expressions
+func getMetricConstants(metadata Metadata) (constants []metricConstant, err error) {
 	var tsc string
 	if flagGranularity == granularitySystem {
 		tsc = fmt.Sprintf("%f", float64(metadata.TSC))
@@ -92,30 +165,36 @@ func ConfigureMetrics(loadedMetrics []MetricDefinition, uncollectableEvents []st
 		err = fmt.Errorf("unknown granularity: %s", flagGranularity)
 		return
 	}
+	constants = []metricConstant{
+		{"[SYSTEM_TSC_FREQ]", fmt.Sprintf("%f", float64(metadata.TSCFrequencyHz))},
+		{"[TSC]", tsc},
+		{"[CORES_PER_SOCKET]", fmt.Sprintf("%f", float64(metadata.CoresPerSocket))},
+		{"[CHAS_PER_SOCKET]", fmt.Sprintf("%f", float64(len(metadata.UncoreDeviceIDs["cha"])))},
+		{"[SOCKET_COUNT]", fmt.Sprintf("%f", float64(metadata.SocketCount))},
+		{"[HYPERTHREADING_ON]", fmt.Sprintf("%t", metadata.ThreadsPerCore > 1)},
+		{"[CONST_THREAD_COUNT]", fmt.Sprintf("%f", float64(metadata.ThreadsPerCore))},
+		{"[TXN]", fmt.Sprintf("%f", flagTransactionRate)},
+	}
+	return
+}
 
-	coresPerSocket := fmt.Sprintf("%f", float64(metadata.CoresPerSocket))
-	chasPerSocket := fmt.Sprintf("%f", float64(len(metadata.UncoreDeviceIDs["cha"])))
-	socketCount := fmt.Sprintf("%f", float64(metadata.SocketCount))
-	hyperThreadingOn := fmt.Sprintf("%t", metadata.ThreadsPerCore > 1)
-	threadsPerCore := fmt.Sprintf("%f", float64(metadata.ThreadsPerCore))
+// ConfigureMetrics prepares metrics for use by the evaluator, by e.g., replacing
+// metric constants with known values and aligning metric variables to perf event
+// groups
+func ConfigureMetrics(loadedMetrics []MetricDefinition, uncollectableEvents map[string]string, evaluatorFunctions map[string]govaluate.ExpressionFunction, metadata Metadata) (metrics []MetricDefinition, err error) {
+	// get constants as strings
+	constants, err := getMetricConstants(metadata)
+	if err != nil {
+		return
+	}
 	// configure each metric
 	for metricIdx := range loadedMetrics {
 		tmpMetric := loadedMetrics[metricIdx]
 		// abbreviate event names in metric expressions to match abbreviations used in uncollectableEvents
 		tmpMetric.Expression = abbreviateEventName(tmpMetric.Expression)
 		// skip metrics that use uncollectable events
-		foundUncollectable := false
-		if flagTransactionRate == 0 {
-			uncollectableEvents = append(uncollectableEvents, "TXN")
-		}
-		for _, uncollectableEvent := range uncollectableEvents {
-			if strings.Contains(tmpMetric.Expression, uncollectableEvent) {
-				slog.Debug("removing metric that uses uncollectable event", slog.String("metric", tmpMetric.Name), slog.String("event", uncollectableEvent))
-				foundUncollectable = true
-				break
-			}
-		}
-		if foundUncollectable {
+		if uncollectableEvent, _ := findUncollectableEvent(tmpMetric.Expression, uncollectableEvents); uncollectableEvent != "" {
+			slog.Debug("removing metric that uses uncollectable event", slog.String("metric", tmpMetric.Name), slog.String("event", uncollectableEvent))
 			continue
 		}
 		// transform if/else to ?/:
@@ -128,14 +207,9 @@ func ConfigureMetrics(loadedMetrics []MetricDefinition, uncollectableEvents []st
 			tmpMetric.Expression = transformed
 		}
 		// replace constants with their values
-		tmpMetric.Expression = strings.ReplaceAll(tmpMetric.Expression, "[SYSTEM_TSC_FREQ]", tscFreq)
-		tmpMetric.Expression = strings.ReplaceAll(tmpMetric.Expression, "[TSC]", tsc)
-		tmpMetric.Expression = strings.ReplaceAll(tmpMetric.Expression, "[CORES_PER_SOCKET]", coresPerSocket)
-		tmpMetric.Expression = strings.ReplaceAll(tmpMetric.Expression, "[CHAS_PER_SOCKET]", chasPerSocket)
-		tmpMetric.Expression = strings.ReplaceAll(tmpMetric.Expression, "[SOCKET_COUNT]", socketCount)
-		tmpMetric.Expression = strings.ReplaceAll(tmpMetric.Expression, "[HYPERTHREADING_ON]", hyperThreadingOn)
-		tmpMetric.Expression = strings.ReplaceAll(tmpMetric.Expression, "[CONST_THREAD_COUNT]", threadsPerCore)
-		tmpMetric.Expression = strings.ReplaceAll(tmpMetric.Expression, "[TXN]", fmt.Sprintf("%f", flagTransactionRate))
+		for _, constant := range constants {
+			tmpMetric.Expression = strings.ReplaceAll(tmpMetric.Expression, constant.Name, constant.Value)
+		}
 		// get a list of the variables in the expression
 		tmpMetric.Variables = make(map[string]int)
 		expressionIdx := 0
@@ -157,6 +231,12 @@ func ConfigureMetrics(loadedMetrics []MetricDefinition, uncollectableEvents []st
 			slog.Error("failed to create evaluable expression for metric", slog.String("error", err.Error()), slog.String("metric name", tmpMetric.Name), slog.String("metric expression", tmpMetric.Expression))
 			return
 		}
+		if tmpMetric.Threshold != "" {
+			if tmpMetric.ThresholdEvaluable, err = govaluate.NewEvaluableExpressionWithFunctions(tmpMetric.Threshold, evaluatorFunctions); err != nil {
+				err = fmt.Errorf("failed to parse threshold of metric %s: %w", tmpMetric.Name, err)
+				return
+			}
+		}
 		metrics = append(metrics, tmpMetric)
 	}
 	return
@@ -3,7 +3,17 @@ package metrics
 // Copyright (C) 2021-2025 Intel Corporation
 // SPDX-License-Identifier: BSD-3-Clause
 
-import "testing"
+import (
+	"encoding/json"
+	"io/fs"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/Knetic/govaluate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
 
 func TestTransformConditional(t *testing.T) {
 	var in string
@@ -73,3 +83,73 @@ func TestTransformConditional(t *testing.T) {
 		t.Errorf("improper transform: [%s] -> [%s]", in, out)
 	}
 }
+
+func TestMetricDefinitionFiles(t *testing.T) {
+	err := fs.WalkDir(resources, "resources/metrics", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".json") {
+			return err
+		}
+		bytes, err := resources.ReadFile(path)
+		require.NoError(t, err)
+		var metrics []MetricDefinition
+		require.NoError(t, json.Unmarshal(bytes, &metrics), path)
+		names := make(map[string]bool)
+		for _, metric := range metrics {
+			assert.NotEmpty(t, metric.Category, "%s: %s", path, metric.Name)
+			if metric.Parent != "" {
+				assert.True(t, names[metric.Parent], "%s: parent of %s must precede it", path, metric.Name)
+			}
+			if metric.Threshold != "" {
+				_, err := govaluate.NewEvaluableExpressionWithFunctions(metric.Threshold, GetEvaluatorFunctions())
+				assert.NoError(t, err, "%s: %s", path, metric.Name)
+			}
+			names[metric.Name] = true
+		}
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestMetricMetadata(t *testing.T) {
+	// the optional fields may be omitted
+	var metrics []MetricDefinition
+	require.NoError(t, json.Unmarshal([]byte(`[
+		{"name": "CPI", "expression": "[cpu-cycles] / [instructions]"},
+		{"name": "TMA_Backend_Bound(%)", "expression": "100", "unit": "%", "category": "TMA", "level": 1, "threshold": "[value] > 20"},
+		{"name": "TMA_..Memory_Bound(%)", "expression": "100", "unit": "%", "category": "TMA", "level": 2, "parent": "TMA_Backend_Bound(%)",
+		 "threshold": "[value] > 20 && [TMA_Backend_Bound(%)] > 20", "description": "memory stalls"}
+	]`), &metrics))
+	require.Len(t, metrics, 3)
+	assert.Equal(t, MetricDefinition{Name: "CPI", Expression: "[cpu-cycles] / [instructions]"}, metrics[0])
+	assert.Equal(t, 2, metrics[2].Level)
+	assert.Equal(t, "TMA_Backend_Bound(%)", metrics[2].Parent)
+
+	metric := newMetric(metrics[2])
+	assert.Equal(t, "%", metric.Unit)
+	assert.Equal(t, "TMA", metric.Category)
+	assert.True(t, math.IsNaN(metric.Value))
+
+	for i := range metrics[1:] {
+		var err error
+		metrics[i+1].ThresholdEvaluable, err = govaluate.NewEvaluableExpressionWithFunctions(metrics[i+1].Threshold, GetEvaluatorFunctions())
+		require.NoError(t, err)
+	}
+	values := map[string]float64{"TMA_Backend_Bound(%)": 30}
+	assert.True(t, isThresholdExceeded(metrics[2], 25, values))
+	assert.False(t, isThresholdExceeded(metrics[2], 15, values))
+	assert.False(t, isThresholdExceeded(metrics[2], math.NaN(), values))
+	// the threshold can't be evaluated when a referenced metric is missing
+	assert.False(t, isThresholdExceeded(metrics[2], 25, map[string]float64{}))
+	assert.False(t, isThresholdExceeded(metrics[0], 25, values))
+
+	catalog := getMetricCatalog(metrics)
+	assert.Equal(t, `
+Other:
+  "CPI"
+
+TMA:
+  "TMA_Backend_Bound(%)" [%]
+  "TMA_..Memory_Bound(%)" [%]
+      memory stalls
+`, catalog)
+}
@@ -11,9 +11,12 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
+	"math"
 	"os"
 	"os/exec"
 	"os/signal"
+	"path"
 	"path/filepath"
 	"regexp"
 	"strconv"
@@ -24,6 +27,7 @@ import (
 
 	"perfspect/internal/common"
 	"perfspect/internal/progress"
+	"perfspect/internal/report"
 	"perfspect/internal/script"
 	"perfspect/internal/target"
 	"perfspect/internal/util"
@@ -42,7 +46,9 @@ var examples = []string{
 	fmt.Sprintf("  Metrics from remote host:                 $ %s %s --target 192.168.1.1 --user fred --key fred_key", common.AppName, cmdName),
 	fmt.Sprintf("  Metrics for \"hot\" processes:              $ %s %s --scope process", common.AppName, cmdName),
 	fmt.Sprintf("  Metrics for specified processes:          $ %s %s --scope process --pids 1234,6789", common.AppName, cmdName),
+	fmt.Sprintf("  Metrics for threads of a process:         $ %s %s --scope thread --pids 1234 --thread-groups \"GC*,worker-*\"", common.AppName, cmdName),
 	fmt.Sprintf("  Start application and collect metrics:    $ %s %s -- /path/to/myapp arg1 arg2", common.AppName, cmdName),
+	fmt.Sprintf("  Repeat application runs after warmup:     $ %s %s --repeat 5 --warmup 1 -- /path/to/myapp arg1 arg2", common.AppName, cmdName),
 	fmt.Sprintf("  Metrics adjusted for transaction rate:    $ %s %s --txnrate 100", common.AppName, cmdName),
 	fmt.Sprintf("  \"Live\" metrics:                           $ %s %s --live", common.AppName, cmdName),
 }
@@ -96,11 +102,23 @@ var (
 	flagFilter   string
 	flagCount    int
 	flagRefresh  int
+	// thread scope options
+	flagThreadGroups []string
+	// application options
+	flagRepeat int
+	flagWarmup int
+	// trigger options
+	flagTrigger         string
+	flagTriggerAction   string
+	flagTriggerDuration int
+	flagTriggerCooldown int
 	// output format options
 	flagGranularity     string
 	flagOutputFormat    []string
 	flagLive            bool
 	flagTransactionRate float64
+	flagUncoreDetail    bool
+	flagReportFormat    []string
 	// advanced options
 	flagShowMetricNames   bool
 	flagMetricsList       []string
@@ -112,6 +130,7 @@ var (
 	flagWriteEventsToFile bool
 	flagInput             string
 	flagNoSystemSummary   bool
+	flagStrict            bool
 
 	// positional arguments
 	argsApplication []string
@@ -126,10 +145,22 @@ const (
 	flagCountName    = "count"
 	flagRefreshName  = "refresh"
 
+	flagThreadGroupsName = "thread-groups"
+
+	flagRepeatName = "repeat"
+	flagWarmupName = "warmup"
+
+	flagTriggerName         = "trigger"
+	flagTriggerActionName   = "trigger-action"
+	flagTriggerDurationName = "trigger-duration"
+	flagTriggerCooldownName = "trigger-cooldown"
+
 	flagGranularityName     = "granularity"
 	flagOutputFormatName    = "format"
 	flagLiveName            = "live"
 	flagTransactionRateName = "txnrate"
+	flagUncoreDetailName    = "uncore-detail"
+	flagReportFormatName    = "report-format"
 
 	flagShowMetricNamesName   = "list"
 	flagMetricsListName       = "metrics"
@@ -141,6 +172,7 @@ const (
 	flagWriteEventsToFileName = "raw"
 	flagInputName             = "input"
 	flagNoSystemSummaryName   = "no-summary"
+	flagStrictName            = "strict"
 )
 
 const (
@@ -155,18 +187,21 @@ const (
 	scopeSystem  = "system"
 	scopeProcess = "process"
 	scopeCgroup  = "cgroup"
+	scopeThread  = "thread"
 )
 
-var scopeOptions = []string{scopeSystem, scopeProcess, scopeCgroup}
+var scopeOptions = []string{scopeSystem, scopeProcess, scopeCgroup, scopeThread}
 
 const (
-	formatTxt  = "txt"
-	formatCSV  = "csv"
-	formatJSON = "json"
-	formatWide = "wide"
+	formatTxt     = "txt"
+	formatCSV     = "csv"
+	formatJSON    = "json"
+	formatWide    = "wide"
+	formatParquet = "parquet"
+	formatSQLite  = "sqlite"
 )
 
-var formatOptions = []string{formatTxt, formatCSV, formatJSON, formatWide}
+var formatOptions = []string{formatTxt, formatCSV, formatJSON, formatWide, formatParquet, formatSQLite}
 
 func init() {
 	Cmd.Flags().IntVar(&flagDuration, flagDurationName, 0, "")
@@ -176,11 +211,20 @@ func init() {
 	Cmd.Flags().StringVar(&flagFilter, flagFilterName, "", "")
 	Cmd.Flags().IntVar(&flagCount, flagCountName, 5, "")
 	Cmd.Flags().IntVar(&flagRefresh, flagRefreshName, 30, "")
+	Cmd.Flags().StringSliceVar(&flagThreadGroups, flagThreadGroupsName, []string{}, "")
+	Cmd.Flags().IntVar(&flagRepeat, flagRepeatName, 1, "")
+	Cmd.Flags().IntVar(&flagWarmup, flagWarmupName, 0, "")
+	Cmd.Flags().StringVar(&flagTrigger, flagTriggerName, "", "")
+	Cmd.Flags().StringVar(&flagTriggerAction, flagTriggerActionName, common.TriggerActionFlame, "")
+	Cmd.Flags().IntVar(&flagTriggerDuration, flagTriggerDurationName, 30, "")
+	Cmd.Flags().IntVar(&flagTriggerCooldown, flagTriggerCooldownName, 300, "")
 
 	Cmd.Flags().StringVar(&flagGranularity, flagGranularityName, granularitySystem, "")
 	Cmd.Flags().StringSliceVar(&flagOutputFormat, flagOutputFormatName, []string{formatCSV}, "")
 	Cmd.Flags().BoolVar(&flagLive, flagLiveName, false, "")
 	Cmd.Flags().Float64Var(&flagTransactionRate, flagTransactionRateName, 0, "")
+	Cmd.Flags().BoolVar(&flagUncoreDetail, flagUncoreDetailName, false, "")
+	Cmd.Flags().StringSliceVar(&flagReportFormat, flagReportFormatName, []string{report.FormatAll}, "")
 
 	Cmd.Flags().BoolVar(&flagShowMetricNames, flagShowMetricNamesName, false, "")
 	Cmd.Flags().StringSliceVar(&flagMetricsList, flagMetricsListName, []string{}, "")
@@ -192,6 +236,7 @@ func init() {
 	Cmd.Flags().BoolVar(&flagWriteEventsToFile, flagWriteEventsToFileName, false, "")
 	Cmd.Flags().StringVar(&flagInput, flagInputName, "", "")
 	Cmd.Flags().BoolVar(&flagNoSystemSummary, flagNoSystemSummaryName, false, "")
+	Cmd.Flags().BoolVar(&flagStrict, flagStrictName, false, "")
 
 	common.AddTargetFlags(Cmd)
 
@@ -239,7 +284,7 @@ func getFlagGroups() []common.FlagGroup {
 		},
 		{
 			Name: flagPidListName,
-			Help: "comma separated list of process ids. If not provided while collecting in process scope, \"hot\" processes will be monitored.",
+			Help: "comma separated list of process ids. If not provided while collecting in process or thread scope, \"hot\" processes will be monitored.",
 		},
 		{
 			Name: flagCidListName,
@@ -257,11 +302,46 @@ func getFlagGroups() []common.FlagGroup {
 			Name: flagRefreshName,
 			Help: "number of seconds to run before refreshing the \"hot\" or \"filtered\" process or cgroup list. If 0, the list will not be refreshed.",
 		},
+		{
+			Name: flagThreadGroupsName,
+			Help: "comma separated list of thread name patterns, e.g., \"GC*,worker-*\". Threads with names matching a pattern are aggregated into one row. Only valid in thread scope.",
+		},
+		{
+			Name: flagRepeatName,
+			Help: "number of times to run the application. Metrics are collected and summarized for each run, and summarized across runs. Only valid with an application argument.",
+		},
+		{
+			Name: flagWarmupName,
+			Help: "number of times to run the application before the measured runs. Metrics from warmup runs are discarded. Only valid with an application argument.",
+		},
 	}
 	groups = append(groups, common.FlagGroup{
 		GroupName: "Collection Options",
 		Flags:     flags,
 	})
+	// trigger options
+	flags = []common.Flag{
+		{
+			Name: flagTriggerName,
+			Help: "expression evaluated over the metrics of each collection interval, e.g., \"[TMA_..Memory_Bound(%)] > 60\". Metric names are enclosed in square brackets. When the expression is true, a capture is started on the target.",
+		},
+		{
+			Name: flagTriggerActionName,
+			Help: fmt.Sprintf("capture started when the trigger fires, options: %s", strings.Join(common.TriggerActionOptions, ", ")),
+		},
+		{
+			Name: flagTriggerDurationName,
+			Help: "number of seconds to run each triggered capture",
+		},
+		{
+			Name: flagTriggerCooldownName,
+			Help: "number of seconds after a triggered capture ends before the trigger can fire again",
+		},
+	}
+	groups = append(groups, common.FlagGroup{
+		GroupName: "Trigger Options",
+		Flags:     flags,
+	})
 	// output options
 	flags = []common.Flag{
 		{
@@ -270,7 +350,7 @@ func getFlagGroups() []common.FlagGroup {
 		},
 		{
 			Name: flagOutputFormatName,
-			Help: fmt.Sprintf("output formats, options: %s", strings.Join(formatOptions, ", ")),
+			Help: fmt.Sprintf("output formats, options: %s. The %s and %s formats have one row per metric value with columns timestamp, host, socket, cpu, cgroup, pid, metric, and value.", strings.Join(formatOptions, ", "), formatParquet, formatSQLite),
 		},
 		{
 			Name: flagLiveName,
@@ -280,6 +360,14 @@ func getFlagGroups() []common.FlagGroup {
 			Name: flagTransactionRateName,
 			Help: "number of transactions per second. Will divide relevant metrics by transactions/second.",
 		},
+		{
+			Name: flagUncoreDetailName,
+			Help: "write per-device uncore metrics (IMC channel bandwidth, UPI link utilization, CHA occupancy) and imbalance warnings to dedicated files. Only valid when collecting at system scope and granularity.",
+		},
+		{
+			Name: flagReportFormatName,
+			Help: fmt.Sprintf("summary report formats, options: %s. The reports include the system summary, the statistics of each metric, and the TMA breakdown. A combined html and xlsx report is created when collecting from more than one target.", strings.Join(append([]string{report.FormatAll}, report.FormatOptions...), ", ")),
+		},
 	}
 	groups = append(groups, common.FlagGroup{
 		GroupName: "Output Options",
@@ -289,7 +377,7 @@ func getFlagGroups() []common.FlagGroup {
 	flags = []common.Flag{
 		{
 			Name: flagShowMetricNamesName,
-			Help: "show metric names available on this platform and exit",
+			Help: "show the metrics available on this platform, grouped by category with units and descriptions, and exit",
 		},
 		{
 			Name: flagMetricsListName,
@@ -327,6 +415,10 @@ func getFlagGroups() []common.FlagGroup {
 			Name: flagNoSystemSummaryName,
 			Help: "do not include system summary table in report",
 		},
+		{
+			Name: flagStrictName,
+			Help: "fail if any of the requested metrics can't be computed on the target, metrics skipped because --txnrate isn't given are ignored",
+		},
 	}
 	groups = append(groups, common.FlagGroup{
 		GroupName: "Advanced Options",
@@ -358,6 +450,53 @@ func validateFlags(cmd *cobra.Command, args []string) error {
 		if cmd.Flags().Lookup(flagCountName).Changed {
 			return common.FlagValidationError(cmd, "count is not supported with an application argument")
 		}
+		if flagScope == scopeThread {
+			return common.FlagValidationError(cmd, fmt.Sprintf("%s scope is not supported with an application argument", scopeThread))
+		}
+	}
+	// repeated application runs
+	if cmd.Flags().Lookup(flagRepeatName).Changed || cmd.Flags().Lookup(flagWarmupName).Changed {
+		if len(args) == 0 {
+			return common.FlagValidationError(cmd, "repeat and warmup are only supported with an application argument")
+		}
+		if flagRepeat < 1 {
+			return common.FlagValidationError(cmd, "repeat must be greater than 0")
+		}
+		if flagWarmup < 0 {
+			return common.FlagValidationError(cmd, "warmup must be greater than or equal to 0")
+		}
+		if flagScope != scopeSystem || flagGranularity != granularitySystem {
+			return common.FlagValidationError(cmd, fmt.Sprintf("repeat and warmup are only supported when scope and granularity are %s", scopeSystem))
+		}
+		if flagLive {
+			return common.FlagValidationError(cmd, fmt.Sprintf("cannot repeat application runs when --%s is set", flagLiveName))
+		}
+	}
+	// trigger options
+	if flagTrigger != "" {
+		if _, err := common.ParseTriggerExpression(flagTrigger, GetEvaluatorFunctions()); err != nil {
+			return common.FlagValidationError(cmd, err.Error())
+		}
+		if !slices.Contains(common.TriggerActionOptions, flagTriggerAction) {
+			return common.FlagValidationError(cmd, fmt.Sprintf("invalid trigger action: %s, valid options are: %s", flagTriggerAction, strings.Join(common.TriggerActionOptions, ", ")))
+		}
+		if flagTriggerDuration < 1 {
+			return common.FlagValidationError(cmd, "trigger duration must be greater than 0")
+		}
+		if flagTriggerCooldown < 0 {
+			return common.FlagValidationError(cmd, "trigger cooldown must be greater than or equal to 0")
+		}
+		if isRepeatingRuns() {
+			return common.FlagValidationError(cmd, "triggers are not supported when repeating application runs")
+		}
+		if flagInput != "" {
+			return common.FlagValidationError(cmd, fmt.Sprintf("triggers are not supported with --%s", flagInputName))
+		}
+		if flagNoRoot {
+			return common.FlagValidationError(cmd, fmt.Sprintf("triggers are not supported with --%s, captures require elevated privileges", flagNoRootName))
+		}
+	} else if cmd.Flags().Lookup(flagTriggerActionName).Changed || cmd.Flags().Lookup(flagTriggerDurationName).Changed || cmd.Flags().Lookup(flagTriggerCooldownName).Changed {
+		return common.FlagValidationError(cmd, fmt.Sprintf("trigger action, duration, and cooldown require --%s", flagTriggerName))
 	}
 	// confirm valid duration
 	if cmd.Flags().Lookup(flagDurationName).Changed && flagDuration != 0 && flagDuration < flagPerfPrintInterval {
@@ -373,12 +512,14 @@ func validateFlags(cmd *cobra.Command, args []string) error {
 	}
 	// pid list changed
 	if len(flagPidList) > 0 {
-		// if scope was set and it wasn't set to process, error
-		if cmd.Flags().Changed(flagScopeName) && flagScope != scopeProcess {
-			return common.FlagValidationError(cmd, fmt.Sprintf("cannot specify pids when scope is not %s", scopeProcess))
+		// if scope was set and it wasn't set to process or thread, error
+		if cmd.Flags().Changed(flagScopeName) && flagScope != scopeProcess && flagScope != scopeThread {
+			return common.FlagValidationError(cmd, fmt.Sprintf("cannot specify pids when scope is not %s or %s", scopeProcess, scopeThread))
 		}
 		// if scope wasn't set, set it to process
-		flagScope = scopeProcess
+		if flagScope != scopeThread {
+			flagScope = scopeProcess
+		}
 		// verify PIDs are integers
 		for _, pid := range flagPidList {
 			if _, err := strconv.Atoi(pid); err != nil {
@@ -398,8 +539,8 @@ func validateFlags(cmd *cobra.Command, args []string) error {
 	// filter changed
 	if flagFilter != "" {
 		// if scope isn't process or cgroup, error
-		if flagScope != scopeProcess && flagScope != scopeCgroup {
-			return common.FlagValidationError(cmd, fmt.Sprintf("cannot specify filter when scope is not %s or %s", scopeProcess, scopeCgroup))
+		if flagScope != scopeProcess && flagScope != scopeCgroup && flagScope != scopeThread {
+			return common.FlagValidationError(cmd, fmt.Sprintf("cannot specify filter when scope is not %s, %s or %s", scopeProcess, scopeCgroup, scopeThread))
 		}
 		// if pids or cids are specified, error
 		if len(flagPidList) > 0 || len(flagCidList) > 0 {
@@ -409,8 +550,8 @@ func validateFlags(cmd *cobra.Command, args []string) error {
 	// count changed
 	if cmd.Flags().Lookup(flagCountName).Changed {
 		// if scope isn't process or cgroup, error
-		if flagScope != scopeProcess && flagScope != scopeCgroup {
-			return common.FlagValidationError(cmd, fmt.Sprintf("cannot specify count when scope is not %s or %s", scopeProcess, scopeCgroup))
+		if flagScope != scopeProcess && flagScope != scopeCgroup && flagScope != scopeThread {
+			return common.FlagValidationError(cmd, fmt.Sprintf("cannot specify count when scope is not %s, %s or %s", scopeProcess, scopeCgroup, scopeThread))
 		}
 		// if count is less than 1, error
 		if flagCount < 1 {
@@ -424,8 +565,8 @@ func validateFlags(cmd *cobra.Command, args []string) error {
 	// refresh changed
 	if cmd.Flags().Lookup(flagRefreshName).Changed {
 		// if scope isn't process or cgroup, error
-		if flagScope != scopeProcess && flagScope != scopeCgroup {
-			return common.FlagValidationError(cmd, fmt.Sprintf("cannot specify refresh when scope is not %s or %s", scopeProcess, scopeCgroup))
+		if flagScope != scopeProcess && flagScope != scopeCgroup && flagScope != scopeThread {
+			return common.FlagValidationError(cmd, fmt.Sprintf("cannot specify refresh when scope is not %s, %s or %s", scopeProcess, scopeCgroup, scopeThread))
 		}
 		// if pidlist or cidlist is set, error
 		if len(flagPidList) > 0 || len(flagCidList) > 0 {
@@ -444,6 +585,17 @@ func validateFlags(cmd *cobra.Command, args []string) error {
 			return common.FlagValidationError(cmd, fmt.Sprintf("refresh must be greater than or equal to the event collection interval (%d)", flagPerfPrintInterval))
 		}
 	}
+	// thread groups changed
+	if len(flagThreadGroups) > 0 {
+		if flagScope != scopeThread {
+			return common.FlagValidationError(cmd, fmt.Sprintf("cannot specify thread groups when scope is not %s", scopeThread))
+		}
+		for _, pattern := range flagThreadGroups {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return common.FlagValidationError(cmd, fmt.Sprintf("invalid thread group pattern: %s", pattern))
+			}
+		}
+	}
 	// output options
 	// confirm valid granularity
 	if cmd.Flags().Lookup(flagGranularityName).Changed && !slices.Contains(granularityOptions, flagGranularity) {
@@ -453,12 +605,27 @@ func validateFlags(cmd *cobra.Command, args []string) error {
 	if flagGranularity != granularitySystem && flagScope != scopeSystem {
 		return common.FlagValidationError(cmd, fmt.Sprintf("granularity option must be %s when collecting at a scope other than %s", granularitySystem, scopeSystem))
 	}
+	// uncore detail requires system scope and granularity, uncore events aren't collected otherwise
+	if flagUncoreDetail {
+		if flagScope != scopeSystem || flagGranularity != granularitySystem {
+			return common.FlagValidationError(cmd, fmt.Sprintf("uncore detail is only supported when scope and granularity are %s", scopeSystem))
+		}
+		if flagLive {
+			return common.FlagValidationError(cmd, fmt.Sprintf("cannot write uncore detail when --%s is set", flagLiveName))
+		}
+	}
 	// confirm valid output format
 	for _, format := range flagOutputFormat {
 		if !slices.Contains(formatOptions, format) {
 			return common.FlagValidationError(cmd, fmt.Sprintf("invalid output format: %s, valid options are: %s", format, strings.Join(formatOptions, ", ")))
 		}
 	}
+	// confirm valid summary report formats
+	for _, format := range flagReportFormat {
+		if format != report.FormatAll && !slices.Contains(report.FormatOptions, format) {
+			return common.FlagValidationError(cmd, fmt.Sprintf("invalid summary report format: %s, valid options are: %s", format, strings.Join(append([]string{report.FormatAll}, report.FormatOptions...), ", ")))
+		}
+	}
 	// advanced options
 	// confirm valid perf print interval
 	if cmd.Flags().Lookup(flagPerfPrintIntervalName).Changed {
@@ -487,6 +654,10 @@ func validateFlags(cmd *cobra.Command, args []string) error {
 	if flagLive && len(flagOutputFormat) > 1 {
 		return common.FlagValidationError(cmd, fmt.Sprintf("specify one output format with --%s <format> when --%s is set", flagOutputFormatName, flagLiveName))
 	}
+	// table formats are only written to file
+	if flagLive && isTableFormat(flagOutputFormat[0]) {
+		return common.FlagValidationError(cmd, fmt.Sprintf("the %s format can't be printed when --%s is set", flagOutputFormat[0], flagLiveName))
+	}
 	// event file path
 	if flagEventFilePath != "" {
 		if _, err := os.Stat(flagEventFilePath); err != nil {
@@ -507,6 +678,9 @@ func validateFlags(cmd *cobra.Command, args []string) error {
 	}
 	// input file path
 	if flagInput != "" {
+		if flagStrict {
+			return common.FlagValidationError(cmd, fmt.Sprintf("--%s is not supported with --%s", flagStrictName, flagInputName))
+		}
 		if _, err := os.Stat(flagInput); err != nil {
 			if os.IsNotExist(err) {
 				return common.FlagValidationError(cmd, fmt.Sprintf("input file path does not exist: %s", flagInput))
@@ -529,10 +703,14 @@ type targetContext struct {
 	nmiDisabled         bool
 	perfMuxIntervalsSet bool
 	perfMuxIntervals    map[string]int
+	pmuBusy             string             // describes the PMU counters in use by another tool, if any
+	capabilities        []metricCapability // analysis of the metrics that can be computed on the target
 	groupDefinitions    []GroupDefinition
 	metricDefinitions   []MetricDefinition
 	printedFiles        []string
 	perfStartTime       time.Time
+	runs                []RunResult            // measured application runs, when repeating runs
+	trigger             *common.TriggerWatcher // starts captures when the trigger expression is true, nil if no trigger
 }
 
 type targetError struct {
@@ -643,7 +821,8 @@ func readNextEventFrame(file *os.File) ([][]byte, error) {
 		lastInterval = string(match[1])
 	}
 }
-func processRawData(localOutputDir string) error {
+func processRawData(appContext common.AppContext) error {
+	localOutputDir := appContext.OutputDir
 	metadata, eventsFile, err := readRawData(flagInput)
 	if err != nil {
 		return err
@@ -651,7 +830,7 @@ func processRawData(localOutputDir string) error {
 	defer eventsFile.Close()
 	// load event definitions
 	var eventGroupDefinitions []GroupDefinition
-	var uncollectableEvents []string
+	var uncollectableEvents map[string]string
 	if eventGroupDefinitions, uncollectableEvents, err = LoadEventGroups(flagEventFilePath, metadata); err != nil {
 		err = fmt.Errorf("failed to load event definitions: %w", err)
 		return err
@@ -670,7 +849,14 @@ func processRawData(localOutputDir string) error {
 	}
 
 	var filesWritten []string
-
+	tableWriters, err := newMetricTableWriters(flagOutputFormat, metadata.Hostname, metadata, localOutputDir)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// close the table writers if processing fails before they are closed below
+		_, _ = closeMetricTableWriters(tableWriters)
+	}()
 	var frameTimestamp float64
 	frameCount := 1
 	for {
@@ -682,18 +868,34 @@ func processRawData(localOutputDir string) error {
 			break
 		}
 		var metricFrames []MetricFrame
-		metricFrames, frameTimestamp, err = ProcessEvents(bytes, eventGroupDefinitions, metricDefinitions, []Process{}, frameTimestamp, metadata)
+		metricFrames, frameTimestamp, err = ProcessEvents(bytes, eventGroupDefinitions, metricDefinitions, []Process{}, frameTimestamp, metadata, nil)
 		if err != nil {
 			return err
 		}
 		filesWritten = printMetrics(metricFrames, frameCount, metadata.Hostname, metadata.CollectionStartTime, localOutputDir)
+		for _, writer := range tableWriters {
+			if err = writer.Write(metricFrames, metadata.CollectionStartTime); err != nil {
+				return err
+			}
+		}
 		frameCount += len(metricFrames)
 	}
-	summaryFiles, err := summarizeMetrics(localOutputDir, metadata.Hostname, metadata)
+	tableFiles, err := closeMetricTableWriters(tableWriters)
+	tableWriters = nil
+	if err != nil {
+		return err
+	}
+	filesWritten = append(filesWritten, tableFiles...)
+	summaryFiles, err := summarizeMetrics(localOutputDir, metadata.Hostname, metadata, metricDefinitions)
 	if err != nil {
 		return err
 	}
 	filesWritten = append(filesWritten, summaryFiles...)
+	reportFiles, err := writeSummaryReports(appContext, []summaryReportSource{{name: metadata.Hostname, metadata: metadata, metricDefinitions: metricDefinitions}}, flagReportFormat)
+	if err != nil {
+		return err
+	}
+	filesWritten = append(filesWritten, reportFiles...)
 	printOutputFileNames([][]string{filesWritten})
 	return nil
 }
@@ -728,7 +930,7 @@ func runCmd(cmd *cobra.Command, args []string) error {
 			return err
 		}
 		// skip data collection and use raw data for reports
-		err = processRawData(localOutputDir)
+		err = processRawData(appContext)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			slog.Error(err.Error())
@@ -865,6 +1067,11 @@ func runCmd(cmd *cobra.Command, args []string) error {
 				err := EnableNMIWatchdog(targetContext.target, localTempDir)
 				if err != nil {
 					slog.Error("failed to re-enable NMI watchdog", slog.String("target", targetContext.target.GetName()), slog.String("error", err.Error()))
+					continue
+				}
+				err = common.ClearJournal(targetContext.target, []string{nmiWatchdogPath}, localTempDir)
+				if err != nil {
+					slog.Error("failed to clear NMI watchdog from journal", slog.String("target", targetContext.target.GetName()), slog.String("error", err.Error()))
 				}
 			}
 		}
@@ -876,6 +1083,11 @@ func runCmd(cmd *cobra.Command, args []string) error {
 				err := SetMuxIntervals(targetContext.target, targetContext.perfMuxIntervals, localTempDir)
 				if err != nil {
 					slog.Error("failed to reset perf mux intervals", slog.String("target", targetContext.target.GetName()), slog.String("error", err.Error()))
+					continue
+				}
+				err = common.ClearJournal(targetContext.target, slices.Collect(maps.Keys(targetContext.perfMuxIntervals)), localTempDir)
+				if err != nil {
+					slog.Error("failed to clear perf mux intervals from journal", slog.String("target", targetContext.target.GetName()), slog.String("error", err.Error()))
 				}
 			}
 		}
@@ -902,10 +1114,35 @@ func runCmd(cmd *cobra.Command, args []string) error {
 			numTargetsWithPreparedMetrics++
 		}
 	}
+	// save the capability analysis of each target, including the targets that failed because of it
+	if !flagLive && !flagShowMetricNames {
+		if err = common.CreateOutputDir(localOutputDir); err != nil {
+			err = fmt.Errorf("failed to create output directory: %w", err)
+			fmt.Fprintf(os.Stderr, "Error: %+v\n", err)
+			cmd.SilenceUsage = true
+			return err
+		}
+		for i := range targetContexts {
+			if len(targetContexts[i].capabilities) == 0 {
+				continue
+			}
+			capabilityFiles, err := writeCapabilities(localOutputDir, targetContexts[i].target.GetName(), targetContexts[i].capabilities)
+			if err != nil {
+				slog.Error("failed to write capability analysis", slog.String("target", targetContexts[i].target.GetName()), slog.String("error", err.Error()))
+			}
+			targetContexts[i].printedFiles = append(targetContexts[i].printedFiles, capabilityFiles...)
+		}
+	}
 	if numTargetsWithPreparedMetrics == 0 {
 		err := fmt.Errorf("no targets had metrics successfully prepared")
 		slog.Error(err.Error())
 		cmd.SilenceUsage = true
+		multiSpinner.Finish()
+		var capabilityFiles [][]string
+		for _, targetContext := range targetContexts {
+			capabilityFiles = append(capabilityFiles, targetContext.printedFiles)
+		}
+		printOutputFileNames(capabilityFiles)
 		return err
 	}
 	// show metric names and exit, if requested
@@ -914,9 +1151,7 @@ func runCmd(cmd *cobra.Command, args []string) error {
 		multiSpinner.Finish()
 		for _, targetContext := range targetContexts {
 			fmt.Printf("\nMetrics available on %s:\n", targetContext.target.GetName())
-			for _, metric := range targetContext.metricDefinitions {
-				fmt.Printf("\"%s\"\n", metric.Name)
-			}
+			fmt.Print(getMetricCatalog(targetContext.metricDefinitions))
 		}
 		return nil
 	}
@@ -953,21 +1188,37 @@ func runCmd(cmd *cobra.Command, args []string) error {
 	// finalize the spinner status, capture any errors, and create output files
 	var exitErrs []error
 	allPrintedFileNames := make([][]string, 0)
+	var reportSources []summaryReportSource
 	for i, targetContext := range targetContexts {
 		if targetContext.err == nil {
-			if !flagLive {
+			if !flagLive && isRepeatingRuns() {
+				_ = multiSpinner.Status(targetContext.target.GetName(), "collection complete")
+				summaryFiles, err := summarizeRepeatedRuns(localOutputDir, targetContext.target.GetName(), targetContext.runs, targetContext.metadata, targetContext.metricDefinitions)
+				if err != nil {
+					err = fmt.Errorf("failed to summarize metrics: %w", err)
+					exitErrs = append(exitErrs, err)
+				}
+				targetContexts[i].printedFiles = append(targetContexts[i].printedFiles, summaryFiles...)
+				for _, run := range targetContext.runs {
+					runName := getRunName(targetContext.target.GetName(), run.Run)
+					if exists, _ := util.FileExists(filepath.Join(localOutputDir, runName+"_metrics.csv")); exists {
+						reportSources = append(reportSources, summaryReportSource{name: runName, metadata: targetContext.metadata, metricDefinitions: targetContext.metricDefinitions})
+					}
+				}
+			} else if !flagLive {
 				_ = multiSpinner.Status(targetContext.target.GetName(), "collection complete")
 				csvMetricsFile := filepath.Join(localOutputDir, targetContext.target.GetName()+"_metrics.csv")
 				exists, _ := util.FileExists(csvMetricsFile)
 				if !exists {
 					_ = multiSpinner.Status(targetContext.target.GetName(), "no metrics collected")
 				} else {
-					summaryFiles, err := summarizeMetrics(localOutputDir, targetContext.target.GetName(), targetContext.metadata)
+					summaryFiles, err := summarizeMetrics(localOutputDir, targetContext.target.GetName(), targetContext.metadata, targetContext.metricDefinitions)
 					if err != nil {
 						err = fmt.Errorf("failed to summarize metrics: %w", err)
 						exitErrs = append(exitErrs, err)
 					}
 					targetContexts[i].printedFiles = append(targetContexts[i].printedFiles, summaryFiles...)
+					reportSources = append(reportSources, summaryReportSource{name: targetContext.target.GetName(), metadata: targetContext.metadata, metricDefinitions: targetContext.metricDefinitions})
 				}
 			}
 		} else {
@@ -985,6 +1236,12 @@ func runCmd(cmd *cobra.Command, args []string) error {
 	}
 	if !flagLive {
 		multiSpinner.Finish()
+		reportFiles, err := writeSummaryReports(appContext, reportSources, flagReportFormat)
+		if err != nil {
+			err = fmt.Errorf("failed to write summary reports: %w", err)
+			exitErrs = append(exitErrs, err)
+		}
+		allPrintedFileNames = append(allPrintedFileNames, reportFiles)
 		printOutputFileNames(allPrintedFileNames)
 	}
 	// join the errors and print them
@@ -1011,19 +1268,21 @@ func prepareTarget(targetContext *targetContext, localTempDir string, localPerfP
 			channelError <- targetError{target: myTarget, err: err}
 			return
 		}
+		// if one of the MSR registers is active (ignore cpu_cycles), then the PMU is in use. The target
+		// fails when its metrics are prepared, after the capability analysis records the busy counters.
+		var busyCounters []string
 		for line := range strings.SplitSeq(output.Stdout, "\n") {
-			// if one of the MSR registers is active (ignore cpu_cycles), then the PMU is in use
 			if strings.Contains(line, "Active") && !strings.Contains(line, "0x30a") {
-				err = fmt.Errorf("PMU in use on target: %s", line)
-				_ = statusUpdate(myTarget.GetName(), fmt.Sprintf("Error: %v", err))
-				targetContext.err = err
-				channelError <- targetError{target: myTarget, err: err}
-				return
+				busyCounters = append(busyCounters, strings.Fields(line)[0])
 			}
 		}
+		if len(busyCounters) > 0 {
+			targetContext.pmuBusy = "active counter(s) " + strings.Join(busyCounters, ", ")
+			slog.Error("PMU in use on target", slog.String("target", myTarget.GetName()), slog.String("counters", targetContext.pmuBusy))
+		}
 	}
 	// check if NMI watchdog is enabled and disable it if necessary
-	if !flagNoRoot {
+	if !flagNoRoot && targetContext.pmuBusy == "" {
 		var nmiWatchdogEnabled bool
 		if nmiWatchdogEnabled, err = NMIWatchdogEnabled(myTarget); err != nil {
 			err = fmt.Errorf("failed to retrieve NMI watchdog status: %w", err)
@@ -1033,6 +1292,12 @@ func prepareTarget(targetContext *targetContext, localTempDir string, localPerfP
 			return
 		}
 		if nmiWatchdogEnabled {
+			if err = common.RecordJournal(myTarget, []string{nmiWatchdogPath}, localTempDir); err != nil {
+				_ = statusUpdate(myTarget.GetName(), fmt.Sprintf("Error: %s", err.Error()))
+				targetContext.err = err
+				channelError <- targetError{target: myTarget, err: err}
+				return
+			}
 			if err = DisableNMIWatchdog(myTarget, localTempDir); err != nil {
 				err = fmt.Errorf("failed to disable NMI watchdog: %w", err)
 				_ = statusUpdate(myTarget.GetName(), fmt.Sprintf("Error: %s", err.Error()))
@@ -1044,7 +1309,7 @@ func prepareTarget(targetContext *targetContext, localTempDir string, localPerfP
 		}
 	}
 	// set perf mux interval to desired value
-	if !flagNoRoot {
+	if !flagNoRoot && targetContext.pmuBusy == "" {
 		if targetContext.perfMuxIntervals, err = GetMuxIntervals(myTarget, localTempDir); err != nil {
 			err = fmt.Errorf("failed to get perf mux intervals: %w", err)
 			_ = statusUpdate(myTarget.GetName(), fmt.Sprintf("Error: %s", err.Error()))
@@ -1060,6 +1325,12 @@ func prepareTarget(targetContext *targetContext, localTempDir string, localPerfP
 				perfMuxInterval = 16
 			}
 		}
+		if err = common.RecordJournal(myTarget, slices.Collect(maps.Keys(targetContext.perfMuxIntervals)), localTempDir); err != nil {
+			_ = statusUpdate(myTarget.GetName(), fmt.Sprintf("Error: %s", err.Error()))
+			targetContext.err = err
+			channelError <- targetError{target: myTarget, err: err}
+			return
+		}
 		if err = SetAllMuxIntervals(myTarget, perfMuxInterval, localTempDir); err != nil {
 			err = fmt.Errorf("failed to set all perf mux intervals: %w", err)
 			_ = statusUpdate(myTarget.GetName(), fmt.Sprintf("Error: %s", err.Error()))
@@ -1101,14 +1372,8 @@ func prepareMetrics(targetContext *targetContext, localTempDir string, channelEr
 		return
 	}
 	slog.Debug("metadata: " + targetContext.metadata.String())
-	if !targetContext.metadata.SupportsInstructions {
-		slog.Error("Target does not support instructions event collection", slog.String("target", myTarget.GetName()))
-		targetContext.err = fmt.Errorf("target not supported, does not support instructions event collection")
-		channelError <- targetError{target: myTarget, err: targetContext.err}
-		return
-	}
 	// load event definitions
-	var uncollectableEvents []string
+	var uncollectableEvents map[string]string
 	if targetContext.groupDefinitions, uncollectableEvents, err = LoadEventGroups(flagEventFilePath, targetContext.metadata); err != nil {
 		err = fmt.Errorf("failed to load event definitions: %w", err)
 		_ = statusUpdate(myTarget.GetName(), fmt.Sprintf("Error: %s", err.Error()))
@@ -1125,6 +1390,23 @@ func prepareMetrics(targetContext *targetContext, localTempDir string, channelEr
 		channelError <- targetError{target: myTarget, err: err}
 		return
 	}
+	// analyze which metrics can be computed on the target
+	blocker := getCollectionBlocker(targetContext.metadata, targetContext.pmuBusy)
+	targetContext.capabilities = analyzeMetricCapabilities(loadedMetrics, uncollectableEvents, targetContext.metadata, blocker)
+	if blocker != "" {
+		err = fmt.Errorf("metrics can't be computed on target, %s", blocker)
+		_ = statusUpdate(myTarget.GetName(), fmt.Sprintf("Error: %s", err.Error()))
+		targetContext.err = err
+		channelError <- targetError{target: myTarget, err: err}
+		return
+	}
+	if impossibleMetrics := getImpossibleMetrics(targetContext.capabilities); flagStrict && len(impossibleMetrics) > 0 {
+		err = fmt.Errorf("%d requested metric(s) can't be computed on target, e.g., %s", len(impossibleMetrics), impossibleMetrics[0])
+		_ = statusUpdate(myTarget.GetName(), fmt.Sprintf("Error: %s", err.Error()))
+		targetContext.err = err
+		channelError <- targetError{target: myTarget, err: err}
+		return
+	}
 	// configure metrics
 	if targetContext.metricDefinitions, err = ConfigureMetrics(loadedMetrics, uncollectableEvents, GetEvaluatorFunctions(), targetContext.metadata); err != nil {
 		err = fmt.Errorf("failed to configure metrics: %w", err)
@@ -1187,7 +1469,7 @@ func collectOnTarget(targetContext *targetContext, localTempDir string, localOut
 	// only refresh if duration is 0, i.e., no timeout and pids/cids are not specified
 	var needsRefresh bool
 	if flagDuration == 0 {
-		if flagScope == scopeProcess {
+		if flagScope == scopeProcess || flagScope == scopeThread {
 			if len(flagPidList) == 0 {
 				needsRefresh = true
 			}
@@ -1197,18 +1479,33 @@ func collectOnTarget(targetContext *targetContext, localTempDir string, localOut
 			}
 		}
 	}
-	errorChannel := make(chan error)
-	frameChannel := make(chan []MetricFrame)
-	printCompleteChannel := make(chan []string)
 	// get current time for use in setting timestamps on output
 	targetContext.metadata.CollectionStartTime = time.Now() // save the start time in the metadata for use when using the --input option to process raw data
-	go printMetricsAsync(targetContext, localOutputDir, frameChannel, printCompleteChannel)
+	if isRepeatingRuns() {
+		collectRunsOnTarget(targetContext, localTempDir, localOutputDir, statusUpdate)
+		return
+	}
+	resultChannel := make(chan perfResult)
+	frameChannel := make(chan []MetricFrame)
+	printCompleteChannel := make(chan []string)
+	// system energy is measured alongside process and thread scope collection for attribution
+	energy := newEnergySampler(targetContext.metadata)
+	if flagTrigger != "" {
+		var err error
+		targetContext.trigger, err = newTriggerWatcher(flagTrigger, flagTriggerAction, flagTriggerDuration, flagTriggerCooldown, targetContext.metricDefinitions, myTarget.GetName(), localOutputDir, common.NewTargetCaptureFunc(myTarget, localTempDir), statusUpdate)
+		if err != nil {
+			_ = statusUpdate(myTarget.GetName(), fmt.Sprintf("Error: %s", err.Error()))
+			targetContext.err = err
+			return
+		}
+	}
+	go printMetricsAsync(targetContext, myTarget.GetName(), localOutputDir, frameChannel, printCompleteChannel)
 	var err error
 	for !getSignalReceived() {
 		var processes []Process
 		var pids []string
 		var cids []string
-		if flagScope == scopeProcess {
+		if flagScope == scopeProcess || flagScope == scopeThread {
 			// get the list of pids to collect
 			processes, err = getProcessesForPerf(myTarget, flagPidList, flagCount, flagFilter)
 			if err != nil {
@@ -1217,6 +1514,15 @@ func collectOnTarget(targetContext *targetContext, localTempDir string, localOut
 				_ = statusUpdate(myTarget.GetName(), fmt.Sprintf("Error: %s", err.Error()))
 				break
 			}
+			// resolve thread names so that per-thread events can be labeled and grouped
+			if flagScope == scopeThread {
+				processes, err = GetProcessThreads(myTarget, processes, localTempDir)
+				if err != nil {
+					err = fmt.Errorf("failed to get threads: %w", err)
+					_ = statusUpdate(myTarget.GetName(), fmt.Sprintf("Error: %s", err.Error()))
+					break
+				}
+			}
 			// get pids from processes
 			for _, process := range processes {
 				pids = append(pids, process.pid)
@@ -1249,9 +1555,9 @@ func collectOnTarget(targetContext *targetContext, localTempDir string, localOut
 		}
 		// this timestamp is used to determine if we need to exit the loop, i.e., we've run long enough
 		targetContext.perfStartTime = time.Now()
-		go runPerf(myTarget, flagNoRoot, processes, perfCommand, targetContext.groupDefinitions, targetContext.metricDefinitions, targetContext.metadata, localTempDir, localOutputDir, frameChannel, errorChannel)
+		go runPerf(myTarget, flagNoRoot, processes, perfCommand, targetContext.groupDefinitions, targetContext.metricDefinitions, targetContext.metadata, energy, localTempDir, localOutputDir, frameChannel, resultChannel)
 		// wait for runPerf to finish
-		perfErr := <-errorChannel // capture and return all errors
+		perfErr := (<-resultChannel).err // capture and return all errors
 		if perfErr != nil {
 			if !getSignalReceived() {
 				err = perfErr
@@ -1268,19 +1574,96 @@ func collectOnTarget(targetContext *targetContext, localTempDir string, localOut
 	}
 	close(frameChannel) // we're done writing frames so shut it down
 	// wait for printing to complete
-	targetContext.printedFiles = <-printCompleteChannel
+	targetContext.printedFiles = append(targetContext.printedFiles, <-printCompleteChannel...)
 	close(printCompleteChannel)
 	// keep track of the error
 	targetContext.err = err
 }
 
+// isRepeatingRuns returns true when the application is to be run more than once, i.e., the
+// metrics of each measured run are written to separate files
+func isRepeatingRuns() bool {
+	return len(argsApplication) > 0 && (flagRepeat > 1 || flagWarmup > 0)
+}
+
+// collectRunsOnTarget runs the application flagWarmup + flagRepeat times, one perf run each. Metrics
+// from the warmup runs are discarded. Metrics from each measured run are written to their own
+// files and the run's exit code and wall time are recorded in the target context.
+func collectRunsOnTarget(targetContext *targetContext, localTempDir string, localOutputDir string, statusUpdate progress.MultiSpinnerUpdateFunc) {
+	myTarget := targetContext.target
+	var err error
+	for i := 0; i < flagWarmup+flagRepeat && !getSignalReceived(); i++ {
+		warmup := i < flagWarmup
+		run := i - flagWarmup + 1
+		if warmup {
+			_ = statusUpdate(myTarget.GetName(), fmt.Sprintf("warmup run %d of %d", i+1, flagWarmup))
+		} else {
+			_ = statusUpdate(myTarget.GetName(), fmt.Sprintf("collecting metrics, run %d of %d", run, flagRepeat))
+		}
+		var perfCommand *exec.Cmd
+		perfCommand, err = getPerfCommand(targetContext.perfPath, targetContext.groupDefinitions, nil, nil)
+		if err != nil {
+			err = fmt.Errorf("failed to get perf command: %w", err)
+			_ = statusUpdate(myTarget.GetName(), fmt.Sprintf("Error: %s", err.Error()))
+			break
+		}
+		resultChannel := make(chan perfResult)
+		frameChannel := make(chan []MetricFrame)
+		printCompleteChannel := make(chan []string)
+		if warmup {
+			go func() {
+				for range frameChannel {
+				}
+				printCompleteChannel <- nil
+			}()
+		} else {
+			go printMetricsAsync(targetContext, getRunName(myTarget.GetName(), run), localOutputDir, frameChannel, printCompleteChannel)
+		}
+		targetContext.perfStartTime = time.Now()
+		go runPerf(myTarget, flagNoRoot, nil, perfCommand, targetContext.groupDefinitions, targetContext.metricDefinitions, targetContext.metadata, nil, localTempDir, localOutputDir, frameChannel, resultChannel)
+		result := <-resultChannel
+		close(frameChannel)
+		printedFiles := <-printCompleteChannel
+		if result.err != nil {
+			if !getSignalReceived() {
+				err = result.err
+				_ = statusUpdate(myTarget.GetName(), fmt.Sprintf("Error: %s", err.Error()))
+			}
+			slog.Debug("perf error", slog.String("error", result.err.Error()))
+			break
+		}
+		if result.exitCode != 0 {
+			slog.Warn("application exited with non-zero exit code", slog.Int("run", run), slog.Bool("warmup", warmup), slog.Int("exit code", result.exitCode))
+		}
+		if !warmup {
+			targetContext.printedFiles = append(targetContext.printedFiles, printedFiles...)
+			if math.IsNaN(result.wallTime) {
+				slog.Warn("application wall time not reported", slog.Int("run", run))
+			}
+			targetContext.runs = append(targetContext.runs, RunResult{Run: run, ExitCode: result.exitCode, WallTime: result.wallTime})
+		}
+	}
+	targetContext.err = err
+}
+
+// perfResult is the outcome of a perf run
+type perfResult struct {
+	exitCode int     // perf's exit code, which is the application's exit code when perf starts an application
+	wallTime float64 // the application's wall time in seconds, as measured on the target, NaN when not measured
+	err      error
+}
+
 // runPerf starts Linux perf using the provided command, then reads perf's output
 // until perf stops. When collecting for cgroups, perf will be manually terminated if/when the
 // run duration exceeds the collection time or the time when the cgroup list needs
 // to be refreshed.
-func runPerf(myTarget target.Target, noRoot bool, processes []Process, cmd *exec.Cmd, eventGroupDefinitions []GroupDefinition, metricDefinitions []MetricDefinition, metadata Metadata, localTempDir string, outputDir string, frameChannel chan []MetricFrame, errorChannel chan error) {
+func runPerf(myTarget target.Target, noRoot bool, processes []Process, cmd *exec.Cmd, eventGroupDefinitions []GroupDefinition, metricDefinitions []MetricDefinition, metadata Metadata, energy *energySampler, localTempDir string, outputDir string, frameChannel chan []MetricFrame, resultChannel chan perfResult) {
 	// start perf
 	perfCommand := strings.Join(cmd.Args, " ")
+	if energy != nil {
+		// run the companion perf process that measures system energy for attribution
+		perfCommand = energy.getScript(cmd.Args[0], perfCommand)
+	}
 	stdoutChannel := make(chan string)
 	stderrChannel := make(chan string)
 	exitcodeChannel := make(chan int)
@@ -1298,7 +1681,7 @@ func runPerf(myTarget target.Target, noRoot bool, processes []Process, cmd *exec
 	case <-cmdChannel:
 	case err := <-scriptErrorChannel:
 		if err != nil {
-			errorChannel <- err // error running the script
+			resultChannel <- perfResult{exitCode: -1, wallTime: math.NaN(), err: err} // error running the script
 			return
 		}
 	}
@@ -1334,6 +1717,7 @@ func runPerf(myTarget target.Target, noRoot bool, processes []Process, cmd *exec
 		metadata,
 		eventGroupDefinitions,
 		metricDefinitions,
+		energy,
 		outputDir,
 		processes,
 		cgroupTimeout,
@@ -1344,16 +1728,27 @@ func runPerf(myTarget target.Target, noRoot bool, processes []Process, cmd *exec
 		donePerfProcessingChannel,
 	)
 	// receive perf output
+	perfExitCode := -1
+	wallTime := math.NaN()
 	done := false
 	for !done {
 		select {
 		case line := <-stderrChannel: // perf output comes in on this channel, one line at a time
+			if runWallTime, ok := parseRunWallTime(line); ok {
+				wallTime = runWallTime // reported by the run wrapper when the application exits
+				continue
+			}
 			perfOutputTimer.Stop()
 			perfOutputTimer.Reset(perfEventWaitTime)
 			// accumulate the lines, they will be processed in the goroutine when the timer expires
 			outputLines = append(outputLines, []byte(line))
+		case line := <-stdoutChannel: // the companion perf process' energy events, if any, come in on this channel
+			if energy != nil {
+				energy.addLine(line)
+			}
 		case exitCode := <-exitcodeChannel: // when perf exits, the exit code comes to this channel
 			slog.Debug("perf exited", slog.Int("exit code", exitCode))
+			perfExitCode = exitCode
 			time.Sleep(perfEventWaitTime) // wait for timer to expire so that last events can be processed
 			done = true                   // exit the loop
 		case err := <-scriptErrorChannel: // if there is an error running perf, it comes here
@@ -1368,7 +1763,7 @@ func runPerf(myTarget target.Target, noRoot bool, processes []Process, cmd *exec
 	cancelPerfProcessing()
 	// wait for processPerfOutput to finish
 	<-donePerfProcessingChannel
-	errorChannel <- nil
+	resultChannel <- perfResult{exitCode: perfExitCode, wallTime: wallTime}
 }
 
 // processPerfOutput processes perf output in a goroutine and supports cancellation via context.
@@ -1383,6 +1778,7 @@ func processPerfOutput(
 	metadata Metadata,
 	eventGroupDefinitions []GroupDefinition,
 	metricDefinitions []MetricDefinition,
+	energy *energySampler,
 	outputDir string,
 	processes []Process,
 	cgroupTimeout int,
@@ -1416,7 +1812,7 @@ func processPerfOutput(
 			// process the events
 			var metricFrames []MetricFrame
 			var err error
-			metricFrames, frameTimestamp, err = ProcessEvents(*outputLines, eventGroupDefinitions, metricDefinitions, processes, frameTimestamp, metadata)
+			metricFrames, frameTimestamp, err = ProcessEvents(*outputLines, eventGroupDefinitions, metricDefinitions, processes, frameTimestamp, metadata, energy)
 			if err != nil {
 				slog.Error(err.Error())
 				numConsecutiveProcessEventErrors++
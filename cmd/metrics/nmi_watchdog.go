@@ -15,6 +15,9 @@ import (
 	"perfspect/internal/target"
 )
 
+// nmiWatchdogPath is the procfs file of the kernel.nmi_watchdog sysctl
+const nmiWatchdogPath = "/proc/sys/kernel/nmi_watchdog"
+
 // EnableNMIWatchdog - sets the kernel.nmi_watchdog value to "1"
 func EnableNMIWatchdog(myTarget target.Target, localTempDir string) (err error) {
 	slog.Info("enabling NMI watchdog")
@@ -82,7 +82,9 @@ func getPerfPath(myTarget target.Target, localPerfPath string) (string, error) {
 //
 // Parameters:
 //   - pids: The process IDs for which to collect performance data. If flagScope is
-//     set to "process", the data will be collected only for these processes.
+//     set to "process", the data will be collected only for these processes. If
+//     flagScope is set to "thread", the data will be collected for each thread of
+//     these processes.
 //   - cgroups: The list of cgroups for which to collect performance data. If
 //     flagScope is set to "cgroup", the data will be collected only for these cgroups.
 //   - timeout: The timeout value in seconds. If flagScope is not set to "cgroup"
@@ -105,6 +107,8 @@ func getPerfCommandArgs(pids []string, cgroups []string, timeout int, eventGroup
 		}
 	} else if flagScope == scopeProcess {
 		args = append(args, "-p", strings.Join(pids, ",")) // collect only for these processes
+	} else if flagScope == scopeThread {
+		args = append(args, "-p", strings.Join(pids, ","), "--per-thread") // collect for each thread of these processes
 	} else if flagScope == scopeCgroup {
 		args = append(args, "--for-each-cgroup", strings.Join(cgroups, ",")) // collect only for these cgroups
 	}
@@ -122,6 +126,10 @@ func getPerfCommandArgs(pids []string, cgroups []string, timeout int, eventGroup
 	if len(argsApplication) > 0 {
 		// add application args
 		args = append(args, "--")
+		if isRepeatingRuns() {
+			// measure each run's wall time on the target
+			args = append(args, getRunWrapperArgs()...)
+		}
 		args = append(args, argsApplication...)
 	} else if flagScope != scopeCgroup && timeout != 0 {
 		// add timeout
@@ -136,7 +144,7 @@ func getPerfCommand(perfPath string, eventGroups []GroupDefinition, pids []strin
 	var duration int
 	if flagScope == scopeSystem {
 		duration = flagDuration
-	} else if flagScope == scopeProcess {
+	} else if flagScope == scopeProcess || flagScope == scopeThread {
 		if flagDuration > 0 {
 			duration = flagDuration
 		} else if len(flagPidList) == 0 { // don't refresh if PIDs are specified
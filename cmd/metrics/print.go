@@ -39,6 +39,15 @@ func printMetrics(metricFrames []MetricFrame, frameCount int, targetName string,
 	} else if fileName != "" {
 		printedFiles = util.UniqueAppend(printedFiles, fileName)
 	}
+	if flagUncoreDetail && !flagLive {
+		fileName, err = printUncoreDetailCSV(metricFrames, frameCount, targetName, collectionStartTime, outputDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			slog.Error(err.Error())
+		} else if fileName != "" {
+			printedFiles = util.UniqueAppend(printedFiles, fileName)
+		}
+	}
 	fileName, err = printMetricsWide(metricFrames, frameCount, targetName, collectionStartTime, flagLive && flagOutputFormat[0] == formatWide, !flagLive && slices.Contains(flagOutputFormat, formatWide), outputDir)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -70,18 +79,42 @@ func printOutputFileNames(allFileNames [][]string) {
 }
 
 // printMetricsAsync receives metric frames over the provided channel and prints them to file and stdout in the requested format.
-// It exits when the channel is closed.
-func printMetricsAsync(targetContext *targetContext, outputDir string, frameChannel chan []MetricFrame, doneChannel chan []string) {
+// Output file names begin with outputName. It exits when the channel is closed.
+func printMetricsAsync(targetContext *targetContext, outputName string, outputDir string, frameChannel chan []MetricFrame, doneChannel chan []string) {
 	var allPrintedFiles []string
+	// table formats are written incrementally to files that stay open until collection ends
+	tableWriters, err := newMetricTableWriters(flagOutputFormat, outputName, targetContext.metadata, outputDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		slog.Error(err.Error())
+	}
 	frameCount := 1
 	// block until next set of metric frames arrives, will exit loop when frameChannel is closed
 	for metricFrames := range frameChannel {
-		printedFiles := printMetrics(metricFrames, frameCount, targetContext.target.GetName(), targetContext.perfStartTime, outputDir)
+		printedFiles := printMetrics(metricFrames, frameCount, outputName, targetContext.perfStartTime, outputDir)
 		for _, file := range printedFiles {
 			allPrintedFiles = util.UniqueAppend(allPrintedFiles, file)
 		}
+		for _, writer := range tableWriters {
+			if err := writer.Write(metricFrames, targetContext.perfStartTime); err != nil {
+				slog.Error(err.Error())
+			}
+		}
+		if targetContext.trigger != nil {
+			targetContext.trigger.Check(getTriggerSamples(metricFrames, targetContext.perfStartTime))
+		}
 		frameCount += len(metricFrames)
 	}
+	// triggered captures in progress are completed before collection is done
+	if targetContext.trigger != nil {
+		allPrintedFiles = append(allPrintedFiles, targetContext.trigger.Wait()...)
+	}
+	tableFiles, err := closeMetricTableWriters(tableWriters)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		slog.Error(err.Error())
+	}
+	allPrintedFiles = append(allPrintedFiles, tableFiles...)
 	doneChannel <- allPrintedFiles
 }
 
@@ -97,7 +130,8 @@ func printMetricsJSON(metricFrames []MetricFrame, targetName string, collectionS
 		filteredMetricFrame.Timestamp = float64(collectionStartTime.Unix() + int64(metricFrame.Timestamp))
 		for _, metric := range metricFrame.Metrics {
 			if math.IsNaN(metric.Value) || math.IsInf(metric.Value, 0) {
-				filteredMetricFrame.Metrics = append(filteredMetricFrame.Metrics, Metric{Name: metric.Name, Value: -1})
+				metric.Value = -1
+				filteredMetricFrame.Metrics = append(filteredMetricFrame.Metrics, metric)
 			} else {
 				filteredMetricFrame.Metrics = append(filteredMetricFrame.Metrics, metric)
 			}
@@ -144,6 +178,9 @@ func printMetricsCSV(metricFrames []MetricFrame, frameCount int, targetName stri
 	for idx, metricFrame := range metricFrames {
 		if idx == 0 && frameCount == 1 {
 			contextHeaders := "TS,SKT,CPU,CID,"
+			if flagScope == scopeThread {
+				contextHeaders += "TID,THREAD,"
+			}
 			if printToStdout {
 				fmt.Print(contextHeaders)
 			}
@@ -169,6 +206,9 @@ func printMetricsCSV(metricFrames []MetricFrame, frameCount int, targetName stri
 			}
 		}
 		metricContext := fmt.Sprintf("%d,%s,%s,%s,", collectionStartTime.Unix()+int64(metricFrame.Timestamp), metricFrame.Socket, metricFrame.CPU, metricFrame.Cgroup)
+		if flagScope == scopeThread {
+			metricContext += fmt.Sprintf("%s,%s,", metricFrame.TID, metricFrame.Thread)
+		}
 		values := make([]string, 0, len(metricFrame.Metrics))
 		for _, metric := range metricFrame.Metrics {
 			values = append(values, strconv.FormatFloat(metric.Value, 'g', 8, 64))
@@ -213,7 +253,11 @@ func printMetricsWide(metricFrames []MetricFrame, frameCount int, targetName str
 		colSpacing := 3
 		if idx == 0 && frameCount == 1 { // print headers
 			header := "Timestamp    " // 10 + 3
-			if metricFrame.PID != "" {
+			if metricFrame.Thread != "" {
+				header += "PID       "         // 7 + 3
+				header += "TID       "         // 7 + 3
+				header += "Thread            " // 15 + 3
+			} else if metricFrame.PID != "" {
 				header += "PID       "         // 7 + 3
 				header += "Command           " // 15 + 3
 			} else if metricFrame.Cgroup != "" {
@@ -245,7 +289,22 @@ func printMetricsWide(metricFrames []MetricFrame, frameCount int, targetName str
 		TimestampColWidth := 10
 		formattedTimestamp := fmt.Sprintf("%d", collectionStartTime.Unix()+int64(metricFrame.Timestamp))
 		row := fmt.Sprintf("%s%*s%*s", formattedTimestamp, TimestampColWidth-len(formattedTimestamp), "", colSpacing, "")
-		if metricFrame.PID != "" {
+		if metricFrame.Thread != "" {
+			PIDColWidth := 7
+			TIDColWidth := 7
+			threadColWidth := 15
+			pid := metricFrame.PID
+			if len(pid) > PIDColWidth {
+				pid = pid[:PIDColWidth]
+			}
+			row += fmt.Sprintf("%s%*s%*s", pid, PIDColWidth-len(pid), "", colSpacing, "")
+			row += fmt.Sprintf("%s%*s%*s", metricFrame.TID, max(TIDColWidth-len(metricFrame.TID), 0), "", colSpacing, "")
+			thread := metricFrame.Thread
+			if len(thread) > threadColWidth {
+				thread = thread[:threadColWidth]
+			}
+			row += fmt.Sprintf("%s%*s%*s", thread, threadColWidth-len(thread), "", colSpacing, "")
+		} else if metricFrame.PID != "" {
 			PIDColWidth := 7
 			commandColWidth := 15
 			row += fmt.Sprintf("%s%*s%*s", metricFrame.PID, PIDColWidth-len(metricFrame.PID), "", colSpacing, "")
@@ -320,6 +379,12 @@ func printMetricsTxt(metricFrames []MetricFrame, targetName string, collectionSt
 			if metricFrame.PID != "" {
 				outputLines = append(outputLines, fmt.Sprintf("- PID: %s", metricFrame.PID))
 				outputLines = append(outputLines, fmt.Sprintf("- CMD: %s", metricFrame.Cmd))
+			}
+			if metricFrame.Thread != "" {
+				if metricFrame.TID != "" {
+					outputLines = append(outputLines, fmt.Sprintf("- TID: %s", metricFrame.TID))
+				}
+				outputLines = append(outputLines, fmt.Sprintf("- Thread: %s", metricFrame.Thread))
 			} else if metricFrame.Cgroup != "" {
 				outputLines = append(outputLines, fmt.Sprintf("- CID: %s", metricFrame.Cgroup))
 			}
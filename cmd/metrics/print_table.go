@@ -0,0 +1,286 @@
+package metrics
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+// metrics written to Parquet and SQLite files in a long (one value per row) schema for analysis in
+// tools like pandas and duckdb
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" database/sql driver
+)
+
+// metricRow is one metric value in the long schema
+type metricRow struct {
+	Timestamp time.Time `parquet:"timestamp,timestamp(millisecond)"`
+	Host      string    `parquet:"host,dict"`
+	Socket    *int32    `parquet:"socket,optional"`
+	CPU       *int32    `parquet:"cpu,optional"`
+	Cgroup    string    `parquet:"cgroup,dict"`
+	PID       string    `parquet:"pid,dict"`
+	Metric    string    `parquet:"metric,dict"`
+	Value     *float64  `parquet:"value,optional"` // null when the metric couldn't be computed
+}
+
+// metadataRow is one field of the target's metadata in the metadata side table
+type metadataRow struct {
+	Key   string `parquet:"key"`
+	Value string `parquet:"value"`
+}
+
+// metricTableWriter writes metric frames to a file as they are collected
+type metricTableWriter interface {
+	Write(metricFrames []MetricFrame, collectionStartTime time.Time) error
+	Close() error
+	Files() []string
+}
+
+// newMetricTableWriters creates a writer for each of the requested table output formats
+func newMetricTableWriters(formats []string, targetName string, metadata Metadata, outputDir string) (writers []metricTableWriter, err error) {
+	for _, format := range formats {
+		var writer metricTableWriter
+		switch format {
+		case formatParquet:
+			writer, err = newParquetMetricWriter(targetName, metadata, outputDir)
+		case formatSQLite:
+			writer, err = newSQLiteMetricWriter(targetName, metadata, outputDir)
+		default:
+			continue
+		}
+		if err != nil {
+			closeMetricTableWriters(writers)
+			return nil, err
+		}
+		writers = append(writers, writer)
+	}
+	return
+}
+
+// closeMetricTableWriters closes the writers and returns the names of the files they wrote
+func closeMetricTableWriters(writers []metricTableWriter) (files []string, err error) {
+	for _, writer := range writers {
+		if closeErr := writer.Close(); closeErr != nil {
+			err = closeErr
+			continue
+		}
+		files = append(files, writer.Files()...)
+	}
+	return
+}
+
+// getMetricRows converts metric frames to rows in the long schema
+func getMetricRows(metricFrames []MetricFrame, host string, collectionStartTime time.Time) (rows []metricRow) {
+	for _, metricFrame := range metricFrames {
+		timestamp := collectionStartTime.Add(time.Duration(metricFrame.Timestamp * float64(time.Second))).UTC()
+		socket := parseOptionalInt(metricFrame.Socket)
+		cpu := parseOptionalInt(metricFrame.CPU)
+		for _, metric := range metricFrame.Metrics {
+			row := metricRow{
+				Timestamp: timestamp,
+				Host:      host,
+				Socket:    socket,
+				CPU:       cpu,
+				Cgroup:    metricFrame.Cgroup,
+				PID:       metricFrame.PID,
+				Metric:    metric.Name,
+			}
+			if !math.IsNaN(metric.Value) && !math.IsInf(metric.Value, 0) {
+				value := metric.Value
+				row.Value = &value
+			}
+			rows = append(rows, row)
+		}
+	}
+	return
+}
+
+// parseOptionalInt returns nil if the string is not an integer, e.g., when a frame is not specific
+// to a socket or CPU
+func parseOptionalInt(s string) *int32 {
+	i, err := strconv.ParseInt(s, 10, 32)
+	if err != nil {
+		return nil
+	}
+	i32 := int32(i)
+	return &i32
+}
+
+// getMetadataRows flattens the metadata to key/value rows, values that aren't strings are JSON encoded
+func getMetadataRows(metadata Metadata) (rows []metadataRow, err error) {
+	jsonBytes, err := json.Marshal(metadata)
+	if err != nil {
+		return
+	}
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(jsonBytes, &fields); err != nil {
+		return
+	}
+	for _, key := range slices.Sorted(maps.Keys(fields)) {
+		value := string(fields[key])
+		var s string
+		if json.Unmarshal(fields[key], &s) == nil {
+			value = s
+		}
+		rows = append(rows, metadataRow{Key: key, Value: value})
+	}
+	return
+}
+
+// parquetMetricWriter writes the metrics to a Parquet file and the metadata to a second Parquet file,
+// Parquet files hold one table each
+type parquetMetricWriter struct {
+	host             string
+	filename         string
+	metadataFilename string
+	file             *os.File
+	writer           *parquet.GenericWriter[metricRow]
+}
+
+func newParquetMetricWriter(targetName string, metadata Metadata, outputDir string) (*parquetMetricWriter, error) {
+	w := &parquetMetricWriter{
+		host:             metadata.Hostname,
+		filename:         outputDir + "/" + targetName + "_" + "metrics.parquet",
+		metadataFilename: outputDir + "/" + targetName + "_" + "metrics_metadata.parquet",
+	}
+	metadataRows, err := getMetadataRows(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert metadata to rows: %w", err)
+	}
+	if err = parquet.WriteFile(w.metadataFilename, metadataRows); err != nil {
+		return nil, fmt.Errorf("failed to write metadata to parquet file: %w", err)
+	}
+	if w.file, err = os.Create(w.filename); err != nil { // #nosec G304
+		return nil, err
+	}
+	w.writer = parquet.NewGenericWriter[metricRow](w.file, parquet.Compression(&parquet.Zstd))
+	return w, nil
+}
+
+func (w *parquetMetricWriter) Write(metricFrames []MetricFrame, collectionStartTime time.Time) error {
+	if _, err := w.writer.Write(getMetricRows(metricFrames, w.host, collectionStartTime)); err != nil {
+		return fmt.Errorf("failed to write metrics to parquet file: %w", err)
+	}
+	return nil
+}
+
+// Close writes the Parquet footer, the file is not readable until it is closed
+func (w *parquetMetricWriter) Close() error {
+	if err := w.writer.Close(); err != nil {
+		w.file.Close()
+		return fmt.Errorf("failed to close parquet file: %w", err)
+	}
+	return w.file.Close()
+}
+
+func (w *parquetMetricWriter) Files() []string {
+	return []string{w.filename, w.metadataFilename}
+}
+
+// sqliteMetricWriter writes the metrics and metadata to tables in a SQLite database
+type sqliteMetricWriter struct {
+	host     string
+	filename string
+	db       *sql.DB
+}
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS metrics (
+	timestamp INTEGER NOT NULL, -- milliseconds since the Unix epoch
+	host TEXT NOT NULL,
+	socket INTEGER,
+	cpu INTEGER,
+	cgroup TEXT NOT NULL,
+	pid TEXT NOT NULL,
+	metric TEXT NOT NULL,
+	value REAL
+);
+CREATE TABLE IF NOT EXISTS metadata (
+	host TEXT NOT NULL,
+	key TEXT NOT NULL,
+	value TEXT NOT NULL,
+	PRIMARY KEY (host, key)
+);`
+
+func newSQLiteMetricWriter(targetName string, metadata Metadata, outputDir string) (*sqliteMetricWriter, error) {
+	w := &sqliteMetricWriter{
+		host:     metadata.Hostname,
+		filename: outputDir + "/" + targetName + "_" + "metrics.db",
+	}
+	metadataRows, err := getMetadataRows(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert metadata to rows: %w", err)
+	}
+	if w.db, err = sql.Open("sqlite", w.filename); err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+	if _, err = w.db.Exec(sqliteSchema); err != nil {
+		w.db.Close()
+		return nil, fmt.Errorf("failed to create sqlite tables: %w", err)
+	}
+	err = w.insert("INSERT OR REPLACE INTO metadata (host, key, value) VALUES (?, ?, ?)", len(metadataRows), func(i int) []any {
+		return []any{w.host, metadataRows[i].Key, metadataRows[i].Value}
+	})
+	if err != nil {
+		w.db.Close()
+		return nil, fmt.Errorf("failed to write metadata to sqlite database: %w", err)
+	}
+	return w, nil
+}
+
+// insert executes the statement once for each of count rows in a single transaction
+func (w *sqliteMetricWriter) insert(statement string, count int, args func(i int) []any) error {
+	tx, err := w.db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(statement)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+	for i := range count {
+		if _, err = stmt.Exec(args(i)...); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (w *sqliteMetricWriter) Write(metricFrames []MetricFrame, collectionStartTime time.Time) error {
+	rows := getMetricRows(metricFrames, w.host, collectionStartTime)
+	err := w.insert("INSERT INTO metrics (timestamp, host, socket, cpu, cgroup, pid, metric, value) VALUES (?, ?, ?, ?, ?, ?, ?, ?)", len(rows), func(i int) []any {
+		row := rows[i]
+		return []any{row.Timestamp.UnixMilli(), row.Host, row.Socket, row.CPU, row.Cgroup, row.PID, row.Metric, row.Value}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write metrics to sqlite database: %w", err)
+	}
+	return nil
+}
+
+func (w *sqliteMetricWriter) Close() error {
+	return w.db.Close()
+}
+
+func (w *sqliteMetricWriter) Files() []string {
+	return []string{w.filename}
+}
+
+// isTableFormat returns true if the format is written by a metricTableWriter
+func isTableFormat(format string) bool {
+	return format == formatParquet || format == formatSQLite
+}
@@ -0,0 +1,74 @@
+package metrics
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+import (
+	"database/sql"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricTableWriters(t *testing.T) {
+	dir := t.TempDir()
+	metadata := Metadata{Hostname: "host1", Microarchitecture: "SPR", SocketCount: 2}
+	startTime := time.Unix(1700000000, 0)
+	frames := []MetricFrame{
+		{Timestamp: 5.25, Socket: "0", Metrics: []Metric{{Name: "CPI", Value: 0.5}, {Name: "IPC", Value: math.NaN()}}},
+		{Timestamp: 5.25, Socket: "1", Metrics: []Metric{{Name: "CPI", Value: 0.75}, {Name: "IPC", Value: 1.33}}},
+	}
+	writers, err := newMetricTableWriters([]string{formatCSV, formatParquet, formatSQLite}, "host1", metadata, dir)
+	require.NoError(t, err)
+	require.Len(t, writers, 2)
+	for _, writer := range writers {
+		require.NoError(t, writer.Write(frames, startTime))
+		require.NoError(t, writer.Write(frames[:1], startTime.Add(time.Second)))
+	}
+	files, err := closeMetricTableWriters(writers)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "host1_metrics.parquet"),
+		filepath.Join(dir, "host1_metrics_metadata.parquet"),
+		filepath.Join(dir, "host1_metrics.db"),
+	}, files)
+
+	// parquet
+	rows, err := parquet.ReadFile[metricRow](files[0])
+	require.NoError(t, err)
+	require.Len(t, rows, 6)
+	assert.Equal(t, time.UnixMilli(1700000005250).UTC(), rows[0].Timestamp.UTC())
+	assert.Equal(t, "host1", rows[0].Host)
+	require.NotNil(t, rows[0].Socket)
+	assert.Equal(t, int32(0), *rows[0].Socket)
+	assert.Nil(t, rows[0].CPU)
+	assert.Equal(t, "CPI", rows[0].Metric)
+	assert.Equal(t, 0.5, *rows[0].Value)
+	assert.Nil(t, rows[1].Value) // NaN is written as null
+	assert.Equal(t, int32(1), *rows[2].Socket)
+	metadataRows, err := parquet.ReadFile[metadataRow](files[1])
+	require.NoError(t, err)
+	assert.Contains(t, metadataRows, metadataRow{Key: "Microarchitecture", Value: "SPR"})
+	assert.Contains(t, metadataRows, metadataRow{Key: "SocketCount", Value: "2"})
+
+	// sqlite
+	db, err := sql.Open("sqlite", files[2])
+	require.NoError(t, err)
+	defer db.Close()
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM metrics").Scan(&count))
+	assert.Equal(t, 6, count)
+	var value float64
+	require.NoError(t, db.QueryRow("SELECT value FROM metrics WHERE timestamp = 1700000005250 AND socket = 1 AND metric = 'IPC'").Scan(&value))
+	assert.Equal(t, 1.33, value)
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM metrics WHERE value IS NULL").Scan(&count))
+	assert.Equal(t, 2, count)
+	var uarch string
+	require.NoError(t, db.QueryRow("SELECT value FROM metadata WHERE host = 'host1' AND key = 'Microarchitecture'").Scan(&uarch))
+	assert.Equal(t, "SPR", uarch)
+}
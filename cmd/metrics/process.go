@@ -19,10 +19,18 @@ import (
 )
 
 type Process struct {
+	pid     string
+	ppid    string
+	comm    string
+	cmd     string
+	threads []Thread // only populated when collecting at thread scope
+}
+
+// Thread represents one task (thread) of a process
+type Thread struct {
 	pid  string
-	ppid string
-	comm string
-	cmd  string
+	tid  string
+	name string // from /proc/<pid>/task/<tid>/comm
 }
 
 // pid,ppid,comm,cmd
@@ -175,6 +183,68 @@ done | sort -nr | head -n %d
 	return
 }
 
+// GetProcessThreads - resolves the threads (TIDs and thread names) of each of the given
+// processes from /proc/<pid>/task/*/comm. The returned list of processes is a copy of the
+// given list with the threads field populated.
+func GetProcessThreads(myTarget target.Target, processes []Process, localTempDir string) (processesWithThreads []Process, err error) {
+	if len(processes) == 0 {
+		return
+	}
+	var pids []string
+	for _, process := range processes {
+		pids = append(pids, process.pid)
+	}
+	threadsScript := script.ScriptDefinition{
+		Name: "process_threads",
+		ScriptTemplate: fmt.Sprintf(`
+for pid in %s; do
+    for task in /proc/$pid/task/*; do
+        if [ -f "$task/comm" ]; then
+            echo "$pid $(basename $task) $(cat $task/comm 2>/dev/null)"
+        fi
+    done
+done
+`, strings.Join(pids, " ")),
+		Superuser: true,
+	}
+	output, err := script.RunScript(myTarget, threadsScript, localTempDir)
+	if err != nil {
+		err = fmt.Errorf("failed to get process threads: %v", err)
+		return
+	}
+	threads := parseProcessThreads(output.Stdout)
+	for _, process := range processes {
+		process.threads = threads[process.pid]
+		processesWithThreads = append(processesWithThreads, process)
+	}
+	var tids []string
+	for _, process := range processesWithThreads {
+		for _, thread := range process.threads {
+			tids = append(tids, thread.tid)
+		}
+	}
+	slog.Debug("Process TIDs", slog.String("TIDs", strings.Join(tids, ", ")))
+	return
+}
+
+// parseProcessThreads parses lines of "pid tid name" into a map of pid to threads
+func parseProcessThreads(output string) (threads map[string][]Thread) {
+	threads = make(map[string][]Thread)
+	for line := range strings.SplitSeq(output, "\n") {
+		fields := strings.SplitN(strings.TrimSpace(line), " ", 3)
+		if len(fields) < 2 {
+			continue
+		}
+		thread := Thread{pid: fields[0], tid: fields[1]}
+		if len(fields) == 3 {
+			// commas would break the CSV output
+			thread.name = strings.ReplaceAll(fields[2], ",", ";")
+		}
+		threads[thread.pid] = append(threads[thread.pid], thread)
+	}
+	return
+}
+
 func processExists(myTarget target.Target, pid string) (exists bool) {
 	cmd := exec.Command("ps", "-p", pid)
 	_, _, _, err := myTarget.RunCommand(cmd, 0, true)
@@ -0,0 +1,316 @@
+package metrics
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+// functions to summarize metrics across repeated application runs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"perfspect/internal/util"
+	"strconv"
+	"strings"
+)
+
+// RunResult records the outcome of one measured application run
+type RunResult struct {
+	Run      int
+	ExitCode int     // exit code of perf, which is the application's exit code
+	WallTime float64 // seconds, as measured on the target, NaN when not reported
+}
+
+// runMetricSummary holds a metric's mean in each run and the statistics across runs
+type runMetricSummary struct {
+	Name     string
+	RunMeans []float64
+	Mean     float64
+	Stddev   float64 // sample standard deviation across runs
+	CILow    float64 // lower bound of the 95% confidence interval of the mean
+	CIHigh   float64 // upper bound of the 95% confidence interval of the mean
+}
+
+const runWallTimeName = "wall time (seconds)"
+
+// runWallTimeMarker starts the line, written to stderr by the run wrapper, that reports the
+// application's wall time in nanoseconds
+const runWallTimeMarker = "perfspect_run_wall_time_ns"
+
+// getRunWrapperArgs returns the arguments that run the application under a shell that measures
+// the application's own wall time on the target, i.e., excluding perf and SSH startup, and reports
+// it on stderr after the application exits. The application's exit code is preserved.
+func getRunWrapperArgs() []string {
+	return []string{"sh", "-c", `'start=$(date +%s%N); "$@"; rc=$?; echo "` + runWallTimeMarker + ` $(($(date +%s%N) - start))" >&2; exit $rc'`, "sh"}
+}
+
+// parseRunWallTime returns the wall time, in seconds, reported by the run wrapper, and false when
+// the line isn't the wrapper's report
+func parseRunWallTime(line string) (float64, bool) {
+	fields := strings.Fields(line)
+	if len(fields) != 2 || fields[0] != runWallTimeMarker {
+		return 0, false
+	}
+	nanoseconds, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil || nanoseconds < 0 {
+		return 0, false
+	}
+	return float64(nanoseconds) / 1e9, true
+}
+
+// tDistribution975 holds the two-sided 95% critical values of Student's t distribution, indexed by
+// degrees of freedom - 1. The normal approximation is used beyond the end of the table.
+var tDistribution975 = []float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+// getRunName returns the name used for the output files of a measured run
+func getRunName(targetName string, run int) string {
+	return fmt.Sprintf("%s_run%d", targetName, run)
+}
+
+// getRunMetricSummary calculates the mean, sample standard deviation, and 95% confidence interval
+// of a metric's per-run means, ignoring runs where the metric could not be calculated
+func getRunMetricSummary(name string, runMeans []float64) (summary runMetricSummary) {
+	summary = runMetricSummary{Name: name, RunMeans: runMeans, Mean: math.NaN(), Stddev: math.NaN(), CILow: math.NaN(), CIHigh: math.NaN()}
+	var valid []float64
+	for _, value := range runMeans {
+		if !math.IsNaN(value) && !math.IsInf(value, 0) {
+			valid = append(valid, value)
+		}
+	}
+	if len(valid) == 0 {
+		return
+	}
+	sum := 0.0
+	for _, value := range valid {
+		sum += value
+	}
+	summary.Mean = sum / float64(len(valid))
+	if len(valid) < 2 {
+		return
+	}
+	distanceSquaredSum := 0.0
+	for _, value := range valid {
+		distanceSquaredSum += (summary.Mean - value) * (summary.Mean - value)
+	}
+	summary.Stddev = math.Sqrt(distanceSquaredSum / float64(len(valid)-1))
+	t := 1.96
+	if len(valid)-1 <= len(tDistribution975) {
+		t = tDistribution975[len(valid)-2]
+	}
+	margin := t * summary.Stddev / math.Sqrt(float64(len(valid)))
+	summary.CILow = summary.Mean - margin
+	summary.CIHigh = summary.Mean + margin
+	return
+}
+
+// getRunSummaries loads the metrics CSV of each run and summarizes each metric across the runs. The
+// wall time of the runs is summarized first.
+func getRunSummaries(localOutputDir string, targetName string, runs []RunResult) (summaries []runMetricSummary, err error) {
+	var wallTimes []float64
+	for _, run := range runs {
+		wallTimes = append(wallTimes, run.WallTime)
+	}
+	summaries = append(summaries, getRunMetricSummary(runWallTimeName, wallTimes))
+	var names []string
+	runMeans := make(map[string][]float64)
+	for runIdx, run := range runs {
+		csvMetricsFile := filepath.Join(localOutputDir, getRunName(targetName, run.Run)+"_metrics.csv")
+		var metrics []metricsFromCSV
+		if metrics, err = newMetricsFromCSV(csvMetricsFile); err != nil {
+			if !os.IsNotExist(err) {
+				return
+			}
+			// no metrics were collected during the run, e.g., the application exited immediately
+			slog.Warn("no metrics collected for run", slog.Int("run", run.Run))
+			err = nil
+			continue
+		}
+		if len(metrics) == 0 {
+			continue
+		}
+		var stats map[string]metricStats
+		if stats, err = metrics[0].getStats(); err != nil {
+			return
+		}
+		for _, name := range metrics[0].names {
+			if _, ok := runMeans[name]; !ok {
+				names = append(names, name)
+				runMeans[name] = make([]float64, len(runs))
+				for i := range runMeans[name] {
+					runMeans[name][i] = math.NaN()
+				}
+			}
+			runMeans[name][runIdx] = stats[name].mean
+		}
+	}
+	for _, name := range names {
+		summaries = append(summaries, getRunMetricSummary(name, runMeans[name]))
+	}
+	return
+}
+
+// summarizeRepeatedRuns summarizes the metrics of each measured run and the statistics across runs
+func summarizeRepeatedRuns(localOutputDir string, targetName string, runs []RunResult, metadata Metadata, metricDefinitions []MetricDefinition) (filesCreated []string, err error) {
+	for _, run := range runs {
+		runName := getRunName(targetName, run.Run)
+		if exists, _ := util.FileExists(filepath.Join(localOutputDir, runName+"_metrics.csv")); !exists {
+			continue
+		}
+		var summaryFiles []string
+		summaryFiles, err = summarizeMetrics(localOutputDir, runName, metadata, metricDefinitions)
+		filesCreated = append(filesCreated, summaryFiles...)
+		if err != nil {
+			return
+		}
+	}
+	var runFiles []string
+	runFiles, err = summarizeRuns(localOutputDir, targetName, runs)
+	filesCreated = append(filesCreated, runFiles...)
+	return
+}
+
+// summarizeRuns writes the per-run and cross-run statistics of repeated application runs to CSV,
+// JSON, and HTML files
+func summarizeRuns(localOutputDir string, targetName string, runs []RunResult) (filesCreated []string, err error) {
+	summaries, err := getRunSummaries(localOutputDir, targetName, runs)
+	if err != nil {
+		return
+	}
+	outputs := []struct {
+		extension string
+		generate  func(string, []RunResult, []runMetricSummary) (string, error)
+	}{
+		{"csv", getRunsCSV},
+		{"json", getRunsJSON},
+		{"html", getRunsHTML},
+	}
+	for _, output := range outputs {
+		var out string
+		if out, err = output.generate(targetName, runs, summaries); err != nil {
+			err = fmt.Errorf("failed to summarize runs as %s: %w", output.extension, err)
+			return
+		}
+		filename := filepath.Join(localOutputDir, targetName+"_metrics_runs."+output.extension)
+		if err = os.WriteFile(filename, []byte(out), 0644); err != nil { // #nosec G306
+			err = fmt.Errorf("failed to write runs summary to file: %w", err)
+			return
+		}
+		filesCreated = append(filesCreated, filename)
+	}
+	return
+}
+
+// getRunsCSV generates CSV with one row per metric, the metric's mean in each run, and the
+// statistics across the runs. The first row is the exit code of each run.
+func getRunsCSV(targetName string, runs []RunResult, summaries []runMetricSummary) (out string, err error) {
+	var sb strings.Builder
+	sb.WriteString("metric")
+	for _, run := range runs {
+		fmt.Fprintf(&sb, ",run %d", run.Run)
+	}
+	sb.WriteString(",mean,stddev,ci95 low,ci95 high\n")
+	sb.WriteString("exit code")
+	for _, run := range runs {
+		fmt.Fprintf(&sb, ",%d", run.ExitCode)
+	}
+	sb.WriteString(",,,,\n")
+	for _, summary := range summaries {
+		sb.WriteString(summary.Name)
+		for _, value := range summary.RunMeans {
+			fmt.Fprintf(&sb, ",%f", value)
+		}
+		fmt.Fprintf(&sb, ",%f,%f,%f,%f\n", summary.Mean, summary.Stddev, summary.CILow, summary.CIHigh)
+	}
+	return sb.String(), nil
+}
+
+// getRunsJSON generates JSON containing the runs and the metric summaries. NaN values, which
+// can't be represented in JSON, are written as null.
+func getRunsJSON(targetName string, runs []RunResult, summaries []runMetricSummary) (out string, err error) {
+	jsonFloat := func(value float64) *float64 {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return nil
+		}
+		return &value
+	}
+	type jsonSummary struct {
+		Name     string
+		RunMeans []*float64
+		Mean     *float64
+		Stddev   *float64
+		CILow    *float64
+		CIHigh   *float64
+	}
+	type jsonRun struct {
+		Run      int
+		ExitCode int
+		WallTime *float64
+	}
+	var jsonRuns []jsonRun
+	for _, run := range runs {
+		jsonRuns = append(jsonRuns, jsonRun{Run: run.Run, ExitCode: run.ExitCode, WallTime: jsonFloat(run.WallTime)})
+	}
+	var jsonSummaries []jsonSummary
+	for _, summary := range summaries {
+		s := jsonSummary{Name: summary.Name, Mean: jsonFloat(summary.Mean), Stddev: jsonFloat(summary.Stddev), CILow: jsonFloat(summary.CILow), CIHigh: jsonFloat(summary.CIHigh)}
+		for _, value := range summary.RunMeans {
+			s.RunMeans = append(s.RunMeans, jsonFloat(value))
+		}
+		jsonSummaries = append(jsonSummaries, s)
+	}
+	jsonBytes, err := json.MarshalIndent(map[string]any{"Target": targetName, "Runs": jsonRuns, "Metrics": jsonSummaries}, "", "  ")
+	if err != nil {
+		return
+	}
+	return string(jsonBytes), nil
+}
+
+var runsHTMLTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; margin-bottom: 24px; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: right; }
+th { background-color: #0071c5; color: white; }
+td:first-child { text-align: left; }
+tr.failed { background-color: #fff3cd; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<h2>Runs</h2>
+<table>
+<tr><th>Run</th><th>Exit Code</th><th>Wall Time (seconds)</th></tr>
+{{range .Runs}}<tr{{if .ExitCode}} class="failed"{{end}}><td>{{.Run}}</td><td>{{.ExitCode}}</td><td>{{printf "%.3f" .WallTime}}</td></tr>
+{{end}}</table>
+<h2>Metrics</h2>
+<table>
+<tr><th>Metric</th>{{range .Runs}}<th>Run {{.Run}}</th>{{end}}<th>Mean</th><th>Stddev</th><th>95% CI</th></tr>
+{{range .Summaries}}<tr><td>{{.Name}}</td>{{range .RunMeans}}<td>{{printf "%.4g" .}}</td>{{end}}<td>{{printf "%.4g" .Mean}}</td><td>{{printf "%.4g" .Stddev}}</td><td>{{printf "%.4g" .CILow}} - {{printf "%.4g" .CIHigh}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`
+
+// getRunsHTML generates an HTML document with a table of the runs and a table of the metric summaries
+func getRunsHTML(targetName string, runs []RunResult, summaries []runMetricSummary) (out string, err error) {
+	tmpl := htmltemplate.Must(htmltemplate.New("runsTemplate").Parse(runsHTMLTemplate))
+	buf := new(bytes.Buffer)
+	if err = tmpl.Execute(buf, map[string]any{"Title": fmt.Sprintf("%s Application Runs", targetName), "Runs": runs, "Summaries": summaries}); err != nil {
+		slog.Error("failed to render runs template", slog.String("error", err.Error()))
+		return
+	}
+	return buf.String(), nil
+}
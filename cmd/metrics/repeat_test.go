@@ -0,0 +1,82 @@
+package metrics
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRunMetricSummary(t *testing.T) {
+	summary := getRunMetricSummary("CPI", []float64{1, 2, 3, math.NaN()})
+	assert.Equal(t, 2.0, summary.Mean)
+	assert.Equal(t, 1.0, summary.Stddev)
+	// t(0.975, 2) = 4.303
+	assert.InDelta(t, 2-4.303/math.Sqrt(3), summary.CILow, 1e-9)
+	assert.InDelta(t, 2+4.303/math.Sqrt(3), summary.CIHigh, 1e-9)
+
+	// a single run has a mean but no spread
+	summary = getRunMetricSummary("CPI", []float64{1})
+	assert.Equal(t, 1.0, summary.Mean)
+	assert.True(t, math.IsNaN(summary.Stddev))
+	assert.True(t, math.IsNaN(summary.CILow))
+}
+
+func TestSummarizeRuns(t *testing.T) {
+	dir := t.TempDir()
+	header := "TS,SKT,CPU,CID,TID,THREAD,CPI,IPC\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "host_run1_metrics.csv"), []byte(header+"1,,,,,,1,1\n2,,,,,,3,\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "host_run2_metrics.csv"), []byte(header+"1,,,,,,4,2\n"), 0644))
+	// run 3 collected no metrics
+	runs := []RunResult{{Run: 1, WallTime: 10}, {Run: 2, ExitCode: 1, WallTime: 12}, {Run: 3, WallTime: 11}}
+	summaries, err := getRunSummaries(dir, "host", runs)
+	require.NoError(t, err)
+	require.Len(t, summaries, 3)
+	assert.Equal(t, runWallTimeName, summaries[0].Name)
+	assert.Equal(t, 11.0, summaries[0].Mean)
+	assert.Equal(t, "CPI", summaries[1].Name)
+	assert.Equal(t, 2.0, summaries[1].RunMeans[0])
+	assert.Equal(t, 4.0, summaries[1].RunMeans[1])
+	assert.True(t, math.IsNaN(summaries[1].RunMeans[2]))
+	assert.Equal(t, 3.0, summaries[1].Mean)
+
+	csv, err := getRunsCSV("host", runs, summaries)
+	require.NoError(t, err)
+	assert.Contains(t, csv, "metric,run 1,run 2,run 3,mean,stddev,ci95 low,ci95 high\nexit code,0,1,0,,,,\n")
+
+	files, err := summarizeRuns(dir, "host", runs)
+	require.NoError(t, err)
+	assert.Len(t, files, 3)
+	jsonBytes, err := os.ReadFile(filepath.Join(dir, "host_metrics_runs.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(jsonBytes), `"ExitCode": 1`)
+	assert.Contains(t, string(jsonBytes), `null`)
+}
+
+func TestRunWrapper(t *testing.T) {
+	// the wrapper's arguments are joined into the perf command's shell script
+	script := strings.Join(append(getRunWrapperArgs(), "sh", "-c", "'sleep 0.2; exit 3'"), " ")
+	cmd := exec.Command("sh", "-c", script)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 3, exitErr.ExitCode())
+	wallTime, ok := parseRunWallTime(strings.TrimSpace(stderr.String()))
+	require.True(t, ok)
+	assert.GreaterOrEqual(t, wallTime, 0.2)
+	assert.Less(t, wallTime, 5.0)
+
+	_, ok = parseRunWallTime(`{"interval" : 1.000, "counter-value" : "1"}`)
+	assert.False(t, ok)
+}
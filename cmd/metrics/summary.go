@@ -22,11 +22,12 @@ import (
 	"time"
 )
 
-func summarizeMetrics(localOutputDir string, targetName string, metadata Metadata) ([]string, error) {
+func summarizeMetrics(localOutputDir string, targetName string, metadata Metadata, metricDefinitions []MetricDefinition) ([]string, error) {
 	filesCreated := []string{}
 	csvMetricsFile := filepath.Join(localOutputDir, targetName+"_metrics.csv")
+	definitions := getMetricDefinitionsByName(metricDefinitions)
 	// csv summary
-	out, err := summarize(csvMetricsFile, false, metadata)
+	out, err := summarize(csvMetricsFile, false, metadata, definitions)
 	if err != nil {
 		err = fmt.Errorf("failed to summarize output: %w", err)
 		return filesCreated, err
@@ -40,8 +41,23 @@ func summarizeMetrics(localOutputDir string, targetName string, metadata Metadat
 	filesCreated = append(filesCreated, csvSummaryFile)
 	// html summary
 	htmlSummary := (flagScope == scopeSystem || flagScope == scopeProcess) && flagGranularity == granularitySystem
+	heatmapSummary := flagScope == scopeSystem && (flagGranularity == granularitySocket || flagGranularity == granularityCPU)
+	if heatmapSummary {
+		out, err = summarizeHeatmap(csvMetricsFile, metadata)
+		if err != nil {
+			err = fmt.Errorf("failed to summarize output as HTML heatmap: %w", err)
+			return filesCreated, err
+		}
+		htmlSummaryFile := filepath.Join(localOutputDir, targetName+"_metrics_summary.html")
+		err = os.WriteFile(htmlSummaryFile, []byte(out), 0644) // #nosec G306
+		if err != nil {
+			err = fmt.Errorf("failed to write HTML summary to file: %w", err)
+			return filesCreated, err
+		}
+		filesCreated = append(filesCreated, htmlSummaryFile)
+	}
 	if htmlSummary {
-		out, err = summarize(csvMetricsFile, true, metadata)
+		out, err = summarize(csvMetricsFile, true, metadata, definitions)
 		if err != nil {
 			err = fmt.Errorf("failed to summarize output as HTML: %w", err)
 			return filesCreated, err
@@ -54,12 +70,33 @@ func summarizeMetrics(localOutputDir string, targetName string, metadata Metadat
 		}
 		filesCreated = append(filesCreated, htmlSummaryFile)
 	}
+	// TMA insights
+	insights, err := getInsightsFromCSV(csvMetricsFile, definitions)
+	if err != nil {
+		err = fmt.Errorf("failed to get insights: %w", err)
+		return filesCreated, err
+	}
+	insightsFiles, err := writeInsights(localOutputDir, targetName, insights)
+	if err != nil {
+		return filesCreated, err
+	}
+	filesCreated = append(filesCreated, insightsFiles...)
+	// per-device uncore summary
+	if flagUncoreDetail {
+		uncoreFiles, err := summarizeUncoreDetail(localOutputDir, targetName)
+		if err != nil {
+			err = fmt.Errorf("failed to summarize uncore detail: %w", err)
+			return filesCreated, err
+		}
+		filesCreated = append(filesCreated, uncoreFiles...)
+	}
 	return filesCreated, nil
 }
 
 // summarize - generates formatted output from a CSV file containing metric values.
 // The output can be in CSV or HTML format. Set html to true to generate HTML output otherwise CSV is generated.
-func summarize(csvInputPath string, html bool, metadata Metadata) (out string, err error) {
+// The metric definitions, by name, provide the units, categories, descriptions, and thresholds of the metrics.
+func summarize(csvInputPath string, html bool, metadata Metadata, definitions map[string]MetricDefinition) (out string, err error) {
 	var metrics []metricsFromCSV
 	if metrics, err = newMetricsFromCSV(csvInputPath); err != nil {
 		return
@@ -69,11 +106,11 @@ func summarize(csvInputPath string, html bool, metadata Metadata) (out string, e
 			err = fmt.Errorf("html format is supported only when data's scope is '%s' or '%s' and granularity is '%s'", scopeSystem, scopeProcess, granularitySystem)
 			return
 		}
-		out, err = metrics[0].getHTML(metadata)
+		out, err = metrics[0].getHTML(metadata, definitions)
 	} else {
 		for i, m := range metrics {
 			var oneOut string
-			if oneOut, err = m.getCSV(i == 0); err != nil {
+			if oneOut, err = m.getCSV(i == 0, definitions); err != nil {
 				return
 			}
 			out += oneOut
@@ -94,14 +131,28 @@ type row struct {
 	socket    string
 	cpu       string
 	cgroup    string
+	tid       string
+	thread    string
 	metrics   map[string]float64
 }
 
-// newRow loads a row structure with given fields and field names
-func newRow(fields []string, names []string) (r row, err error) {
+// newRow loads a row structure with given fields and field names, the metrics start at the
+// firstMetric field
+func newRow(fields []string, names []string, firstMetric int) (r row, err error) {
 	r.metrics = make(map[string]float64)
 	for fIdx, field := range fields {
-		if fIdx == idxTimestamp {
+		if fIdx >= firstMetric {
+			// metrics
+			var v float64
+			if field != "" {
+				if v, err = strconv.ParseFloat(field, 64); err != nil {
+					return
+				}
+			} else {
+				v = math.NaN()
+			}
+			r.metrics[names[fIdx-firstMetric]] = v
+		} else if fIdx == idxTimestamp {
 			var ts float64
 			if ts, err = strconv.ParseFloat(field, 64); err != nil {
 				return
@@ -113,30 +164,34 @@ func newRow(fields []string, names []string) (r row, err error) {
 			r.cpu = field
 		} else if fIdx == idxCgroup {
 			r.cgroup = field
-		} else {
-			// metrics
-			var v float64
-			if field != "" {
-				if v, err = strconv.ParseFloat(field, 64); err != nil {
-					return
-				}
-			} else {
-				v = math.NaN()
-			}
-			r.metrics[names[fIdx-idxFirstMetric]] = v
+		} else if fIdx == idxTID {
+			r.tid = field
+		} else if fIdx == idxThread {
+			r.thread = field
 		}
 	}
 	return
 }
 
+// the fields that precede the metrics in the metrics CSV file, the TID and THREAD fields are
+// present only in thread scope
 const (
 	idxTimestamp int = iota
 	idxSocket
 	idxCPU
 	idxCgroup
-	idxFirstMetric
+	idxTID
+	idxThread
 )
 
+// getFirstMetricIndex returns the index of the first metric field in the metrics CSV file's headers
+func getFirstMetricIndex(headers []string) int {
+	if len(headers) > idxThread && headers[idxTID] == "TID" && headers[idxThread] == "THREAD" {
+		return idxThread + 1
+	}
+	return idxTID
+}
+
 type metricsFromCSV struct {
 	names        []string
 	rows         []row
@@ -156,6 +211,7 @@ func newMetricsFromCSV(csvPath string) (metrics []metricsFromCSV, err error) {
 	var groupByValues []string
 	var metricNames []string
 	var nonMetricNames []string
+	firstMetric := idxTID
 	for idx := 0; true; idx++ {
 		var fields []string
 		if fields, err = reader.Read(); err != nil {
@@ -170,8 +226,9 @@ func newMetricsFromCSV(csvPath string) (metrics []metricsFromCSV, err error) {
 		}
 		if idx == 0 {
 			// headers
+			firstMetric = getFirstMetricIndex(fields)
 			for fIdx, field := range fields {
-				if fIdx < idxFirstMetric {
+				if fIdx < firstMetric {
 					nonMetricNames = append(nonMetricNames, field)
 				} else {
 					metricNames = append(metricNames, field)
@@ -189,11 +246,13 @@ func newMetricsFromCSV(csvPath string) (metrics []metricsFromCSV, err error) {
 				groupByField = idxCPU
 			} else if fields[idxCgroup] != "" {
 				groupByField = idxCgroup
+			} else if firstMetric > idxThread && fields[idxThread] != "" {
+				groupByField = idxThread
 			}
 		}
 		// Load row into a row structure
 		var r row
-		if r, err = newRow(fields, metricNames); err != nil {
+		if r, err = newRow(fields, metricNames, firstMetric); err != nil {
 			return
 		}
 		// put the row into the associated list based on groupByField
@@ -205,6 +264,10 @@ func newMetricsFromCSV(csvPath string) (metrics []metricsFromCSV, err error) {
 			metrics[0].rows = append(metrics[0].rows, r)
 		} else {
 			groupByValue := fields[groupByField]
+			if groupByField == idxThread {
+				// threads are identified by TID and name, thread groups by name (pattern) only
+				groupByValue = fields[idxTID] + "," + fields[idxThread]
+			}
 			var listIdx int
 			if listIdx = slices.Index(groupByValues, groupByValue); listIdx == -1 {
 				groupByValues = append(groupByValues, groupByValue)
@@ -217,6 +280,8 @@ func newMetricsFromCSV(csvPath string) (metrics []metricsFromCSV, err error) {
 					metrics[listIdx].groupByField = nonMetricNames[idxCPU]
 				} else if groupByField == idxCgroup {
 					metrics[listIdx].groupByField = nonMetricNames[idxCgroup]
+				} else if groupByField == idxThread {
+					metrics[listIdx].groupByField = nonMetricNames[idxTID] + "," + nonMetricNames[idxThread]
 				}
 				metrics[listIdx].groupByValue = groupByValue
 			}
@@ -230,60 +295,51 @@ func newMetricsFromCSV(csvPath string) (metrics []metricsFromCSV, err error) {
 func (m *metricsFromCSV) getStats() (stats map[string]metricStats, err error) {
 	stats = make(map[string]metricStats)
 	for _, metricName := range m.names {
-		min := math.NaN()
-		max := math.NaN()
-		mean := math.NaN()
-		stddev := math.NaN()
-		count := 0
-		sum := 0.0
+		values := make([]float64, 0, len(m.rows))
 		for _, row := range m.rows {
-			val := row.metrics[metricName]
-			if math.IsNaN(val) || math.IsInf(val, 0) {
-				continue
-			}
-			if math.IsNaN(min) { // min was initialized to NaN
-				// first non-NaN value, so initialize
-				min = math.MaxFloat64
-				max = 0
-				sum = 0
-			}
-			if val < min {
-				min = val
-			}
-			if val > max {
-				max = val
-			}
-			sum += val
-			count++
+			values = append(values, row.metrics[metricName])
 		}
-		// must be at least one valid value for this metric to calculate mean and standard deviation
-		if count > 0 {
-			mean = sum / float64(count)
-			distanceSquaredSum := 0.0
-			for _, row := range m.rows {
-				val := row.metrics[metricName]
-				if math.IsNaN(val) || math.IsInf(val, 0) {
-					continue
-				}
-				distance := mean - val
-				squared := distance * distance
-				distanceSquaredSum += squared
-			}
-			stddev = math.Sqrt(distanceSquaredSum / float64(count))
+		stats[metricName] = getValueStats(values)
+	}
+	return
+}
+
+// getValueStats calculates the summary stats (min, max, mean, stddev) of a list of values, ignoring
+// NaN and Inf values
+func getValueStats(values []float64) (stats metricStats) {
+	stats = metricStats{mean: math.NaN(), min: math.NaN(), max: math.NaN(), stddev: math.NaN()}
+	var valid []float64
+	for _, value := range values {
+		if !math.IsNaN(value) && !math.IsInf(value, 0) {
+			valid = append(valid, value)
 		}
-		stats[metricName] = metricStats{mean: mean, min: min, max: max, stddev: stddev}
 	}
+	if len(valid) == 0 {
+		return
+	}
+	sum := 0.0
+	for _, value := range valid {
+		sum += value
+	}
+	stats.mean = sum / float64(len(valid))
+	stats.min = slices.Min(valid)
+	stats.max = slices.Max(valid)
+	distanceSquaredSum := 0.0
+	for _, value := range valid {
+		distanceSquaredSum += (stats.mean - value) * (stats.mean - value)
+	}
+	stats.stddev = math.Sqrt(distanceSquaredSum / float64(len(valid)))
 	return
 }
 
 // getHTML - generate a string containing HTML representing the metrics
-func (m *metricsFromCSV) getHTML(metadata Metadata) (out string, err error) {
+func (m *metricsFromCSV) getHTML(metadata Metadata, definitions map[string]MetricDefinition) (out string, err error) {
 	var htmlTemplateBytes []byte
 	if htmlTemplateBytes, err = resources.ReadFile("resources/base.html"); err != nil {
 		slog.Error("failed to read base.html template", slog.String("error", err.Error()))
 		return
 	}
-	templateVals, err := m.loadHTMLTemplateValues(metadata)
+	templateVals, err := m.loadHTMLTemplateValues(metadata, definitions)
 	if err != nil {
 		slog.Error("failed to load template values", slog.String("error", err.Error()))
 		return
@@ -297,7 +353,7 @@ func (m *metricsFromCSV) getHTML(metadata Metadata) (out string, err error) {
 	return buf.String(), nil
 }
 
-func (m *metricsFromCSV) loadHTMLTemplateValues(metadata Metadata) (templateVals map[string]string, err error) {
+func (m *metricsFromCSV) loadHTMLTemplateValues(metadata Metadata, definitions map[string]MetricDefinition) (templateVals map[string]string, err error) {
 	templateVals = make(map[string]string)
 	var stats map[string]metricStats
 	if stats, err = m.getStats(); err != nil {
@@ -392,6 +448,11 @@ func (m *metricsFromCSV) loadHTMLTemplateValues(metadata Metadata) (templateVals
 		// Power Tab
 		{"PKGPOWER", []string{"package power (watts)", "package power (watts)"}},
 		{"DRAMPOWER", []string{"DRAM power (watts)", ""}},
+		// Efficiency Tab
+		{"INSTPERJOULE", []string{"instructions per joule", "instructions per joule"}},
+		{"ENERGYPERTXN", []string{"package energy per txn (joules)", "package energy per txn (joules)"}},
+		{"PKGPOWERATTR", []string{metricPackagePowerAttributed, metricPackagePowerAttributed}},
+		{"DRAMPOWERATTR", []string{metricDRAMPowerAttributed, ""}},
 	}
 	// replace the template variables with the series data
 	for tIdx, tmpl := range templateReplace {
@@ -437,6 +498,47 @@ func (m *metricsFromCSV) loadHTMLTemplateValues(metadata Metadata) (templateVals
 	}
 	jsonMetrics := string(jsonMetricsBytes)
 	templateVals["ALLMETRICS"] = jsonMetrics
+	// the metric info is kept separate from ALLMETRICS, whose format is parsed when comparing reports
+	type metricInfo struct {
+		Unit        string `json:"unit,omitempty"`
+		Category    string `json:"category,omitempty"`
+		Description string `json:"description,omitempty"`
+		Exceeded    bool   `json:"exceeded,omitempty"`
+	}
+	means := getMeans(stats)
+	allMetricInfo := make(map[string]metricInfo)
+	for _, name := range m.names {
+		if definition, ok := definitions[name]; ok {
+			allMetricInfo[name] = metricInfo{
+				Unit:        definition.Unit,
+				Category:    definition.Category,
+				Description: definition.Description,
+				Exceeded:    isThresholdExceeded(definition, stats[name].mean, means),
+			}
+		}
+	}
+	var jsonMetricInfoBytes []byte
+	if jsonMetricInfoBytes, err = json.Marshal(allMetricInfo); err != nil {
+		return
+	}
+	templateVals["METRICINFO"] = string(jsonMetricInfoBytes)
+	// Insights tab
+	insights := getTMAInsights(m.names, stats, definitions)
+	if insights == nil {
+		insights = []Insight{}
+	}
+	var jsonInsightsBytes []byte
+	if jsonInsightsBytes, err = json.Marshal(insights); err != nil {
+		return
+	}
+	templateVals["INSIGHTS"] = string(jsonInsightsBytes)
+	// Metadata and System Info tabs
+	err = loadMetadataTemplateValues(metadata, templateVals)
+	return
+}
+
+// loadMetadataTemplateValues sets the METADATA and SYSTEMINFO template values
+func loadMetadataTemplateValues(metadata Metadata, templateVals map[string]string) (err error) {
 	// Metadata tab
 	jsonMetadata, err := metadata.JSON()
 	if err != nil {
@@ -458,24 +560,82 @@ func (m *metricsFromCSV) loadHTMLTemplateValues(metadata Metadata) (templateVals
 	return
 }
 
-// getCSV - generate CSV string representing the summary statistics of the metrics
-func (m *metricsFromCSV) getCSV(includeFieldNames bool) (out string, err error) {
+// getInsightsFromCSV - generates the ranked TMA insights for each scope or granularity unit found in the
+// metrics CSV file
+func getInsightsFromCSV(csvInputPath string, definitions map[string]MetricDefinition) (insights []Insight, err error) {
+	var metrics []metricsFromCSV
+	if metrics, err = newMetricsFromCSV(csvInputPath); err != nil {
+		return
+	}
+	for _, m := range metrics {
+		var stats map[string]metricStats
+		if stats, err = m.getStats(); err != nil {
+			return
+		}
+		for _, insight := range getTMAInsights(m.names, stats, definitions) {
+			if m.groupByValue != "" {
+				insight.Group = m.groupByField + " " + m.groupByValue
+			}
+			insights = append(insights, insight)
+		}
+	}
+	return
+}
+
+// getMeans returns the mean of each metric, by metric name
+func getMeans(stats map[string]metricStats) map[string]float64 {
+	means := make(map[string]float64, len(stats))
+	for name, s := range stats {
+		means[name] = s.mean
+	}
+	return means
+}
+
+// getCSV - generate CSV string representing the summary statistics of the metrics, followed by the
+// metric's unit, category, whether its mean exceeds its threshold, and its description
+func (m *metricsFromCSV) getCSV(includeFieldNames bool, definitions map[string]MetricDefinition) (out string, err error) {
 	var stats map[string]metricStats
 	if stats, err = m.getStats(); err != nil {
 		return
 	}
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
 	if includeFieldNames {
-		out = "metric,mean,min,max,stddev\n"
+		fieldNames := []string{"metric", "mean", "min", "max", "stddev", "unit", "category", "exceeded", "description"}
 		if m.groupByField != "" {
-			out = m.groupByField + "," + out
+			fieldNames = append([]string{m.groupByField}, fieldNames...)
+		}
+		if err = writer.Write(fieldNames); err != nil {
+			return
 		}
 	}
+	means := getMeans(stats)
 	for _, name := range m.names {
-		if m.groupByValue == "" {
-			out += fmt.Sprintf("%s,%f,%f,%f,%f\n", name, stats[name].mean, stats[name].min, stats[name].max, stats[name].stddev)
-		} else {
-			out += fmt.Sprintf("%s,%s,%f,%f,%f,%f\n", m.groupByValue, name, stats[name].mean, stats[name].min, stats[name].max, stats[name].stddev)
+		definition := definitions[name]
+		exceeded := ""
+		if definition.ThresholdEvaluable != nil {
+			exceeded = strconv.FormatBool(isThresholdExceeded(definition, stats[name].mean, means))
+		}
+		record := []string{
+			name,
+			fmt.Sprintf("%f", stats[name].mean),
+			fmt.Sprintf("%f", stats[name].min),
+			fmt.Sprintf("%f", stats[name].max),
+			fmt.Sprintf("%f", stats[name].stddev),
+			definition.Unit,
+			definition.Category,
+			exceeded,
+			definition.Description,
+		}
+		if m.groupByValue != "" {
+			record = append([]string{m.groupByValue}, record...)
+		}
+		if err = writer.Write(record); err != nil {
+			return
 		}
 	}
+	writer.Flush()
+	err = writer.Error()
+	out = buf.String()
 	return
 }
@@ -0,0 +1,169 @@
+package metrics
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+// functions to create the heatmap HTML summary for socket and cpu granularity
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strconv"
+	texttemplate "text/template" // nosemgrep
+)
+
+// heatmapMaxColumns is the maximum number of samples, i.e., columns, in the heatmap. Longer collections
+// are downsampled by averaging consecutive samples, so that the size of the HTML doesn't grow with the
+// duration of the collection.
+const heatmapMaxColumns = 300
+
+// heatmapUnit is one row of the heatmap, i.e., a socket or a logical CPU, with
+// its location in the processor topology
+type heatmapUnit struct {
+	Label  string
+	Socket int
+	Die    int
+	Core   int
+	Thread int // index of the logical CPU within its core
+}
+
+// summarizeHeatmap - generate a string containing the heatmap HTML summary of the
+// socket or cpu granularity metrics found in the metrics CSV file
+func summarizeHeatmap(csvInputPath string, metadata Metadata) (out string, err error) {
+	var metrics []metricsFromCSV
+	if metrics, err = newMetricsFromCSV(csvInputPath); err != nil {
+		return
+	}
+	templateVals, err := loadHeatmapTemplateValues(metrics, metadata)
+	if err != nil {
+		slog.Error("failed to load heatmap template values", slog.String("error", err.Error()))
+		return
+	}
+	var htmlTemplateBytes []byte
+	if htmlTemplateBytes, err = resources.ReadFile("resources/heatmap.html"); err != nil {
+		slog.Error("failed to read heatmap.html template", slog.String("error", err.Error()))
+		return
+	}
+	fg := texttemplate.Must(texttemplate.New("metricsHeatmapTemplate").Delims("<<", ">>").Parse(string(htmlTemplateBytes)))
+	buf := new(bytes.Buffer)
+	if err = fg.Execute(buf, templateVals); err != nil {
+		slog.Error("failed to render heatmap template", slog.String("error", err.Error()))
+		return
+	}
+	return buf.String(), nil
+}
+
+func loadHeatmapTemplateValues(metrics []metricsFromCSV, metadata Metadata) (templateVals map[string]string, err error) {
+	if len(metrics) == 0 || metrics[0].groupByField == "" {
+		err = fmt.Errorf("no socket or cpu granularity metrics found")
+		return
+	}
+	templateVals = make(map[string]string)
+	granularity := granularitySocket
+	if metrics[0].groupByField == "CPU" {
+		granularity = granularityCPU
+	}
+	templateVals["GRANULARITY"] = strconv.Quote(granularity)
+	// order the units numerically, CSV order is the order perf reported them in
+	slices.SortStableFunc(metrics, func(a, b metricsFromCSV) int {
+		aID, _ := strconv.Atoi(a.groupByValue)
+		bID, _ := strconv.Atoi(b.groupByValue)
+		return aID - bID
+	})
+	units := getHeatmapUnits(metrics, granularity, metadata)
+	// timestamps, from the unit with the most samples
+	var timestamps []float64
+	for _, m := range metrics {
+		if len(m.rows) > len(timestamps) {
+			timestamps = timestamps[:0]
+			for _, r := range m.rows {
+				timestamps = append(timestamps, r.timestamp)
+			}
+		}
+	}
+	// each column is the mean of samplesPerColumn consecutive samples, labeled with the time of the first
+	samplesPerColumn := max(1, (len(timestamps)+heatmapMaxColumns-1)/heatmapMaxColumns)
+	numColumns := (len(timestamps) + samplesPerColumn - 1) / samplesPerColumn
+	var columnTimestamps []float64
+	for column := range numColumns {
+		columnTimestamps = append(columnTimestamps, timestamps[column*samplesPerColumn])
+	}
+	// values, indexed by metric, unit, and column
+	values := make([][][]any, len(metrics[0].names))
+	for metricIdx, name := range metrics[0].names {
+		values[metricIdx] = make([][]any, len(metrics))
+		for unitIdx, m := range metrics {
+			values[metricIdx][unitIdx] = make([]any, numColumns)
+			sums := make([]float64, numColumns)
+			counts := make([]int, numColumns)
+			for rowIdx, r := range m.rows {
+				if rowIdx >= len(timestamps) {
+					break
+				}
+				if val, ok := r.metrics[name]; ok && !math.IsNaN(val) && !math.IsInf(val, 0) {
+					sums[rowIdx/samplesPerColumn] += val
+					counts[rowIdx/samplesPerColumn]++
+				}
+			}
+			for column := range numColumns {
+				if counts[column] > 0 {
+					values[metricIdx][unitIdx][column] = sums[column] / float64(counts[column])
+				}
+			}
+		}
+	}
+	jsonVals := map[string]any{
+		"METRICNAMES":      metrics[0].names,
+		"UNITS":            units,
+		"TIMESTAMPS":       columnTimestamps,
+		"SAMPLESPERCOLUMN": samplesPerColumn,
+		"VALUES":           values,
+	}
+	for key, val := range jsonVals {
+		var jsonBytes []byte
+		if jsonBytes, err = json.Marshal(val); err != nil {
+			return
+		}
+		templateVals[key] = string(jsonBytes)
+	}
+	err = loadMetadataTemplateValues(metadata, templateVals)
+	return
+}
+
+// getHeatmapUnits - returns the topology of each socket or CPU in the same order as the metrics
+func getHeatmapUnits(metrics []metricsFromCSV, granularity string, metadata Metadata) (units []heatmapUnit) {
+	threadsPerCore := make(map[[3]int]int)
+	for _, m := range metrics {
+		id, err := strconv.Atoi(m.groupByValue)
+		if err != nil {
+			id = -1
+		}
+		unit := heatmapUnit{Label: m.groupByField + " " + m.groupByValue}
+		if granularity == granularitySocket {
+			unit.Socket = id
+		} else if topology, ok := metadata.CPUTopology[id]; ok {
+			unit.Socket = topology.Socket
+			unit.Die = topology.Die
+			unit.Core = topology.Core
+		} else {
+			// topology not available, assume the common enumeration where the
+			// SMT siblings of a core follow all of the first logical CPUs
+			unit.Socket = metadata.CPUSocketMap[id]
+			unit.Core = id
+			if numCores := metadata.CoresPerSocket * metadata.SocketCount; numCores > 0 {
+				unit.Core = id % numCores
+			}
+		}
+		if granularity == granularityCPU {
+			core := [3]int{unit.Socket, unit.Die, unit.Core}
+			unit.Thread = threadsPerCore[core]
+			threadsPerCore[core]++
+		}
+		units = append(units, unit)
+	}
+	return
+}
@@ -0,0 +1,82 @@
+package metrics
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarizeHeatmap(t *testing.T) {
+	dir := t.TempDir()
+	csv := "TS,SKT,CPU,CID,TID,THREAD,CPU utilization %,CPI\n" +
+		"1,,2,,,,90,1.5\n" +
+		"1,,0,,,,10,0.5\n" +
+		"1,,1,,,,20,\n" +
+		"1,,3,,,,30,1\n" +
+		"2,,2,,,,80,1.5\n" +
+		"2,,0,,,,15,0.5\n" +
+		"2,,1,,,,25,0.6\n" +
+		"2,,3,,,,35,1\n"
+	csvPath := filepath.Join(dir, "host_metrics.csv")
+	require.NoError(t, os.WriteFile(csvPath, []byte(csv), 0644))
+	metadata := Metadata{
+		CPUTopology: map[int]CPUTopology{
+			0: {Socket: 0, Die: 0, Core: 0},
+			1: {Socket: 0, Die: 0, Core: 1},
+			2: {Socket: 0, Die: 0, Core: 0},
+			3: {Socket: 0, Die: 0, Core: 1},
+		},
+	}
+	metrics, err := newMetricsFromCSV(csvPath)
+	require.NoError(t, err)
+	templateVals, err := loadHeatmapTemplateValues(metrics, metadata)
+	require.NoError(t, err)
+	assert.Equal(t, `"cpu"`, templateVals["GRANULARITY"])
+	assert.Equal(t, `["CPU utilization %","CPI"]`, templateVals["METRICNAMES"])
+	assert.Equal(t, `[1,2]`, templateVals["TIMESTAMPS"])
+	// units are sorted by CPU and SMT siblings are numbered within their core
+	assert.Equal(t, `[{"Label":"CPU 0","Socket":0,"Die":0,"Core":0,"Thread":0},`+
+		`{"Label":"CPU 1","Socket":0,"Die":0,"Core":1,"Thread":0},`+
+		`{"Label":"CPU 2","Socket":0,"Die":0,"Core":0,"Thread":1},`+
+		`{"Label":"CPU 3","Socket":0,"Die":0,"Core":1,"Thread":1}]`, templateVals["UNITS"])
+	assert.Equal(t, `[[[10,15],[20,25],[90,80],[30,35]],[[0.5,0.5],[null,0.6],[1.5,1.5],[1,1]]]`, templateVals["VALUES"])
+
+	out, err := summarizeHeatmap(csvPath, metadata)
+	require.NoError(t, err)
+	assert.Contains(t, out, `const metric_names = ["CPU utilization %","CPI"]`)
+}
+
+func TestHeatmapDownsampling(t *testing.T) {
+	// one sample more than fits, so each column averages two samples and the last column has one
+	m := metricsFromCSV{names: []string{"CPI"}, groupByField: "CPU", groupByValue: "0"}
+	for i := range heatmapMaxColumns + 1 {
+		value := float64(i)
+		if i == 1 {
+			value = math.NaN()
+		}
+		m.rows = append(m.rows, row{timestamp: float64(i + 1), cpu: "0", metrics: map[string]float64{"CPI": value}})
+	}
+	templateVals, err := loadHeatmapTemplateValues([]metricsFromCSV{m}, Metadata{})
+	require.NoError(t, err)
+	assert.Equal(t, "2", templateVals["SAMPLESPERCOLUMN"])
+	var timestamps []float64
+	require.NoError(t, json.Unmarshal([]byte(templateVals["TIMESTAMPS"]), &timestamps))
+	require.Len(t, timestamps, heatmapMaxColumns/2+1)
+	assert.Equal(t, []float64{1, 3, 5}, timestamps[:3])
+	var values [][][]*float64
+	require.NoError(t, json.Unmarshal([]byte(templateVals["VALUES"]), &values))
+	columns := values[0][0]
+	require.Len(t, columns, heatmapMaxColumns/2+1)
+	// invalid values are left out of the mean
+	assert.Equal(t, 0.0, *columns[0])
+	assert.Equal(t, 2.5, *columns[1])
+	assert.Equal(t, float64(heatmapMaxColumns), *columns[len(columns)-1])
+}
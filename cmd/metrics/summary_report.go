@@ -0,0 +1,151 @@
+package metrics
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+// summary reports created through the common report renderers, i.e., html, xlsx, json, and txt,
+// including a combined report when metrics are collected from more than one target
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"perfspect/internal/common"
+	"perfspect/internal/report"
+)
+
+// summaryReportSource identifies the metrics summarized in a report, there is one report per target,
+// or per run when repeating application runs
+type summaryReportSource struct {
+	name              string
+	metadata          Metadata
+	metricDefinitions []MetricDefinition
+}
+
+// writeSummaryReports writes the summary report of each source in the requested formats, and the
+// combined reports when there is more than one source
+func writeSummaryReports(appContext common.AppContext, sources []summaryReportSource, formats []string) ([]string, error) {
+	if len(sources) == 0 || len(formats) == 0 {
+		return nil, nil
+	}
+	if slices.Contains(formats, report.FormatAll) {
+		formats = report.FormatOptions
+	}
+	var names []string
+	var allSourcesTableValues [][]report.TableValues
+	for _, source := range sources {
+		csvMetricsFile := filepath.Join(appContext.OutputDir, source.name+"_metrics.csv")
+		allTableValues, err := getSummaryTableValues(csvMetricsFile, source.metadata, source.metricDefinitions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create summary tables for %s: %w", source.name, err)
+		}
+		allTableValues = append(allTableValues, common.GetPerfspectTableValues(appContext))
+		names = append(names, source.name)
+		allSourcesTableValues = append(allSourcesTableValues, allTableValues)
+	}
+	return common.WriteReports(appContext.OutputDir, "metrics_report", names, allSourcesTableValues, formats)
+}
+
+// getSummaryTableValues returns the summary of the metrics in the CSV file as report tables: the
+// system summary, the statistics of each metric, the TMA breakdown, and the TMA insights
+func getSummaryTableValues(csvMetricsFile string, metadata Metadata, metricDefinitions []MetricDefinition) (allTableValues []report.TableValues, err error) {
+	var metrics []metricsFromCSV
+	if metrics, err = newMetricsFromCSV(csvMetricsFile); err != nil {
+		return
+	}
+	definitions := getMetricDefinitionsByName(metricDefinitions)
+	statisticsTableValues := report.TableValues{TableDefinition: report.GetMetricsTableDefinition(report.MetricStatisticsTableName)}
+	tmaTableValues := report.TableValues{TableDefinition: report.GetMetricsTableDefinition(report.TMABreakdownTableName)}
+	grouped := slices.ContainsFunc(metrics, func(m metricsFromCSV) bool { return m.groupByValue != "" })
+	statisticsFieldNames := []string{report.MetricsMetricFieldName, report.MetricsUnitFieldName, report.MetricsCategoryFieldName, report.MetricsMeanFieldName, report.MetricsMinFieldName, report.MetricsMaxFieldName, report.MetricsStddevFieldName, report.MetricsExceededFieldName, report.MetricsDescriptionFieldName}
+	tmaFieldNames := []string{report.MetricsLevelFieldName, report.MetricsMetricFieldName, report.MetricsParentFieldName, report.MetricsMeanFieldName, report.MetricsExceededFieldName}
+	if grouped {
+		statisticsFieldNames = append([]string{report.MetricsGroupFieldName}, statisticsFieldNames...)
+		tmaFieldNames = append([]string{report.MetricsGroupFieldName}, tmaFieldNames...)
+	}
+	statisticsTableValues.Fields = newFields(statisticsFieldNames)
+	tmaTableValues.Fields = newFields(tmaFieldNames)
+	for _, m := range metrics {
+		var stats map[string]metricStats
+		if stats, err = m.getStats(); err != nil {
+			return
+		}
+		means := getMeans(stats)
+		group := m.groupByField + " " + m.groupByValue
+		for _, name := range m.names {
+			definition := definitions[name]
+			exceeded := ""
+			if definition.ThresholdEvaluable != nil {
+				exceeded = strconv.FormatBool(isThresholdExceeded(definition, stats[name].mean, means))
+			}
+			values := []string{name, definition.Unit, definition.Category, formatStat(stats[name].mean), formatStat(stats[name].min), formatStat(stats[name].max), formatStat(stats[name].stddev), exceeded, definition.Description}
+			if grouped {
+				values = append([]string{group}, values...)
+			}
+			appendRow(statisticsTableValues.Fields, values)
+			if definition.Level > 0 {
+				values = []string{strconv.Itoa(definition.Level), name, definition.Parent, formatStat(stats[name].mean), exceeded}
+				if grouped {
+					values = append([]string{group}, values...)
+				}
+				appendRow(tmaTableValues.Fields, values)
+			}
+		}
+	}
+	// the ranked TMA insights are the recommendations shown in the insights table
+	insights, err := getInsightsFromCSV(csvMetricsFile, definitions)
+	if err != nil {
+		return
+	}
+	for _, insight := range insights {
+		justification := insight.Finding
+		if insight.Group != "" {
+			justification = insight.Group + ": " + justification
+		}
+		tmaTableValues.Insights = append(tmaTableValues.Insights, report.Insight{Recommendation: insight.Recommendation, Justification: justification})
+	}
+	allTableValues = append(allTableValues, getSystemSummaryTableValues(metadata), statisticsTableValues, tmaTableValues)
+	allTableValues = append(allTableValues, common.DefaultInsightsFunc(allTableValues, nil))
+	return
+}
+
+// getSystemSummaryTableValues returns the system summary collected with the metadata as a table
+func getSystemSummaryTableValues(metadata Metadata) report.TableValues {
+	tableValues := report.TableValues{TableDefinition: report.GetMetricsTableDefinition(report.SystemSummaryTableName)}
+	for _, field := range metadata.SystemSummaryFields {
+		// fields without names are messages, e.g., when the system summary isn't collected
+		if len(field) < 2 || field[0] == "" {
+			continue
+		}
+		tableValues.Fields = append(tableValues.Fields, report.Field{Name: field[0], Values: []string{field[1]}})
+	}
+	return tableValues
+}
+
+// newFields returns fields with the given names and no values
+func newFields(names []string) []report.Field {
+	fields := make([]report.Field, 0, len(names))
+	for _, name := range names {
+		fields = append(fields, report.Field{Name: name, Values: []string{}})
+	}
+	return fields
+}
+
+// appendRow appends one value to each field
+func appendRow(fields []report.Field, values []string) {
+	for i := range fields {
+		fields[i].Values = append(fields[i].Values, values[i])
+	}
+}
+
+// formatStat formats a summary statistic like the summary CSV does, values that couldn't be
+// calculated are left empty
+func formatStat(value float64) string {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return ""
+	}
+	return fmt.Sprintf("%f", value)
+}
@@ -0,0 +1,69 @@
+package metrics
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Knetic/govaluate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perfspect/internal/common"
+	"perfspect/internal/report"
+)
+
+func TestSummaryReports(t *testing.T) {
+	dir := t.TempDir()
+	csv := "TS,SKT,CPU,CID,TID,THREAD,CPI,TMA_Frontend_Bound(%),TMA_Fetch_Latency(%)\n" +
+		"1,,,,,,1.5,30,20\n" +
+		"2,,,,,,0.5,10,\n"
+	for _, name := range []string{"host1", "host2"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name+"_metrics.csv"), []byte(csv), 0644))
+	}
+	threshold, err := govaluate.NewEvaluableExpression("[value] > 15")
+	require.NoError(t, err)
+	definitions := []MetricDefinition{
+		{Name: "CPI", Unit: "cycles/instruction", Category: "CPU", Description: "cycles per instruction"},
+		{Name: "TMA_Frontend_Bound(%)", Unit: "%", Category: "TMA", Level: 1, Threshold: "[value] > 15", ThresholdEvaluable: threshold},
+		{Name: "TMA_Fetch_Latency(%)", Unit: "%", Category: "TMA", Level: 2, Parent: "TMA_Frontend_Bound(%)"},
+	}
+	metadata := Metadata{SystemSummaryFields: [][]string{{"Host Name", "host1"}, {"Microarchitecture", "SPR"}}}
+
+	allTableValues, err := getSummaryTableValues(filepath.Join(dir, "host1_metrics.csv"), metadata, definitions)
+	require.NoError(t, err)
+	require.Len(t, allTableValues, 4)
+	assert.Equal(t, report.SystemSummaryTableName, allTableValues[0].Name)
+	assert.Equal(t, []report.Field{{Name: "Host Name", Values: []string{"host1"}}, {Name: "Microarchitecture", Values: []string{"SPR"}}}, allTableValues[0].Fields)
+	statistics := allTableValues[1]
+	assert.Equal(t, report.MetricStatisticsTableName, statistics.Name)
+	assert.Equal(t, report.Field{Name: report.MetricsMetricFieldName, Values: []string{"CPI", "TMA_Frontend_Bound(%)", "TMA_Fetch_Latency(%)"}}, statistics.Fields[0])
+	assert.Equal(t, report.Field{Name: report.MetricsMeanFieldName, Values: []string{"1.000000", "20.000000", "20.000000"}}, statistics.Fields[3])
+	assert.Equal(t, report.Field{Name: report.MetricsExceededFieldName, Values: []string{"", "true", ""}}, statistics.Fields[7])
+	tma := allTableValues[2]
+	assert.Equal(t, report.TMABreakdownTableName, tma.Name)
+	assert.Equal(t, report.Field{Name: report.MetricsLevelFieldName, Values: []string{"1", "2"}}, tma.Fields[0])
+	assert.Equal(t, report.Field{Name: report.MetricsParentFieldName, Values: []string{"", "TMA_Frontend_Bound(%)"}}, tma.Fields[2])
+	assert.Equal(t, common.TableNameInsights, allTableValues[3].Name)
+
+	// one report per source in each format, and combined reports for more than one source
+	sources := []summaryReportSource{
+		{name: "host1", metadata: metadata, metricDefinitions: definitions},
+		{name: "host2", metadata: metadata, metricDefinitions: definitions},
+	}
+	files, err := writeSummaryReports(common.AppContext{OutputDir: dir, Version: "test"}, sources, []string{report.FormatAll})
+	require.NoError(t, err)
+	for _, name := range []string{"host1_metrics_report.html", "host1_metrics_report.xlsx", "host1_metrics_report.json", "host1_metrics_report.txt", "host2_metrics_report.html", "all_hosts.html", "all_hosts.xlsx"} {
+		assert.Contains(t, files, filepath.Join(dir, name))
+	}
+	html, err := os.ReadFile(filepath.Join(dir, "host1_metrics_report.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(html), `<span title="cycles per instruction">CPI</span>`)
+	assert.Contains(t, string(html), `style="background-color:rgba(255,165,0,.4)">20.000000</td>`)
+	combined, err := os.ReadFile(filepath.Join(dir, "all_hosts.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(combined), "<th>host1 Mean</th><th>host2 Mean</th>")
+}
@@ -0,0 +1,62 @@
+package metrics
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Knetic/govaluate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarizeCSVWithMetricMetadata(t *testing.T) {
+	dir := t.TempDir()
+	csv := "TS,SKT,CPU,CID,TID,THREAD,CPU utilization %,CPI\n" +
+		"1,,,,,,90,1.5\n" +
+		"2,,,,,,70,0.5\n"
+	csvPath := filepath.Join(dir, "host_metrics.csv")
+	require.NoError(t, os.WriteFile(csvPath, []byte(csv), 0644))
+	threshold, err := govaluate.NewEvaluableExpression("[value] > 50")
+	require.NoError(t, err)
+	definitions := getMetricDefinitionsByName([]MetricDefinition{
+		{Name: "CPU utilization %", Unit: "%", Category: "CPU", Description: "time in C0, excluding idle", Threshold: "[value] > 50", ThresholdEvaluable: threshold},
+	})
+	out, err := summarize(csvPath, false, Metadata{}, definitions)
+	require.NoError(t, err)
+	assert.Equal(t, "metric,mean,min,max,stddev,unit,category,exceeded,description\n"+
+		"CPU utilization %,80.000000,70.000000,90.000000,10.000000,%,CPU,true,\"time in C0, excluding idle\"\n"+
+		"CPI,1.000000,0.500000,1.500000,0.500000,,,,\n", out)
+	// the attributed power metrics are described even though they aren't in the metric files
+	assert.Equal(t, "W", definitions[metricPackagePowerAttributed].Unit)
+}
+
+func TestNewMetricsFromCSVLayouts(t *testing.T) {
+	dir := t.TempDir()
+	// system, socket, cpu and cgroup scopes don't write the TID and THREAD fields
+	csvPath := filepath.Join(dir, "cpu_metrics.csv")
+	require.NoError(t, os.WriteFile(csvPath, []byte("TS,SKT,CPU,CID,CPI\n"+
+		"1,,0,,1.5\n"+
+		"1,,1,,0.5\n"), 0644))
+	metrics, err := newMetricsFromCSV(csvPath)
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+	assert.Equal(t, "CPU", metrics[0].groupByField)
+	assert.Equal(t, "0", metrics[0].groupByValue)
+	assert.Equal(t, 1.5, metrics[0].rows[0].metrics["CPI"])
+	assert.Equal(t, 0.5, metrics[1].rows[0].metrics["CPI"])
+	// thread scope writes them
+	csvPath = filepath.Join(dir, "thread_metrics.csv")
+	require.NoError(t, os.WriteFile(csvPath, []byte("TS,SKT,CPU,CID,TID,THREAD,CPI\n"+
+		"1,,,,42,java,2.5\n"), 0644))
+	metrics, err = newMetricsFromCSV(csvPath)
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, "TID,THREAD", metrics[0].groupByField)
+	assert.Equal(t, "42,java", metrics[0].groupByValue)
+	assert.Equal(t, "java", metrics[0].rows[0].thread)
+	assert.Equal(t, 2.5, metrics[0].rows[0].metrics["CPI"])
+}
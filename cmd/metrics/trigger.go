@@ -0,0 +1,52 @@
+package metrics
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+// threshold-triggered collection over the collected metrics, see common.TriggerWatcher
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"perfspect/internal/common"
+	"perfspect/internal/progress"
+)
+
+// newTriggerWatcher returns a watcher for the trigger expression. All metrics referenced by the
+// expression must be in the metric definitions, i.e., collected on the target.
+func newTriggerWatcher(expression string, action string, duration int, cooldown int, metricDefinitions []MetricDefinition, targetName string, outputDir string, capture common.CaptureFunc, status progress.MultiSpinnerUpdateFunc) (*common.TriggerWatcher, error) {
+	watcher, err := common.NewTriggerWatcher(expression, GetEvaluatorFunctions(), action, duration, cooldown, targetName, outputDir, capture, status)
+	if err != nil {
+		return nil, err
+	}
+	for _, variable := range watcher.Vars() {
+		if !slices.ContainsFunc(metricDefinitions, func(m MetricDefinition) bool { return m.Name == variable }) {
+			return nil, fmt.Errorf("trigger expression refers to a metric that isn't collected: %s", variable)
+		}
+	}
+	return watcher, nil
+}
+
+// getTriggerSamples returns the metric frames as the samples that the trigger is evaluated over
+func getTriggerSamples(metricFrames []MetricFrame, collectionStartTime time.Time) []common.TriggerSample {
+	var samples []common.TriggerSample
+	for _, metricFrame := range metricFrames {
+		sample := common.TriggerSample{
+			Time:   collectionStartTime.Add(time.Duration(metricFrame.Timestamp * float64(time.Second))),
+			Labels: make(map[string]string),
+			Values: make(map[string]float64, len(metricFrame.Metrics)),
+		}
+		for name, value := range map[string]string{"Socket": metricFrame.Socket, "CPU": metricFrame.CPU, "Cgroup": metricFrame.Cgroup, "PID": metricFrame.PID, "Cmd": metricFrame.Cmd} {
+			if value != "" {
+				sample.Labels[name] = value
+			}
+		}
+		for _, metric := range metricFrame.Metrics {
+			sample.Values[metric.Name] = metric.Value
+		}
+		samples = append(samples, sample)
+	}
+	return samples
+}
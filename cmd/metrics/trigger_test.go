@@ -0,0 +1,39 @@
+package metrics
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"perfspect/internal/common"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTriggerWatcher(t *testing.T) {
+	definitions := []MetricDefinition{{Name: "CPI"}}
+	_, err := newTriggerWatcher("[CPI] > 1 &&", common.TriggerActionFlame, 10, 60, definitions, "host", t.TempDir(), nil, nil)
+	assert.Error(t, err)
+	_, err = newTriggerWatcher("[IPC] < 1", common.TriggerActionFlame, 10, 60, definitions, "host", t.TempDir(), nil, nil)
+	assert.ErrorContains(t, err, "IPC")
+	watcher, err := newTriggerWatcher("[CPI] > 1", common.TriggerActionFlame, 10, 60, definitions, "host", t.TempDir(), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"CPI"}, watcher.Vars())
+}
+
+func TestGetTriggerSamples(t *testing.T) {
+	start := time.Unix(2000, 0)
+	samples := getTriggerSamples([]MetricFrame{
+		{Timestamp: 5.25, Socket: "1", Metrics: []Metric{{Name: "TMA_..Memory_Bound(%)", Value: 70}, {Name: "CPI", Value: math.NaN()}}},
+	}, start)
+	require.Len(t, samples, 1)
+	// sub-second timestamps are kept
+	assert.Equal(t, start.Add(5250*time.Millisecond), samples[0].Time)
+	assert.Equal(t, map[string]string{"Socket": "1"}, samples[0].Labels)
+	assert.Equal(t, 70.0, samples[0].Values["TMA_..Memory_Bound(%)"])
+	assert.True(t, math.IsNaN(samples[0].Values["CPI"]))
+}
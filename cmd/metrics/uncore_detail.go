@@ -0,0 +1,384 @@
+package metrics
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+// per-device (IMC channel, UPI link, CHA) uncore metrics produced when the uncore detail mode is enabled
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UncoreDevice represents the event values collected from one uncore device, e.g., imc 3
+type UncoreDevice struct {
+	Type        string             // device type, e.g., imc, upi, cha
+	ID          int                // device index, e.g., 3 for uncore_imc_3
+	EventValues map[string]float64 // event name (without device ID suffix) -> event value
+}
+
+// UncoreDeviceMetric represents a metric derived from the events of one uncore device
+type UncoreDeviceMetric struct {
+	Device string
+	ID     int
+	Name   string
+	Value  float64
+}
+
+// names of the per-device metrics
+const (
+	uncoreMetricReadBandwidth  = "read bandwidth (MB/sec)"
+	uncoreMetricWriteBandwidth = "write bandwidth (MB/sec)"
+	uncoreMetricTxBandwidth    = "transmit data bandwidth (MB/sec)"
+	uncoreMetricUtilization    = "utilization (%)"
+	uncoreMetricOccupancy      = "average TOR occupancy"
+)
+
+// uncoreImbalanceThreshold is the relative deviation from the mean across devices of the same
+// type above which a device is flagged as imbalanced
+const uncoreImbalanceThreshold = 0.25
+
+// getUncoreDevices extracts the per-device event values from the (not yet collapsed) uncore groups
+// in the frame. The device type of a group is taken from its event group definition and the device
+// ID from the event name suffix, e.g., UNC_M_CAS_COUNT.RD.3 is imc device 3.
+func getUncoreDevices(frame EventFrame, eventGroupDefinitions []GroupDefinition, metadata Metadata) (devices []UncoreDevice) {
+	for _, group := range frame.EventGroups {
+		if group.GroupID >= len(eventGroupDefinitions) || len(eventGroupDefinitions[group.GroupID]) == 0 {
+			continue
+		}
+		deviceType := eventGroupDefinitions[group.GroupID][0].Device
+		if _, ok := metadata.UncoreDeviceIDs[deviceType]; !ok {
+			continue
+		}
+		for name, value := range group.EventValues {
+			if !strings.HasPrefix(name, "UNC") {
+				continue
+			}
+			idx := strings.LastIndex(name, ".")
+			if idx == -1 {
+				continue
+			}
+			deviceID, err := strconv.Atoi(name[idx+1:])
+			if err != nil || !slices.Contains(metadata.UncoreDeviceIDs[deviceType], deviceID) {
+				continue
+			}
+			deviceIdx := slices.IndexFunc(devices, func(d UncoreDevice) bool { return d.Type == deviceType && d.ID == deviceID })
+			if deviceIdx == -1 {
+				devices = append(devices, UncoreDevice{Type: deviceType, ID: deviceID, EventValues: make(map[string]float64)})
+				deviceIdx = len(devices) - 1
+			}
+			// the same event may be collected in more than one group, use the first
+			if _, ok := devices[deviceIdx].EventValues[name[:idx]]; !ok {
+				devices[deviceIdx].EventValues[name[:idx]] = value
+			}
+		}
+	}
+	slices.SortFunc(devices, func(a, b UncoreDevice) int {
+		if a.Type != b.Type {
+			return strings.Compare(a.Type, b.Type)
+		}
+		return a.ID - b.ID
+	})
+	return
+}
+
+// sumEventValues sums the values of the events whose names match the given prefix and suffix
+func sumEventValues(eventValues map[string]float64, prefix string, suffix string) (sum float64, found bool) {
+	for name, value := range eventValues {
+		if strings.HasPrefix(name, prefix) && strings.HasSuffix(name, suffix) && !math.IsNaN(value) {
+			sum += value
+			found = true
+		}
+	}
+	return
+}
+
+// getUncoreDeviceMetrics calculates the per-device metrics from the per-device event values
+// collected over the given interval (in seconds)
+//   - imc: read and write bandwidth from CAS counts (64 bytes per CAS)
+//   - upi: transmit data bandwidth from data flits (64 bytes per 9 flits) and, if the clockticks and
+//     non-data flits are collected, the link utilization assuming up to 3 flits per UPI clock
+//   - cha: average TOR occupancy of demand data read misses per CHA clock
+func getUncoreDeviceMetrics(devices []UncoreDevice, interval float64) (metrics []UncoreDeviceMetric) {
+	if interval <= 0 {
+		return
+	}
+	for _, device := range devices {
+		switch device.Type {
+		case "imc":
+			// UNC_M_CAS_COUNT.RD, or UNC_M_CAS_COUNT_SCH0.RD + UNC_M_CAS_COUNT_SCH1.RD (abbreviated to UNCMCC)
+			for _, direction := range []struct {
+				suffix string
+				name   string
+			}{{".RD", uncoreMetricReadBandwidth}, {".WR", uncoreMetricWriteBandwidth}} {
+				cas, found := sumEventValues(device.EventValues, "UNC_M_CAS_COUNT", direction.suffix)
+				casSch, foundSch := sumEventValues(device.EventValues, "UNCMCC", direction.suffix)
+				if found || foundSch {
+					metrics = append(metrics, UncoreDeviceMetric{Device: device.Type, ID: device.ID, Name: direction.name, Value: (cas + casSch) * 64 / 1000000 / interval})
+				}
+			}
+		case "upi":
+			dataFlits, found := device.EventValues["UNC_UPI_TxL_FLITS.ALL_DATA"]
+			if !found {
+				continue
+			}
+			metrics = append(metrics, UncoreDeviceMetric{Device: device.Type, ID: device.ID, Name: uncoreMetricTxBandwidth, Value: dataFlits * (64 / 9.0) / 1000000 / interval})
+			nonDataFlits, foundNonData := device.EventValues["UNC_UPI_TxL_FLITS.NON_DATA"]
+			clockticks, foundClockticks := device.EventValues["UNC_UPI_CLOCKTICKS"]
+			if foundNonData && foundClockticks && clockticks > 0 {
+				metrics = append(metrics, UncoreDeviceMetric{Device: device.Type, ID: device.ID, Name: uncoreMetricUtilization, Value: 100 * (dataFlits + nonDataFlits) / (3 * clockticks)})
+			}
+		case "cha":
+			clockticks, found := device.EventValues["UNCCCT"] // UNC_CHA_CLOCKTICKS
+			if !found || clockticks <= 0 {
+				continue
+			}
+			occupancy, found := device.EventValues["UNCCTO.IMD"] // UNC_CHA_TOR_OCCUPANCY.IA_MISS_DRD
+			if !found {
+				// local + remote, when the combined event isn't collected
+				local, foundLocal := device.EventValues["UNCCTO.IMDL"]
+				remote, foundRemote := device.EventValues["UNCCTO.IMDR"]
+				if !foundLocal && !foundRemote {
+					continue
+				}
+				occupancy = local + remote
+			}
+			metrics = append(metrics, UncoreDeviceMetric{Device: device.Type, ID: device.ID, Name: uncoreMetricOccupancy, Value: occupancy / clockticks})
+		}
+	}
+	return
+}
+
+// printUncoreDetailCSV appends the per-device uncore metrics of the given frames to the uncore CSV
+// file, one row per device metric
+func printUncoreDetailCSV(metricFrames []MetricFrame, frameCount int, targetName string, collectionStartTime time.Time, outputDir string) (outputFilename string, err error) {
+	filename := outputDir + "/" + targetName + "_" + "metrics_uncore.csv"
+	var file *os.File
+	file, err = os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644) // #nosec G304 G302
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	if frameCount == 1 {
+		if _, err = file.WriteString("TS,DEVICE,ID,METRIC,VALUE\n"); err != nil {
+			return
+		}
+	}
+	for _, metricFrame := range metricFrames {
+		for _, metric := range metricFrame.UncoreMetrics {
+			value := strings.ReplaceAll(strconv.FormatFloat(metric.Value, 'g', 8, 64), "NaN", "")
+			row := fmt.Sprintf("%d,%s,%d,%s,%s\n", collectionStartTime.Unix()+int64(metricFrame.Timestamp), metric.Device, metric.ID, metric.Name, value)
+			if _, err = file.WriteString(row); err != nil {
+				return
+			}
+		}
+	}
+	outputFilename = filename
+	return
+}
+
+// uncoreDetailSummary holds the summary statistics of one per-device metric
+type uncoreDetailSummary struct {
+	Device  string
+	ID      int
+	Name    string
+	Stats   metricStats
+	Warning string
+}
+
+// summarizeUncoreDetail creates the summary CSV and HTML tables from the uncore CSV file, including
+// warnings for devices whose mean deviates from the mean of all devices of the same type
+func summarizeUncoreDetail(localOutputDir string, targetName string) (filesCreated []string, err error) {
+	csvUncoreFile := filepath.Join(localOutputDir, targetName+"_metrics_uncore.csv")
+	var summaries []uncoreDetailSummary
+	if summaries, err = loadUncoreDetailSummaries(csvUncoreFile); err != nil {
+		return
+	}
+	if len(summaries) == 0 {
+		slog.Warn("no per-device uncore metrics collected", slog.String("target", targetName))
+		return
+	}
+	// csv
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	_ = writer.Write([]string{"device", "id", "metric", "mean", "min", "max", "stddev", "warning"})
+	for _, s := range summaries {
+		_ = writer.Write([]string{s.Device, strconv.Itoa(s.ID), s.Name, fmt.Sprintf("%f", s.Stats.mean), fmt.Sprintf("%f", s.Stats.min), fmt.Sprintf("%f", s.Stats.max), fmt.Sprintf("%f", s.Stats.stddev), s.Warning})
+	}
+	writer.Flush()
+	csvSummaryFile := filepath.Join(localOutputDir, targetName+"_metrics_uncore_summary.csv")
+	if err = os.WriteFile(csvSummaryFile, buf.Bytes(), 0644); err != nil { // #nosec G306
+		err = fmt.Errorf("failed to write uncore summary to file: %w", err)
+		return
+	}
+	filesCreated = append(filesCreated, csvSummaryFile)
+	// html
+	var out string
+	if out, err = getUncoreDetailHTML(targetName, summaries); err != nil {
+		return
+	}
+	htmlSummaryFile := filepath.Join(localOutputDir, targetName+"_metrics_uncore_summary.html")
+	if err = os.WriteFile(htmlSummaryFile, []byte(out), 0644); err != nil { // #nosec G306
+		err = fmt.Errorf("failed to write uncore HTML summary to file: %w", err)
+		return
+	}
+	filesCreated = append(filesCreated, htmlSummaryFile)
+	return
+}
+
+// loadUncoreDetailSummaries reads the uncore CSV file and calculates the summary statistics for
+// each device metric
+func loadUncoreDetailSummaries(csvPath string) (summaries []uncoreDetailSummary, err error) {
+	file, err := os.Open(csvPath) // #nosec G304
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	reader := csv.NewReader(file)
+	type key struct {
+		device string
+		id     int
+		name   string
+	}
+	var keys []key
+	values := make(map[key][]float64)
+	for idx := 0; ; idx++ {
+		var fields []string
+		if fields, err = reader.Read(); err != nil {
+			if err == io.EOF {
+				err = nil
+				break
+			}
+			return
+		}
+		if idx == 0 || len(fields) < 5 {
+			continue // headers
+		}
+		var id int
+		if id, err = strconv.Atoi(fields[2]); err != nil {
+			return
+		}
+		k := key{device: fields[1], id: id, name: fields[3]}
+		if _, ok := values[k]; !ok {
+			keys = append(keys, k)
+		}
+		value := math.NaN()
+		if fields[4] != "" {
+			if value, err = strconv.ParseFloat(fields[4], 64); err != nil {
+				return
+			}
+		}
+		values[k] = append(values[k], value)
+	}
+	for _, k := range keys {
+		summaries = append(summaries, uncoreDetailSummary{Device: k.device, ID: k.id, Name: k.name, Stats: getValueStats(values[k])})
+	}
+	setUncoreImbalanceWarnings(summaries)
+	return
+}
+
+// setUncoreImbalanceWarnings sets a warning on each device metric whose mean deviates from the
+// mean of the same metric across all devices of the same type by more than the threshold
+func setUncoreImbalanceWarnings(summaries []uncoreDetailSummary) {
+	type key struct {
+		device string
+		name   string
+	}
+	sums := make(map[key]float64)
+	counts := make(map[key]int)
+	for _, s := range summaries {
+		if math.IsNaN(s.Stats.mean) {
+			continue
+		}
+		k := key{device: s.Device, name: s.Name}
+		sums[k] += s.Stats.mean
+		counts[k]++
+	}
+	for i, s := range summaries {
+		k := key{device: s.Device, name: s.Name}
+		if counts[k] < 2 || math.IsNaN(s.Stats.mean) {
+			continue
+		}
+		mean := sums[k] / float64(counts[k])
+		if mean == 0 {
+			continue
+		}
+		deviation := (s.Stats.mean - mean) / mean
+		if math.Abs(deviation) > uncoreImbalanceThreshold {
+			direction := "above"
+			if deviation < 0 {
+				direction = "below"
+			}
+			summaries[i].Warning = fmt.Sprintf("%s %d %s is %.0f%% %s the average of all %s devices", s.Device, s.ID, s.Name, math.Abs(deviation)*100, direction, s.Device)
+			slog.Warn("uncore imbalance", slog.String("warning", summaries[i].Warning))
+		}
+	}
+}
+
+var uncoreDetailHTMLTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; margin-bottom: 24px; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: right; }
+th { background-color: #0071c5; color: white; }
+td:first-child, td:last-child { text-align: left; }
+tr.warning { background-color: #fff3cd; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{range .Tables}}
+<h2>{{.Device}}</h2>
+<table>
+<tr><th>ID</th><th>Metric</th><th>Mean</th><th>Min</th><th>Max</th><th>Stddev</th><th>Warning</th></tr>
+{{range .Rows}}<tr{{if .Warning}} class="warning"{{end}}><td>{{.ID}}</td><td>{{.Name}}</td><td>{{printf "%.2f" .Mean}}</td><td>{{printf "%.2f" .Min}}</td><td>{{printf "%.2f" .Max}}</td><td>{{printf "%.2f" .Stddev}}</td><td>{{.Warning}}</td></tr>
+{{end}}</table>
+{{end}}
+</body>
+</html>
+`
+
+// getUncoreDetailHTML generates an HTML document with one table per uncore device type
+func getUncoreDetailHTML(targetName string, summaries []uncoreDetailSummary) (out string, err error) {
+	type htmlRow struct {
+		ID                     int
+		Name                   string
+		Mean, Min, Max, Stddev float64
+		Warning                string
+	}
+	type htmlTable struct {
+		Device string
+		Rows   []htmlRow
+	}
+	var tables []htmlTable
+	for _, s := range summaries {
+		tableIdx := slices.IndexFunc(tables, func(t htmlTable) bool { return t.Device == s.Device })
+		if tableIdx == -1 {
+			tables = append(tables, htmlTable{Device: s.Device})
+			tableIdx = len(tables) - 1
+		}
+		tables[tableIdx].Rows = append(tables[tableIdx].Rows, htmlRow{ID: s.ID, Name: s.Name, Mean: s.Stats.mean, Min: s.Stats.min, Max: s.Stats.max, Stddev: s.Stats.stddev, Warning: s.Warning})
+	}
+	tmpl := htmltemplate.Must(htmltemplate.New("uncoreDetailTemplate").Parse(uncoreDetailHTMLTemplate))
+	buf := new(bytes.Buffer)
+	if err = tmpl.Execute(buf, map[string]any{"Title": fmt.Sprintf("%s Uncore Device Metrics", targetName), "Tables": tables}); err != nil {
+		slog.Error("failed to render uncore detail template", slog.String("error", err.Error()))
+		return
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,72 @@
+package metrics
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetUncoreDeviceMetrics(t *testing.T) {
+	groupDefinitions := []GroupDefinition{
+		{{Name: "instructions"}},
+		{{Name: "UNC_M_CAS_COUNT.RD.0", Device: "imc"}, {Name: "UNC_M_CAS_COUNT.WR.0", Device: "imc"}},
+		{{Name: "UNC_M_CAS_COUNT.RD.1", Device: "imc"}, {Name: "UNC_M_CAS_COUNT.WR.1", Device: "imc"}},
+		{{Name: "UNCCCT.0", Device: "cha"}, {Name: "UNCCTO.IMD.0", Device: "cha"}},
+	}
+	frame := EventFrame{
+		EventGroups: []EventGroup{
+			{GroupID: 0, EventValues: map[string]float64{"instructions": 100}},
+			{GroupID: 1, EventValues: map[string]float64{"UNC_M_CAS_COUNT.RD.0": 1000000, "UNC_M_CAS_COUNT.WR.0": 500000}},
+			{GroupID: 2, EventValues: map[string]float64{"UNC_M_CAS_COUNT.RD.1": 3000000, "UNC_M_CAS_COUNT.WR.1": 500000}},
+			{GroupID: 3, EventValues: map[string]float64{"UNCCCT.0": 1000, "UNCCTO.IMD.0": 4000}},
+		},
+	}
+	metadata := Metadata{UncoreDeviceIDs: map[string][]int{"imc": {0, 1}, "cha": {0}}}
+	devices := getUncoreDevices(frame, groupDefinitions, metadata)
+	require.Len(t, devices, 3)
+	assert.Equal(t, UncoreDevice{Type: "cha", ID: 0, EventValues: map[string]float64{"UNCCCT": 1000, "UNCCTO.IMD": 4000}}, devices[0])
+	assert.Equal(t, "imc", devices[1].Type)
+	assert.Equal(t, 0, devices[1].ID)
+	assert.Equal(t, 1, devices[2].ID)
+
+	metrics := getUncoreDeviceMetrics(devices, 2)
+	assert.Equal(t, []UncoreDeviceMetric{
+		{Device: "cha", ID: 0, Name: uncoreMetricOccupancy, Value: 4},
+		{Device: "imc", ID: 0, Name: uncoreMetricReadBandwidth, Value: 32},
+		{Device: "imc", ID: 0, Name: uncoreMetricWriteBandwidth, Value: 16},
+		{Device: "imc", ID: 1, Name: uncoreMetricReadBandwidth, Value: 96},
+		{Device: "imc", ID: 1, Name: uncoreMetricWriteBandwidth, Value: 16},
+	}, metrics)
+}
+
+func TestSummarizeUncoreDetail(t *testing.T) {
+	dir := t.TempDir()
+	csv := "TS,DEVICE,ID,METRIC,VALUE\n" +
+		"1,imc,0,read bandwidth (MB/sec),100\n" +
+		"1,imc,1,read bandwidth (MB/sec),100\n" +
+		"1,imc,2,read bandwidth (MB/sec),100\n" +
+		"1,imc,3,read bandwidth (MB/sec),200\n" +
+		"2,imc,0,read bandwidth (MB/sec),100\n" +
+		"2,imc,1,read bandwidth (MB/sec),\n" +
+		"2,imc,2,read bandwidth (MB/sec),100\n" +
+		"2,imc,3,read bandwidth (MB/sec),200\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "host_metrics_uncore.csv"), []byte(csv), 0644))
+	summaries, err := loadUncoreDetailSummaries(filepath.Join(dir, "host_metrics_uncore.csv"))
+	require.NoError(t, err)
+	require.Len(t, summaries, 4)
+	assert.Equal(t, 100.0, summaries[1].Stats.mean)
+	assert.Empty(t, summaries[0].Warning)
+	assert.Empty(t, summaries[1].Warning)
+	assert.Empty(t, summaries[2].Warning)
+	assert.Contains(t, summaries[3].Warning, "60% above")
+
+	files, err := summarizeUncoreDetail(dir, "host")
+	require.NoError(t, err)
+	assert.Len(t, files, 2)
+}
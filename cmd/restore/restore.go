@@ -0,0 +1,195 @@
+// Package restore is a subcommand of the root command. It restores settings left modified on target(s) by interrupted runs.
+package restore
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"perfspect/internal/common"
+	"perfspect/internal/target"
+)
+
+const cmdName = "restore"
+
+var examples = []string{
+	fmt.Sprintf("  Restore settings on local host:       $ %s %s", common.AppName, cmdName),
+	fmt.Sprintf("  Restore settings on remote target:    $ %s %s --target 192.168.1.1 --user fred --key fred_key", common.AppName, cmdName),
+	fmt.Sprintf("  Restore settings on multiple targets: $ %s %s --targets targets.yaml", common.AppName, cmdName),
+	fmt.Sprintf("  Restore settings right after a crash: $ %s %s --force", common.AppName, cmdName),
+}
+
+var Cmd = &cobra.Command{
+	Use:   cmdName,
+	Short: "Restore settings left modified on target(s) and stop orphaned telemetry recorders",
+	Long: fmt.Sprintf(`Restores the system settings, e.g., the NMI watchdog and perf event mux intervals, that %[1]s changed on target(s) during a run that did not exit cleanly.
+
+The original values of the settings are recorded in a journal on the target (%[2]s) before they are changed. A running session refreshes its journal every minute, journals that haven't been refreshed for a few minutes belong to sessions that are no longer running. Those journals are replayed automatically when %[1]s next runs on the target. Use this command to restore the settings without running another command. Use --force to also restore the journals that were refreshed within the last few minutes, e.g., right after %[1]s was killed or the connection to the target was lost. Don't force the restoration while %[1]s is running on the target.
+
+The telemetry recorders started by '%[1]s telemetry --record' keep running when %[1]s exits abnormally, so that a later run into the same store can reattach to them. This command also stops the recorders that haven't been pulled from for a few minutes after their pull interval, or all recorders with --force. The samples that weren't pulled remain in the recorder's directory on the target, they are pulled by the next run into the store.`, common.AppName, common.JournalDir),
+	Example:       strings.Join(examples, "\n"),
+	RunE:          runCmd,
+	PreRunE:       validateFlags,
+	GroupID:       "primary",
+	Args:          cobra.NoArgs,
+	SilenceErrors: true,
+	// this command restores the journals itself so that it can report the restored settings
+	Annotations: map[string]string{common.AnnotationSkipJournalReplay: "true"},
+}
+
+var (
+	flagForce bool
+)
+
+const (
+	flagForceName = "force"
+)
+
+func init() {
+	Cmd.Flags().BoolVar(&flagForce, flagForceName, false, "")
+
+	common.AddTargetFlags(Cmd)
+
+	Cmd.SetUsageFunc(usageFunc)
+}
+
+func usageFunc(cmd *cobra.Command) error {
+	cmd.Printf("Usage: %s [flags]\n\n", cmd.CommandPath())
+	cmd.Printf("Examples:\n%s\n\n", cmd.Example)
+	cmd.Println("Flags:")
+	for _, group := range getFlagGroups() {
+		cmd.Printf("  %s:\n", group.GroupName)
+		for _, flag := range group.Flags {
+			flagDefault := ""
+			if cmd.Flags().Lookup(flag.Name).DefValue != "" {
+				flagDefault = fmt.Sprintf(" (default: %s)", cmd.Flags().Lookup(flag.Name).DefValue)
+			}
+			cmd.Printf("    --%-20s %s%s\n", flag.Name, flag.Help, flagDefault)
+		}
+	}
+	cmd.Println("\nGlobal Flags:")
+	cmd.Parent().PersistentFlags().VisitAll(func(pf *pflag.Flag) {
+		flagDefault := ""
+		if cmd.Parent().PersistentFlags().Lookup(pf.Name).DefValue != "" {
+			flagDefault = fmt.Sprintf(" (default: %s)", cmd.Flags().Lookup(pf.Name).DefValue)
+		}
+		cmd.Printf("  --%-20s %s%s\n", pf.Name, pf.Usage, flagDefault)
+	})
+	return nil
+}
+
+func getFlagGroups() []common.FlagGroup {
+	var groups []common.FlagGroup
+	flags := []common.Flag{
+		{
+			Name: flagForceName,
+			Help: "restore all journals and stop all telemetry recorders, including those of sessions that appear to be running",
+		},
+	}
+	groups = append(groups, common.FlagGroup{
+		GroupName: "Options",
+		Flags:     flags,
+	})
+	groups = append(groups, common.GetTargetFlagGroup())
+
+	return groups
+}
+
+func validateFlags(cmd *cobra.Command, args []string) error {
+	// common target flags
+	if err := common.ValidateTargetFlags(cmd); err != nil {
+		return common.FlagValidationError(cmd, err.Error())
+	}
+	return nil
+}
+
+func runCmd(cmd *cobra.Command, args []string) error {
+	// appContext is the application context that holds common data and resources.
+	appContext := cmd.Parent().Context().Value(common.AppContext{}).(common.AppContext)
+	localTempDir := appContext.LocalTempDir
+	// get the targets
+	myTargets, targetErrs, err := common.GetTargets(cmd, true, true, localTempDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		slog.Error(err.Error())
+		cmd.SilenceUsage = true
+		return err
+	}
+	// schedule the removal of the temp directory on each target (if the debug flag is not set)
+	if cmd.Parent().PersistentFlags().Lookup("debug").Value.String() != "true" {
+		for _, myTarget := range myTargets {
+			if myTarget.GetTempDirectory() != "" {
+				defer func(deferTarget target.Target) {
+					err := deferTarget.RemoveTempDirectory()
+					if err != nil {
+						slog.Error("error removing target temporary directory", slog.String("error", err.Error()))
+					}
+				}(myTarget)
+			}
+		}
+	}
+	// check for errors in target creation
+	for i := len(targetErrs) - 1; i >= 0; i-- {
+		if targetErrs[i] != nil {
+			fmt.Fprintf(os.Stderr, "Error: target: %s, %v\n", myTargets[i].GetName(), targetErrs[i])
+			slog.Error(targetErrs[i].Error())
+			myTargets = slices.Delete(myTargets, i, i+1)
+		}
+	}
+	if len(myTargets) == 0 {
+		err := fmt.Errorf("no targets remain")
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		slog.Error(err.Error())
+		cmd.SilenceUsage = true
+		return err
+	}
+	// restore the settings on each target
+	var restoreErr error
+	for _, myTarget := range myTargets {
+		restored, active, err := common.RestoreJournals(myTarget, flagForce, localTempDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: target: %s, %v\n", myTarget.GetName(), err)
+			slog.Error(err.Error(), slog.String("target", myTarget.GetName()))
+			restoreErr = err
+			continue
+		}
+		for _, journal := range active {
+			fmt.Printf("%s: skipped %s, its %s session is still running or exited within the last few minutes, use --%s to restore it\n", myTarget.GetName(), journal, common.AppName, flagForceName)
+		}
+		if len(restored) == 0 {
+			fmt.Printf("%s: no settings to restore\n", myTarget.GetName())
+		} else {
+			fmt.Printf("%s: restored %d setting(s)\n", myTarget.GetName(), len(restored))
+			for _, setting := range restored {
+				fmt.Printf("  %s\n", setting)
+			}
+		}
+		stopped, active, err := common.StopRecorders(myTarget, flagForce, localTempDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: target: %s, %v\n", myTarget.GetName(), err)
+			slog.Error(err.Error(), slog.String("target", myTarget.GetName()))
+			restoreErr = err
+			continue
+		}
+		for _, entry := range active {
+			fmt.Printf("%s: skipped %s, its telemetry recorder is still being pulled from, use --%s to stop it\n", myTarget.GetName(), entry, flagForceName)
+		}
+		for _, recorder := range stopped {
+			pid, dir, _ := strings.Cut(recorder, " ")
+			fmt.Printf("%s: stopped telemetry recorder %s, its samples that weren't pulled remain in %s\n", myTarget.GetName(), pid, dir)
+		}
+	}
+	if restoreErr != nil {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("failed to restore settings on one or more targets")
+	}
+	return nil
+}
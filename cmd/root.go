@@ -27,6 +27,7 @@ import (
 	"perfspect/cmd/lock"
 	"perfspect/cmd/metrics"
 	"perfspect/cmd/report"
+	"perfspect/cmd/restore"
 	"perfspect/cmd/telemetry"
 	"perfspect/internal/common"
 	"perfspect/internal/util"
@@ -116,6 +117,7 @@ Additional help topics:{{range .Commands}}{{if .IsAdditionalHelpTopicCommand}}
 	rootCmd.AddCommand(flame.Cmd)
 	rootCmd.AddCommand(lock.Cmd)
 	rootCmd.AddCommand(config.Cmd)
+	rootCmd.AddCommand(restore.Cmd)
 	if onIntelNetwork() {
 		rootCmd.AddGroup([]*cobra.Group{{ID: "other", Title: "Other Commands:"}}...)
 		rootCmd.AddCommand(updateCmd)
@@ -0,0 +1,407 @@
+package telemetry
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"perfspect/internal/report"
+	"perfspect/internal/script"
+)
+
+const (
+	liveFormatCSV  = "csv"
+	liveFormatJSON = "json"
+)
+
+var liveFormatOptions = []string{liveFormatCSV, liveFormatJSON}
+
+// liveLabelFields are the fields that identify the series in a table's rows, e.g., the CPU or the network
+// interface. They are exported as labels, the other numeric fields as gauges.
+var liveLabelFields = []string{"CPU", "CORE", "SOCK", "NODE", "PID", "Command", "Device", "IFACE", "Interface", "Queue", "Cgroup"}
+
+// liveRows are the rows of a telemetry table that haven't been printed yet
+type liveRows struct {
+	target string
+	table  string
+	fields []string
+	rows   [][]string
+}
+
+// liveSeries is the most recent value of a metric in a table
+type liveSeries struct {
+	name   string
+	labels [][2]string // name, value
+	value  float64
+}
+
+// livePrinter prints the rows of the telemetry tables as they are collected and passes the latest values to
+// the Prometheus and OTLP sinks
+type livePrinter struct {
+	mutex      sync.Mutex
+	out        io.Writer
+	format     string
+	tableNames []string
+	printed    map[string]int // target and table -> number of rows printed
+	prometheus *prometheusSink
+	otlp       *otlpSink
+}
+
+func newLivePrinter(out io.Writer, format string, tableNames []string) *livePrinter {
+	return &livePrinter{
+		out:        out,
+		format:     format,
+		tableNames: tableNames,
+		printed:    make(map[string]int),
+	}
+}
+
+// update is the common.LiveFunc that receives the recent script outputs from a target, the new rows are the
+// rows of scriptOutputs after the rows of previousOutputs
+func (p *livePrinter) update(targetName string, previousOutputs map[string]script.ScriptOutput, scriptOutputs map[string]script.ScriptOutput) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	var allNewRows []liveRows
+	for _, tableName := range p.tableNames {
+		tableValues, ok := liveTableValues(tableName, scriptOutputs)
+		if !ok {
+			continue
+		}
+		previousRows := 0
+		if previousTableValues, ok := liveTableValues(tableName, previousOutputs); ok {
+			previousRows = len(previousTableValues.Fields[0].Values)
+		}
+		key := targetName + "/" + tableName
+		newRows := newLiveRows(targetName, tableValues, previousRows)
+		if len(newRows.rows) == 0 {
+			continue
+		}
+		if err := p.print(newRows, p.printed[key] == 0); err != nil {
+			slog.Error("failed to print live telemetry", slog.String("table", tableName), slog.String("error", err.Error()))
+		}
+		p.printed[key] += len(newRows.rows)
+		allNewRows = append(allNewRows, newRows)
+	}
+	if len(allNewRows) == 0 {
+		return
+	}
+	var series []liveSeries
+	tableSeries := make(map[string][]liveSeries)
+	for _, newRows := range allNewRows {
+		tableSeries[newRows.table] = latestSeries(newRows)
+		series = append(series, tableSeries[newRows.table]...)
+	}
+	if p.prometheus != nil {
+		p.prometheus.set(targetName, tableSeries)
+	}
+	if p.otlp != nil {
+		if err := p.otlp.send(targetName, series, time.Now()); err != nil {
+			slog.Warn("failed to send telemetry to OTLP endpoint", slog.String("error", err.Error()))
+		}
+	}
+}
+
+// liveTableValues returns the values of a telemetry table with a time series, the table is skipped if the
+// scripts' output can't be parsed yet
+func liveTableValues(tableName string, scriptOutputs map[string]script.ScriptOutput) (tableValues report.TableValues, ok bool) {
+	defer func() {
+		if errx := recover(); errx != nil {
+			slog.Debug("skipping partial live telemetry", slog.String("table", tableName), slog.Any("error", errx))
+			ok = false
+		}
+	}()
+	tableValues = report.GetValuesForTable(tableName, scriptOutputs)
+	if len(tableValues.Fields) == 0 || len(tableValues.Fields[0].Values) == 0 || !strings.EqualFold(tableValues.Fields[0].Name, "Time") {
+		return tableValues, false
+	}
+	return tableValues, true
+}
+
+// newLiveRows returns the rows of the table after the given number of rows
+func newLiveRows(targetName string, tableValues report.TableValues, skip int) liveRows {
+	newRows := liveRows{target: targetName, table: tableValues.Name}
+	for _, field := range tableValues.Fields {
+		newRows.fields = append(newRows.fields, field.Name)
+	}
+	for row := skip; row < len(tableValues.Fields[0].Values); row++ {
+		values := make([]string, len(tableValues.Fields))
+		for i, field := range tableValues.Fields {
+			values[i] = field.Values[row]
+		}
+		newRows.rows = append(newRows.rows, values)
+	}
+	return newRows
+}
+
+// print writes the rows in the live format, the CSV header is written with the first rows of each table
+func (p *livePrinter) print(newRows liveRows, first bool) error {
+	if p.format == liveFormatJSON {
+		encoder := json.NewEncoder(p.out)
+		for _, row := range newRows.rows {
+			values := make(map[string]string, len(row))
+			for i, value := range row {
+				values[newRows.fields[i]] = value
+			}
+			err := encoder.Encode(struct {
+				Target string            `json:"target"`
+				Table  string            `json:"table"`
+				Values map[string]string `json:"values"`
+			}{newRows.target, newRows.table, values})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	writer := csv.NewWriter(p.out)
+	if first {
+		if err := writer.Write(append([]string{"Target", "Table"}, newRows.fields...)); err != nil {
+			return err
+		}
+	}
+	for _, row := range newRows.rows {
+		if err := writer.Write(append([]string{newRows.target, newRows.table}, row...)); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// latestSeries returns the values of the most recent sample in the rows, i.e., the rows with the last time
+func latestSeries(newRows liveRows) (series []liveSeries) {
+	lastTime := newRows.rows[len(newRows.rows)-1][0]
+	for _, row := range newRows.rows {
+		if row[0] != lastTime {
+			continue
+		}
+		var labels [][2]string
+		for i, field := range newRows.fields {
+			if slices.Contains(liveLabelFields, field) {
+				labels = append(labels, [2]string{liveMetricName(field), row[i]})
+			}
+		}
+		for i, field := range newRows.fields[1:] {
+			if slices.Contains(liveLabelFields, field) {
+				continue
+			}
+			value, err := strconv.ParseFloat(row[i+1], 64)
+			if err != nil {
+				continue
+			}
+			series = append(series, liveSeries{
+				name:   "perfspect_telemetry_" + liveMetricName(strings.TrimSuffix(newRows.table, " Telemetry")) + "_" + liveMetricName(field),
+				labels: labels,
+				value:  value,
+			})
+		}
+	}
+	return
+}
+
+var reLiveMetricName = regexp.MustCompile(`[^a-z0-9]+`)
+
+// liveMetricName converts a table or field name to a metric or label name, e.g., "kB_read/s" -> "kb_read_per_sec"
+func liveMetricName(name string) string {
+	name = strings.ToLower(name)
+	name = strings.ReplaceAll(name, "%", "pct_")
+	name = strings.ReplaceAll(name, "/s", "_per_sec")
+	name = reLiveMetricName.ReplaceAllString(name, "_")
+	return strings.Trim(name, "_")
+}
+
+// prometheusSink serves the latest telemetry values in the Prometheus text exposition format
+type prometheusSink struct {
+	mutex  sync.Mutex
+	series map[string][]liveSeries // target and table -> series of the table's latest sample
+	server *http.Server
+}
+
+// newPrometheusSink starts serving the latest values at http://<address>/metrics
+func newPrometheusSink(address string) (*prometheusSink, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", address, err)
+	}
+	sink := &prometheusSink{series: make(map[string][]liveSeries)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		_, _ = w.Write(sink.exposition())
+	})
+	sink.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := sink.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			slog.Error("prometheus endpoint stopped", slog.String("error", err.Error()))
+		}
+	}()
+	return sink, nil
+}
+
+// set replaces the series of the tables with the tables' latest values, so that series that are no longer
+// reported, e.g., of processes that exited, are dropped
+func (s *prometheusSink) set(targetName string, tableSeries map[string][]liveSeries) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for table, series := range tableSeries {
+		key := targetName + "/" + table
+		s.series[key] = nil
+		for _, oneSeries := range series {
+			oneSeries.labels = append([][2]string{{"target", targetName}}, oneSeries.labels...)
+			s.series[key] = append(s.series[key], oneSeries)
+		}
+	}
+}
+
+// exposition returns the series in the Prometheus text format, grouped by metric name
+func (s *prometheusSink) exposition() []byte {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	byName := make(map[string][]liveSeries)
+	for _, series := range s.series {
+		for _, oneSeries := range series {
+			byName[oneSeries.name] = append(byName[oneSeries.name], oneSeries)
+		}
+	}
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var buf bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&buf, "# TYPE %s gauge\n", name)
+		lines := make([]string, 0, len(byName[name]))
+		for _, oneSeries := range byName[name] {
+			labels := make([]string, 0, len(oneSeries.labels))
+			for _, label := range oneSeries.labels {
+				labels = append(labels, fmt.Sprintf("%s=%q", label[0], label[1]))
+			}
+			lines = append(lines, fmt.Sprintf("%s{%s} %s", name, strings.Join(labels, ","), strconv.FormatFloat(oneSeries.value, 'g', -1, 64)))
+		}
+		slices.Sort(lines)
+		for _, line := range lines {
+			buf.WriteString(line + "\n")
+		}
+	}
+	return buf.Bytes()
+}
+
+func (s *prometheusSink) close() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.server.Shutdown(ctx); err != nil {
+		slog.Warn("failed to shut down prometheus endpoint", slog.String("error", err.Error()))
+	}
+}
+
+// otlpSink posts the latest telemetry values to an OpenTelemetry collector's OTLP/HTTP metrics endpoint
+type otlpSink struct {
+	url    string
+	client *http.Client
+}
+
+func newOTLPSink(url string) *otlpSink {
+	return &otlpSink{url: url, client: &http.Client{Timeout: 5 * time.Second}}
+}
+
+// the OTLP/HTTP JSON encoding of the metrics, see opentelemetry-proto's metrics.proto
+type otlpAttribute struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue string `json:"stringValue"`
+	} `json:"value"`
+}
+
+type otlpDataPoint struct {
+	Attributes   []otlpAttribute `json:"attributes,omitempty"`
+	TimeUnixNano string          `json:"timeUnixNano"`
+	AsDouble     float64         `json:"asDouble"`
+}
+
+type otlpMetric struct {
+	Name  string `json:"name"`
+	Gauge struct {
+		DataPoints []otlpDataPoint `json:"dataPoints"`
+	} `json:"gauge"`
+}
+
+func newOTLPAttribute(key, value string) otlpAttribute {
+	attribute := otlpAttribute{Key: key}
+	attribute.Value.StringValue = value
+	return attribute
+}
+
+// payload returns the OTLP/HTTP JSON request body for the series
+func (s *otlpSink) payload(targetName string, series []liveSeries, timestamp time.Time) ([]byte, error) {
+	var metrics []*otlpMetric
+	byName := make(map[string]*otlpMetric)
+	for _, oneSeries := range series {
+		metric, ok := byName[oneSeries.name]
+		if !ok {
+			metric = &otlpMetric{Name: oneSeries.name}
+			byName[oneSeries.name] = metric
+			metrics = append(metrics, metric)
+		}
+		dataPoint := otlpDataPoint{TimeUnixNano: strconv.FormatInt(timestamp.UnixNano(), 10), AsDouble: oneSeries.value}
+		for _, label := range oneSeries.labels {
+			dataPoint.Attributes = append(dataPoint.Attributes, newOTLPAttribute(label[0], label[1]))
+		}
+		metric.Gauge.DataPoints = append(metric.Gauge.DataPoints, dataPoint)
+	}
+	type scopeMetrics struct {
+		Scope struct {
+			Name string `json:"name"`
+		} `json:"scope"`
+		Metrics []*otlpMetric `json:"metrics"`
+	}
+	type resourceMetrics struct {
+		Resource struct {
+			Attributes []otlpAttribute `json:"attributes"`
+		} `json:"resource"`
+		ScopeMetrics []scopeMetrics `json:"scopeMetrics"`
+	}
+	var resource resourceMetrics
+	resource.Resource.Attributes = []otlpAttribute{newOTLPAttribute("service.name", "perfspect"), newOTLPAttribute("host.name", targetName)}
+	scope := scopeMetrics{Metrics: metrics}
+	scope.Scope.Name = "perfspect telemetry"
+	resource.ScopeMetrics = []scopeMetrics{scope}
+	return json.Marshal(struct {
+		ResourceMetrics []resourceMetrics `json:"resourceMetrics"`
+	}{[]resourceMetrics{resource}})
+}
+
+func (s *otlpSink) send(targetName string, series []liveSeries, timestamp time.Time) error {
+	if len(series) == 0 {
+		return nil
+	}
+	body, err := s.payload(targetName, series, timestamp)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s returned %s", s.url, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package telemetry
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"perfspect/internal/report"
+	"perfspect/internal/script"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTableValues() report.TableValues {
+	return report.TableValues{
+		TableDefinition: report.TableDefinition{Name: "Network Telemetry"},
+		Fields: []report.Field{
+			{Name: "Time", Values: []string{"10:00:00", "10:00:00", "10:00:02", "10:00:02"}},
+			{Name: "IFACE", Values: []string{"eth0", "eth1", "eth0", "eth1"}},
+			{Name: "rxpck/s", Values: []string{"1.00", "2.00", "3.00", "4.00"}},
+			{Name: "%ifutil", Values: []string{"0.50", "N/A", "0.75", "0.25"}},
+		},
+	}
+}
+
+func TestLivePrinterCSV(t *testing.T) {
+	var out bytes.Buffer
+	printer := newLivePrinter(&out, liveFormatCSV, nil)
+	newRows := newLiveRows("host1", newTestTableValues(), 0)
+	require.Len(t, newRows.rows, 4)
+	require.NoError(t, printer.print(newRows, true))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 5)
+	assert.Equal(t, "Target,Table,Time,IFACE,rxpck/s,%ifutil", lines[0])
+	assert.Equal(t, "host1,Network Telemetry,10:00:00,eth0,1.00,0.50", lines[1])
+	// only the rows after those already printed, without the header
+	out.Reset()
+	newRows = newLiveRows("host1", newTestTableValues(), 3)
+	require.NoError(t, printer.print(newRows, false))
+	assert.Equal(t, "host1,Network Telemetry,10:00:02,eth1,4.00,0.25\n", out.String())
+}
+
+func TestLivePrinterJSON(t *testing.T) {
+	var out bytes.Buffer
+	printer := newLivePrinter(&out, liveFormatJSON, nil)
+	require.NoError(t, printer.print(newLiveRows("host1", newTestTableValues(), 2), true))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	var row struct {
+		Target string            `json:"target"`
+		Table  string            `json:"table"`
+		Values map[string]string `json:"values"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &row))
+	assert.Equal(t, "host1", row.Target)
+	assert.Equal(t, "Network Telemetry", row.Table)
+	assert.Equal(t, map[string]string{"Time": "10:00:02", "IFACE": "eth0", "rxpck/s": "3.00", "%ifutil": "0.75"}, row.Values)
+}
+
+func TestLiveMetricName(t *testing.T) {
+	assert.Equal(t, "kb_read_per_sec", liveMetricName("kB_read/s"))
+	assert.Equal(t, "pct_usr", liveMetricName("%usr"))
+	assert.Equal(t, "cpu_some_pct", liveMetricName("CPU Some %"))
+	assert.Equal(t, "core_avg", liveMetricName("Core (Avg.)"))
+}
+
+func TestLatestSeries(t *testing.T) {
+	series := latestSeries(newLiveRows("host1", newTestTableValues(), 0))
+	// the last sample only, "N/A" isn't a value
+	require.Len(t, series, 4)
+	assert.Equal(t, liveSeries{name: "perfspect_telemetry_network_rxpck_per_sec", labels: [][2]string{{"iface", "eth0"}}, value: 3}, series[0])
+	assert.Equal(t, liveSeries{name: "perfspect_telemetry_network_pct_ifutil", labels: [][2]string{{"iface", "eth1"}}, value: 0.25}, series[3])
+}
+
+func TestPrometheusExposition(t *testing.T) {
+	sink := &prometheusSink{series: make(map[string][]liveSeries)}
+	sink.set("host1", map[string][]liveSeries{"Network Telemetry": latestSeries(newLiveRows("host1", newTestTableValues(), 0))})
+	expected := `# TYPE perfspect_telemetry_network_pct_ifutil gauge
+perfspect_telemetry_network_pct_ifutil{target="host1",iface="eth0"} 0.75
+perfspect_telemetry_network_pct_ifutil{target="host1",iface="eth1"} 0.25
+# TYPE perfspect_telemetry_network_rxpck_per_sec gauge
+perfspect_telemetry_network_rxpck_per_sec{target="host1",iface="eth0"} 3
+perfspect_telemetry_network_rxpck_per_sec{target="host1",iface="eth1"} 4
+`
+	assert.Equal(t, expected, string(sink.exposition()))
+	// series that are no longer reported are dropped
+	sink.set("host1", map[string][]liveSeries{"Network Telemetry": {{name: "perfspect_telemetry_network_rxpck_per_sec", labels: [][2]string{{"iface", "eth0"}}, value: 5}}})
+	assert.Equal(t, "# TYPE perfspect_telemetry_network_rxpck_per_sec gauge\nperfspect_telemetry_network_rxpck_per_sec{target=\"host1\",iface=\"eth0\"} 5\n", string(sink.exposition()))
+}
+
+func TestOTLPPayload(t *testing.T) {
+	sink := newOTLPSink("http://localhost:4318/v1/metrics")
+	series := latestSeries(newLiveRows("host1", newTestTableValues(), 0))
+	body, err := sink.payload("host1", series, time.Unix(1700000000, 0))
+	require.NoError(t, err)
+	var payload struct {
+		ResourceMetrics []struct {
+			Resource struct {
+				Attributes []otlpAttribute `json:"attributes"`
+			} `json:"resource"`
+			ScopeMetrics []struct {
+				Metrics []otlpMetric `json:"metrics"`
+			} `json:"scopeMetrics"`
+		} `json:"resourceMetrics"`
+	}
+	require.NoError(t, json.Unmarshal(body, &payload))
+	require.Len(t, payload.ResourceMetrics, 1)
+	assert.Equal(t, newOTLPAttribute("host.name", "host1"), payload.ResourceMetrics[0].Resource.Attributes[1])
+	metrics := payload.ResourceMetrics[0].ScopeMetrics[0].Metrics
+	require.Len(t, metrics, 2)
+	assert.Equal(t, "perfspect_telemetry_network_rxpck_per_sec", metrics[0].Name)
+	require.Len(t, metrics[0].Gauge.DataPoints, 2)
+	assert.Equal(t, otlpDataPoint{Attributes: []otlpAttribute{newOTLPAttribute("iface", "eth1")}, TimeUnixNano: "1700000000000000000", AsDouble: 4}, metrics[0].Gauge.DataPoints[1])
+}
+
+func TestLivePrinterUpdate(t *testing.T) {
+	var out bytes.Buffer
+	printer := newLivePrinter(&out, liveFormatCSV, []string{report.MemoryTelemetryTableName})
+	outputs := func(stdout string) map[string]script.ScriptOutput {
+		return map[string]script.ScriptOutput{script.MemoryTelemetryScriptName: {Stdout: stdout}}
+	}
+	sample1 := "10:00:01 100 200 300 1.0 4 5 6 7.0 8 9 10\n"
+	sample2 := "10:00:02 101 201 301 1.0 4 5 6 7.0 8 9 10\n"
+	sample3 := "10:00:03 102 202 302 1.0 4 5 6 7.0 8 9 10\n"
+	printer.update("host1", outputs(""), outputs(sample1))
+	// only the rows after those of the previous outputs are printed
+	printer.update("host1", outputs(sample1), outputs(sample1+sample2))
+	printer.update("host1", outputs(sample1+sample2), outputs(sample1+sample2+sample3))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.True(t, strings.HasPrefix(lines[0], "Target,Table,Time,free"))
+	assert.True(t, strings.HasPrefix(lines[1], "host1,Memory Telemetry,10:00:01,100"))
+	assert.True(t, strings.HasPrefix(lines[2], "host1,Memory Telemetry,10:00:02,101"))
+	assert.True(t, strings.HasPrefix(lines[3], "host1,Memory Telemetry,10:00:03,102"))
+}
@@ -0,0 +1,531 @@
+package telemetry
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"perfspect/internal/common"
+	"perfspect/internal/progress"
+	"perfspect/internal/report"
+	"perfspect/internal/script"
+	"perfspect/internal/target"
+	"perfspect/internal/util"
+)
+
+// recordedSources are the procstat sources that the recorder samples in place of each telemetry script
+var recordedSources = map[string][]string{
+	script.MpstatTelemetryScriptName:   {"cpu", "softirq", "interrupts"},
+	script.IostatTelemetryScriptName:   {"disk"},
+	script.MemoryTelemetryScriptName:   {"memory", "vmstat"},
+	script.NetworkTelemetryScriptName:  {"net"},
+	script.ProcessTelemetryScriptName:  {"process"},
+	script.PressureTelemetryScriptName: {"pressure", "sched"},
+	script.NetStackTelemetryScriptName: {"netstack"},
+}
+
+// storeFileName is the name of the file that describes a target's recording in the store
+const storeFileName = "store.json"
+
+// reSegmentFileName matches the names of the files written by the recorder, the number is the time, in
+// seconds since the epoch, that the file was started
+var reSegmentFileName = regexp.MustCompile(`^telemetry\.(\d+)\.jsonl$`)
+
+// pullTimeout is the number of seconds to wait for a command that lists or removes the segments on the target
+const pullTimeout = 60
+
+// telemetryStore describes the recording of a target. The store is a directory for each target that holds
+// this description and the segments pulled from the target.
+type telemetryStore struct {
+	TargetName    string
+	TableNames    []string
+	ScriptOutputs map[string]script.ScriptOutput // the outputs of the scripts that aren't recorded, e.g., for the system summary
+	ClockOffset   *target.ClockOffset            // measured when the recording started
+	Recorder      *recorderState                 `json:",omitempty"` // the recorder that may still run on the target
+}
+
+// recorderState describes a recorder on the target. It is kept in the store until the recorder is stopped
+// and its segments are pulled, so that a later run can reattach to a recorder that was left running, e.g.,
+// when perfspect was killed.
+type recorderState struct {
+	PID   int
+	Dir   string // the directory on the target that the recorder writes its segments to
+	Start int64  // seconds since the epoch, when the recorder was started
+}
+
+// segment is a file of recorded samples
+type segment struct {
+	name  string
+	start int64 // seconds since the epoch
+}
+
+// recordableTable returns true if the table's scripts are all replaced by the recorder
+func recordableTable(tableName string) bool {
+	for _, scriptName := range report.GetScriptNamesForTable(tableName) {
+		if _, ok := recordedSources[scriptName]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// recorder records telemetry on the targets into the store
+type recorder struct {
+	ctx          context.Context // canceled to stop recording
+	dir          string          // the store
+	tableNames   []string
+	scriptParams map[string]string
+	duration     time.Duration // 0 records until stopped
+	interval     time.Duration
+	pullInterval time.Duration
+}
+
+// collect is the common.CollectFunc that records telemetry on a target. The scripts that aren't replaced
+// by the recorder are run once. The recorder writes rotating segments on the target, they are pulled into
+// the store periodically. Failures to pull, e.g., when the connection to the target is lost, are retried
+// at the next pull. The returned outputs are those recorded by this run.
+func (r *recorder) collect(myTarget target.Target, scriptsToRun []script.ScriptDefinition, localTempDir string, statusUpdate progress.MultiSpinnerUpdateFunc) (map[string]script.ScriptOutput, error) {
+	status := func(status string) {
+		if statusUpdate != nil {
+			_ = statusUpdate(myTarget.GetName(), status)
+		}
+	}
+	storeDir := filepath.Join(r.dir, myTarget.GetName())
+	if err := os.MkdirAll(storeDir, 0755); err != nil { // #nosec G301
+		return nil, err
+	}
+	var sources []string
+	var recordedScripts, otherScripts []script.ScriptDefinition
+	for _, scriptToRun := range scriptsToRun {
+		if _, ok := recordedSources[scriptToRun.Name]; ok {
+			recordedScripts = append(recordedScripts, scriptToRun)
+			for _, source := range recordedSources[scriptToRun.Name] {
+				sources = util.UniqueAppend(sources, source)
+			}
+		} else {
+			otherScripts = append(otherScripts, scriptToRun)
+		}
+	}
+	scriptOutputs := make(map[string]script.ScriptOutput)
+	if len(otherScripts) > 0 {
+		var err error
+		scriptOutputs, err = script.RunScripts(myTarget, otherScripts, true, localTempDir)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// the recorder of an earlier run into the store may have been left running
+	var previousRecorder *recorderState
+	if previousStore, err := readStore(storeDir); err == nil {
+		previousRecorder = previousStore.Recorder
+	}
+	store := telemetryStore{TargetName: myTarget.GetName(), TableNames: r.tableNames, ScriptOutputs: scriptOutputs, Recorder: previousRecorder}
+	if clock, err := target.MeasureClockOffset(myTarget); err != nil {
+		slog.Warn("failed to measure the clock offset", slog.String("target", myTarget.GetName()), slog.String("error", err.Error()))
+	} else {
+		store.ClockOffset = &clock
+	}
+	if len(recordedScripts) == 0 {
+		return scriptOutputs, writeStore(storeDir, store)
+	}
+	rec, err := r.startRecorder(myTarget, storeDir, previousRecorder, sources, localTempDir, status)
+	if err != nil {
+		return nil, err
+	}
+	store.Recorder = rec
+	if err := writeStore(storeDir, store); err != nil {
+		return nil, err
+	}
+	status("recording, press Ctrl+c to stop")
+	// pull the segments until stopped
+	var deadline <-chan time.Time
+	if r.duration > 0 {
+		// the recorder stops by itself after the duration, give it the time to write the last sample
+		deadline = time.After(r.duration + r.interval)
+	}
+	ticker := time.NewTicker(r.pullInterval)
+	defer ticker.Stop()
+	stopped := false
+	for !stopped {
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			stopped = true
+			continue
+		case <-r.ctx.Done():
+			stopped = true
+			continue
+		}
+		if count, err := pullSegments(myTarget, rec.Dir, storeDir, false); err != nil {
+			slog.Warn("failed to pull telemetry segments, will retry", slog.String("target", myTarget.GetName()), slog.String("error", err.Error()))
+			status(fmt.Sprintf("recording, failed to pull at %s, retrying, press Ctrl+c to stop", time.Now().Format("15:04:05")))
+		} else {
+			if err := common.RefreshRecorder(myTarget, rec.Dir, localTempDir); err != nil {
+				slog.Warn("failed to refresh the telemetry recorder", slog.String("target", myTarget.GetName()), slog.String("error", err.Error()))
+			}
+			status(fmt.Sprintf("recording, %d segments pulled at %s, press Ctrl+c to stop", count, time.Now().Format("15:04:05")))
+		}
+	}
+	// stop the recorder and pull the remaining samples
+	status("stopping the recorder")
+	stopRecorder := getStopRecorderScript(rec.PID)
+	for attempt := range 3 {
+		if attempt > 0 {
+			time.Sleep(5 * time.Second)
+		}
+		if _, err = script.RunScript(myTarget, stopRecorder, localTempDir); err != nil {
+			continue
+		}
+		if _, err = pullSegments(myTarget, rec.Dir, storeDir, true); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		// the recorder stays in the store, a later run reattaches to it or pulls its remaining segments
+		slog.Error("failed to stop the telemetry recorder", slog.String("target", myTarget.GetName()), slog.String("error", err.Error()))
+		status(fmt.Sprintf("failed to stop the recorder, run again with the same store to pull the remaining samples: %v", err))
+	} else {
+		r.removeRecorder(myTarget, *rec, localTempDir)
+		store.Recorder = nil
+		if err := writeStore(storeDir, store); err != nil {
+			return nil, err
+		}
+	}
+	samples, err := readStoreSamples(storeDir, time.Unix(rec.Start, 0), time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	for _, recordedScript := range recordedScripts {
+		scriptOutputs[recordedScript.Name] = script.ScriptOutput{ScriptDefinition: recordedScript, Stdout: samples}
+	}
+	return scriptOutputs, nil
+}
+
+// startRecorder reattaches to the recorder of an earlier run into the store if it still runs on the target,
+// otherwise it starts a new recorder. The segments left by an earlier recorder that stopped are pulled into
+// the store first. The recorder is recorded in the journal directory so that 'perfspect restore' can stop it
+// if it is orphaned.
+func (r *recorder) startRecorder(myTarget target.Target, storeDir string, previous *recorderState, sources []string, localTempDir string, status func(string)) (*recorderState, error) {
+	if previous != nil {
+		recorderStatus, err := getRecorderStatus(myTarget, *previous, localTempDir)
+		if err != nil {
+			return nil, err
+		}
+		switch recorderStatus {
+		case recorderRunning:
+			slog.Info("reattached to telemetry recorder", slog.String("target", myTarget.GetName()), slog.Int("pid", previous.PID), slog.String("dir", previous.Dir))
+			status(fmt.Sprintf("reattached to the recorder started at %s", time.Unix(previous.Start, 0).Format(time.DateTime)))
+			if err := common.RefreshRecorder(myTarget, previous.Dir, localTempDir); err != nil {
+				slog.Warn("failed to refresh the telemetry recorder", slog.String("target", myTarget.GetName()), slog.String("error", err.Error()))
+			}
+			return previous, nil
+		case recorderStopped:
+			status("pulling the samples of the earlier recorder")
+			if _, err := pullSegments(myTarget, previous.Dir, storeDir, true); err != nil {
+				return nil, fmt.Errorf("failed to pull the segments of the earlier recorder: %w", err)
+			}
+			r.removeRecorder(myTarget, *previous, localTempDir)
+		default:
+			if err := common.ClearRecorder(myTarget, previous.Dir, localTempDir); err != nil {
+				slog.Warn("failed to clear the telemetry recorder", slog.String("target", myTarget.GetName()), slog.String("error", err.Error()))
+			}
+		}
+	}
+	params := maps.Clone(r.scriptParams)
+	params["RecordSources"] = strings.Join(sources, ",")
+	recorderOutput, err := script.RunScript(myTarget, script.GetParameterizedScriptByName(script.TelemetryRecorderScriptName, params), localTempDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start the telemetry recorder: %v", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(recorderOutput.Stdout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to start the telemetry recorder: %s", strings.TrimSpace(recorderOutput.Stderr))
+	}
+	rec := &recorderState{PID: pid, Dir: myTarget.GetTempDirectory(), Start: time.Now().Unix()}
+	slog.Info("started telemetry recorder", slog.String("target", myTarget.GetName()), slog.Int("pid", pid))
+	if err := common.RecordRecorder(myTarget, rec.PID, rec.Dir, r.pullInterval, localTempDir); err != nil {
+		// a recorder that can't be found after the run must not be left running
+		if _, stopErr := script.RunScript(myTarget, getStopRecorderScript(rec.PID), localTempDir); stopErr != nil {
+			slog.Error("failed to stop the telemetry recorder", slog.String("target", myTarget.GetName()), slog.String("error", stopErr.Error()))
+		}
+		return nil, err
+	}
+	return rec, nil
+}
+
+// removeRecorder removes the stopped recorder's entry from the journal directory and the recorder's directory,
+// if it is left by an earlier run. The current run's directory is removed when the run ends.
+func (r *recorder) removeRecorder(myTarget target.Target, rec recorderState, localTempDir string) {
+	if err := common.ClearRecorder(myTarget, rec.Dir, localTempDir); err != nil {
+		slog.Warn("failed to clear the telemetry recorder", slog.String("target", myTarget.GetName()), slog.String("error", err.Error()))
+	}
+	if rec.Dir == myTarget.GetTempDirectory() {
+		return
+	}
+	if _, stderr, _, err := myTarget.RunCommand(exec.Command("rm", "-rf", rec.Dir), pullTimeout, false); err != nil { // #nosec G204
+		slog.Warn("failed to remove the directory of the earlier recorder", slog.String("target", myTarget.GetName()), slog.String("dir", rec.Dir), slog.String("error", fmt.Sprintf("%v: %s", err, strings.TrimSpace(stderr))))
+	}
+}
+
+// getStopRecorderScript returns a script that stops the recorder
+func getStopRecorderScript(pid int) script.ScriptDefinition {
+	return script.ScriptDefinition{Name: "stop telemetry recorder", ScriptTemplate: fmt.Sprintf("kill -TERM %d 2>/dev/null || true", pid), Superuser: true}
+}
+
+const (
+	recorderRunning = "running" // the recorder still writes to its directory
+	recorderStopped = "stopped" // the recorder stopped, its directory may hold segments that weren't pulled
+	recorderGone    = "gone"    // the recorder's directory was removed, e.g., the target was rebooted
+)
+
+// getRecorderStatus checks if the recorder still runs on the target. The recorder's PID may have been reused,
+// so the process must also have the recorder's directory as its working directory.
+func getRecorderStatus(myTarget target.Target, rec recorderState, localTempDir string) (string, error) {
+	scriptOutput, err := script.RunScript(myTarget, script.ScriptDefinition{
+		Name: "telemetry recorder status",
+		ScriptTemplate: fmt.Sprintf(`pid=%d
+dir='%s'
+if [ ! -d "$dir" ]; then
+    echo %s
+elif [ "$(readlink -f /proc/$pid/cwd 2>/dev/null)" = "$(readlink -f "$dir")" ]; then
+    echo %s
+else
+    echo %s
+fi
+`, rec.PID, rec.Dir, recorderGone, recorderRunning, recorderStopped),
+		Superuser: true,
+	}, localTempDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to check the earlier telemetry recorder: %v", err)
+	}
+	return strings.TrimSpace(scriptOutput.Stdout), nil
+}
+
+// pullSegments copies the recorder's segments from the target into the store. The segments that the
+// recorder has finished writing are removed from the target, unless final is set, i.e., the recorder is
+// stopped and its directory will be removed. It returns the number of segments in the store.
+func pullSegments(myTarget target.Target, dir string, storeDir string, final bool) (int, error) {
+	stdout, stderr, _, err := myTarget.RunCommand(exec.Command("ls", "-1", dir), pullTimeout, false) // #nosec G204
+	if err != nil {
+		return 0, fmt.Errorf("failed to list segments: %v: %s", err, strings.TrimSpace(stderr))
+	}
+	segments := parseSegments(strings.Fields(stdout))
+	var finished []string
+	for i, seg := range segments {
+		segmentPath := path.Join(dir, seg.name)
+		if err := myTarget.PullFile(segmentPath, storeDir); err != nil {
+			return 0, fmt.Errorf("failed to pull %s: %v", seg.name, err)
+		}
+		if i < len(segments)-1 {
+			finished = append(finished, segmentPath)
+		}
+	}
+	if len(finished) > 0 && !final {
+		_, stderr, _, err = myTarget.RunCommand(exec.Command("rm", append([]string{"-f"}, finished...)...), pullTimeout, false) // #nosec G204
+		if err != nil {
+			return 0, fmt.Errorf("failed to remove pulled segments: %v: %s", err, strings.TrimSpace(stderr))
+		}
+	}
+	return countSegments(storeDir)
+}
+
+// parseSegments returns the segments in the names, ordered by start time
+func parseSegments(names []string) (segments []segment) {
+	for _, name := range names {
+		match := reSegmentFileName.FindStringSubmatch(name)
+		if match == nil {
+			continue
+		}
+		start, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		segments = append(segments, segment{name: name, start: start})
+	}
+	slices.SortFunc(segments, func(a, b segment) int { return int(a.start - b.start) })
+	return
+}
+
+func listSegments(storeDir string) ([]segment, error) {
+	entries, err := os.ReadDir(storeDir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return parseSegments(names), nil
+}
+
+func countSegments(storeDir string) (int, error) {
+	segments, err := listSegments(storeDir)
+	return len(segments), err
+}
+
+// readStoreSamples returns the recorded samples from from to to, in the recorder's output format. A zero
+// time leaves that end of the window open. A partial sample at the end of a segment, i.e., one that was
+// being written when the segment was pulled, is skipped.
+func readStoreSamples(storeDir string, from, to time.Time) (string, error) {
+	segments, err := listSegments(storeDir)
+	if err != nil {
+		return "", err
+	}
+	var samples strings.Builder
+	for i, seg := range segments {
+		if !to.IsZero() && seg.start > to.Unix() {
+			break
+		}
+		// the samples in a segment are older than the start of the next segment
+		if !from.IsZero() && i < len(segments)-1 && segments[i+1].start < from.Unix() {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(storeDir, seg.name)) // #nosec G304
+		if err != nil {
+			return "", err
+		}
+		lines := strings.Split(string(content), "\n")
+		for _, line := range lines[:len(lines)-1] {
+			var sample struct {
+				Timestamp int64 `json:"timestamp"`
+			}
+			if err := json.Unmarshal([]byte(line), &sample); err != nil {
+				continue
+			}
+			if (!from.IsZero() && sample.Timestamp < from.Unix()) || (!to.IsZero() && sample.Timestamp > to.Unix()) {
+				continue
+			}
+			samples.WriteString(line + "\n")
+		}
+	}
+	return samples.String(), nil
+}
+
+// writeStore writes the description of a target's recording, the table names are merged with those of
+// earlier recordings in the store
+func writeStore(storeDir string, store telemetryStore) error {
+	if previous, err := readStore(storeDir); err == nil {
+		for _, tableName := range store.TableNames {
+			previous.TableNames = util.UniqueAppend(previous.TableNames, tableName)
+		}
+		store.TableNames = previous.TableNames
+	}
+	content, err := json.MarshalIndent(store, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(storeDir, storeFileName), content, 0644) // #nosec G306
+}
+
+func readStore(storeDir string) (store telemetryStore, err error) {
+	content, err := os.ReadFile(filepath.Join(storeDir, storeFileName)) // #nosec G304
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(content, &store)
+	return
+}
+
+// storeDirs returns the directories of the targets' recordings in the store
+func storeDirs(dir string) []string {
+	matches, _ := filepath.Glob(filepath.Join(dir, "*", storeFileName))
+	var dirs []string
+	for _, match := range matches {
+		dirs = append(dirs, filepath.Dir(match))
+	}
+	return dirs
+}
+
+// isStore returns true if the directory is a telemetry store
+func isStore(dir string) bool {
+	return len(storeDirs(dir)) > 0
+}
+
+// readStores is the common.InputFunc that reads the recordings of the targets in the store, limited to the
+// samples from from to to on the controller's clock
+func readStores(dir string, from, to time.Time) ([]common.TargetScriptOutputs, error) {
+	var orderedTargetScriptOutputs []common.TargetScriptOutputs
+	for _, storeDir := range storeDirs(dir) {
+		store, err := readStore(storeDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", filepath.Join(storeDir, storeFileName), err)
+		}
+		// the samples' timestamps are on the target's clock
+		targetFrom, targetTo := from, to
+		if store.ClockOffset != nil {
+			if !from.IsZero() {
+				targetFrom = from.Add(store.ClockOffset.Offset)
+			}
+			if !to.IsZero() {
+				targetTo = to.Add(store.ClockOffset.Offset)
+			}
+		}
+		samples, err := readStoreSamples(storeDir, targetFrom, targetTo)
+		if err != nil {
+			return nil, err
+		}
+		if samples == "" {
+			slog.Warn("no recorded telemetry in the time window", slog.String("target", store.TargetName))
+		}
+		// the samples' times are counted from the first sample in the window, which may be days after the
+		// clock was measured
+		if store.ClockOffset != nil {
+			if firstTime, ok := firstSampleTime(samples); ok {
+				store.ClockOffset.TargetTime = firstTime.In(store.ClockOffset.TargetTime.Location())
+			}
+		}
+		scriptOutputs := make(map[string]script.ScriptOutput)
+		maps.Copy(scriptOutputs, store.ScriptOutputs)
+		for _, tableName := range store.TableNames {
+			for _, scriptName := range report.GetScriptNamesForTable(tableName) {
+				if _, ok := recordedSources[scriptName]; ok {
+					scriptOutputs[scriptName] = script.ScriptOutput{ScriptDefinition: script.GetScriptByName(scriptName), Stdout: samples}
+				}
+			}
+		}
+		orderedTargetScriptOutputs = append(orderedTargetScriptOutputs, common.TargetScriptOutputs{TargetName: store.TargetName, ScriptOutputs: scriptOutputs, TableNames: store.TableNames, ClockOffset: store.ClockOffset})
+	}
+	if len(orderedTargetScriptOutputs) == 0 {
+		return nil, fmt.Errorf("no telemetry recordings found in %s", dir)
+	}
+	return orderedTargetScriptOutputs, nil
+}
+
+// firstSampleTime returns the time of the first of the recorded samples
+func firstSampleTime(samples string) (time.Time, bool) {
+	line, _, _ := strings.Cut(samples, "\n")
+	var sample struct {
+		Timestamp int64 `json:"timestamp"`
+	}
+	if err := json.Unmarshal([]byte(line), &sample); err != nil || sample.Timestamp == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(sample.Timestamp, 0), true
+}
+
+// parseWindowTime parses the --from and --to times, in RFC 3339 format or local time
+func parseWindowTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time: %s, use RFC 3339, e.g., 2006-01-02T15:04:05Z, or local time, e.g., \"2006-01-02 15:04:05\"", value)
+}
@@ -0,0 +1,120 @@
+package telemetry
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"perfspect/internal/script"
+	"perfspect/internal/target"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestSegment(t *testing.T, storeDir string, start int64, timestamps []int64, partial bool) {
+	var content string
+	for _, timestamp := range timestamps {
+		content += fmt.Sprintf("{\"version\":1,\"timestamp\":%d}\n", timestamp)
+	}
+	if partial {
+		content += "{\"version\":1,\"timest"
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(storeDir, fmt.Sprintf("telemetry.%d.jsonl", start)), []byte(content), 0644))
+}
+
+func TestParseSegments(t *testing.T) {
+	segments := parseSegments([]string{"telemetry.200.jsonl", "store.json", "telemetry.100.jsonl", "telemetry.x.jsonl", "telemetry.300.jsonl.tmp"})
+	assert.Equal(t, []segment{{name: "telemetry.100.jsonl", start: 100}, {name: "telemetry.200.jsonl", start: 200}}, segments)
+}
+
+func TestReadStoreSamples(t *testing.T) {
+	storeDir := t.TempDir()
+	writeTestSegment(t, storeDir, 100, []int64{100, 150}, false)
+	writeTestSegment(t, storeDir, 200, []int64{200, 250, 300}, true)
+	// the whole recording, without the partial sample
+	samples, err := readStoreSamples(storeDir, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, "{\"version\":1,\"timestamp\":100}\n{\"version\":1,\"timestamp\":150}\n{\"version\":1,\"timestamp\":200}\n{\"version\":1,\"timestamp\":250}\n{\"version\":1,\"timestamp\":300}\n", samples)
+	// a window across the segments
+	samples, err = readStoreSamples(storeDir, time.Unix(150, 0), time.Unix(250, 0))
+	require.NoError(t, err)
+	assert.Equal(t, "{\"version\":1,\"timestamp\":150}\n{\"version\":1,\"timestamp\":200}\n{\"version\":1,\"timestamp\":250}\n", samples)
+	// a window after the recording
+	samples, err = readStoreSamples(storeDir, time.Unix(400, 0), time.Time{})
+	require.NoError(t, err)
+	assert.Empty(t, samples)
+}
+
+func TestWriteStore(t *testing.T) {
+	storeDir := t.TempDir()
+	require.NoError(t, writeStore(storeDir, telemetryStore{TargetName: "host1", TableNames: []string{"Memory Telemetry"}}))
+	// a resumed recording with more tables keeps the tables of the earlier recording
+	require.NoError(t, writeStore(storeDir, telemetryStore{TargetName: "host1", TableNames: []string{"CPU Utilization Telemetry", "Memory Telemetry"}}))
+	store, err := readStore(storeDir)
+	require.NoError(t, err)
+	assert.Equal(t, "host1", store.TargetName)
+	assert.Equal(t, []string{"Memory Telemetry", "CPU Utilization Telemetry"}, store.TableNames)
+	assert.Nil(t, store.Recorder)
+	// the recorder is kept in the store until it is stopped, so that a later run can reattach to it
+	recorder := &recorderState{PID: 1234, Dir: "/tmp/perfspect.abc", Start: 1700000000}
+	require.NoError(t, writeStore(storeDir, telemetryStore{TargetName: "host1", TableNames: []string{"Memory Telemetry"}, Recorder: recorder}))
+	store, err = readStore(storeDir)
+	require.NoError(t, err)
+	assert.Equal(t, recorder, store.Recorder)
+}
+
+func TestReadStores(t *testing.T) {
+	dir := t.TempDir()
+	_, err := readStores(dir, time.Time{}, time.Time{})
+	require.Error(t, err)
+	assert.False(t, isStore(dir))
+	storeDir := filepath.Join(dir, "host1")
+	require.NoError(t, os.Mkdir(storeDir, 0755))
+	require.NoError(t, writeStore(storeDir, telemetryStore{
+		TargetName:    "host1",
+		TableNames:    []string{"Memory Telemetry"},
+		ScriptOutputs: map[string]script.ScriptOutput{"other": {Stdout: "other output"}},
+	}))
+	writeTestSegment(t, storeDir, 100, []int64{100, 150}, false)
+	assert.True(t, isStore(dir))
+	outputs, err := readStores(dir, time.Unix(150, 0), time.Time{})
+	require.NoError(t, err)
+	require.Len(t, outputs, 1)
+	assert.Equal(t, "host1", outputs[0].TargetName)
+	assert.Equal(t, "other output", outputs[0].ScriptOutputs["other"].Stdout)
+	assert.Equal(t, "{\"version\":1,\"timestamp\":150}\n", outputs[0].ScriptOutputs[script.MemoryTelemetryScriptName].Stdout)
+}
+
+func TestReadStoresClockOffset(t *testing.T) {
+	dir := t.TempDir()
+	storeDir := filepath.Join(dir, "host1")
+	require.NoError(t, os.Mkdir(storeDir, 0755))
+	// the target's clock is 100 seconds ahead of the controller's
+	clock := &target.ClockOffset{Offset: 100 * time.Second, TargetTime: time.Unix(100, 0)}
+	require.NoError(t, writeStore(storeDir, telemetryStore{TargetName: "host1", TableNames: []string{"Memory Telemetry"}, ClockOffset: clock}))
+	writeTestSegment(t, storeDir, 100, []int64{100, 150, 200}, false)
+	// the window is on the controller's clock
+	outputs, err := readStores(dir, time.Unix(50, 0), time.Unix(50, 0))
+	require.NoError(t, err)
+	assert.Equal(t, "{\"version\":1,\"timestamp\":150}\n", outputs[0].ScriptOutputs[script.MemoryTelemetryScriptName].Stdout)
+	// the times of the samples are counted from the first sample in the window
+	require.NotNil(t, outputs[0].ClockOffset)
+	assert.Equal(t, int64(150), outputs[0].ClockOffset.TargetTime.Unix())
+}
+
+func TestParseWindowTime(t *testing.T) {
+	parsed, err := parseWindowTime("2025-06-01T08:00:00Z")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC).Unix(), parsed.Unix())
+	parsed, err = parseWindowTime("2025-06-01 08:30")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 6, 1, 8, 30, 0, 0, time.Local), parsed)
+	_, err = parseWindowTime("yesterday")
+	assert.Error(t, err)
+}
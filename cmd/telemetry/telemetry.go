@@ -5,12 +5,20 @@ package telemetry
 // SPDX-License-Identifier: BSD-3-Clause
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"os/signal"
+	"path"
 	"regexp"
 	"slices"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"perfspect/internal/common"
 	"perfspect/internal/report"
@@ -29,6 +37,10 @@ var examples = []string{
 	fmt.Sprintf("  Telemetry from remote target:    $ %s %s --target 192.168.1.1 --user fred --key fred_key", common.AppName, cmdName),
 	fmt.Sprintf("  Memory telemetry for 60 seconds: $ %s %s --memory --duration 60", common.AppName, cmdName),
 	fmt.Sprintf("  Telemetry from multiple targets: $ %s %s --targets targets.yaml", common.AppName, cmdName),
+	fmt.Sprintf("  Live telemetry as JSON lines:    $ %s %s --live --live-format json", common.AppName, cmdName),
+	fmt.Sprintf("  Record telemetry until stopped:  $ %s %s --target 192.168.1.1 --user fred --key fred_key --duration 0 --record store", common.AppName, cmdName),
+	fmt.Sprintf("  Report a window of a recording:  $ %s %s --input store --from \"2025-06-01 08:00\" --to \"2025-06-01 09:00\"", common.AppName, cmdName),
+	fmt.Sprintf("  Flamegraph when iowait is high:  $ %s %s --cpu --duration 0 --trigger \"[Utilization Categories Telemetry/%%iowait] > 20\"", common.AppName, cmdName),
 }
 
 var Cmd = &cobra.Command{
@@ -62,13 +74,44 @@ var (
 	flagTemperature bool
 	flagInstrMix    bool
 	flagGaudi       bool
+	flagProcess     bool
+	flagPressure    bool
+	flagNetStack    bool
 
 	flagNoSystemSummary bool
 
 	flagInstrMixPid    int
 	flagInstrMixFilter []string
+
+	flagProcessTop   int
+	flagProcessPids  []int
+	flagProcessNames []string
+
+	flagPressureCgroups []string
+
+	flagNetStackIfaces []string
+
+	flagLive           bool
+	flagLiveFormat     string
+	flagLivePrometheus string
+	flagLiveOTLP       string
+
+	flagRecord       string
+	flagRecordRotate int
+	flagRecordKeep   int
+	flagRecordPull   int
+	flagFrom         string
+	flagTo           string
+
+	flagTrigger         string
+	flagTriggerAction   string
+	flagTriggerDuration int
+	flagTriggerCooldown int
 )
 
+// the time window of the reports created from a telemetry store, parsed from --from and --to
+var windowFrom, windowTo time.Time
+
 const (
 	flagDurationName = "duration"
 	flagIntervalName = "interval"
@@ -87,11 +130,39 @@ const (
 	flagTemperatureName = "temperature"
 	flagInstrMixName    = "instrmix"
 	flagGaudiName       = "gaudi"
+	flagProcessName     = "process"
+	flagPressureName    = "pressure"
+	flagNetStackName    = "netstack"
 
 	flagNoSystemSummaryName = "no-summary"
 
 	flagInstrMixPidName    = "instrmix-pid"
 	flagInstrMixFilterName = "instrmix-filter"
+
+	flagProcessTopName   = "process-top"
+	flagProcessPidsName  = "process-pids"
+	flagProcessNamesName = "process-names"
+
+	flagPressureCgroupsName = "pressure-cgroups"
+
+	flagNetStackIfacesName = "netstack-ifaces"
+
+	flagLiveName           = "live"
+	flagLiveFormatName     = "live-format"
+	flagLivePrometheusName = "live-prometheus"
+	flagLiveOTLPName       = "live-otlp"
+
+	flagRecordName       = "record"
+	flagRecordRotateName = "record-rotate"
+	flagRecordKeepName   = "record-keep"
+	flagRecordPullName   = "record-pull"
+	flagFromName         = "from"
+	flagToName           = "to"
+
+	flagTriggerName         = "trigger"
+	flagTriggerActionName   = "trigger-action"
+	flagTriggerDurationName = "trigger-duration"
+	flagTriggerCooldownName = "trigger-cooldown"
 )
 
 var telemetrySummaryTableName = "Telemetry Summary"
@@ -105,10 +176,13 @@ var categories = []common.Category{
 	{FlagName: flagTemperatureName, FlagVar: &flagTemperature, DefaultValue: false, Help: "monitor temperature", TableNames: []string{report.TemperatureTelemetryTableName}},
 	{FlagName: flagMemoryName, FlagVar: &flagMemory, DefaultValue: false, Help: "monitor memory", TableNames: []string{report.MemoryTelemetryTableName}},
 	{FlagName: flagNetworkName, FlagVar: &flagNetwork, DefaultValue: false, Help: "monitor network", TableNames: []string{report.NetworkTelemetryTableName}},
+	{FlagName: flagNetStackName, FlagVar: &flagNetStack, DefaultValue: false, Help: "monitor network stack", TableNames: []string{report.NetworkStackTelemetryTableName, report.NetworkSoftirqTelemetryTableName, report.NetworkQueueTelemetryTableName}},
 	{FlagName: flagStorageName, FlagVar: &flagStorage, DefaultValue: false, Help: "monitor storage", TableNames: []string{report.DriveTelemetryTableName}},
 	{FlagName: flagIRQRateName, FlagVar: &flagIRQRate, DefaultValue: false, Help: "monitor IRQ rate", TableNames: []string{report.IRQRateTelemetryTableName}},
 	{FlagName: flagInstrMixName, FlagVar: &flagInstrMix, DefaultValue: false, Help: "monitor instruction mix", TableNames: []string{report.InstructionTelemetryTableName}},
 	{FlagName: flagGaudiName, FlagVar: &flagGaudi, DefaultValue: false, Help: "monitor gaudi", TableNames: []string{report.GaudiTelemetryTableName}},
+	{FlagName: flagPressureName, FlagVar: &flagPressure, DefaultValue: false, Help: "monitor resource pressure and run queue", TableNames: []string{report.PressureTelemetryTableName, report.CgroupPressureTelemetryTableName, report.SchedulerTelemetryTableName}},
+	{FlagName: flagProcessName, FlagVar: &flagProcess, DefaultValue: false, Help: "monitor processes", TableNames: []string{report.ProcessTelemetryTableName, report.ProcessSummaryTelemetryTableName}},
 }
 
 func init() {
@@ -123,7 +197,26 @@ func init() {
 	Cmd.Flags().IntVar(&flagInterval, flagIntervalName, 2, "")
 	Cmd.Flags().IntVar(&flagInstrMixPid, flagInstrMixPidName, 0, "")
 	Cmd.Flags().StringSliceVar(&flagInstrMixFilter, flagInstrMixFilterName, []string{"SSE", "AVX", "AVX2", "AVX512", "AMX_TILE"}, "")
+	Cmd.Flags().IntVar(&flagProcessTop, flagProcessTopName, 10, "")
+	Cmd.Flags().IntSliceVar(&flagProcessPids, flagProcessPidsName, []int{}, "")
+	Cmd.Flags().StringSliceVar(&flagProcessNames, flagProcessNamesName, []string{}, "")
+	Cmd.Flags().StringSliceVar(&flagPressureCgroups, flagPressureCgroupsName, []string{}, "")
+	Cmd.Flags().StringSliceVar(&flagNetStackIfaces, flagNetStackIfacesName, []string{}, "")
 	Cmd.Flags().BoolVar(&flagNoSystemSummary, flagNoSystemSummaryName, false, "")
+	Cmd.Flags().BoolVar(&flagLive, flagLiveName, false, "")
+	Cmd.Flags().StringVar(&flagLiveFormat, flagLiveFormatName, liveFormatCSV, "")
+	Cmd.Flags().StringVar(&flagLivePrometheus, flagLivePrometheusName, "", "")
+	Cmd.Flags().StringVar(&flagLiveOTLP, flagLiveOTLPName, "", "")
+	Cmd.Flags().StringVar(&flagRecord, flagRecordName, "", "")
+	Cmd.Flags().IntVar(&flagRecordRotate, flagRecordRotateName, 3600, "")
+	Cmd.Flags().IntVar(&flagRecordKeep, flagRecordKeepName, 48, "")
+	Cmd.Flags().IntVar(&flagRecordPull, flagRecordPullName, 60, "")
+	Cmd.Flags().StringVar(&flagFrom, flagFromName, "", "")
+	Cmd.Flags().StringVar(&flagTo, flagToName, "", "")
+	Cmd.Flags().StringVar(&flagTrigger, flagTriggerName, "", "")
+	Cmd.Flags().StringVar(&flagTriggerAction, flagTriggerActionName, common.TriggerActionFlame, "")
+	Cmd.Flags().IntVar(&flagTriggerDuration, flagTriggerDurationName, 30, "")
+	Cmd.Flags().IntVar(&flagTriggerCooldown, flagTriggerCooldownName, 300, "")
 
 	common.AddTargetFlags(Cmd)
 
@@ -194,6 +287,26 @@ func getFlagGroups() []common.FlagGroup {
 			Name: flagInstrMixFilterName,
 			Help: "filter to apply to instruction mix",
 		},
+		{
+			Name: flagProcessTopName,
+			Help: "number of processes, by CPU utilization, to monitor in each interval",
+		},
+		{
+			Name: flagProcessPidsName,
+			Help: "comma separated list of pids to monitor instead of the top processes",
+		},
+		{
+			Name: flagProcessNamesName,
+			Help: "comma separated list of process names to monitor instead of the top processes, wildcards (*, ?) are supported",
+		},
+		{
+			Name: flagPressureCgroupsName,
+			Help: "comma separated list of cgroup v2 paths, relative to /sys/fs/cgroup, e.g., system.slice, to monitor pressure",
+		},
+		{
+			Name: flagNetStackIfacesName,
+			Help: "comma separated list of network interfaces to monitor per-queue stats, no interfaces means all physical interfaces",
+		},
 		{
 			Name: flagNoSystemSummaryName,
 			Help: "do not include system summary table in report",
@@ -203,11 +316,85 @@ func getFlagGroups() []common.FlagGroup {
 		GroupName: "Other Options",
 		Flags:     flags,
 	})
+	flags = []common.Flag{
+		{
+			Name: flagLiveName,
+			Help: "print the telemetry to stdout as it is collected, the reports are created when the collection stops",
+		},
+		{
+			Name: flagLiveFormatName,
+			Help: fmt.Sprintf("format of the live telemetry, choose from: %s", strings.Join(liveFormatOptions, ", ")),
+		},
+		{
+			Name: flagLivePrometheusName,
+			Help: "address, e.g., :9100, to serve the latest live telemetry values to Prometheus at /metrics",
+		},
+		{
+			Name: flagLiveOTLPName,
+			Help: "URL of an OTLP/HTTP metrics endpoint, e.g., http://localhost:4318/v1/metrics, to send the live telemetry to",
+		},
+	}
+	groups = append(groups, common.FlagGroup{
+		GroupName: "Live Options",
+		Flags:     flags,
+	})
+	flags = []common.Flag{
+		{
+			Name: flagRecordName,
+			Help: "directory of the store to record the telemetry into. The target writes the samples to files that are pulled into the store periodically, so collection continues through lost connections.",
+		},
+		{
+			Name: flagRecordRotateName,
+			Help: "number of seconds of samples in each recorded file",
+		},
+		{
+			Name: flagRecordKeepName,
+			Help: "number of recorded files kept on the target, the oldest files are removed if they haven't been pulled, e.g., while the connection to the target is lost",
+		},
+		{
+			Name: flagRecordPullName,
+			Help: "number of seconds between pulls of the recorded files from the target",
+		},
+	}
+	groups = append(groups, common.FlagGroup{
+		GroupName: "Recording Options",
+		Flags:     flags,
+	})
+	flags = []common.Flag{
+		{
+			Name: flagTriggerName,
+			Help: "expression evaluated over the telemetry of each interval, e.g., \"[Utilization Categories Telemetry/%iowait] > 20\". Telemetry fields are enclosed in square brackets and named <table>/<field>. When the expression is true, a capture is started on the target.",
+		},
+		{
+			Name: flagTriggerActionName,
+			Help: fmt.Sprintf("capture started when the trigger fires, options: %s", strings.Join(common.TriggerActionOptions, ", ")),
+		},
+		{
+			Name: flagTriggerDurationName,
+			Help: "number of seconds to run each triggered capture",
+		},
+		{
+			Name: flagTriggerCooldownName,
+			Help: "number of seconds after a triggered capture ends before the trigger can fire again",
+		},
+	}
+	groups = append(groups, common.FlagGroup{
+		GroupName: "Trigger Options",
+		Flags:     flags,
+	})
 	groups = append(groups, common.GetTargetFlagGroup())
 	flags = []common.Flag{
 		{
 			Name: common.FlagInputName,
-			Help: "\".raw\" file, or directory containing \".raw\" files. Will skip data collection and use raw data for reports.",
+			Help: "\".raw\" file, directory containing \".raw\" files, or telemetry store. Will skip data collection and use raw or recorded data for reports.",
+		},
+		{
+			Name: flagFromName,
+			Help: "start of the time window to report from a telemetry store, e.g., \"2025-06-01 08:00\" (local time) or 2025-06-01T08:00:00Z",
+		},
+		{
+			Name: flagToName,
+			Help: "end of the time window to report from a telemetry store",
 		},
 	}
 	groups = append(groups, common.FlagGroup{
@@ -253,7 +440,7 @@ func validateFlags(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		panic("failed to get targets flag")
 	}
-	if flagDuration == 0 && (target != "" || targets != "") {
+	if flagDuration == 0 && (target != "" || targets != "") && flagRecord == "" {
 		return common.FlagValidationError(cmd, "duration must be greater than 0 when collecting from a remote target")
 	}
 	if cmd.Flags().Lookup(flagInstrMixFilterName).Changed {
@@ -264,6 +451,128 @@ func validateFlags(cmd *cobra.Command, args []string) error {
 			}
 		}
 	}
+	if flagProcessTop < 1 {
+		return common.FlagValidationError(cmd, "process-top must be 1 or greater")
+	}
+	for _, pid := range flagProcessPids {
+		if pid < 1 {
+			return common.FlagValidationError(cmd, fmt.Sprintf("invalid pid: %d", pid))
+		}
+	}
+	// names are passed to the collector in a quoted script argument
+	reName := regexp.MustCompile(`^[\w.:*?\[\]-]+$`)
+	for _, name := range flagProcessNames {
+		if !reName.MatchString(name) {
+			return common.FlagValidationError(cmd, fmt.Sprintf("invalid process name: %s, must be letters, numbers, and . _ : - * ? [ ]", name))
+		}
+		if _, err := path.Match(name, ""); err != nil {
+			return common.FlagValidationError(cmd, fmt.Sprintf("invalid process name pattern: %s", name))
+		}
+	}
+	// cgroups are passed to the collector in a quoted script argument
+	reCgroup := regexp.MustCompile(`^[\w.@:/-]+$`)
+	for _, cgroup := range flagPressureCgroups {
+		if !reCgroup.MatchString(cgroup) || slices.Contains(strings.Split(cgroup, "/"), "..") {
+			return common.FlagValidationError(cmd, fmt.Sprintf("invalid cgroup: %s, must be a path relative to /sys/fs/cgroup", cgroup))
+		}
+	}
+	// interfaces are passed to the collection script in a quoted argument
+	reIface := regexp.MustCompile(`^[\w.:@-]+$`)
+	for _, iface := range flagNetStackIfaces {
+		if !reIface.MatchString(iface) {
+			return common.FlagValidationError(cmd, fmt.Sprintf("invalid network interface: %s", iface))
+		}
+	}
+	// live telemetry
+	if !slices.Contains(liveFormatOptions, flagLiveFormat) {
+		return common.FlagValidationError(cmd, fmt.Sprintf("live format options are: %s", strings.Join(liveFormatOptions, ", ")))
+	}
+	if !flagLive {
+		for _, flagName := range []string{flagLiveFormatName, flagLivePrometheusName, flagLiveOTLPName} {
+			if cmd.Flags().Lookup(flagName).Changed {
+				return common.FlagValidationError(cmd, fmt.Sprintf("--%s requires --%s", flagName, flagLiveName))
+			}
+		}
+	}
+	if flagLive && common.FlagInput != "" {
+		return common.FlagValidationError(cmd, fmt.Sprintf("--%s can't be used with --%s", flagLiveName, common.FlagInputName))
+	}
+	if flagLivePrometheus != "" {
+		if _, _, err := net.SplitHostPort(flagLivePrometheus); err != nil {
+			return common.FlagValidationError(cmd, fmt.Sprintf("invalid prometheus address: %s, must be host:port or :port", flagLivePrometheus))
+		}
+	}
+	if flagLiveOTLP != "" {
+		otlpURL, err := url.Parse(flagLiveOTLP)
+		if err != nil || (otlpURL.Scheme != "http" && otlpURL.Scheme != "https") || otlpURL.Host == "" {
+			return common.FlagValidationError(cmd, fmt.Sprintf("invalid OTLP endpoint: %s, must be an http or https URL", flagLiveOTLP))
+		}
+	}
+	// recording
+	if flagRecord != "" {
+		if flagLive {
+			return common.FlagValidationError(cmd, fmt.Sprintf("--%s can't be used with --%s", flagRecordName, flagLiveName))
+		}
+		if common.FlagInput != "" {
+			return common.FlagValidationError(cmd, fmt.Sprintf("--%s can't be used with --%s", flagRecordName, common.FlagInputName))
+		}
+		for _, cat := range categories {
+			if *cat.FlagVar && !slices.ContainsFunc(cat.TableNames, recordableTable) {
+				return common.FlagValidationError(cmd, fmt.Sprintf("--%s telemetry can't be recorded", cat.FlagName))
+			}
+		}
+	}
+	if flagRecordRotate < 1 {
+		return common.FlagValidationError(cmd, "record-rotate must be 1 or greater")
+	}
+	if flagRecordKeep < 2 {
+		return common.FlagValidationError(cmd, "record-keep must be 2 or greater")
+	}
+	if flagRecordPull < 1 {
+		return common.FlagValidationError(cmd, "record-pull must be 1 or greater")
+	}
+	if flagFrom != "" || flagTo != "" {
+		if common.FlagInput == "" || !isStore(common.FlagInput) {
+			return common.FlagValidationError(cmd, fmt.Sprintf("--%s and --%s require --%s with a telemetry store", flagFromName, flagToName, common.FlagInputName))
+		}
+		var err error
+		if flagFrom != "" {
+			if windowFrom, err = parseWindowTime(flagFrom); err != nil {
+				return common.FlagValidationError(cmd, err.Error())
+			}
+		}
+		if flagTo != "" {
+			if windowTo, err = parseWindowTime(flagTo); err != nil {
+				return common.FlagValidationError(cmd, err.Error())
+			}
+		}
+		if !windowFrom.IsZero() && !windowTo.IsZero() && !windowFrom.Before(windowTo) {
+			return common.FlagValidationError(cmd, fmt.Sprintf("--%s must be before --%s", flagFromName, flagToName))
+		}
+	}
+	// trigger options
+	if flagTrigger != "" {
+		if _, err := common.ParseTriggerExpression(flagTrigger, nil); err != nil {
+			return common.FlagValidationError(cmd, err.Error())
+		}
+		if !slices.Contains(common.TriggerActionOptions, flagTriggerAction) {
+			return common.FlagValidationError(cmd, fmt.Sprintf("invalid trigger action: %s, valid options are: %s", flagTriggerAction, strings.Join(common.TriggerActionOptions, ", ")))
+		}
+		if flagTriggerDuration < 1 {
+			return common.FlagValidationError(cmd, "trigger duration must be greater than 0")
+		}
+		if flagTriggerCooldown < 0 {
+			return common.FlagValidationError(cmd, "trigger cooldown must be greater than or equal to 0")
+		}
+		if common.FlagInput != "" {
+			return common.FlagValidationError(cmd, fmt.Sprintf("triggers are not supported with --%s", common.FlagInputName))
+		}
+		if flagRecord != "" {
+			return common.FlagValidationError(cmd, fmt.Sprintf("triggers are not supported with --%s", flagRecordName))
+		}
+	} else if cmd.Flags().Lookup(flagTriggerActionName).Changed || cmd.Flags().Lookup(flagTriggerDurationName).Changed || cmd.Flags().Lookup(flagTriggerCooldownName).Changed {
+		return common.FlagValidationError(cmd, fmt.Sprintf("trigger action, duration, and cooldown require --%s", flagTriggerName))
+	}
 	// common target flags
 	if err := common.ValidateTargetFlags(cmd); err != nil {
 		return common.FlagValidationError(cmd, err.Error())
@@ -278,7 +587,13 @@ func runCmd(cmd *cobra.Command, args []string) error {
 	}
 	for _, cat := range categories {
 		if *cat.FlagVar || flagAll {
-			tableNames = append(tableNames, cat.TableNames...)
+			for _, tableName := range cat.TableNames {
+				// only some of the telemetry can be recorded
+				if flagRecord != "" && !recordableTable(tableName) {
+					continue
+				}
+				tableNames = append(tableNames, tableName)
+			}
 		}
 	}
 	// include telemetry summary table if all telemetry options are selected
@@ -291,6 +606,10 @@ func runCmd(cmd *cobra.Command, args []string) error {
 	if flagAll {
 		insightsFunc = common.DefaultInsightsFunc
 	}
+	var pids []string
+	for _, pid := range flagProcessPids {
+		pids = append(pids, strconv.Itoa(pid))
+	}
 	reportingCommand := common.ReportingCommand{
 		Cmd:            cmd,
 		ReportNamePost: "telem",
@@ -299,6 +618,17 @@ func runCmd(cmd *cobra.Command, args []string) error {
 			"Duration": strconv.Itoa(flagDuration),
 			"PID":      strconv.Itoa(flagInstrMixPid),
 			"Filter":   strings.Join(flagInstrMixFilter, " "),
+			// process telemetry
+			"ProcessTop":   strconv.Itoa(flagProcessTop),
+			"ProcessPIDs":  strings.Join(pids, ","),
+			"ProcessNames": strings.Join(flagProcessNames, ","),
+			// pressure telemetry
+			"PressureCgroups": strings.Join(flagPressureCgroups, ","),
+			// network stack telemetry
+			"NetworkInterfaces": strings.Join(flagNetStackIfaces, ","),
+			// recording
+			"RecordRotate": strconv.Itoa(flagRecordRotate),
+			"RecordKeep":   strconv.Itoa(flagRecordKeep),
 		},
 		TableNames:             tableNames,
 		SummaryFunc:            summaryFunc,
@@ -306,7 +636,78 @@ func runCmd(cmd *cobra.Command, args []string) error {
 		SummaryBeforeTableName: report.CPUUtilizationTelemetryTableName,
 		InsightsFunc:           insightsFunc,
 	}
-	return reportingCommand.Run()
+	if flagLive {
+		printer := newLivePrinter(os.Stdout, flagLiveFormat, tableNames)
+		if flagLivePrometheus != "" {
+			sink, err := newPrometheusSink(flagLivePrometheus)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				slog.Error(err.Error())
+				cmd.SilenceUsage = true
+				return err
+			}
+			defer sink.close()
+			printer.prometheus = sink
+		}
+		if flagLiveOTLP != "" {
+			printer.otlp = newOTLPSink(flagLiveOTLP)
+		}
+		reportingCommand.LiveFunc = printer.update
+	}
+	if flagRecord != "" {
+		if err := os.MkdirAll(flagRecord, 0755); err != nil { // #nosec G301
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			slog.Error(err.Error())
+			cmd.SilenceUsage = true
+			return err
+		}
+		// recording stops on Ctrl+c, the recorders on the targets are stopped by the recorder
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		rec := &recorder{
+			ctx:          ctx,
+			dir:          flagRecord,
+			tableNames:   tableNames,
+			scriptParams: reportingCommand.ScriptParams,
+			duration:     time.Duration(flagDuration) * time.Second,
+			interval:     time.Duration(flagInterval) * time.Second,
+			pullInterval: time.Duration(flagRecordPull) * time.Second,
+		}
+		reportingCommand.CollectFunc = rec.collect
+	}
+	var trigger *telemetryTrigger
+	if flagTrigger != "" {
+		if err := checkTriggerVars(flagTrigger, tableNames); err != nil {
+			err = common.FlagValidationError(cmd, err.Error())
+			slog.Error(err.Error())
+			return err
+		}
+		appContext := cmd.Parent().Context().Value(common.AppContext{}).(common.AppContext)
+		// the telemetry is streamed to the trigger, and to the live printer, if any
+		trigger = &telemetryTrigger{
+			expression: flagTrigger,
+			action:     flagTriggerAction,
+			duration:   flagTriggerDuration,
+			cooldown:   flagTriggerCooldown,
+			tableNames: tableNames,
+			outputDir:  appContext.OutputDir,
+			live:       reportingCommand.LiveFunc,
+		}
+		reportingCommand.CollectFunc = trigger.collect
+	}
+	if common.FlagInput != "" && isStore(common.FlagInput) {
+		reportingCommand.InputFunc = func(input string) ([]common.TargetScriptOutputs, error) {
+			return readStores(input, windowFrom, windowTo)
+		}
+	}
+	err := reportingCommand.Run()
+	if trigger != nil && len(trigger.getFiles()) > 0 {
+		fmt.Println("Trigger capture files:")
+		for _, file := range trigger.getFiles() {
+			fmt.Printf("  %s\n", file)
+		}
+	}
+	return err
 }
 
 func getTableValues(allTableValues []report.TableValues, tableName string) report.TableValues {
@@ -330,6 +731,11 @@ func summaryFromTableValues(allTableValues []report.TableValues, _ map[string]sc
 	networkReads := getMetricAverage(getTableValues(allTableValues, report.NetworkTelemetryTableName), []string{"rxkB/s"}, "Time")
 	networkWrites := getMetricAverage(getTableValues(allTableValues, report.NetworkTelemetryTableName), []string{"txkB/s"}, "Time")
 	memAvail := getMetricAverage(getTableValues(allTableValues, report.MemoryTelemetryTableName), []string{"avail"}, "Time")
+	cpuPressure := getMetricAverage(getTableValues(allTableValues, report.PressureTelemetryTableName), []string{"CPU Some %"}, "Time")
+	memoryPressure := getMetricAverage(getTableValues(allTableValues, report.PressureTelemetryTableName), []string{"Memory Some %"}, "Time")
+	ioPressure := getMetricAverage(getTableValues(allTableValues, report.PressureTelemetryTableName), []string{"IO Some %"}, "Time")
+	tcpRetrans := getMetricAverage(getTableValues(allTableValues, report.NetworkStackTelemetryTableName), []string{"TcpRetrans%"}, "Time")
+	runQueue := getMetricAverage(getTableValues(allTableValues, report.SchedulerTelemetryTableName), []string{"runq-sz"}, "Time")
 	return report.TableValues{
 		TableDefinition: report.TableDefinition{
 			Name:      telemetrySummaryTableName,
@@ -348,6 +754,11 @@ func summaryFromTableValues(allTableValues []report.TableValues, _ map[string]sc
 			{Name: "Drive Writes (kB/s)", Values: []string{driveWrites}},
 			{Name: "Network RX (kB/s)", Values: []string{networkReads}},
 			{Name: "Network TX (kB/s)", Values: []string{networkWrites}},
+			{Name: "TCP Retransmits (%)", Values: []string{tcpRetrans}},
+			{Name: "CPU Pressure (%)", Values: []string{cpuPressure}},
+			{Name: "Memory Pressure (%)", Values: []string{memoryPressure}},
+			{Name: "IO Pressure (%)", Values: []string{ioPressure}},
+			{Name: "Run Queue Length", Values: []string{runQueue}},
 		},
 	}
 }
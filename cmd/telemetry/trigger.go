@@ -0,0 +1,154 @@
+package telemetry
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+// threshold-triggered collection over the collected telemetry, see common.TriggerWatcher. The variables of
+// the trigger expression are telemetry fields named "<table>/<field>", e.g., "[Memory Telemetry/avail]".
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"perfspect/internal/common"
+	"perfspect/internal/progress"
+	"perfspect/internal/script"
+	"perfspect/internal/target"
+)
+
+// telemetryTrigger streams the telemetry from each target and starts a capture on the target when the
+// trigger expression is true
+type telemetryTrigger struct {
+	expression string
+	action     string
+	duration   int // seconds
+	cooldown   int // seconds
+	tableNames []string
+	outputDir  string
+	live       common.LiveFunc // if set, also receives the streamed telemetry
+
+	mutex sync.Mutex
+	files []string // the files written by the captures on all targets
+}
+
+// checkTriggerVars checks that the variables of the trigger expression are "<table>/<field>" names of the
+// collected tables
+func checkTriggerVars(expression string, tableNames []string) error {
+	evaluable, err := common.ParseTriggerExpression(expression, nil)
+	if err != nil {
+		return err
+	}
+	for _, variable := range evaluable.Vars() {
+		tableName, fieldName, ok := strings.Cut(variable, "/")
+		if !ok || fieldName == "" {
+			return fmt.Errorf("trigger expression variable must be <table>/<field>: %s", variable)
+		}
+		if !slices.Contains(tableNames, tableName) {
+			return fmt.Errorf("trigger expression refers to a table that isn't collected: %s", tableName)
+		}
+	}
+	return nil
+}
+
+// collect is the common.CollectFunc that streams the telemetry from the target, evaluates the trigger over
+// each sample, and waits for the captures in progress when the collection ends
+func (t *telemetryTrigger) collect(myTarget target.Target, scriptsToRun []script.ScriptDefinition, localTempDir string, statusUpdate progress.MultiSpinnerUpdateFunc) (map[string]script.ScriptOutput, error) {
+	watcher, err := common.NewTriggerWatcher(t.expression, nil, t.action, t.duration, t.cooldown, myTarget.GetName(), t.outputDir, common.NewTargetCaptureFunc(myTarget, localTempDir), statusUpdate)
+	if err != nil {
+		return nil, err
+	}
+	sampler := newTriggerSampler(t.tableNames)
+	scriptOutputs, err := common.StreamOnTarget(myTarget, scriptsToRun, localTempDir, func(targetName string, previousOutputs map[string]script.ScriptOutput, scriptOutputs map[string]script.ScriptOutput) {
+		if t.live != nil {
+			t.live(targetName, previousOutputs, scriptOutputs)
+		}
+		watcher.Check(sampler.samples(previousOutputs, scriptOutputs, time.Now()))
+	})
+	files := watcher.Wait()
+	t.mutex.Lock()
+	t.files = append(t.files, files...)
+	t.mutex.Unlock()
+	return scriptOutputs, err
+}
+
+// getFiles returns the files written by the captures
+func (t *telemetryTrigger) getFiles() []string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.files
+}
+
+// triggerSampler turns the telemetry streamed from a target into the samples that the trigger is evaluated
+// over. The fields of the tables with one row per interval, e.g., memory, are in every sample. Each row of the
+// tables with multiple rows per interval, e.g., one per CPU or device, is a sample of its own, labeled with
+// the row's label fields, so fields of different multi-row tables can't be combined in an expression.
+type triggerSampler struct {
+	tableNames []string
+	system     map[string]float64 // the latest values of the single-row tables, they may arrive in separate calls
+}
+
+func newTriggerSampler(tableNames []string) *triggerSampler {
+	return &triggerSampler{tableNames: tableNames, system: make(map[string]float64)}
+}
+
+// samples returns the samples of the last interval in the new telemetry
+func (s *triggerSampler) samples(previousOutputs map[string]script.ScriptOutput, scriptOutputs map[string]script.ScriptOutput, now time.Time) (samples []common.TriggerSample) {
+	var rowSamples []common.TriggerSample
+	newValues := false
+	for _, tableName := range s.tableNames {
+		tableValues, ok := liveTableValues(tableName, scriptOutputs)
+		if !ok {
+			continue
+		}
+		previousRows := 0
+		if previousTableValues, ok := liveTableValues(tableName, previousOutputs); ok {
+			previousRows = len(previousTableValues.Fields[0].Values)
+		}
+		newRows := newLiveRows("", tableValues, previousRows)
+		if len(newRows.rows) == 0 {
+			continue
+		}
+		newValues = true
+		lastTime := newRows.rows[len(newRows.rows)-1][0]
+		var lastRows [][]string
+		for _, row := range newRows.rows {
+			if row[0] == lastTime {
+				lastRows = append(lastRows, row)
+			}
+		}
+		for _, row := range lastRows {
+			labels := make(map[string]string)
+			values := make(map[string]float64)
+			for i, field := range newRows.fields[1:] {
+				if slices.Contains(liveLabelFields, field) {
+					labels[field] = row[i+1]
+				} else if value, err := strconv.ParseFloat(row[i+1], 64); err == nil {
+					values[tableName+"/"+field] = value
+				}
+			}
+			if len(lastRows) == 1 {
+				maps.Copy(s.system, values)
+			} else {
+				rowSamples = append(rowSamples, common.TriggerSample{Time: now, Labels: labels, Values: values})
+			}
+		}
+	}
+	if !newValues {
+		return nil
+	}
+	if len(rowSamples) == 0 {
+		return []common.TriggerSample{{Time: now, Values: maps.Clone(s.system)}}
+	}
+	for _, sample := range rowSamples {
+		values := maps.Clone(s.system)
+		maps.Copy(values, sample.Values)
+		sample.Values = values
+		samples = append(samples, sample)
+	}
+	return samples
+}
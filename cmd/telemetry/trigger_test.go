@@ -0,0 +1,54 @@
+package telemetry
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+import (
+	"testing"
+	"time"
+
+	"perfspect/internal/report"
+	"perfspect/internal/script"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckTriggerVars(t *testing.T) {
+	tableNames := []string{report.MemoryTelemetryTableName}
+	assert.NoError(t, checkTriggerVars("[Memory Telemetry/avail] < 1000", tableNames))
+	assert.ErrorContains(t, checkTriggerVars("[avail] < 1000", tableNames), "<table>/<field>")
+	assert.ErrorContains(t, checkTriggerVars("[Network Telemetry/rxkB/s] > 1000", tableNames), "Network Telemetry")
+	assert.Error(t, checkTriggerVars("[Memory Telemetry/avail] <", tableNames))
+}
+
+func TestTriggerSamplerSamples(t *testing.T) {
+	sampler := newTriggerSampler([]string{report.MemoryTelemetryTableName, report.NetworkTelemetryTableName})
+	outputs := func(memory string, network string) map[string]script.ScriptOutput {
+		return map[string]script.ScriptOutput{
+			script.MemoryTelemetryScriptName:  {Stdout: memory},
+			script.NetworkTelemetryScriptName: {Stdout: network},
+		}
+	}
+	now := time.Unix(1700000000, 0)
+	memory1 := "10:00:01 100 200 300 1.0 4 5 6 7.0 8 9 10\n"
+	memory2 := "10:00:02 101 201 301 1.0 4 5 6 7.0 8 9 10\n"
+	// the single-row tables are in one sample
+	samples := sampler.samples(outputs("", ""), outputs(memory1, ""), now)
+	require.Len(t, samples, 1)
+	assert.Equal(t, now, samples[0].Time)
+	assert.Equal(t, 200.0, samples[0].Values["Memory Telemetry/avail"])
+	// each row of the multi-row tables is a sample, with the latest values of the single-row tables
+	network := "10:00:02 eth0 1.00 2.00 3.00 4.00 0.00 0.00 0.00 0.00\n" +
+		"10:00:02 eth1 5.00 6.00 7.00 8.00 0.00 0.00 0.00 0.00\n"
+	samples = sampler.samples(outputs(memory1, ""), outputs(memory1, network), now)
+	require.Len(t, samples, 2)
+	assert.Equal(t, map[string]string{"IFACE": "eth1"}, samples[1].Labels)
+	assert.Equal(t, 7.0, samples[1].Values["Network Telemetry/rxkB/s"])
+	assert.Equal(t, 200.0, samples[1].Values["Memory Telemetry/avail"])
+	samples = sampler.samples(outputs(memory1, network), outputs(memory1+memory2, network), now)
+	require.Len(t, samples, 1)
+	assert.Equal(t, 201.0, samples[0].Values["Memory Telemetry/avail"])
+	// no samples without new telemetry
+	assert.Empty(t, sampler.samples(outputs(memory1, network), outputs(memory1, network), now))
+}
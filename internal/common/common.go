@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -18,7 +19,9 @@ import (
 	"perfspect/internal/target"
 	"perfspect/internal/util"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"slices"
 
@@ -48,6 +51,7 @@ type TargetScriptOutputs struct {
 	TargetName    string
 	ScriptOutputs map[string]script.ScriptOutput
 	TableNames    []string
+	ClockOffset   *target.ClockOffset // nil if the target's clock offset wasn't measured
 }
 
 func (tso *TargetScriptOutputs) GetScriptOutputs() map[string]script.ScriptOutput {
@@ -84,6 +88,21 @@ type SummaryFunc func([]report.TableValues, map[string]script.ScriptOutput) repo
 type InsightsFunc SummaryFunc
 type AdhocFunc func(AppContext, map[string]script.ScriptOutput, target.Target, progress.MultiSpinnerUpdateFunc) error
 
+// LiveFunc receives the outputs of the scripts while the scripts are running on a target. So that the cost of a
+// call doesn't grow with the time the scripts have run, the outputs hold only the start of each script's output,
+// i.e., the output that arrived before the first call, followed by the output that arrived since the previous
+// call's new output. previousOutputs are the same outputs without the output that's new in this call, so the
+// new rows of a table are those parsed from scriptOutputs after the rows parsed from previousOutputs.
+// It is called from multiple goroutines when collecting from multiple targets.
+type LiveFunc func(targetName string, previousOutputs map[string]script.ScriptOutput, scriptOutputs map[string]script.ScriptOutput)
+
+// CollectFunc collects the outputs of the scripts from a target in place of running the scripts, e.g., to
+// record telemetry. It is called from multiple goroutines when collecting from multiple targets.
+type CollectFunc func(myTarget target.Target, scriptsToRun []script.ScriptDefinition, localTempDir string, statusUpdate progress.MultiSpinnerUpdateFunc) (map[string]script.ScriptOutput, error)
+
+// InputFunc reads the outputs of the scripts from the input path in place of reading raw reports
+type InputFunc func(input string) ([]TargetScriptOutputs, error)
+
 type ReportingCommand struct {
 	Cmd                    *cobra.Command
 	ReportNamePost         string
@@ -94,6 +113,9 @@ type ReportingCommand struct {
 	SummaryBeforeTableName string // the name of the table that the summary table should be placed before in the report
 	InsightsFunc           InsightsFunc
 	AdhocFunc              AdhocFunc
+	LiveFunc               LiveFunc    // if set, the scripts' output is streamed to LiveFunc while they run
+	CollectFunc            CollectFunc // if set, collects the scripts' output from the targets
+	InputFunc              InputFunc   // if set, reads the scripts' output from the --input path
 }
 
 // Run is the common flow/logic for all reporting commands, i.e., 'report', 'telemetry', 'flame', 'lock'
@@ -125,7 +147,11 @@ func (rc *ReportingCommand) Run() error {
 	var myTargets []target.Target
 	if FlagInput != "" {
 		var err error
-		orderedTargetScriptOutputs, err = outputsFromInput(rc.SummaryTableName)
+		if rc.InputFunc != nil {
+			orderedTargetScriptOutputs, err = rc.InputFunc(FlagInput)
+		} else {
+			orderedTargetScriptOutputs, err = outputsFromInput(rc.SummaryTableName)
+		}
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			slog.Error(err.Error())
@@ -144,7 +170,7 @@ func (rc *ReportingCommand) Run() error {
 			return err
 		}
 		// schedule the cleanup of the temporary directory on each target (if not debugging)
-		if rc.Cmd.Parent().PersistentFlags().Lookup("debug").Value.String() != "true" {
+		if rc.Cmd.Root().PersistentFlags().Lookup("debug").Value.String() != "true" {
 			for _, myTarget := range myTargets {
 				if myTarget.GetTempDirectory() != "" {
 					deferTarget := myTarget // create a new variable to capture the current value
@@ -158,22 +184,31 @@ func (rc *ReportingCommand) Run() error {
 			}
 		}
 		// setup and start the progress indicator
+		// the progress indicator would be interleaved with the streamed output, so status is written to stderr instead
 		multiSpinner := progress.NewMultiSpinner()
-		for _, target := range myTargets {
-			err := multiSpinner.AddSpinner(target.GetName())
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				slog.Error(err.Error())
-				rc.Cmd.SilenceUsage = true
-				return err
+		statusUpdate := multiSpinner.Status
+		if rc.LiveFunc != nil {
+			statusUpdate = func(targetName string, status string) error {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", targetName, status)
+				return nil
+			}
+		} else {
+			for _, target := range myTargets {
+				err := multiSpinner.AddSpinner(target.GetName())
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					slog.Error(err.Error())
+					rc.Cmd.SilenceUsage = true
+					return err
+				}
 			}
+			multiSpinner.Start()
 		}
-		multiSpinner.Start()
 		// remove targets that had errors
 		var indicesToRemove []int
 		for i := range targetErrs {
 			if targetErrs[i] != nil {
-				_ = multiSpinner.Status(myTargets[i].GetName(), fmt.Sprintf("Error: %v", targetErrs[i]))
+				_ = statusUpdate(myTargets[i].GetName(), fmt.Sprintf("Error: %v", targetErrs[i]))
 				indicesToRemove = append(indicesToRemove, i)
 			}
 		}
@@ -181,7 +216,7 @@ func (rc *ReportingCommand) Run() error {
 			myTargets = slices.Delete(myTargets, indicesToRemove[i], indicesToRemove[i]+1)
 		}
 		// collect data from targets
-		orderedTargetScriptOutputs, err = outputsFromTargets(rc.Cmd, myTargets, rc.TableNames, rc.ScriptParams, multiSpinner.Status, localTempDir)
+		orderedTargetScriptOutputs, err = outputsFromTargets(rc.Cmd, myTargets, rc.TableNames, rc.ScriptParams, statusUpdate, rc.LiveFunc, rc.CollectFunc, localTempDir)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			slog.Error(err.Error())
@@ -189,8 +224,10 @@ func (rc *ReportingCommand) Run() error {
 			return err
 		}
 		// stop the progress indicator
-		multiSpinner.Finish()
-		fmt.Println()
+		if rc.LiveFunc == nil {
+			multiSpinner.Finish()
+			fmt.Println()
+		}
 		// exit with error if no targets remain
 		if len(myTargets) == 0 {
 			err := fmt.Errorf("no successful targets found")
@@ -218,7 +255,10 @@ func (rc *ReportingCommand) Run() error {
 	// check report formats
 	formats := FlagFormat
 	if slices.Contains(formats, report.FormatAll) {
-		formats = report.FormatOptions
+		// formats that aren't included in all, e.g., profile formats, are kept
+		formats = append(slices.Clone(report.FormatOptions), slices.DeleteFunc(slices.Clone(formats), func(format string) bool {
+			return format == report.FormatAll || slices.Contains(report.FormatOptions, format)
+		})...)
 	}
 	// process the collected data and create the requested report(s)
 	reportFilePaths, err := rc.createReports(appContext, orderedTargetScriptOutputs, formats)
@@ -310,7 +350,7 @@ func FlagValidationError(cmd *cobra.Command, msg string) error {
 // createRawReports creates the raw report(s) from the collected data
 func (rc *ReportingCommand) createRawReports(appContext AppContext, orderedTargetScriptOutputs []TargetScriptOutputs) error {
 	for _, targetScriptOutputs := range orderedTargetScriptOutputs {
-		reportBytes, err := report.CreateRawReport(rc.TableNames, targetScriptOutputs.ScriptOutputs, targetScriptOutputs.TargetName)
+		reportBytes, err := report.CreateRawReport(rc.TableNames, targetScriptOutputs.ScriptOutputs, targetScriptOutputs.TargetName, targetScriptOutputs.ClockOffset)
 		if err != nil {
 			err = fmt.Errorf("failed to create raw report: %w", err)
 			return err
@@ -343,8 +383,8 @@ func writeReport(reportBytes []byte, reportPath string) error {
 
 // createReports processes the collected data and creates the requested report(s)
 func (rc *ReportingCommand) createReports(appContext AppContext, orderedTargetScriptOutputs []TargetScriptOutputs, formats []string) ([]string, error) {
-	reportFilePaths := []string{}
 	allTargetsTableValues := make([][]report.TableValues, 0)
+	targetNames := make([]string, 0)
 	for _, targetScriptOutputs := range orderedTargetScriptOutputs {
 		// process the tables, i.e., get field values from script output
 		allTableValues, err := report.ProcessTables(targetScriptOutputs.TableNames, targetScriptOutputs.ScriptOutputs)
@@ -352,6 +392,10 @@ func (rc *ReportingCommand) createReports(appContext AppContext, orderedTargetSc
 			err = fmt.Errorf("failed to process collected data: %w", err)
 			return nil, err
 		}
+		// put the targets' time series on the controller's clock
+		if targetScriptOutputs.ClockOffset != nil {
+			report.NormalizeTableTimes(allTableValues, *targetScriptOutputs.ClockOffset)
+		}
 		// special case - the summary table is built from the post-processed data, i.e., table values
 		if rc.SummaryFunc != nil {
 			summaryTableValues := rc.SummaryFunc(allTableValues, targetScriptOutputs.ScriptOutputs)
@@ -378,49 +422,78 @@ func (rc *ReportingCommand) createReports(appContext AppContext, orderedTargetSc
 			allTableValues = append(allTableValues, insightsTableValues)
 		}
 		// special case - add tableValues for the application version
-		allTableValues = append(allTableValues, report.TableValues{
-			TableDefinition: report.TableDefinition{
-				Name: TableNamePerfspect,
-			},
-			Fields: []report.Field{
-				{Name: "Version", Values: []string{appContext.Version}},
-				{Name: "Args", Values: []string{strings.Join(os.Args, " ")}},
-				{Name: "OutputDir", Values: []string{appContext.OutputDir}},
-			},
-		})
+		allTableValues = append(allTableValues, GetPerfspectTableValues(appContext))
+		// keep all the targets table values for combined reports
+		allTargetsTableValues = append(allTargetsTableValues, allTableValues)
+		targetNames = append(targetNames, targetScriptOutputs.TargetName)
+	}
+	return WriteReports(appContext.OutputDir, rc.ReportNamePost, targetNames, allTargetsTableValues, formats)
+}
+
+// GetPerfspectTableValues returns the table values that describe the application version and how it was run
+func GetPerfspectTableValues(appContext AppContext) report.TableValues {
+	return report.TableValues{
+		TableDefinition: report.TableDefinition{
+			Name: TableNamePerfspect,
+		},
+		Fields: []report.Field{
+			{Name: "Version", Values: []string{appContext.Version}},
+			{Name: "Args", Values: []string{strings.Join(os.Args, " ")}},
+			{Name: "OutputDir", Values: []string{appContext.OutputDir}},
+		},
+	}
+}
+
+// WriteReports creates the report(s) in the requested formats for each target from the target's
+// table values, and combined HTML and XLSX reports when there is more than one target. Report
+// file names are the target name followed by reportNamePost, if not empty. It returns the paths
+// of the report files.
+func WriteReports(outputDir string, reportNamePost string, targetNames []string, allTargetsTableValues [][]report.TableValues, formats []string) ([]string, error) {
+	reportFilePaths := []string{}
+	for targetIdx, allTableValues := range allTargetsTableValues {
+		targetName := targetNames[targetIdx]
+		post := ""
+		if reportNamePost != "" {
+			post = "_" + reportNamePost
+		}
 		// create the report(s)
 		for _, format := range formats {
-			reportBytes, err := report.Create(format, allTableValues, targetScriptOutputs.ScriptOutputs, targetScriptOutputs.TargetName)
+			// special case - folded stacks are written to a file per process
+			if format == report.FormatFolded {
+				foldedReports, err := report.CreateFoldedReports(allTableValues)
+				if err != nil {
+					err = fmt.Errorf("failed to create report: %w", err)
+					return nil, err
+				}
+				for _, name := range slices.Sorted(maps.Keys(foldedReports)) {
+					reportPath := filepath.Join(outputDir, fmt.Sprintf("%s%s_%s.%s", targetName, post, name, format))
+					if err = writeReport(foldedReports[name], reportPath); err != nil {
+						err = fmt.Errorf("failed to write report: %w", err)
+						return nil, err
+					}
+					reportFilePaths = append(reportFilePaths, reportPath)
+				}
+				continue
+			}
+			reportBytes, err := report.Create(format, allTableValues, nil, targetName)
 			if err != nil {
 				err = fmt.Errorf("failed to create report: %w", err)
 				return nil, err
 			}
 			if len(formats) == 1 && format == report.FormatTxt {
-				fmt.Printf("%s:\n", targetScriptOutputs.TargetName)
+				fmt.Printf("%s:\n", targetName)
 				fmt.Print(string(reportBytes))
 			}
-			post := ""
-			if rc.ReportNamePost != "" {
-				post = "_" + rc.ReportNamePost
-			}
-			reportFilename := fmt.Sprintf("%s%s.%s", targetScriptOutputs.TargetName, post, format)
-			reportPath := filepath.Join(appContext.OutputDir, reportFilename)
+			reportFilename := fmt.Sprintf("%s%s.%s", targetName, post, report.FileExtension(format))
+			reportPath := filepath.Join(outputDir, reportFilename)
 			if err = writeReport(reportBytes, reportPath); err != nil {
 				err = fmt.Errorf("failed to write report: %w", err)
 				return nil, err
 			}
 			reportFilePaths = append(reportFilePaths, reportPath)
 		}
-		// keep all the targets table values for combined reports
-		allTargetsTableValues = append(allTargetsTableValues, allTableValues)
 	}
-	if len(allTargetsTableValues) > 1 && len(orderedTargetScriptOutputs) > 1 {
-		// list of target names for the combined report
-		// - only those that we received output from
-		targetNames := make([]string, 0)
-		for _, targetScriptOutputs := range orderedTargetScriptOutputs {
-			targetNames = append(targetNames, targetScriptOutputs.TargetName)
-		}
+	if len(allTargetsTableValues) > 1 {
 		// merge table names from all targets maintaining the order of the tables
 		mergedTableNames := util.MergeOrderedUnique(extractTableNamesFromValues(allTargetsTableValues))
 		multiTargetFormats := []string{report.FormatHtml, report.FormatXlsx}
@@ -434,7 +507,7 @@ func (rc *ReportingCommand) createReports(appContext AppContext, orderedTargetSc
 				return nil, err
 			}
 			reportFilename := fmt.Sprintf("%s.%s", "all_hosts", format)
-			reportPath := filepath.Join(appContext.OutputDir, reportFilename)
+			reportPath := filepath.Join(outputDir, reportFilename)
 			if err = writeReport(reportBytes, reportPath); err != nil {
 				err = fmt.Errorf("failed to write multi-target %s report: %w", format, err)
 				return nil, err
@@ -477,13 +550,13 @@ func outputsFromInput(summaryTableName string) ([]TargetScriptOutputs, error) {
 			}
 			tableNames = util.UniqueAppend(tableNames, tableName)
 		}
-		orderedTargetScriptOutputs = append(orderedTargetScriptOutputs, TargetScriptOutputs{TargetName: rawReport.TargetName, ScriptOutputs: rawReport.ScriptOutputs, TableNames: tableNames})
+		orderedTargetScriptOutputs = append(orderedTargetScriptOutputs, TargetScriptOutputs{TargetName: rawReport.TargetName, ScriptOutputs: rawReport.ScriptOutputs, TableNames: tableNames, ClockOffset: rawReport.ClockOffset})
 	}
 	return orderedTargetScriptOutputs, nil
 }
 
 // outputsFromTargets runs the scripts on the targets and returns the data in the order of the targets
-func outputsFromTargets(cmd *cobra.Command, myTargets []target.Target, tableNames []string, scriptParams map[string]string, statusUpdate progress.MultiSpinnerUpdateFunc, localTempDir string) ([]TargetScriptOutputs, error) {
+func outputsFromTargets(cmd *cobra.Command, myTargets []target.Target, tableNames []string, scriptParams map[string]string, statusUpdate progress.MultiSpinnerUpdateFunc, liveFunc LiveFunc, collectFunc CollectFunc, localTempDir string) ([]TargetScriptOutputs, error) {
 	orderedTargetScriptOutputs := []TargetScriptOutputs{}
 	channelTargetScriptOutputs := make(chan TargetScriptOutputs)
 	channelError := make(chan error)
@@ -514,7 +587,8 @@ func outputsFromTargets(cmd *cobra.Command, myTargets []target.Target, tableName
 			scriptsToRunOnTarget = append(scriptsToRunOnTarget, script)
 		}
 		// run the selected scripts on the target
-		go collectOnTarget(target, scriptsToRunOnTarget, localTempDir, scriptParams["Duration"], cmd.Name() == "telemetry", channelTargetScriptOutputs, channelError, statusUpdate)
+		measureClock := report.HasTimeSeriesTable(targetTableNames[targetIdx])
+		go collectOnTarget(target, scriptsToRunOnTarget, localTempDir, scriptParams["Duration"], cmd.Name() == "telemetry", measureClock, liveFunc, collectFunc, channelTargetScriptOutputs, channelError, statusUpdate)
 	}
 	// wait for scripts to run on all targets
 	var allTargetScriptOutputs []TargetScriptOutputs
@@ -554,8 +628,9 @@ func elevatedPrivilegesRequired(tableNames []string) bool {
 	return false
 }
 
-// collectOnTarget runs the scripts on the target and sends the results to the appropriate channels
-func collectOnTarget(myTarget target.Target, scriptsToRun []script.ScriptDefinition, localTempDir string, duration string, isTelemetry bool, channelTargetScriptOutputs chan TargetScriptOutputs, channelError chan error, statusUpdate progress.MultiSpinnerUpdateFunc) {
+// collectOnTarget runs the scripts on the target and sends the results to the appropriate channels. The
+// target's clock offset is measured when measureClock is true, i.e., when the tables include time series.
+func collectOnTarget(myTarget target.Target, scriptsToRun []script.ScriptDefinition, localTempDir string, duration string, isTelemetry bool, measureClock bool, liveFunc LiveFunc, collectFunc CollectFunc, channelTargetScriptOutputs chan TargetScriptOutputs, channelError chan error, statusUpdate progress.MultiSpinnerUpdateFunc) {
 	// run the scripts on the target
 	status := "collecting data"
 	if isTelemetry && duration == "0" { // telemetry is the only command that uses this common code that can run indefinitely
@@ -566,7 +641,46 @@ func collectOnTarget(myTarget target.Target, scriptsToRun []script.ScriptDefinit
 	if statusUpdate != nil {
 		_ = statusUpdate(myTarget.GetName(), status)
 	}
-	scriptOutputs, err := script.RunScripts(myTarget, scriptsToRun, true, localTempDir)
+	// record the settings that the scripts change in the journal, so that they can be restored if we don't
+	// get the chance to wait for the scripts to restore them
+	var settings []string
+	for _, scriptToRun := range scriptsToRun {
+		for _, setting := range scriptToRun.Settings {
+			settings = util.UniqueAppend(settings, setting)
+		}
+	}
+	if err := RecordJournal(myTarget, settings, localTempDir); err != nil {
+		if statusUpdate != nil {
+			_ = statusUpdate(myTarget.GetName(), fmt.Sprintf("error collecting data: %v", err))
+		}
+		channelError <- fmt.Errorf("error preparing data collection on %s: %v", myTarget.GetName(), err)
+		return
+	}
+	// measure the target's clock so that the time series can be put on the controller's clock
+	var clockOffset *target.ClockOffset
+	if measureClock {
+		if clock, err := target.MeasureClockOffset(myTarget); err != nil {
+			slog.Warn("failed to measure the clock offset", slog.String("target", myTarget.GetName()), slog.String("error", err.Error()))
+		} else {
+			slog.Info("measured the clock offset", slog.String("target", myTarget.GetName()), slog.Duration("offset", clock.Offset), slog.Duration("round trip", clock.RoundTrip))
+			clockOffset = &clock
+		}
+	}
+	var scriptOutputs map[string]script.ScriptOutput
+	var err error
+	if collectFunc != nil {
+		scriptOutputs, err = collectFunc(myTarget, scriptsToRun, localTempDir, statusUpdate)
+	} else if liveFunc != nil {
+		scriptOutputs, err = StreamOnTarget(myTarget, scriptsToRun, localTempDir, liveFunc)
+	} else {
+		scriptOutputs, err = script.RunScripts(myTarget, scriptsToRun, true, localTempDir)
+	}
+	// the scripts restore their settings before exiting, but they may have been interrupted
+	if len(settings) > 0 {
+		if restoreErr := RestoreJournal(myTarget, localTempDir); restoreErr != nil {
+			slog.Error("failed to restore journal", slog.String("target", myTarget.GetName()), slog.String("error", restoreErr.Error()))
+		}
+	}
 	if err != nil {
 		if statusUpdate != nil {
 			_ = statusUpdate(myTarget.GetName(), fmt.Sprintf("error collecting data: %v", err))
@@ -578,5 +692,84 @@ func collectOnTarget(myTarget target.Target, scriptsToRun []script.ScriptDefinit
 	if statusUpdate != nil {
 		_ = statusUpdate(myTarget.GetName(), "collection complete")
 	}
-	channelTargetScriptOutputs <- TargetScriptOutputs{TargetName: myTarget.GetName(), ScriptOutputs: scriptOutputs}
+	channelTargetScriptOutputs <- TargetScriptOutputs{TargetName: myTarget.GetName(), ScriptOutputs: scriptOutputs, ClockOffset: clockOffset}
+}
+
+// liveQuietTime is how long the scripts' output must be quiet before it is passed to the LiveFunc. The
+// collectors write a burst of lines each interval, so this avoids passing a partial interval.
+const liveQuietTime = 100 * time.Millisecond
+
+// liveWindow is the part of a script's output that is passed to the LiveFunc
+type liveWindow struct {
+	head     string          // the output that arrived before the first call
+	previous string          // the output that was new in the previous call
+	current  strings.Builder // the output that arrived since the previous call
+}
+
+// outputs returns the window's output without and with the output that arrived since the previous call
+func (w *liveWindow) outputs() (previous string, current string) {
+	previous = w.head + w.previous
+	return previous, previous + w.current.String()
+}
+
+// advance moves the output that arrived since the previous call to the start of the window, or in place of the
+// previous call's output
+func (w *liveWindow) advance() {
+	if w.current.Len() == 0 {
+		return
+	}
+	if w.head == "" {
+		w.head = w.current.String()
+	} else {
+		w.previous = w.current.String()
+	}
+	w.current.Reset()
+}
+
+// StreamOnTarget runs the scripts on the target, passing their output to liveFunc as it arrives, and returns
+// the complete outputs when the scripts exit
+func StreamOnTarget(myTarget target.Target, scriptsToRun []script.ScriptDefinition, localTempDir string, liveFunc LiveFunc) (map[string]script.ScriptOutput, error) {
+	var mutex sync.Mutex
+	windows := make(map[string]*liveWindow)
+	definitions := make(map[string]script.ScriptDefinition)
+	for _, scriptToRun := range scriptsToRun {
+		windows[scriptToRun.Name] = &liveWindow{}
+		definitions[scriptToRun.Name] = scriptToRun
+	}
+	// the LiveFunc is called once the output has been quiet for a moment
+	update := func() {
+		mutex.Lock()
+		defer mutex.Unlock()
+		previousOutputs := make(map[string]script.ScriptOutput, len(windows))
+		scriptOutputs := make(map[string]script.ScriptOutput, len(windows))
+		newOutput := false
+		for name, window := range windows {
+			previous, current := window.outputs()
+			previousOutputs[name] = script.ScriptOutput{ScriptDefinition: definitions[name], Stdout: previous}
+			scriptOutputs[name] = script.ScriptOutput{ScriptDefinition: definitions[name], Stdout: current}
+			newOutput = newOutput || window.current.Len() > 0
+		}
+		if !newOutput {
+			return
+		}
+		liveFunc(myTarget.GetName(), previousOutputs, scriptOutputs)
+		for _, window := range windows {
+			window.advance()
+		}
+	}
+	timer := time.AfterFunc(time.Hour, update)
+	timer.Stop()
+	scriptOutputs, err := script.RunScriptsStream(myTarget, scriptsToRun, localTempDir, func(scriptName string, line string) {
+		mutex.Lock()
+		windows[scriptName].current.WriteString(line + "\n")
+		mutex.Unlock()
+		timer.Reset(liveQuietTime)
+	})
+	timer.Stop()
+	if err != nil {
+		return nil, err
+	}
+	// pass the output that arrived after the last quiet moment so that the LiveFunc sees the last interval
+	update()
+	return scriptOutputs, nil
 }
@@ -0,0 +1,40 @@
+package common
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLiveWindow(t *testing.T) {
+	var window liveWindow
+	// the first call's output is kept as the start of the window
+	window.current.WriteString("header\nsample 1\n")
+	previous, current := window.outputs()
+	assert.Equal(t, "", previous)
+	assert.Equal(t, "header\nsample 1\n", current)
+	window.advance()
+	window.current.WriteString("sample 2\n")
+	previous, current = window.outputs()
+	assert.Equal(t, "header\nsample 1\n", previous)
+	assert.Equal(t, "header\nsample 1\nsample 2\n", current)
+	window.advance()
+	// later calls keep only the previous call's output after the start
+	window.current.WriteString("sample 3\n")
+	previous, current = window.outputs()
+	assert.Equal(t, "header\nsample 1\nsample 2\n", previous)
+	assert.Equal(t, "header\nsample 1\nsample 2\nsample 3\n", current)
+	window.advance()
+	window.current.WriteString("sample 4\n")
+	previous, current = window.outputs()
+	assert.Equal(t, "header\nsample 1\nsample 3\n", previous)
+	assert.Equal(t, "header\nsample 1\nsample 3\nsample 4\n", current)
+	// a call without new output leaves the window as it is
+	window.advance()
+	window.advance()
+	previous, _ = window.outputs()
+	assert.Equal(t, "header\nsample 1\nsample 4\n", previous)
+}
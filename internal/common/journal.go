@@ -0,0 +1,422 @@
+package common
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+// the state journal records the original values of the system settings that perfspect changes on a
+// target so that they can be restored if perfspect exits before restoring them, e.g., when it is
+// killed or the connection to the target is lost
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"perfspect/internal/script"
+	"perfspect/internal/target"
+)
+
+// JournalDir is the directory on the target where the journals are stored. It is outside of the
+// target's temporary directory so that the journals survive when perfspect exits abnormally.
+const JournalDir = "/var/tmp/perfspect_journal"
+
+// AnnotationSkipJournalReplay is set in a command's annotations to skip the replay of leftover
+// journals when the command's targets are acquired
+const AnnotationSkipJournalReplay = "skipJournalReplay"
+
+// A session refreshes the modification time of its journal every journalHeartbeatInterval while
+// it runs. Journals that haven't been refreshed for journalStaleMinutes belong to sessions that are
+// no longer running, only those journals are replayed.
+const (
+	journalHeartbeatInterval = time.Minute
+	journalStaleMinutes      = 5
+)
+
+// journalHeartbeats maps the journal paths whose heartbeat has been started by this session to
+// the running heartbeats
+var journalHeartbeats sync.Map
+
+// journalHeartbeat is a running heartbeat, cancel stops it
+type journalHeartbeat struct {
+	cancel context.CancelFunc
+}
+
+// journaledPathRegex matches the settings that can be recorded in a journal, i.e., sysctl and sysfs
+// files. The journal is replayed by a superuser script, so no other files may be written.
+var journaledPathRegex = regexp.MustCompile(`^/(proc/sys|sys)/[A-Za-z0-9_.:/-]+$`)
+
+// recorderDirRegex matches the directories of the telemetry recorders that can be recorded in the journal
+// directory, i.e., absolute paths without spaces or quotes
+var recorderDirRegex = regexp.MustCompile(`^/[A-Za-z0-9_.:/-]+$`)
+
+// getJournalPath returns the path to the journal of the current perfspect session on the target.
+// Each session has its own journal, named after the session's temporary directory on the target.
+func getJournalPath(myTarget target.Target) string {
+	return JournalDir + "/" + filepath.Base(myTarget.GetTempDirectory()) + ".journal"
+}
+
+// getRecordJournalScript returns a script that appends the current values of the given settings to
+// the journal. Settings that are already in the journal are skipped so that the journal always
+// holds the values from before the session's first change.
+func getRecordJournalScript(journalPath string, paths []string) string {
+	var quotedPaths []string
+	for _, path := range paths {
+		quotedPaths = append(quotedPaths, "'"+path+"'")
+	}
+	return fmt.Sprintf(`mkdir -p %[1]s && chmod 755 %[1]s || exit 1
+journal=%[2]s
+touch "$journal" && chmod 600 "$journal" || exit 1
+for path in %[3]s; do
+    if [ -f "$path" ] && ! awk -v p="$path" '$1 == p {found=1} END {exit !found}' "$journal"; then
+        echo "$path $(cat "$path")" >> "$journal" || exit 1
+    fi
+done
+sync "$journal" 2>/dev/null || sync
+`, JournalDir, journalPath, strings.Join(quotedPaths, " "))
+}
+
+// getClearJournalScript returns a script that removes the given settings from the journal, and
+// removes the journal when no settings remain. It prints "removed" when the journal doesn't exist
+// after the settings are cleared.
+func getClearJournalScript(journalPath string, paths []string) string {
+	return fmt.Sprintf(`journal=%s
+[ -f "$journal" ] || { echo removed; exit 0; }
+awk -v paths='%s' 'BEGIN {split(paths, p, " "); for (i in p) cleared[p[i]]=1} !($1 in cleared)' "$journal" > "$journal.tmp" && mv "$journal.tmp" "$journal"
+[ -s "$journal" ] || { rm -f "$journal"; echo removed; }
+exit 0
+`, journalPath, strings.Join(paths, " "))
+}
+
+// getHeartbeatJournalScript returns a script that refreshes the modification time of the journal,
+// if it exists, to show that its session is still running. It prints "removed" when the journal
+// doesn't exist.
+func getHeartbeatJournalScript(journalPath string) string {
+	return fmt.Sprintf(`journal=%s
+[ -f "$journal" ] || { echo removed; exit 0; }
+touch "$journal"
+`, journalPath)
+}
+
+// getRestoreJournalsScript returns a script that writes the values in the journals matching the given
+// pattern back to their settings and then removes the journals. The restored settings are printed.
+// If staleMinutes is greater than zero, journals that were refreshed within the last staleMinutes
+// belong to running sessions, they are skipped and printed as "active <journal>".
+func getRestoreJournalsScript(journalPattern string, staleMinutes int) string {
+	return fmt.Sprintf(`stale=%d
+for journal in %s; do
+    [ -f "$journal" ] || continue
+    if [ "$stale" -gt 0 ] && [ -n "$(find "$journal" -mmin -"$stale" 2>/dev/null)" ]; then
+        echo "active $journal"
+        continue
+    fi
+    while read -r path value; do
+        case "$path" in
+            /proc/sys/*|/sys/*) echo "$value" > "$path" && echo "$path $value" ;;
+        esac
+    done < "$journal"
+    rm -f "$journal"
+done
+`, staleMinutes, journalPattern)
+}
+
+// RecordJournal saves the current values of the given sysctl or sysfs files in the target's journal.
+// It must be called before the settings are changed. Call ClearJournal after the settings are restored.
+func RecordJournal(myTarget target.Target, paths []string, localTempDir string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	for _, path := range paths {
+		if !journaledPathRegex.MatchString(path) {
+			return fmt.Errorf("setting can't be recorded in journal: %s", path)
+		}
+	}
+	_, err := script.RunScript(myTarget, script.ScriptDefinition{
+		Name:           "record journal",
+		ScriptTemplate: getRecordJournalScript(getJournalPath(myTarget), paths),
+		Superuser:      true,
+	}, localTempDir)
+	if err != nil {
+		return fmt.Errorf("failed to record settings in journal: %v", err)
+	}
+	startJournalHeartbeat(myTarget, localTempDir)
+	return nil
+}
+
+// startJournalHeartbeat refreshes the target's journal, so that other sessions don't replay it
+// while this session runs. It is started once per journal and runs until the journal is restored
+// or removed, see stopJournalHeartbeat.
+func startJournalHeartbeat(myTarget target.Target, localTempDir string) {
+	journalPath := getJournalPath(myTarget)
+	ctx, cancel := context.WithCancel(context.Background())
+	heartbeat := &journalHeartbeat{cancel: cancel}
+	if _, running := journalHeartbeats.LoadOrStore(journalPath, heartbeat); running {
+		cancel()
+		return
+	}
+	go func() {
+		defer journalHeartbeats.CompareAndDelete(journalPath, heartbeat)
+		ticker := time.NewTicker(journalHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			scriptOutput, err := script.RunScript(myTarget, script.ScriptDefinition{
+				Name:           "journal heartbeat",
+				ScriptTemplate: getHeartbeatJournalScript(journalPath),
+				Superuser:      true,
+			}, localTempDir)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				slog.Warn("failed to refresh journal", slog.String("target", myTarget.GetName()), slog.String("error", err.Error()))
+				continue
+			}
+			if strings.TrimSpace(scriptOutput.Stdout) == "removed" {
+				return
+			}
+		}
+	}()
+}
+
+// stopJournalHeartbeat stops the heartbeat of the target's journal, if it is running
+func stopJournalHeartbeat(myTarget target.Target) {
+	if heartbeat, ok := journalHeartbeats.LoadAndDelete(getJournalPath(myTarget)); ok {
+		heartbeat.(*journalHeartbeat).cancel()
+	}
+}
+
+// ClearJournal removes the given settings from the target's journal after they have been restored
+func ClearJournal(myTarget target.Target, paths []string, localTempDir string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	scriptOutput, err := script.RunScript(myTarget, script.ScriptDefinition{
+		Name:           "clear journal",
+		ScriptTemplate: getClearJournalScript(getJournalPath(myTarget), paths),
+		Superuser:      true,
+	}, localTempDir)
+	if err != nil {
+		return fmt.Errorf("failed to clear settings from journal: %v", err)
+	}
+	if strings.TrimSpace(scriptOutput.Stdout) == "removed" {
+		stopJournalHeartbeat(myTarget)
+	}
+	return nil
+}
+
+// HasJournals checks if journals left by earlier perfspect sessions exist on the target. Elevated
+// privileges are not required.
+func HasJournals(myTarget target.Target, localTempDir string) (bool, error) {
+	scriptOutput, err := script.RunScript(myTarget, script.ScriptDefinition{
+		Name:           "find journals",
+		ScriptTemplate: fmt.Sprintf("ls %s/*.journal 2>/dev/null || true", JournalDir),
+		Superuser:      false,
+	}, localTempDir)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(scriptOutput.Stdout) != "", nil
+}
+
+// RestoreJournal restores the settings recorded in the current session's journal on the target,
+// removes the journal, and stops its heartbeat
+func RestoreJournal(myTarget target.Target, localTempDir string) error {
+	stopJournalHeartbeat(myTarget)
+	_, err := script.RunScript(myTarget, script.ScriptDefinition{
+		Name:           "restore journal",
+		ScriptTemplate: getRestoreJournalsScript(getJournalPath(myTarget), 0),
+		Superuser:      true,
+	}, localTempDir)
+	if err != nil {
+		return fmt.Errorf("failed to restore settings from journal: %v", err)
+	}
+	return nil
+}
+
+// RestoreJournals restores the settings recorded in the journals of sessions that are no longer
+// running and removes the journals. It returns the restored settings as "path value" strings and
+// the journals of running sessions, which are left in place. If force is true, all journals are
+// restored, including those of sessions that appear to be running.
+func RestoreJournals(myTarget target.Target, force bool, localTempDir string) (restored []string, active []string, err error) {
+	staleMinutes := journalStaleMinutes
+	if force {
+		staleMinutes = 0
+	}
+	scriptOutput, err := script.RunScript(myTarget, script.ScriptDefinition{
+		Name:           "restore journals",
+		ScriptTemplate: getRestoreJournalsScript(JournalDir+"/*.journal", staleMinutes),
+		Superuser:      true,
+	}, localTempDir)
+	if err != nil {
+		err = fmt.Errorf("failed to restore settings from journal: %v", err)
+		return
+	}
+	for line := range strings.SplitSeq(scriptOutput.Stdout, "\n") {
+		line = strings.TrimSpace(line)
+		if journal, ok := strings.CutPrefix(line, "active "); ok {
+			active = append(active, journal)
+		} else if line != "" {
+			restored = append(restored, line)
+		}
+	}
+	return
+}
+
+// replayJournals restores the settings left modified on the target by earlier perfspect sessions
+// that exited before restoring them. Problems are reported but don't prevent using the target.
+func replayJournals(myTarget target.Target, localTempDir string) {
+	hasJournals, err := HasJournals(myTarget, localTempDir)
+	if err != nil {
+		slog.Warn("failed to check for journals", slog.String("target", myTarget.GetName()), slog.String("error", err.Error()))
+		return
+	}
+	if !hasJournals {
+		return
+	}
+	if !myTarget.IsSuperUser() && !myTarget.CanElevatePrivileges() {
+		slog.Warn("journal found but elevated privileges are not available to restore it", slog.String("target", myTarget.GetName()))
+		fmt.Fprintf(os.Stderr, "Warning: %s has settings left modified by an earlier %s run, run '%s restore' with elevated privileges to restore them\n", myTarget.GetName(), AppName, AppName)
+		return
+	}
+	restored, active, err := RestoreJournals(myTarget, false, localTempDir)
+	if err != nil {
+		slog.Error("failed to replay journals", slog.String("target", myTarget.GetName()), slog.String("error", err.Error()))
+		fmt.Fprintf(os.Stderr, "Warning: failed to restore settings left modified on %s by an earlier %s run: %v\n", myTarget.GetName(), AppName, err)
+		return
+	}
+	if len(active) > 0 {
+		slog.Info("skipped journals of running sessions", slog.String("target", myTarget.GetName()), slog.Any("journals", active))
+	}
+	if len(restored) > 0 {
+		slog.Info("restored settings from journal", slog.String("target", myTarget.GetName()), slog.Any("settings", restored))
+	}
+}
+
+// a telemetry recorder keeps running on the target after perfspect exits, e.g., when perfspect is killed
+// or the connection to the target is lost, so that a later run can reattach to it. Its entry in the
+// journal directory lets 'perfspect restore' stop it when it is orphaned.
+
+// getRecorderEntryPath returns the path of the entry of the telemetry recorder that writes to dir. The
+// entry is named after dir so that it can be found by the runs that reattach to the recorder.
+func getRecorderEntryPath(dir string) string {
+	return JournalDir + "/" + filepath.Base(dir) + ".recorder"
+}
+
+// getRecordRecorderScript returns a script that writes the recorder's entry, i.e., the recorder's PID,
+// directory, and the number of minutes after which the entry is stale
+func getRecordRecorderScript(entryPath string, pid int, dir string, staleMinutes int) string {
+	return fmt.Sprintf(`mkdir -p %[1]s && chmod 755 %[1]s || exit 1
+entry=%[2]s
+echo "%[3]d %[4]s %[5]d" > "$entry" && chmod 600 "$entry"
+`, JournalDir, entryPath, pid, dir, staleMinutes)
+}
+
+// getStopRecordersScript returns a script that stops the recorders of the entries matching the given
+// pattern and removes the entries. A recorder is only stopped if it still writes to its directory, i.e.,
+// the PID wasn't reused. The stopped recorders are printed as "<pid> <dir>". Unless force is set, the
+// entries that were refreshed within their stale minutes belong to running sessions, they are skipped
+// and printed as "active <entry>".
+func getStopRecordersScript(entryPattern string, force bool) string {
+	forceValue := 0
+	if force {
+		forceValue = 1
+	}
+	return fmt.Sprintf(`force=%d
+for entry in %s; do
+    [ -f "$entry" ] || continue
+    read -r pid dir minutes < "$entry"
+    case "$pid$minutes" in
+        ''|*[!0-9]*) rm -f "$entry"; continue ;;
+    esac
+    if [ "$force" -eq 0 ] && [ -n "$(find "$entry" -mmin -"$minutes" 2>/dev/null)" ]; then
+        echo "active $entry"
+        continue
+    fi
+    if [ -d "$dir" ] && [ "$(readlink -f /proc/"$pid"/cwd 2>/dev/null)" = "$(readlink -f "$dir")" ]; then
+        kill -TERM "$pid" 2>/dev/null && echo "$pid $dir"
+    fi
+    rm -f "$entry"
+done
+`, forceValue, entryPattern)
+}
+
+// RecordRecorder records the telemetry recorder that writes to dir on the target. The entry must be
+// refreshed with RefreshRecorder at least every refreshInterval, otherwise it becomes stale a few minutes
+// later and 'perfspect restore' stops the recorder. Call ClearRecorder after the recorder is stopped.
+func RecordRecorder(myTarget target.Target, pid int, dir string, refreshInterval time.Duration, localTempDir string) error {
+	if !recorderDirRegex.MatchString(dir) {
+		return fmt.Errorf("recorder directory can't be recorded in journal: %s", dir)
+	}
+	staleMinutes := int(refreshInterval.Minutes()+0.5) + journalStaleMinutes
+	_, err := script.RunScript(myTarget, script.ScriptDefinition{
+		Name:           "record recorder",
+		ScriptTemplate: getRecordRecorderScript(getRecorderEntryPath(dir), pid, dir, staleMinutes),
+		Superuser:      true,
+	}, localTempDir)
+	if err != nil {
+		return fmt.Errorf("failed to record the telemetry recorder in journal: %v", err)
+	}
+	return nil
+}
+
+// RefreshRecorder refreshes the entry of the telemetry recorder that writes to dir, if it exists, to show
+// that a session still collects the recorder's output
+func RefreshRecorder(myTarget target.Target, dir string, localTempDir string) error {
+	_, err := script.RunScript(myTarget, script.ScriptDefinition{
+		Name:           "refresh recorder",
+		ScriptTemplate: getHeartbeatJournalScript(getRecorderEntryPath(dir)),
+		Superuser:      true,
+	}, localTempDir)
+	if err != nil {
+		return fmt.Errorf("failed to refresh the telemetry recorder in journal: %v", err)
+	}
+	return nil
+}
+
+// ClearRecorder removes the entry of the telemetry recorder that writes to dir
+func ClearRecorder(myTarget target.Target, dir string, localTempDir string) error {
+	_, err := script.RunScript(myTarget, script.ScriptDefinition{
+		Name:           "clear recorder",
+		ScriptTemplate: fmt.Sprintf("rm -f %s\n", getRecorderEntryPath(dir)),
+		Superuser:      true,
+	}, localTempDir)
+	if err != nil {
+		return fmt.Errorf("failed to clear the telemetry recorder from journal: %v", err)
+	}
+	return nil
+}
+
+// StopRecorders stops the telemetry recorders on the target that no session collects from, i.e., whose
+// entries are stale, and removes their entries. It returns the stopped recorders as "<pid> <dir>" strings
+// and the entries of the recorders that are still collected from, which are left running. If force is true,
+// all recorders are stopped.
+func StopRecorders(myTarget target.Target, force bool, localTempDir string) (stopped []string, active []string, err error) {
+	scriptOutput, err := script.RunScript(myTarget, script.ScriptDefinition{
+		Name:           "stop recorders",
+		ScriptTemplate: getStopRecordersScript(JournalDir+"/*.recorder", force),
+		Superuser:      true,
+	}, localTempDir)
+	if err != nil {
+		err = fmt.Errorf("failed to stop telemetry recorders: %v", err)
+		return
+	}
+	for line := range strings.SplitSeq(scriptOutput.Stdout, "\n") {
+		line = strings.TrimSpace(line)
+		if entry, ok := strings.CutPrefix(line, "active "); ok {
+			active = append(active, entry)
+		} else if line != "" {
+			stopped = append(stopped, line)
+		}
+	}
+	return
+}
@@ -0,0 +1,111 @@
+package common
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournaledPathRegex(t *testing.T) {
+	assert.True(t, journaledPathRegex.MatchString("/proc/sys/kernel/nmi_watchdog"))
+	assert.True(t, journaledPathRegex.MatchString("/sys/devices/uncore_imc_0/perf_event_mux_interval_ms"))
+	assert.False(t, journaledPathRegex.MatchString("/etc/passwd"))
+	assert.False(t, journaledPathRegex.MatchString("/proc/sys/kernel/x'; rm -rf /'"))
+	assert.False(t, journaledPathRegex.MatchString("/sys/devices/x y"))
+}
+
+func TestClearAndRestoreJournalScripts(t *testing.T) {
+	dir := t.TempDir()
+	journal := filepath.Join(dir, "session.journal")
+	require.NoError(t, os.WriteFile(journal, []byte("/sys/a 4\n/sys/b 1\n/proc/sys/c 0\n"), 0600))
+	out, err := exec.Command("bash", "-c", getClearJournalScript(journal, []string{"/sys/a", "/proc/sys/c"})).CombinedOutput()
+	require.NoError(t, err, string(out))
+	assert.Empty(t, string(out))
+	contents, err := os.ReadFile(journal)
+	require.NoError(t, err)
+	assert.Equal(t, "/sys/b 1\n", string(contents))
+	// the journal is removed when the last setting is cleared
+	out, err = exec.Command("bash", "-c", getClearJournalScript(journal, []string{"/sys/b"})).CombinedOutput()
+	require.NoError(t, err, string(out))
+	assert.Equal(t, "removed\n", string(out))
+	assert.NoFileExists(t, journal)
+	// the heartbeat reports the removed journal so that it can stop
+	out, err = exec.Command("bash", "-c", getHeartbeatJournalScript(journal)).CombinedOutput()
+	require.NoError(t, err, string(out))
+	assert.Equal(t, "removed\n", string(out))
+	assert.NoFileExists(t, journal)
+
+	// settings outside of procfs and sysfs are never written
+	outside := filepath.Join(dir, "outside")
+	require.NoError(t, os.WriteFile(journal, []byte(outside+" 1\n"), 0600))
+	out, err = exec.Command("bash", "-c", getRestoreJournalsScript(filepath.Join(dir, "*.journal"), 0)).CombinedOutput()
+	require.NoError(t, err, string(out))
+	assert.Empty(t, string(out))
+	assert.NoFileExists(t, outside)
+	assert.NoFileExists(t, journal)
+}
+
+func TestRestoreJournalsScriptSkipsActiveJournals(t *testing.T) {
+	dir := t.TempDir()
+	active := filepath.Join(dir, "active.journal")
+	stale := filepath.Join(dir, "stale.journal")
+	require.NoError(t, os.WriteFile(active, []byte(filepath.Join(dir, "a")+" 1\n"), 0600))
+	require.NoError(t, os.WriteFile(stale, []byte(filepath.Join(dir, "b")+" 1\n"), 0600))
+	old := time.Now().Add(-time.Duration(journalStaleMinutes+1) * time.Minute)
+	require.NoError(t, os.Chtimes(stale, old, old))
+	out, err := exec.Command("bash", "-c", getRestoreJournalsScript(filepath.Join(dir, "*.journal"), journalStaleMinutes)).CombinedOutput()
+	require.NoError(t, err, string(out))
+	assert.Equal(t, "active "+active+"\n", string(out))
+	assert.FileExists(t, active)
+	assert.NoFileExists(t, stale)
+
+	// the heartbeat keeps the journal active
+	require.NoError(t, os.Chtimes(active, old, old))
+	out, err = exec.Command("bash", "-c", getHeartbeatJournalScript(active)).CombinedOutput()
+	require.NoError(t, err, string(out))
+	assert.Empty(t, string(out))
+	info, err := os.Stat(active)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), info.ModTime(), time.Minute)
+}
+
+func TestStopRecordersScript(t *testing.T) {
+	dir := t.TempDir()
+	recorderDir := t.TempDir()
+	recorder := exec.Command("sleep", "60")
+	recorder.Dir = recorderDir
+	require.NoError(t, recorder.Start())
+	defer func() { _ = recorder.Process.Kill() }()
+	entry := filepath.Join(dir, "session.recorder")
+	out, err := exec.Command("bash", "-c", strings.ReplaceAll(getRecordRecorderScript(entry, recorder.Process.Pid, recorderDir, 5), JournalDir, dir)).CombinedOutput()
+	require.NoError(t, err, string(out))
+	// the recorders of entries that are refreshed are left running
+	out, err = exec.Command("bash", "-c", getStopRecordersScript(filepath.Join(dir, "*.recorder"), false)).CombinedOutput()
+	require.NoError(t, err, string(out))
+	assert.Equal(t, "active "+entry+"\n", string(out))
+	assert.FileExists(t, entry)
+	// forced, the recorder is stopped and its entry removed
+	out, err = exec.Command("bash", "-c", getStopRecordersScript(filepath.Join(dir, "*.recorder"), true)).CombinedOutput()
+	require.NoError(t, err, string(out))
+	assert.Equal(t, fmt.Sprintf("%d %s\n", recorder.Process.Pid, recorderDir), string(out))
+	assert.NoFileExists(t, entry)
+	assert.Error(t, recorder.Wait())
+	// the process isn't signaled when the PID doesn't write to the directory, e.g., it was reused
+	require.NoError(t, os.WriteFile(entry, []byte(fmt.Sprintf("%d %s 5\n", os.Getpid(), recorderDir)), 0600))
+	old := time.Now().Add(-10 * time.Minute)
+	require.NoError(t, os.Chtimes(entry, old, old))
+	out, err = exec.Command("bash", "-c", getStopRecordersScript(filepath.Join(dir, "*.recorder"), false)).CombinedOutput()
+	require.NoError(t, err, string(out))
+	assert.Empty(t, string(out))
+	assert.NoFileExists(t, entry)
+}
@@ -120,7 +120,7 @@ func ValidateTargetFlags(cmd *cobra.Command) error {
 // GetTargets retrieves the list of targets based on the provided command and parameters. It creates
 // a temporary directory for each target and returns a slice of target.Target objects.
 func GetTargets(cmd *cobra.Command, needsElevatedPrivileges bool, failIfCantElevate bool, localTempDir string) (targets []target.Target, targetErrs []error, err error) {
-	targetTempDirRoot := cmd.Parent().PersistentFlags().Lookup("tempdir").Value.String()
+	targetTempDirRoot := cmd.Root().PersistentFlags().Lookup("tempdir").Value.String()
 	flagTargetsFile, _ := cmd.Flags().GetString(flagTargetsFileName)
 	if flagTargetsFile != "" {
 		targets, targetErrs, err = getTargetsFromFile(flagTargetsFile, localTempDir)
@@ -154,6 +154,11 @@ func GetTargets(cmd *cobra.Command, needsElevatedPrivileges bool, failIfCantElev
 		} else if noExec {
 			targetErrs[targetIdx] = fmt.Errorf("target's temp directory must not be on a file system mounted with the 'noexec' option, override the default with --tempdir")
 			slog.Error(targetErrs[targetIdx].Error(), slog.String("target", myTarget.GetName()))
+			continue
+		}
+		// restore settings left modified by earlier runs that didn't exit cleanly
+		if cmd.Annotations[AnnotationSkipJournalReplay] != "true" {
+			replayJournals(myTarget, localTempDir)
 		}
 	}
 	return
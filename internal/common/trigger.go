@@ -0,0 +1,279 @@
+package common
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+// threshold-triggered collection, i.e., a watch mode that evaluates a trigger expression over the
+// values collected by the metrics or telemetry command and starts a flame or lock capture on the
+// target when the expression is true
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"perfspect/internal/progress"
+	"perfspect/internal/report"
+	"perfspect/internal/script"
+	"perfspect/internal/target"
+
+	"github.com/Knetic/govaluate"
+)
+
+const (
+	TriggerActionFlame = "flame"
+	TriggerActionLock  = "lock"
+)
+
+var TriggerActionOptions = []string{TriggerActionFlame, TriggerActionLock}
+
+// TriggerSample holds the values that the trigger expression is evaluated over, e.g., the metrics of
+// a collection interval
+type TriggerSample struct {
+	Time   time.Time
+	Labels map[string]string  // identify the sample, e.g., the socket or CPU of the values
+	Values map[string]float64 // variable name -> value
+}
+
+// TriggerContext describes why a capture was triggered, it is written to each capture's directory
+type TriggerContext struct {
+	Expression  string
+	Action      string
+	Duration    int
+	TriggeredAt string
+	Labels      map[string]string  `json:",omitempty"`
+	Values      map[string]float64 // the values in the sample that fired the trigger
+	Files       []string           `json:",omitempty"`
+	Error       string             `json:",omitempty"`
+}
+
+// CaptureFunc runs a capture and returns the files it wrote to the capture directory
+type CaptureFunc func(action string, duration int, captureDir string) ([]string, error)
+
+// TriggerWatcher evaluates the trigger expression over each sample. When the expression is true, and
+// no capture is in progress or cooling down, it starts a capture in the background.
+type TriggerWatcher struct {
+	expression string
+	evaluable  *govaluate.EvaluableExpression
+	action     string
+	duration   int           // seconds
+	cooldown   time.Duration // time after a capture ends before the trigger can fire again
+	targetName string
+	outputDir  string
+	capture    CaptureFunc
+	status     progress.MultiSpinnerUpdateFunc
+	now        func() time.Time
+
+	mu          sync.Mutex
+	capturing   bool
+	nextAllowed time.Time
+	captures    int
+	files       []string
+	wg          sync.WaitGroup
+}
+
+// ParseTriggerExpression parses the trigger expression. Variables are enclosed in square brackets,
+// e.g., "[TMA_..Memory_Bound(%)] > 60". The functions, if any, can be called in the expression.
+func ParseTriggerExpression(expression string, functions map[string]govaluate.ExpressionFunction) (*govaluate.EvaluableExpression, error) {
+	evaluable, err := govaluate.NewEvaluableExpressionWithFunctions(expression, functions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse trigger expression: %w", err)
+	}
+	return evaluable, nil
+}
+
+// NewTriggerWatcher returns a watcher for the trigger expression. Captures are written to
+// <outputDir>/<targetName>_trigger_<N>.
+func NewTriggerWatcher(expression string, functions map[string]govaluate.ExpressionFunction, action string, duration int, cooldown int, targetName string, outputDir string, capture CaptureFunc, status progress.MultiSpinnerUpdateFunc) (*TriggerWatcher, error) {
+	evaluable, err := ParseTriggerExpression(expression, functions)
+	if err != nil {
+		return nil, err
+	}
+	return &TriggerWatcher{
+		expression: expression,
+		evaluable:  evaluable,
+		action:     action,
+		duration:   duration,
+		cooldown:   time.Duration(cooldown) * time.Second,
+		targetName: targetName,
+		outputDir:  outputDir,
+		capture:    capture,
+		status:     status,
+		now:        time.Now,
+	}, nil
+}
+
+// Vars returns the names of the variables in the trigger expression
+func (w *TriggerWatcher) Vars() []string {
+	return w.evaluable.Vars()
+}
+
+// isTriggered evaluates the trigger expression with the sample's values. It returns false when a
+// referenced variable has no valid value in the sample.
+func (w *TriggerWatcher) isTriggered(sample TriggerSample) (triggered bool) {
+	parameters := make(govaluate.MapParameters, len(sample.Values))
+	for _, variable := range w.evaluable.Vars() {
+		value, ok := sample.Values[variable]
+		if !ok || math.IsNaN(value) || math.IsInf(value, 0) {
+			return false
+		}
+		parameters[variable] = value
+	}
+	defer func() {
+		if errx := recover(); errx != nil {
+			triggered = false
+		}
+	}()
+	result, err := w.evaluable.Eval(parameters)
+	if err != nil {
+		slog.Debug("failed to evaluate trigger", slog.String("expression", w.expression), slog.String("error", err.Error()))
+		return false
+	}
+	triggered, _ = result.(bool)
+	return
+}
+
+// Check evaluates the trigger over the samples and starts a capture for the first sample that fires
+// the trigger, unless a capture is in progress or the trigger is cooling down
+func (w *TriggerWatcher) Check(samples []TriggerSample) {
+	for _, sample := range samples {
+		if !w.isTriggered(sample) {
+			continue
+		}
+		w.mu.Lock()
+		if w.capturing || w.now().Before(w.nextAllowed) {
+			w.mu.Unlock()
+			slog.Debug("trigger fired while capturing or cooling down", slog.String("target", w.targetName), slog.String("expression", w.expression))
+			return
+		}
+		w.capturing = true
+		w.captures++
+		captureNumber := w.captures
+		w.mu.Unlock()
+		context := newTriggerContext(w.expression, w.action, w.duration, sample)
+		w.wg.Add(1)
+		go w.runCapture(context, captureNumber)
+		return
+	}
+}
+
+// runCapture runs the capture and writes the trigger context to the capture directory
+func (w *TriggerWatcher) runCapture(context TriggerContext, captureNumber int) {
+	defer w.wg.Done()
+	captureDir := filepath.Join(w.outputDir, fmt.Sprintf("%s_trigger_%d", w.targetName, captureNumber))
+	slog.Info("trigger fired", slog.String("target", w.targetName), slog.String("expression", w.expression), slog.String("action", w.action))
+	if w.status != nil {
+		_ = w.status(w.targetName, fmt.Sprintf("trigger fired, capturing %s for %d seconds", w.action, w.duration))
+	}
+	var files []string
+	err := os.MkdirAll(captureDir, 0755) // #nosec G301
+	if err == nil {
+		files, err = w.capture(w.action, w.duration, captureDir)
+	}
+	context.Files = files
+	if err != nil {
+		context.Error = err.Error()
+		slog.Error("triggered capture failed", slog.String("target", w.targetName), slog.String("error", err.Error()))
+	}
+	contextFile := filepath.Join(captureDir, "trigger.json")
+	if jsonBytes, jsonErr := json.MarshalIndent(context, "", "  "); jsonErr != nil {
+		slog.Error("failed to marshal trigger context", slog.String("error", jsonErr.Error()))
+	} else if writeErr := os.WriteFile(contextFile, jsonBytes, 0644); writeErr != nil { // #nosec G306
+		slog.Error("failed to write trigger context", slog.String("error", writeErr.Error()))
+	} else {
+		files = append(files, contextFile)
+	}
+	if w.status != nil {
+		_ = w.status(w.targetName, fmt.Sprintf("%s capture %d complete, collecting", w.action, captureNumber))
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.capturing = false
+	w.nextAllowed = w.now().Add(w.cooldown)
+	w.files = append(w.files, files...)
+}
+
+// Wait blocks until captures in progress complete and returns the files written by all captures
+func (w *TriggerWatcher) Wait() []string {
+	w.wg.Wait()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.files
+}
+
+// newTriggerContext records the trigger and the sample that fired it
+func newTriggerContext(expression string, action string, duration int, sample TriggerSample) TriggerContext {
+	context := TriggerContext{
+		Expression:  expression,
+		Action:      action,
+		Duration:    duration,
+		TriggeredAt: sample.Time.UTC().Format(time.RFC3339Nano),
+		Labels:      sample.Labels,
+		Values:      make(map[string]float64, len(sample.Values)),
+	}
+	for name, value := range sample.Values {
+		// NaN and Inf can't be marshaled to JSON
+		if !math.IsNaN(value) && !math.IsInf(value, 0) {
+			context.Values[name] = value
+		}
+	}
+	return context
+}
+
+// NewTargetCaptureFunc returns a function that runs the flame or lock collection on the target and
+// writes the collection's html and json reports to the capture directory
+func NewTargetCaptureFunc(myTarget target.Target, localTempDir string) CaptureFunc {
+	return func(action string, duration int, captureDir string) ([]string, error) {
+		var tableName string
+		params := map[string]string{
+			"Duration":  strconv.Itoa(duration),
+			"Frequency": "11",
+		}
+		switch action {
+		case TriggerActionFlame:
+			tableName = report.CallStackFrequencyTableName
+			params["PIDs"] = ""
+			params["MaxDepth"] = "0"
+			params["Mode"] = report.CallStackModeCPU
+			params["PerCPU"] = "false"
+			params["Timeline"] = "false"
+		case TriggerActionLock:
+			tableName = report.KernelLockAnalysisTableName
+			params["Package"] = "false"
+		default:
+			return nil, fmt.Errorf("unknown trigger action: %s", action)
+		}
+		var scripts []script.ScriptDefinition
+		var settings []string
+		for _, scriptName := range report.GetScriptNamesForTable(tableName) {
+			scriptDef := script.GetParameterizedScriptByName(scriptName, params)
+			scripts = append(scripts, scriptDef)
+			settings = append(settings, scriptDef.Settings...)
+		}
+		// the scripts restore the settings they change, the journal covers an interrupted capture. Only
+		// the capture's settings are cleared afterwards, the collection's settings stay journaled.
+		if err := RecordJournal(myTarget, settings, localTempDir); err != nil {
+			return nil, err
+		}
+		defer func() {
+			if err := ClearJournal(myTarget, settings, localTempDir); err != nil {
+				slog.Error("failed to clear capture settings from journal", slog.String("target", myTarget.GetName()), slog.String("error", err.Error()))
+			}
+		}()
+		scriptOutputs, err := script.RunScripts(myTarget, scripts, true, localTempDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run %s collection: %w", action, err)
+		}
+		allTableValues, err := report.ProcessTables([]string{tableName}, scriptOutputs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to process %s collection: %w", action, err)
+		}
+		return WriteReports(captureDir, action, []string{myTarget.GetName()}, [][]report.TableValues{allTableValues}, []string{report.FormatHtml, report.FormatJson})
+	}
+}
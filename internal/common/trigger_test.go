@@ -0,0 +1,89 @@
+package common
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTriggerWatcher(t *testing.T, expression string, capture CaptureFunc) *TriggerWatcher {
+	watcher, err := NewTriggerWatcher(expression, nil, TriggerActionFlame, 10, 60, "host", t.TempDir(), capture, nil)
+	require.NoError(t, err)
+	return watcher
+}
+
+func newTestTriggerSample(memoryBound float64) TriggerSample {
+	return TriggerSample{Time: time.Unix(2005, 250000000), Values: map[string]float64{"TMA_..Memory_Bound(%)": memoryBound, "CPI": math.NaN()}}
+}
+
+func TestNewTriggerWatcher(t *testing.T) {
+	_, err := NewTriggerWatcher("[CPI] > 1 &&", nil, TriggerActionFlame, 10, 60, "host", t.TempDir(), nil, nil)
+	assert.Error(t, err)
+}
+
+func TestTriggerIsTriggered(t *testing.T) {
+	watcher := newTestTriggerWatcher(t, "[TMA_..Memory_Bound(%)] > 60", nil)
+	assert.True(t, watcher.isTriggered(newTestTriggerSample(61)))
+	assert.False(t, watcher.isTriggered(newTestTriggerSample(59)))
+	assert.False(t, watcher.isTriggered(newTestTriggerSample(math.NaN())))
+	// variables without valid values don't fire the trigger
+	watcher = newTestTriggerWatcher(t, "[CPI] > 1 || [TMA_..Memory_Bound(%)] > 60", nil)
+	assert.False(t, watcher.isTriggered(newTestTriggerSample(61)))
+	watcher = newTestTriggerWatcher(t, "[IPC] > 1 || [TMA_..Memory_Bound(%)] > 60", nil)
+	assert.False(t, watcher.isTriggered(newTestTriggerSample(61)))
+}
+
+func TestTriggerCaptureAndCooldown(t *testing.T) {
+	captures := 0
+	watcher := newTestTriggerWatcher(t, "[TMA_..Memory_Bound(%)] > 60", func(action string, duration int, captureDir string) ([]string, error) {
+		captures++
+		assert.Equal(t, TriggerActionFlame, action)
+		assert.Equal(t, 10, duration)
+		if captures == 2 {
+			return nil, errors.New("capture failed")
+		}
+		file := filepath.Join(captureDir, "host_flame.html")
+		return []string{file}, os.WriteFile(file, []byte("flame"), 0644)
+	})
+	now := time.Unix(1000, 0)
+	watcher.now = func() time.Time { return now }
+
+	watcher.Check([]TriggerSample{newTestTriggerSample(50), newTestTriggerSample(70)})
+	files := watcher.Wait()
+	require.Len(t, files, 2)
+	assert.Equal(t, filepath.Join(watcher.outputDir, "host_trigger_1", "host_flame.html"), files[0])
+	contextBytes, err := os.ReadFile(files[1])
+	require.NoError(t, err)
+	var context TriggerContext
+	require.NoError(t, json.Unmarshal(contextBytes, &context))
+	assert.Equal(t, "[TMA_..Memory_Bound(%)] > 60", context.Expression)
+	assert.Equal(t, "1970-01-01T00:33:25.25Z", context.TriggeredAt)
+	assert.Equal(t, map[string]float64{"TMA_..Memory_Bound(%)": 70}, context.Values)
+	assert.Equal(t, []string{files[0]}, context.Files)
+
+	// the trigger doesn't fire again until the cooldown has passed
+	now = now.Add(59 * time.Second)
+	watcher.Check([]TriggerSample{newTestTriggerSample(70)})
+	assert.Len(t, watcher.Wait(), 2)
+	assert.Equal(t, 1, captures)
+	now = now.Add(time.Second)
+	watcher.Check([]TriggerSample{newTestTriggerSample(70)})
+	files = watcher.Wait()
+	assert.Equal(t, 2, captures)
+	// failed captures are recorded in the trigger context
+	require.Len(t, files, 3)
+	contextBytes, err = os.ReadFile(filepath.Join(watcher.outputDir, "host_trigger_2", "trigger.json"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(contextBytes, &context))
+	assert.Equal(t, "capture failed", context.Error)
+}
@@ -288,3 +288,82 @@ func getValueForCell(value string) (val any) {
 	val = value
 	return
 }
+
+// metricStatisticsTableXlsxRenderer renders the statistics of each metric with the means that exceed
+// the metric's threshold highlighted
+func metricStatisticsTableXlsxRenderer(tableValues TableValues, f *excelize.File, sheetName string, row *int) {
+	exceeded := getFieldValues(tableValues, MetricsExceededFieldName)
+	renderMetricsXlsxTable(tableValues, f, sheetName, row, func(fieldName string, tableRow int) bool {
+		return fieldName == MetricsMeanFieldName && exceeded != nil && exceeded[tableRow] == "true"
+	}, nil)
+}
+
+// tmaBreakdownTableXlsxRenderer renders the TMA hierarchy with each metric indented by its level
+func tmaBreakdownTableXlsxRenderer(tableValues TableValues, f *excelize.File, sheetName string, row *int) {
+	exceeded := getFieldValues(tableValues, MetricsExceededFieldName)
+	levels := getFieldValues(tableValues, MetricsLevelFieldName)
+	renderMetricsXlsxTable(tableValues, f, sheetName, row, func(fieldName string, tableRow int) bool {
+		return fieldName == MetricsMeanFieldName && exceeded != nil && exceeded[tableRow] == "true"
+	}, func(fieldName string, tableRow int) int {
+		if fieldName != MetricsMetricFieldName || levels == nil {
+			return 0
+		}
+		level, err := strconv.Atoi(levels[tableRow])
+		if err != nil || level < 1 {
+			return 0
+		}
+		return (level - 1) * 2
+	})
+}
+
+// renderMetricsXlsxTable renders a table with rows like the default renderer. Cells for which
+// highlight returns true are filled, and cells are indented by the number of characters returned by
+// indent, if not nil.
+func renderMetricsXlsxTable(tableValues TableValues, f *excelize.File, sheetName string, row *int, highlight func(fieldName string, tableRow int) bool, indent func(fieldName string, tableRow int) int) {
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{
+			Bold: true,
+		},
+	})
+	styles := make(map[[2]int]int) // highlighted, indent -> style
+	getStyle := func(highlighted bool, indentChars int) int {
+		key := [2]int{0, indentChars}
+		if highlighted {
+			key[0] = 1
+		}
+		if style, ok := styles[key]; ok {
+			return style
+		}
+		style := &excelize.Style{
+			Alignment: &excelize.Alignment{
+				Horizontal: "left",
+				Indent:     indentChars,
+			},
+		}
+		if highlighted {
+			style.Fill = excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"FFC966"}}
+		}
+		styles[key], _ = f.NewStyle(style)
+		return styles[key]
+	}
+	col := 2
+	for _, field := range tableValues.Fields {
+		_ = f.SetCellValue(sheetName, cellName(col, *row), field.Name)
+		_ = f.SetCellStyle(sheetName, cellName(col, *row), cellName(col, *row), headerStyle)
+		col++
+	}
+	*row++
+	for tableRow := range tableValues.Fields[0].Values {
+		col = 2
+		for _, field := range tableValues.Fields {
+			indentChars := 0
+			if indent != nil {
+				indentChars = indent(field.Name, tableRow)
+			}
+			_ = f.SetCellValue(sheetName, cellName(col, *row), getValueForCell(field.Values[tableRow]))
+			_ = f.SetCellStyle(sheetName, cellName(col, *row), cellName(col, *row), getStyle(highlight(field.Name, tableRow), indentChars))
+			col++
+		}
+		*row++
+	}
+}
@@ -247,6 +247,7 @@ func createHtmlReportMultiTarget(allTargetsTableValues [][]TableValues, targetNa
 	// print the tables in the order they were passed in
 	for _, tableName := range allTableNames {
 		oneTableValuesForAllTargets := []TableValues{}
+		multiTargetNames := []string{}
 		// build list of target names and TableValues for targets that have values for this table
 		tableTargets := []string{}
 		tableValues := []TableValues{}
@@ -281,16 +282,17 @@ func createHtmlReportMultiTarget(allTargetsTableValues [][]TableValues, targetNa
 				}
 			} else { // if the table has no rows or a custom renderer, add the table to the list to render as a multi-target table
 				oneTableValuesForAllTargets = append(oneTableValuesForAllTargets, targetTableValues)
+				multiTargetNames = append(multiTargetNames, targetName)
 			}
 		}
 		// print the multi-target table, if any
 		if len(oneTableValuesForAllTargets) > 0 {
 			sb.WriteString(fmt.Sprintf("<h2 id=\"%[1]s\">%[1]s</h2>\n", html.EscapeString(oneTableValuesForAllTargets[0].Name)))
 			if oneTableValuesForAllTargets[0].HTMLMultiTargetTableRendererFunc != nil {
-				sb.WriteString(oneTableValuesForAllTargets[0].HTMLMultiTargetTableRendererFunc(oneTableValuesForAllTargets, targetNames))
+				sb.WriteString(oneTableValuesForAllTargets[0].HTMLMultiTargetTableRendererFunc(oneTableValuesForAllTargets, multiTargetNames))
 			} else {
 				// render the multi-target table
-				sb.WriteString(RenderMultiTargetTableValuesAsHTML(oneTableValuesForAllTargets, targetNames))
+				sb.WriteString(RenderMultiTargetTableValuesAsHTML(oneTableValuesForAllTargets, multiTargetNames))
 			}
 		}
 	}
@@ -356,9 +358,15 @@ new Chart(document.getElementById('{{.ID}}'), {
                     display: true
                 },
 				suggestedMin: {{.SuggestedMin}},
-				suggestedMax: {{.SuggestedMax}},
+				suggestedMax: {{.SuggestedMax}},{{if .Stacked}}
+				stacked: true,{{end}}
             }
-        },
+        },{{if .Stacked}}
+        elements: {
+            line: {
+                fill: 'stack'
+            }
+        },{{end}}
         plugins: {
             title: {
                 text: "{{.TitleText}}",
@@ -446,6 +454,7 @@ type chartTemplateStruct struct {
 	AspectRatio   string
 	SuggestedMin  string
 	SuggestedMax  string
+	Stacked       bool // only for line charts
 }
 
 func renderHTMLTable(tableHeaders []string, tableValues [][]string, class string, valuesStyle [][]string) string {
@@ -715,6 +724,10 @@ func renderLineChart(xAxisLabels []string, data [][]float64, datasetNames []stri
 	for dataIdx := range data {
 		formattedPoints := []string{}
 		for _, point := range data[dataIdx] {
+			if math.IsNaN(point) { // a gap in the line
+				formattedPoints = append(formattedPoints, "null")
+				continue
+			}
 			formattedPoints = append(formattedPoints, fmt.Sprintf("%f", point))
 		}
 		allFormattedPoints = append(allFormattedPoints, strings.Join(formattedPoints, ","))
@@ -1112,6 +1125,369 @@ func memoryTelemetryTableHTMLRenderer(tableValues TableValues, targetName string
 	return telemetryTableHTMLRenderer(tableValues, data, datasetNames, chartConfig)
 }
 
+// telemetryFieldsChartData returns a dataset for each field, fields without values are skipped
+func telemetryFieldsChartData(fields []Field) (data [][]float64, datasetNames []string, err error) {
+	for _, field := range fields {
+		points := []float64{}
+		for _, val := range field.Values {
+			var stat float64
+			if stat, err = strconv.ParseFloat(val, 64); err != nil {
+				return
+			}
+			points = append(points, stat)
+		}
+		if len(points) > 0 {
+			data = append(data, points)
+			datasetNames = append(datasetNames, field.Name)
+		}
+	}
+	return
+}
+
+func pressureTelemetryTableHTMLRenderer(tableValues TableValues, targetName string) string {
+	data, datasetNames, err := telemetryFieldsChartData(tableValues.Fields[1:])
+	if err != nil {
+		slog.Error("error parsing stat", slog.String("error", err.Error()))
+		return ""
+	}
+	chartConfig := chartTemplateStruct{
+		ID:            fmt.Sprintf("%s%d", tableValues.Name, util.RandUint(10000)),
+		XaxisText:     "Time",
+		YaxisText:     "% of time stalled",
+		TitleText:     "",
+		DisplayTitle:  "false",
+		DisplayLegend: "true",
+		AspectRatio:   "2",
+		SuggestedMin:  "0",
+		SuggestedMax:  "0",
+	}
+	return telemetryTableHTMLRenderer(tableValues, data, datasetNames, chartConfig)
+}
+
+func cgroupPressureTelemetryTableHTMLRenderer(tableValues TableValues, targetName string) string {
+	cgroupFieldIdx := 1
+	// split the rows by cgroup, keeping the order in which the cgroups appear
+	var cgroups []string
+	cgroupRows := make(map[string][]int)
+	for i, cgroup := range tableValues.Fields[cgroupFieldIdx].Values {
+		if _, ok := cgroupRows[cgroup]; !ok {
+			cgroups = append(cgroups, cgroup)
+		}
+		cgroupRows[cgroup] = append(cgroupRows[cgroup], i)
+	}
+	var out string
+	for _, cgroup := range cgroups {
+		var fields []Field
+		for _, field := range tableValues.Fields {
+			cgroupField := Field{Name: field.Name}
+			for _, row := range cgroupRows[cgroup] {
+				cgroupField.Values = append(cgroupField.Values, field.Values[row])
+			}
+			fields = append(fields, cgroupField)
+		}
+		data, datasetNames, err := telemetryFieldsChartData(fields[cgroupFieldIdx+1:])
+		if err != nil {
+			slog.Error("error parsing stat", slog.String("cgroup", cgroup), slog.String("error", err.Error()))
+			return ""
+		}
+		chartConfig := chartTemplateStruct{
+			ID:            fmt.Sprintf("%s%d", tableValues.Name, util.RandUint(10000)),
+			XaxisText:     "Time",
+			YaxisText:     "% of time stalled",
+			TitleText:     cgroup,
+			DisplayTitle:  "true",
+			DisplayLegend: "true",
+			AspectRatio:   "2",
+			SuggestedMin:  "0",
+			SuggestedMax:  "0",
+		}
+		out += renderLineChart(fields[0].Values, data, datasetNames, chartConfig)
+	}
+	return out
+}
+
+func schedulerTelemetryTableHTMLRenderer(tableValues TableValues, targetName string) string {
+	// task counts and rates have different scales
+	var out string
+	for _, chart := range []struct {
+		fields    []Field
+		yAxisText string
+	}{
+		{tableValues.Fields[1:3], "tasks"},
+		{tableValues.Fields[3:], "per second"},
+	} {
+		data, datasetNames, err := telemetryFieldsChartData(chart.fields)
+		if err != nil {
+			slog.Error("error parsing stat", slog.String("error", err.Error()))
+			return ""
+		}
+		chartConfig := chartTemplateStruct{
+			ID:            fmt.Sprintf("%s%d", tableValues.Name, util.RandUint(10000)),
+			XaxisText:     "Time",
+			YaxisText:     chart.yAxisText,
+			TitleText:     "",
+			DisplayTitle:  "false",
+			DisplayLegend: "true",
+			AspectRatio:   "2",
+			SuggestedMin:  "0",
+			SuggestedMax:  "0",
+		}
+		out += telemetryTableHTMLRenderer(tableValues, data, datasetNames, chartConfig)
+	}
+	return out
+}
+
+// telemetrySeriesByKey splits a value field into a series for each key, e.g., for each CPU, with a
+// point for each timestamp. Keys are in the order they first appear, missing points are 0.
+func telemetrySeriesByKey(tableValues TableValues, keyFunc func(row int) string, valueFieldIdx int) (timestamps []string, keys []string, series map[string][]float64, err error) {
+	values := make(map[string]map[string]float64) // key -> timestamp -> value
+	for i := range tableValues.Fields[0].Values {
+		timestamp := tableValues.Fields[0].Values[i]
+		if len(timestamps) == 0 || timestamps[len(timestamps)-1] != timestamp {
+			timestamps = append(timestamps, timestamp)
+		}
+		key := keyFunc(i)
+		if _, ok := values[key]; !ok {
+			values[key] = make(map[string]float64)
+			keys = append(keys, key)
+		}
+		if values[key][timestamp], err = strconv.ParseFloat(tableValues.Fields[valueFieldIdx].Values[i], 64); err != nil {
+			return
+		}
+	}
+	series = make(map[string][]float64, len(keys))
+	for _, key := range keys {
+		points := make([]float64, len(timestamps))
+		for i, timestamp := range timestamps {
+			points[i] = values[key][timestamp]
+		}
+		series[key] = points
+	}
+	return
+}
+
+func networkStackTelemetryTableHTMLRenderer(tableValues TableValues, targetName string) string {
+	var out string
+	for _, chart := range []struct {
+		title      string
+		fieldNames []string
+	}{
+		{"TCP", []string{"TcpInSegs/s", "TcpOutSegs/s", "TcpRetransSegs/s", "TcpActiveOpens/s", "TcpPassiveOpens/s"}},
+		{"UDP", []string{"UdpInDatagrams/s", "UdpOutDatagrams/s"}},
+		{"Errors and Drops", []string{"TcpInErrs/s", "TcpOutRsts/s", "TcpExtListenOverflows/s", "TcpExtListenDrops/s", "TcpExtTCPBacklogDrop/s", "TcpExtTCPTimeouts/s", "TcpExtTCPSynRetrans/s", "UdpInErrors/s", "UdpRcvbufErrors/s", "UdpSndbufErrors/s", "UdpNoPorts/s"}},
+	} {
+		var fields []Field
+		for _, field := range tableValues.Fields {
+			if slices.Contains(chart.fieldNames, field.Name) {
+				fields = append(fields, field)
+			}
+		}
+		data, datasetNames, err := telemetryFieldsChartData(fields)
+		if err != nil {
+			slog.Error("error parsing stat", slog.String("error", err.Error()))
+			return ""
+		}
+		chartConfig := chartTemplateStruct{
+			ID:            fmt.Sprintf("%s%d", tableValues.Name, util.RandUint(10000)),
+			XaxisText:     "Time",
+			YaxisText:     "per second",
+			TitleText:     chart.title,
+			DisplayTitle:  "true",
+			DisplayLegend: "true",
+			AspectRatio:   "2",
+			SuggestedMin:  "0",
+			SuggestedMax:  "0",
+		}
+		out += telemetryTableHTMLRenderer(tableValues, data, datasetNames, chartConfig)
+	}
+	return out
+}
+
+// networkSoftirqChartedCPUs is the number of CPUs, those handling the most network softirqs, shown
+// in the network softirq charts
+const networkSoftirqChartedCPUs = 10
+
+func networkSoftirqTelemetryTableHTMLRenderer(tableValues TableValues, targetName string) string {
+	totals, _ := networkSoftirqTotals(tableValues)
+	cpus := make([]string, 0, len(totals))
+	for cpu := range totals {
+		cpus = append(cpus, cpu)
+	}
+	sort.Slice(cpus, func(i, j int) bool {
+		if totals[cpus[i]] != totals[cpus[j]] {
+			return totals[cpus[i]] > totals[cpus[j]]
+		}
+		return cpus[i] < cpus[j]
+	})
+	cpus = cpus[:min(len(cpus), networkSoftirqChartedCPUs)]
+	cpuKey := func(row int) string { return tableValues.Fields[1].Values[row] }
+	var out string
+	for _, chart := range []struct {
+		title         string
+		yAxisText     string
+		valueFieldIdx []int
+	}{
+		{"NET_RX + NET_TX", "softirqs per second", []int{2, 3}},
+		{"Softirq Time", "% of time", []int{4}},
+	} {
+		var timestamps []string
+		sums := make(map[string][]float64)
+		for _, valueFieldIdx := range chart.valueFieldIdx {
+			var series map[string][]float64
+			var err error
+			timestamps, _, series, err = telemetrySeriesByKey(tableValues, cpuKey, valueFieldIdx)
+			if err != nil {
+				slog.Error("error parsing stat", slog.String("error", err.Error()))
+				return ""
+			}
+			for _, cpu := range cpus {
+				if sums[cpu] == nil {
+					sums[cpu] = make([]float64, len(timestamps))
+				}
+				for i, value := range series[cpu] {
+					sums[cpu][i] += value
+				}
+			}
+		}
+		data := [][]float64{}
+		datasetNames := []string{}
+		for _, cpu := range cpus {
+			data = append(data, sums[cpu])
+			datasetNames = append(datasetNames, "CPU "+cpu)
+		}
+		chartConfig := chartTemplateStruct{
+			ID:            fmt.Sprintf("%s%d", tableValues.Name, util.RandUint(10000)),
+			XaxisText:     "Time",
+			YaxisText:     chart.yAxisText,
+			TitleText:     chart.title,
+			DisplayTitle:  "true",
+			DisplayLegend: "true",
+			AspectRatio:   "2",
+			SuggestedMin:  "0",
+			SuggestedMax:  "0",
+		}
+		out += renderLineChart(timestamps, data, datasetNames, chartConfig)
+	}
+	return out
+}
+
+func networkQueueTelemetryTableHTMLRenderer(tableValues TableValues, targetName string) string {
+	ifaceFieldIdx, queueFieldIdx := 1, 2
+	var ifaces []string
+	for _, iface := range tableValues.Fields[ifaceFieldIdx].Values {
+		if !slices.Contains(ifaces, iface) {
+			ifaces = append(ifaces, iface)
+		}
+	}
+	queueKey := func(row int) string {
+		return tableValues.Fields[ifaceFieldIdx].Values[row] + " queue " + tableValues.Fields[queueFieldIdx].Values[row]
+	}
+	var out string
+	for _, valueFieldIdx := range []int{3, 4} {
+		timestamps, keys, series, err := telemetrySeriesByKey(tableValues, queueKey, valueFieldIdx)
+		if err != nil {
+			slog.Error("error parsing stat", slog.String("error", err.Error()))
+			return ""
+		}
+		for _, iface := range ifaces {
+			data := [][]float64{}
+			datasetNames := []string{}
+			for _, key := range keys {
+				if queue, found := strings.CutPrefix(key, iface+" queue "); found {
+					data = append(data, series[key])
+					datasetNames = append(datasetNames, "queue "+queue)
+				}
+			}
+			chartConfig := chartTemplateStruct{
+				ID:            fmt.Sprintf("%s%d", tableValues.Name, util.RandUint(10000)),
+				XaxisText:     "Time",
+				YaxisText:     tableValues.Fields[valueFieldIdx].Name,
+				TitleText:     iface,
+				DisplayTitle:  "true",
+				DisplayLegend: "true",
+				AspectRatio:   "2",
+				SuggestedMin:  "0",
+				SuggestedMax:  "0",
+			}
+			out += renderLineChart(timestamps, data, datasetNames, chartConfig)
+		}
+	}
+	return out
+}
+
+// processTelemetryChartedProcesses is the number of processes, the heaviest CPU consumers, shown
+// in the process telemetry charts
+const processTelemetryChartedProcesses = 10
+
+func processTelemetryTableHTMLRenderer(tableValues TableValues, targetName string) string {
+	pidFieldIdx, commandFieldIdx, cpuFieldIdx, rssFieldIdx := 1, 2, 3, 6
+	processKey := func(row int) string {
+		// quotes would break the chart's javascript
+		command := strings.NewReplacer("'", "", "\\", "").Replace(tableValues.Fields[commandFieldIdx].Values[row])
+		return fmt.Sprintf("%s (%s)", command, tableValues.Fields[pidFieldIdx].Values[row])
+	}
+	// processes are missing from the samples in which they weren't among the top processes
+	timestamps, processes, cpuSeries, err := telemetrySeriesByKey(tableValues, processKey, cpuFieldIdx)
+	if err != nil {
+		slog.Error("error parsing process CPU utilization", slog.String("error", err.Error()))
+		return ""
+	}
+	_, _, rssSeries, err := telemetrySeriesByKey(tableValues, processKey, rssFieldIdx)
+	if err != nil {
+		slog.Error("error parsing process RSS", slog.String("error", err.Error()))
+		return ""
+	}
+	// heaviest CPU consumers first
+	cpuTotals := make(map[string]float64, len(processes))
+	for _, process := range processes {
+		for _, value := range cpuSeries[process] {
+			cpuTotals[process] += value
+		}
+	}
+	sort.Slice(processes, func(i, j int) bool {
+		if cpuTotals[processes[i]] != cpuTotals[processes[j]] {
+			return cpuTotals[processes[i]] > cpuTotals[processes[j]]
+		}
+		return processes[i] < processes[j]
+	})
+	charted := processes[:min(len(processes), processTelemetryChartedProcesses)]
+	cpuData := [][]float64{}
+	rssData := [][]float64{}
+	for _, process := range charted {
+		cpuData = append(cpuData, cpuSeries[process])
+		rssData = append(rssData, rssSeries[process])
+	}
+	cpuDatasetNames := slices.Clone(charted)
+	if len(processes) > len(charted) {
+		other := make([]float64, len(timestamps))
+		for _, process := range processes[len(charted):] {
+			for i, value := range cpuSeries[process] {
+				other[i] += value
+			}
+		}
+		cpuData = append(cpuData, other)
+		cpuDatasetNames = append(cpuDatasetNames, "other")
+	}
+	chartConfig := chartTemplateStruct{
+		ID:            fmt.Sprintf("%s%d", tableValues.Name, util.RandUint(10000)),
+		XaxisText:     "Time",
+		YaxisText:     "% CPU",
+		TitleText:     "CPU Utilization",
+		DisplayTitle:  "true",
+		DisplayLegend: "true",
+		AspectRatio:   "2",
+		SuggestedMin:  "0",
+		SuggestedMax:  "0",
+		Stacked:       true,
+	}
+	out := renderLineChart(timestamps, cpuData, cpuDatasetNames, chartConfig)
+	chartConfig.ID = fmt.Sprintf("%s%d", tableValues.Name, util.RandUint(10000))
+	chartConfig.YaxisText = "RSS (kB)"
+	chartConfig.TitleText = "Resident Memory"
+	out += renderLineChart(timestamps, rssData, charted, chartConfig)
+	return out
+}
+
 func averageFrequencyTelemetryTableHTMLRenderer(tableValues TableValues, targetName string) string {
 	data := [][]float64{}
 	datasetNames := []string{}
@@ -1390,31 +1766,50 @@ func gaudiTelemetryTableHTMLRenderer(tableValues TableValues, targetName string)
 }
 
 func callStackFrequencyTableHTMLRenderer(tableValues TableValues, targetName string) string {
-	out := `<style>
-
-/* Custom page header */
-.fgheader {
-	padding-bottom: 15px;
-	padding-right: 15px;
-	padding-left: 15px;
-	border-bottom: 1px solid #e5e5e5;
+	mode := callStackModeFromTable(tableValues)
+	out := flameGraphStyle
+	for _, stacks := range []string{"Native", "Java"} {
+		stacksTableValues := tableValues
+		if !callStackModeCollects(mode, stacks) {
+			stacksTableValues.NoDataFound = fmt.Sprintf("%s stacks are not collected in %s mode.", stacks, mode)
+		}
+		header := flameGraphHeader(stacks, mode)
+		if stacks == "Native" && isPerCPUFromTable(tableValues) {
+			header += " per CPU" + framePointerStacksNote
+		}
+		out += renderFlameGraph(header, stacksTableValues, stacks+" Stacks")
+	}
+	return out
 }
 
-/* Make the masthead heading the same height as the navigation */
-.fgheader h3 {
-    margin-top: 0;
-    margin-bottom: 0;
-    line-height: 40px;
+// isPerCPUFromTable returns true when the table's native stacks are broken down by CPU
+func isPerCPUFromTable(tableValues TableValues) bool {
+	perCPUFieldIndex, err := getFieldIndex("Per CPU", tableValues)
+	if err != nil || len(tableValues.Fields[perCPUFieldIndex].Values) == 0 {
+		return false
+	}
+	return tableValues.Fields[perCPUFieldIndex].Values[0] == "true"
 }
 
-/* Customize container */
-.fgcontainer {
-	max-width: 990px;
-}
-</style>
-`
-	out += renderFlameGraph("Native", tableValues, "Native Stacks")
-	out += renderFlameGraph("Java", tableValues, "Java Stacks")
+func callStackTimelineTableHTMLRenderer(tableValues TableValues, targetName string) string {
+	out := flameGraphStyle
+	header := flameGraphHeader("Native", CallStackModeCPU) + " over Time" + framePointerStacksNote
+	samplesFieldIndex, err := getFieldIndex("Samples", tableValues)
+	if err != nil {
+		slog.Error("didn't find expected field (Samples) in table", slog.String("error", err.Error()))
+		return out
+	}
+	stacksFieldIndex, err := getFieldIndex("Stacks", tableValues)
+	if err != nil {
+		slog.Error("didn't find expected field (Stacks) in table", slog.String("error", err.Error()))
+		return out
+	}
+	timeline, err := parseCallStackTimeline(tableValues.Fields[samplesFieldIndex].Values[0], tableValues.Fields[stacksFieldIndex].Values[0])
+	if err != nil {
+		slog.Error("failed to parse call stack timeline", slog.String("error", err.Error()))
+		return out
+	}
+	out += renderTimeline(header, timeline, isPerCPUFromTable(tableValues))
 	return out
 }
 
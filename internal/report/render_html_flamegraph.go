@@ -9,13 +9,39 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"math"
 	"perfspect/internal/util"
+	"regexp"
 	"slices"
 	"strconv"
 	"strings"
 	texttemplate "text/template" // nosemgrep
 )
 
+const flameGraphStyle = `<style>
+
+/* Custom page header */
+.fgheader {
+	padding-bottom: 15px;
+	padding-right: 15px;
+	padding-left: 15px;
+	border-bottom: 1px solid #e5e5e5;
+}
+
+/* Make the masthead heading the same height as the navigation */
+.fgheader h3 {
+    margin-top: 0;
+    margin-bottom: 0;
+    line-height: 40px;
+}
+
+/* Customize container */
+.fgcontainer {
+	max-width: 990px;
+}
+</style>
+`
+
 const flameGraphTemplate = `
 <div class="fgcontainer">
 	<div class="fgheader clearfix">
@@ -44,6 +70,19 @@ const flameGraphTemplate = `
     .inverted(false)
 	.sort(true)
 	.minFrameSize(5);
+{{- if .Differential}}
+  // red frames have more samples in the after profile, blue frames have fewer
+  chart{{.ID}}.setColorMapper(function(d, originalColor) {
+    var delta = d.data.delta || 0;
+    var c = Math.round(230 * (1 - Math.min(1, Math.abs(delta) / {{.MaxDelta}})));
+    if (delta > 0) {
+      return "rgb(255," + c + "," + c + ")";
+    } else if (delta < 0) {
+      return "rgb(" + c + "," + c + ",255)";
+    }
+    return "rgb(230,230,230)";
+  });
+{{- end}}
   d3.select("#chart{{.ID}}")
     .datum({{.Data}})
     .call(chart{{.ID}});
@@ -74,9 +113,32 @@ const flameGraphTemplate = `
 `
 
 type flameGraphTemplateStruct struct {
-	ID     string
-	Data   string
-	Header string
+	ID           string
+	Data         string
+	Header       string
+	Differential bool   // color the frames by their delta instead of their name
+	MaxDelta     string // the delta with the most intense color
+}
+
+var flameGraphIDRe = regexp.MustCompile(`[^A-Za-z0-9]`)
+
+// flameGraphID returns a unique ID for a flamegraph's elements and script variables
+func flameGraphID(header string) string {
+	return fmt.Sprintf("%d%s", util.RandUint(10000), flameGraphIDRe.ReplaceAllString(header, ""))
+}
+
+// framePointerStacksNote is added to the headers of the native stacks that are taken from the frame
+// pointer samples only, i.e., without the stacks that needed dwarf unwinding
+const framePointerStacksNote = " (frame pointer stacks only)"
+
+// flameGraphHeader labels a flamegraph of the native or java stacks with what the collection
+// mode's frame widths are
+func flameGraphHeader(stacks string, mode string) string {
+	label, ok := callStackModeLabels[mode]
+	if !ok {
+		label = mode
+	}
+	return stacks + ": " + label
 }
 
 // Folded data conversion adapted from https://github.com/spiermar/burn
@@ -183,7 +245,7 @@ func renderFlameGraph(header string, tableValues TableValues, field string) (out
 	fg := texttemplate.Must(texttemplate.New("flameGraphTemplate").Parse(flameGraphTemplate))
 	buf := new(bytes.Buffer)
 	err = fg.Execute(buf, flameGraphTemplateStruct{
-		ID:     fmt.Sprintf("%d%s", util.RandUint(10000), header),
+		ID:     flameGraphID(header),
 		Data:   jsonStacks,
 		Header: header,
 	})
@@ -196,3 +258,384 @@ func renderFlameGraph(header string, tableValues TableValues, field string) (out
 	out += "\n"
 	return
 }
+
+// diffNode is a frame in a differential flamegraph. Value is the frame's samples in the profile
+// whose shape is rendered, Delta is the change in the frame's samples from the before profile to
+// the after profile, scaled to the rendered profile's total samples.
+type diffNode struct {
+	Name     string
+	Value    int
+	Delta    float64
+	Children map[string]*diffNode
+}
+
+// add adds a stack to the node's children, frames that aren't in the tree are created only if
+// create is true, otherwise the delta stops at the deepest frame that is in the tree
+func (n *diffNode) add(stack []string, value int, delta float64, create bool) {
+	n.Value += value
+	n.Delta += delta
+	if len(stack) == 0 {
+		return
+	}
+	child, ok := n.Children[stack[0]]
+	if !ok {
+		if !create {
+			return
+		}
+		child = &diffNode{Name: stack[0], Children: make(map[string]*diffNode)}
+		n.Children[stack[0]] = child
+	}
+	child.add(stack[1:], value, delta, create)
+}
+
+// maxAbsDelta returns the largest absolute delta of the node's descendants
+func (n *diffNode) maxAbsDelta() (maxDelta float64) {
+	for _, child := range n.Children {
+		maxDelta = max(maxDelta, math.Abs(child.Delta), child.maxAbsDelta())
+	}
+	return
+}
+
+func (n *diffNode) MarshalJSON() ([]byte, error) {
+	v := make([]*diffNode, 0, len(n.Children))
+	for _, value := range n.Children {
+		v = append(v, value)
+	}
+	return json.Marshal(&struct {
+		Name     string      `json:"name"`
+		Value    int         `json:"value"`
+		Delta    float64     `json:"delta"`
+		Children []*diffNode `json:"children"`
+	}{
+		Name:     n.Name,
+		Value:    n.Value,
+		Delta:    math.Round(n.Delta*100) / 100,
+		Children: v,
+	})
+}
+
+// newDiffTree builds the differential tree of the shape profile, which is either the before or
+// the after profile. Both profiles are scaled to the shape profile's total samples, so the deltas
+// are changes in the share of the samples, not in the number of samples.
+func newDiffTree(shape, before, after ProcessStacks, maxStackDepth int) *diffNode {
+	root := &diffNode{Name: "root", Children: make(map[string]*diffNode)}
+	shapeTotal := shape.totalSamples()
+	shape.walkFrames(maxStackDepth, func(frames []string, count int) {
+		root.add(frames, count, 0, true)
+	})
+	for _, profile := range []struct {
+		stacks ProcessStacks
+		sign   float64
+	}{{after, 1}, {before, -1}} {
+		total := profile.stacks.totalSamples()
+		if total == 0 {
+			continue
+		}
+		scale := profile.sign * float64(shapeTotal) / float64(total)
+		profile.stacks.walkFrames(maxStackDepth, func(frames []string, count int) {
+			root.add(frames, 0, float64(count)*scale, false)
+		})
+	}
+	return root
+}
+
+// renderDiffFlameGraphs renders the after profile colored by the change from the before profile,
+// and then the before profile colored the same way, so that frames that are gone after can be seen
+func renderDiffFlameGraphs(header string, before, after ProcessStacks, maxStackDepth int) (out string) {
+	if before.totalSamples() == 0 || after.totalSamples() == 0 {
+		out += `<div class="fgheader clearfix"><h3 class="text-muted">` + header + `</h3></div>`
+		out += noDataFound
+		return
+	}
+	fg := texttemplate.Must(texttemplate.New("flameGraphTemplate").Parse(flameGraphTemplate))
+	for _, graph := range []struct {
+		header string
+		shape  ProcessStacks
+	}{{header + " (after)", after}, {header + " (before)", before}} {
+		tree := newDiffTree(graph.shape, before, after, maxStackDepth)
+		jsonStacks, err := tree.MarshalJSON()
+		if err != nil {
+			slog.Error("failed to convert differential stacks", slog.String("error", err.Error()))
+			return ""
+		}
+		maxDelta := tree.maxAbsDelta()
+		if maxDelta == 0 {
+			maxDelta = 1
+		}
+		buf := new(bytes.Buffer)
+		err = fg.Execute(buf, flameGraphTemplateStruct{
+			ID:           flameGraphID(graph.header),
+			Data:         string(jsonStacks),
+			Header:       graph.header,
+			Differential: true,
+			MaxDelta:     strconv.FormatFloat(maxDelta, 'f', 2, 64),
+		})
+		if err != nil {
+			slog.Error("failed to render flame graph template", slog.String("error", err.Error()))
+			return ""
+		}
+		out += buf.String()
+		out += "\n"
+	}
+	return
+}
+
+// timelineTemplate renders heat strips of the samples over time, a strip per CPU or process, and a
+// flamegraph of the samples in the time range that's selected by dragging across the strips
+const timelineTemplate = `
+<div class="fgcontainer">
+	<div class="fgheader clearfix">
+		<nav>
+			<div class="pull-right">
+			<form class="form-inline" id="form{{.ID}}">
+				<div class="form-group">
+				<label for="group{{.ID}}">Strips</label>
+				<select class="form-control" id="group{{.ID}}">
+					<option value="cpu"{{if .PerCPU}} selected{{end}}>per CPU</option>
+					<option value="process"{{if not .PerCPU}} selected{{end}}>per process</option>
+				</select>
+				</div>
+				<div class="checkbox">
+				<label><input type="checkbox" id="percpu{{.ID}}"{{if .PerCPU}} checked{{end}}> Per-CPU flamegraph</label>
+				</div>
+				<a class="btn" href="javascript: selectAll{{.ID}}();">All samples</a>
+			</form>
+			</div>
+		</nav>
+		<h3 class="text-muted">{{.Header}}</h3>
+	</div>
+	<p id="range{{.ID}}"></p>
+	<div id="strips{{.ID}}" style="position: relative; user-select: none; cursor: crosshair;">
+		<div id="rows{{.ID}}"></div>
+		<div id="selection{{.ID}}" style="position: absolute; top: 0; bottom: 0; background: rgba(0, 0, 255, 0.2); pointer-events: none;"></div>
+	</div>
+	<div id="axis{{.ID}}" style="display: flex; justify-content: space-between; font-size: 11px;"></div>
+	<hr>
+	<div id="chart{{.ID}}"></div>
+	<hr>
+	<div id="details{{.ID}}"></div>
+</div>
+<script type="text/javascript">
+  var timeline{{.ID}} = {{.Data}};
+  var timelineLabelWidth{{.ID}} = 150;
+  var timelineStripWidth{{.ID}} = 840;
+  var timelineBins{{.ID}} = 280;
+  var timelineMaxProcesses{{.ID}} = 32;
+  var timelineRange{{.ID}} = [0, timeline{{.ID}}.duration];
+
+  // the samples of each strip counted in bins of equal time
+  function timelineStrips{{.ID}}(group) {
+    var d = timeline{{.ID}};
+    var binTime = d.duration / timelineBins{{.ID}};
+    var strips = {};
+    var list = [];
+    for (var i = 0; i < d.times.length; i++) {
+      var key = group === "cpu" ? d.cpus[i] : d.names[d.stacks[i]].split(";", 1)[0];
+      var strip = strips[key];
+      if (!strip) {
+        strip = {label: group === "cpu" ? "CPU " + key : key, key: key, total: 0, counts: new Array(timelineBins{{.ID}}).fill(0)};
+        strips[key] = strip;
+        list.push(strip);
+      }
+      strip.counts[Math.min(timelineBins{{.ID}} - 1, Math.floor(d.times[i] / binTime))]++;
+      strip.total++;
+    }
+    if (group === "cpu") {
+      list.sort(function(a, b) { return a.key - b.key; });
+      return list;
+    }
+    // the processes with the fewest samples share a strip
+    list.sort(function(a, b) { return b.total - a.total; });
+    if (list.length > timelineMaxProcesses{{.ID}}) {
+      var other = {label: "other processes", total: 0, counts: new Array(timelineBins{{.ID}}).fill(0)};
+      list.splice(timelineMaxProcesses{{.ID}} - 1).forEach(function(strip) {
+        strip.counts.forEach(function(count, bin) { other.counts[bin] += count; });
+        other.total += strip.total;
+      });
+      list.push(other);
+    }
+    return list;
+  }
+
+  // white bins have no samples, the bins with the most samples are red
+  function timelineDrawStrips{{.ID}}() {
+    var strips = timelineStrips{{.ID}}(document.getElementById("group{{.ID}}").value);
+    var maxCount = 1;
+    strips.forEach(function(strip) { maxCount = Math.max(maxCount, Math.max.apply(null, strip.counts)); });
+    var height = strips.length > 32 ? 6 : 14;
+    var rows = document.getElementById("rows{{.ID}}");
+    rows.innerHTML = "";
+    strips.forEach(function(strip) {
+      var row = document.createElement("div");
+      row.style.display = "flex";
+      row.title = strip.label + ": " + strip.total + " samples";
+      var label = document.createElement("div");
+      label.textContent = strip.label;
+      label.style.cssText = "width: " + timelineLabelWidth{{.ID}} + "px; height: " + height + "px; font-size: " + Math.min(11, height) + "px; line-height: " + height + "px; overflow: hidden; white-space: nowrap;";
+      var canvas = document.createElement("canvas");
+      canvas.width = timelineStripWidth{{.ID}};
+      canvas.height = height;
+      var ctx = canvas.getContext("2d");
+      var binWidth = timelineStripWidth{{.ID}} / timelineBins{{.ID}};
+      strip.counts.forEach(function(count, bin) {
+        var c = Math.round(230 * (1 - count / maxCount));
+        ctx.fillStyle = count === 0 ? "rgb(245,245,245)" : "rgb(255," + c + "," + Math.round(c / 2) + ")";
+        ctx.fillRect(bin * binWidth, 0, Math.ceil(binWidth), height - 1);
+      });
+      row.appendChild(label);
+      row.appendChild(canvas);
+      rows.appendChild(row);
+    });
+    var axis = document.getElementById("axis{{.ID}}");
+    axis.style.marginLeft = timelineLabelWidth{{.ID}} + "px";
+    axis.style.width = timelineStripWidth{{.ID}} + "px";
+    axis.innerHTML = "";
+    [0, 0.25, 0.5, 0.75, 1].forEach(function(fraction) {
+      var tick = document.createElement("span");
+      tick.textContent = (timeline{{.ID}}.duration * fraction).toFixed(1) + "s";
+      axis.appendChild(tick);
+    });
+  }
+
+  function timelineShowRange{{.ID}}() {
+    var d = timeline{{.ID}};
+    var start = timelineRange{{.ID}}[0], end = timelineRange{{.ID}}[1];
+    var selection = document.getElementById("selection{{.ID}}");
+    selection.style.left = (timelineLabelWidth{{.ID}} + start / d.duration * timelineStripWidth{{.ID}}) + "px";
+    selection.style.width = ((end - start) / d.duration * timelineStripWidth{{.ID}}) + "px";
+    selection.style.display = start === 0 && end === d.duration ? "none" : "block";
+    var perCPU = document.getElementById("percpu{{.ID}}").checked;
+    var root = {name: "root", value: 0, children: [], lookup: {}};
+    var frames = {};
+    for (var i = 0; i < d.times.length; i++) {
+      if (d.times[i] < start || d.times[i] > end) {
+        continue;
+      }
+      if (!frames[d.stacks[i]]) {
+        frames[d.stacks[i]] = d.names[d.stacks[i]].split(";");
+      }
+      var stack = perCPU ? ["CPU " + d.cpus[i]].concat(frames[d.stacks[i]]) : frames[d.stacks[i]];
+      var node = root;
+      node.value++;
+      stack.forEach(function(frame) {
+        var child = node.lookup[frame];
+        if (!child) {
+          child = {name: frame, value: 0, children: [], lookup: {}};
+          node.lookup[frame] = child;
+          node.children.push(child);
+        }
+        child.value++;
+        node = child;
+      });
+    }
+    document.getElementById("range{{.ID}}").textContent = "Flamegraph of the " + root.value + " samples from " + start.toFixed(3) + "s to " + end.toFixed(3) + "s. Drag across the strips to select a time range.";
+    var chart = document.getElementById("chart{{.ID}}");
+    chart.innerHTML = "";
+    if (root.value === 0) {
+      return;
+    }
+    var flameChart = flamegraph()
+      .width(990)
+      .cellHeight(18)
+      .inverted(false)
+      .sort(true)
+      .minFrameSize(5);
+    d3.select("#chart{{.ID}}")
+      .datum(root)
+      .call(flameChart);
+    flameChart.setDetailsElement(document.getElementById("details{{.ID}}"));
+  }
+
+  function selectAll{{.ID}}() {
+    timelineRange{{.ID}} = [0, timeline{{.ID}}.duration];
+    timelineShowRange{{.ID}}();
+  }
+
+  (function() {
+    var strips = document.getElementById("strips{{.ID}}");
+    var dragStart = null;
+    // the time at a mouse event's position in the strips
+    function timeAt(event) {
+      var x = event.clientX - strips.getBoundingClientRect().left - timelineLabelWidth{{.ID}};
+      return Math.max(0, Math.min(1, x / timelineStripWidth{{.ID}})) * timeline{{.ID}}.duration;
+    }
+    strips.addEventListener("mousedown", function(event) {
+      event.preventDefault();
+      dragStart = timeAt(event);
+    });
+    strips.addEventListener("mousemove", function(event) {
+      if (dragStart === null) {
+        return;
+      }
+      var t = timeAt(event);
+      var selection = document.getElementById("selection{{.ID}}");
+      selection.style.display = "block";
+      selection.style.left = (timelineLabelWidth{{.ID}} + Math.min(dragStart, t) / timeline{{.ID}}.duration * timelineStripWidth{{.ID}}) + "px";
+      selection.style.width = (Math.abs(t - dragStart) / timeline{{.ID}}.duration * timelineStripWidth{{.ID}}) + "px";
+    });
+    document.addEventListener("mouseup", function(event) {
+      if (dragStart === null) {
+        return;
+      }
+      var t = timeAt(event);
+      // a click selects the bin under the mouse
+      var binTime = timeline{{.ID}}.duration / timelineBins{{.ID}};
+      if (Math.abs(t - dragStart) < binTime) {
+        var bin = Math.min(timelineBins{{.ID}} - 1, Math.floor(dragStart / binTime));
+        timelineRange{{.ID}} = [bin * binTime, (bin + 1) * binTime];
+      } else {
+        timelineRange{{.ID}} = [Math.min(dragStart, t), Math.max(dragStart, t)];
+      }
+      dragStart = null;
+      timelineShowRange{{.ID}}();
+    });
+    document.getElementById("group{{.ID}}").addEventListener("change", timelineDrawStrips{{.ID}});
+    document.getElementById("percpu{{.ID}}").addEventListener("change", timelineShowRange{{.ID}});
+    document.getElementById("form{{.ID}}").addEventListener("submit", function(event) {
+      event.preventDefault();
+    });
+    timelineDrawStrips{{.ID}}();
+    timelineShowRange{{.ID}}();
+  })();
+</script>
+`
+
+type timelineTemplateStruct struct {
+	ID     string
+	Data   string
+	Header string
+	PerCPU bool // the strips and the flamegraph are broken down by CPU initially
+}
+
+// renderTimeline renders the heat strips and the time range flamegraph of a call stack timeline
+func renderTimeline(header string, timeline callStackTimeline, perCPU bool) (out string) {
+	if len(timeline.Times) == 0 {
+		out += `<div class="fgheader clearfix"><h3 class="text-muted">` + header + `</h3></div>`
+		out += noDataFound
+		return
+	}
+	if timeline.Duration == 0 {
+		// all samples at the same time still need a range to be drawn in
+		timeline.Duration = 0.001
+	}
+	jsonTimeline, err := json.Marshal(timeline)
+	if err != nil {
+		slog.Error("failed to convert call stack timeline", slog.String("error", err.Error()))
+		return
+	}
+	tl := texttemplate.Must(texttemplate.New("timelineTemplate").Parse(timelineTemplate))
+	buf := new(bytes.Buffer)
+	err = tl.Execute(buf, timelineTemplateStruct{
+		ID:     flameGraphID(header),
+		Data:   string(jsonTimeline),
+		Header: header,
+		PerCPU: perCPU,
+	})
+	if err != nil {
+		slog.Error("failed to render timeline template", slog.String("error", err.Error()))
+		return
+	}
+	out += buf.String()
+	out += "\n"
+	return
+}
@@ -0,0 +1,216 @@
+package report
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+// HTML renderers for the metrics command's summary tables
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"strconv"
+	"strings"
+)
+
+// exceededThresholdStyle highlights metric values that exceed their threshold
+const exceededThresholdStyle = "background-color:rgba(255,165,0,.4)"
+
+// getFieldValues returns the values of the named field, or nil if the table doesn't have the field
+func getFieldValues(tableValues TableValues, fieldName string) []string {
+	for _, field := range tableValues.Fields {
+		if field.Name == fieldName {
+			return field.Values
+		}
+	}
+	return nil
+}
+
+// metricNameHTML returns the escaped metric name with its description, if any, as a tooltip
+func metricNameHTML(name string, description string) string {
+	if description == "" {
+		return htmltemplate.HTMLEscapeString(name)
+	}
+	return fmt.Sprintf(`<span title="%s">%s</span>`, htmltemplate.HTMLEscapeString(description), htmltemplate.HTMLEscapeString(name))
+}
+
+// metricStatisticsTableHTMLRenderer renders the statistics of each metric. Descriptions are shown
+// as tooltips on the metric names and means that exceed the metric's threshold are highlighted.
+func metricStatisticsTableHTMLRenderer(tableValues TableValues, targetName string) string {
+	descriptions := getFieldValues(tableValues, MetricsDescriptionFieldName)
+	exceeded := getFieldValues(tableValues, MetricsExceededFieldName)
+	var headers []string
+	for _, field := range tableValues.Fields {
+		if field.Name != MetricsDescriptionFieldName {
+			headers = append(headers, field.Name)
+		}
+	}
+	var values [][]string
+	var styles [][]string
+	for row := range tableValues.Fields[0].Values {
+		var rowValues []string
+		var rowStyles []string
+		for _, field := range tableValues.Fields {
+			var value, style string
+			switch field.Name {
+			case MetricsDescriptionFieldName:
+				continue
+			case MetricsMetricFieldName:
+				var description string
+				if descriptions != nil {
+					description = descriptions[row]
+				}
+				value = metricNameHTML(field.Values[row], description)
+			case MetricsMeanFieldName:
+				value = htmltemplate.HTMLEscapeString(field.Values[row])
+				if exceeded != nil && exceeded[row] == "true" {
+					style = exceededThresholdStyle
+				}
+			default:
+				value = htmltemplate.HTMLEscapeString(field.Values[row])
+			}
+			rowValues = append(rowValues, value)
+			rowStyles = append(rowStyles, style)
+		}
+		values = append(values, rowValues)
+		styles = append(styles, rowStyles)
+	}
+	return renderHTMLTable(headers, values, "pure-table pure-table-striped", styles)
+}
+
+// metricStatisticsTableMultiTargetHTMLRenderer renders the mean of each metric on each target side
+// by side. Metrics that aren't collected on a target are left blank for that target.
+func metricStatisticsTableMultiTargetHTMLRenderer(allTableValues []TableValues, targetNames []string) string {
+	type meanValue struct {
+		mean     string
+		exceeded bool
+	}
+	var keys []string // group and metric name, in order of first appearance
+	seen := make(map[string]bool)
+	units := make(map[string]string)
+	descriptions := make(map[string]string)
+	means := make([]map[string]meanValue, len(allTableValues))
+	hasGroups := false
+	for targetIdx, tableValues := range allTableValues {
+		means[targetIdx] = make(map[string]meanValue)
+		groups := getFieldValues(tableValues, MetricsGroupFieldName)
+		hasGroups = hasGroups || groups != nil
+		names := getFieldValues(tableValues, MetricsMetricFieldName)
+		tableUnits := getFieldValues(tableValues, MetricsUnitFieldName)
+		tableDescriptions := getFieldValues(tableValues, MetricsDescriptionFieldName)
+		tableMeans := getFieldValues(tableValues, MetricsMeanFieldName)
+		exceeded := getFieldValues(tableValues, MetricsExceededFieldName)
+		for row, name := range names {
+			key := name
+			if groups != nil {
+				key = groups[row] + "\x00" + name
+			}
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+				if tableUnits != nil {
+					units[key] = tableUnits[row]
+				}
+				if tableDescriptions != nil {
+					descriptions[key] = tableDescriptions[row]
+				}
+			}
+			means[targetIdx][key] = meanValue{mean: tableMeans[row], exceeded: exceeded != nil && exceeded[row] == "true"}
+		}
+	}
+	var headers []string
+	if hasGroups {
+		headers = append(headers, MetricsGroupFieldName)
+	}
+	headers = append(headers, MetricsMetricFieldName, MetricsUnitFieldName)
+	for _, targetName := range targetNames {
+		headers = append(headers, htmltemplate.HTMLEscapeString(targetName)+" "+MetricsMeanFieldName)
+	}
+	var values [][]string
+	var styles [][]string
+	for _, key := range keys {
+		var rowValues []string
+		var rowStyles []string
+		group, name, found := strings.Cut(key, "\x00")
+		if !found {
+			group, name = "", key
+		}
+		if hasGroups {
+			rowValues = append(rowValues, htmltemplate.HTMLEscapeString(group))
+			rowStyles = append(rowStyles, "")
+		}
+		rowValues = append(rowValues, metricNameHTML(name, descriptions[key]), htmltemplate.HTMLEscapeString(units[key]))
+		rowStyles = append(rowStyles, "", "")
+		for targetIdx := range allTableValues {
+			value := means[targetIdx][key]
+			rowValues = append(rowValues, htmltemplate.HTMLEscapeString(value.mean))
+			style := ""
+			if value.exceeded {
+				style = exceededThresholdStyle
+			}
+			rowStyles = append(rowStyles, style)
+		}
+		values = append(values, rowValues)
+		styles = append(styles, rowStyles)
+	}
+	return renderHTMLTable(headers, values, "pure-table pure-table-striped", styles)
+}
+
+// tmaBreakdownTableHTMLRenderer renders the TMA hierarchy with each metric indented by its level and
+// a bar showing its share of the pipeline slots
+func tmaBreakdownTableHTMLRenderer(tableValues TableValues, targetName string) string {
+	groups := getFieldValues(tableValues, MetricsGroupFieldName)
+	levels := getFieldValues(tableValues, MetricsLevelFieldName)
+	names := getFieldValues(tableValues, MetricsMetricFieldName)
+	means := getFieldValues(tableValues, MetricsMeanFieldName)
+	exceeded := getFieldValues(tableValues, MetricsExceededFieldName)
+	var headers []string
+	if groups != nil {
+		headers = append(headers, MetricsGroupFieldName)
+	}
+	headers = append(headers, MetricsMetricFieldName, MetricsMeanFieldName+" (% of pipeline slots)")
+	var values [][]string
+	var styles [][]string
+	for row, name := range names {
+		var rowValues []string
+		var rowStyles []string
+		if groups != nil {
+			rowValues = append(rowValues, htmltemplate.HTMLEscapeString(groups[row]))
+			rowStyles = append(rowStyles, "")
+		}
+		level, err := strconv.Atoi(levels[row])
+		if err != nil || level < 1 {
+			level = 1
+		}
+		rowValues = append(rowValues, htmltemplate.HTMLEscapeString(name))
+		rowStyles = append(rowStyles, fmt.Sprintf("padding-left:%.1fem", 0.5+1.5*float64(level-1)))
+		color := "rgba(54,162,235,.6)"
+		if exceeded != nil && exceeded[row] == "true" {
+			color = "rgba(255,165,0,.8)"
+		}
+		width, err := strconv.ParseFloat(means[row], 64)
+		if err != nil {
+			width = 0
+		}
+		width = min(max(width, 0), 100)
+		bar := fmt.Sprintf(`<div style="display:inline-block;vertical-align:middle;width:200px;height:1em;background-color:#eee"><div style="width:%.1f%%;height:100%%;background-color:%s"></div></div> %s`, width, color, htmltemplate.HTMLEscapeString(means[row]))
+		rowValues = append(rowValues, bar)
+		rowStyles = append(rowStyles, "")
+		values = append(values, rowValues)
+		styles = append(styles, rowStyles)
+	}
+	return renderHTMLTable(headers, values, "pure-table pure-table-striped", styles)
+}
+
+// tmaBreakdownTableMultiTargetHTMLRenderer renders the TMA breakdown of each target, one after the other
+func tmaBreakdownTableMultiTargetHTMLRenderer(allTableValues []TableValues, targetNames []string) string {
+	var sb strings.Builder
+	for targetIdx, tableValues := range allTableValues {
+		sb.WriteString(fmt.Sprintf("<h3>%s</h3>\n", htmltemplate.HTMLEscapeString(targetNames[targetIdx])))
+		if len(tableValues.Fields) == 0 || len(tableValues.Fields[0].Values) == 0 {
+			sb.WriteString("<p>" + tableValues.NoDataFound + "</p>\n")
+			continue
+		}
+		sb.WriteString(tmaBreakdownTableHTMLRenderer(tableValues, targetNames[targetIdx]))
+	}
+	return sb.String()
+}
@@ -0,0 +1,393 @@
+package report
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+// HTML renderers that compare the telemetry of multiple targets
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"log/slog"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"perfspect/internal/util"
+)
+
+const secondsPerDay = 24 * 60 * 60
+
+// telemetrySeries is a target's series of one statistic, e.g., the average CPU utilization, with one
+// value for each sample time
+type telemetrySeries struct {
+	times  []string // HH:MM:SS on the target's clock, or RFC 3339 on the controller's clock
+	values []float64
+}
+
+// telemetrySeriesFunc derives the series to compare from a target's telemetry table
+type telemetrySeriesFunc func(tableValues TableValues) (telemetrySeries, error)
+
+// telemetryFieldSeries returns a telemetrySeriesFunc for the values of the named field. Samples
+// without a value, e.g., "N/A", are skipped.
+func telemetryFieldSeries(fieldName string) telemetrySeriesFunc {
+	return func(tableValues TableValues) (series telemetrySeries, err error) {
+		times := getFieldValues(tableValues, "Time")
+		values := getFieldValues(tableValues, fieldName)
+		if times == nil || values == nil {
+			err = fmt.Errorf("field not found: %s", fieldName)
+			return
+		}
+		for i := range times {
+			value, err := strconv.ParseFloat(values[i], 64)
+			if err != nil {
+				continue
+			}
+			series.times = append(series.times, times[i])
+			series.values = append(series.values, value)
+		}
+		return
+	}
+}
+
+// telemetryFieldsSumSeries returns a telemetrySeriesFunc for the sum of the fields with the prefix
+// in their names, e.g., the power of all packages
+func telemetryFieldsSumSeries(fieldNamePrefix string) telemetrySeriesFunc {
+	return func(tableValues TableValues) (series telemetrySeries, err error) {
+		var fields []Field
+		for _, field := range tableValues.Fields {
+			if strings.HasPrefix(field.Name, fieldNamePrefix) {
+				fields = append(fields, field)
+			}
+		}
+		if len(fields) == 0 {
+			err = fmt.Errorf("no fields found with prefix: %s", fieldNamePrefix)
+			return
+		}
+		for i, sampleTime := range tableValues.Fields[0].Values {
+			sum := 0.0
+			for _, field := range fields {
+				value, err := strconv.ParseFloat(field.Values[i], 64)
+				if err != nil {
+					continue
+				}
+				sum += value
+			}
+			series.times = append(series.times, sampleTime)
+			series.values = append(series.values, sum)
+		}
+		return
+	}
+}
+
+// telemetryRowsSeries returns a telemetrySeriesFunc that combines the rows of each sample, e.g., one
+// row per CPU or device, into one value. The value of each row is the sum of the named fields,
+// optionally subtracted from 100. The rows' values are averaged if mean is true, otherwise summed.
+func telemetryRowsSeries(fieldNames []string, fromHundred bool, mean bool) telemetrySeriesFunc {
+	return func(tableValues TableValues) (series telemetrySeries, err error) {
+		times := getFieldValues(tableValues, "Time")
+		var fields [][]string
+		for _, fieldName := range fieldNames {
+			values := getFieldValues(tableValues, fieldName)
+			if times == nil || values == nil {
+				err = fmt.Errorf("field not found: %s", fieldName)
+				return
+			}
+			fields = append(fields, values)
+		}
+		var counts []int
+		for row, sampleTime := range times {
+			value := 0.0
+			for _, values := range fields {
+				fieldValue, err := strconv.ParseFloat(values[row], 64)
+				if err != nil {
+					continue
+				}
+				value += fieldValue
+			}
+			if fromHundred {
+				value = 100 - value
+			}
+			// the rows of a sample are adjacent
+			if len(series.times) == 0 || series.times[len(series.times)-1] != sampleTime {
+				series.times = append(series.times, sampleTime)
+				series.values = append(series.values, 0)
+				counts = append(counts, 0)
+			}
+			series.values[len(series.values)-1] += value
+			counts[len(counts)-1]++
+		}
+		if mean {
+			for i := range series.values {
+				series.values[i] /= float64(counts[i])
+			}
+		}
+		return
+	}
+}
+
+// telemetrySampleSeconds converts the sample times to seconds. Times with dates, i.e., those normalized
+// to the controller's clock, are seconds since the epoch. Times of day, HH:MM:SS, are seconds since
+// midnight of the first sample's day and a time earlier than the previous sample's time is on the next day.
+func telemetrySampleSeconds(times []string) ([]int, error) {
+	var seconds []int
+	day := 0
+	for i, sampleTime := range times {
+		if t, err := time.Parse(time.RFC3339, sampleTime); err == nil {
+			seconds = append(seconds, int(t.Unix()))
+			continue
+		}
+		t, err := time.Parse("15:04:05", sampleTime)
+		if err != nil {
+			return nil, err
+		}
+		s := t.Hour()*3600 + t.Minute()*60 + t.Second()
+		if i > 0 && s+day < seconds[i-1] {
+			day += secondsPerDay
+		}
+		seconds = append(seconds, s+day)
+	}
+	return seconds, nil
+}
+
+// alignTelemetrySeries places the targets' series on a common timeline with a point for each
+// sampling interval. The targets' samples are matched by their time of day, so series that start at
+// slightly different times line up. Samples that fall into the same interval are averaged and an
+// interval without a sample is a gap, i.e., NaN. If a target's series doesn't overlap the others,
+// e.g., the telemetry was collected at different times, the series are aligned by the time elapsed
+// since their first samples instead.
+func alignTelemetrySeries(allSeries []telemetrySeries) (labels []string, data [][]float64, err error) {
+	allSeconds := make([][]int, len(allSeries))
+	interval := 0
+	for i, series := range allSeries {
+		if allSeconds[i], err = telemetrySampleSeconds(series.times); err != nil {
+			return
+		}
+		for j := 1; j < len(allSeconds[i]); j++ {
+			if diff := allSeconds[i][j] - allSeconds[i][j-1]; diff > 0 && (interval == 0 || diff < interval) {
+				interval = diff
+			}
+		}
+	}
+	interval = max(interval, 1)
+	// move the series onto the first series' day, i.e., to within 12 hours of its first sample
+	first := -1
+	for i := range allSeconds {
+		if len(allSeconds[i]) == 0 {
+			continue
+		}
+		if first == -1 {
+			first = allSeconds[i][0]
+			continue
+		}
+		shift := 0
+		if diff := allSeconds[i][0] - first; diff > secondsPerDay/2 {
+			shift = -secondsPerDay
+		} else if diff < -secondsPerDay/2 {
+			shift = secondsPerDay
+		}
+		for j := range allSeconds[i] {
+			allSeconds[i][j] += shift
+		}
+	}
+	if first == -1 {
+		return
+	}
+	elapsed := !telemetrySeriesOverlap(allSeconds)
+	start := math.MaxInt
+	for i := range allSeconds {
+		if len(allSeconds[i]) == 0 {
+			continue
+		}
+		if elapsed {
+			// each series starts at zero
+			offset := allSeconds[i][0]
+			for j := range allSeconds[i] {
+				allSeconds[i][j] -= offset
+			}
+		}
+		start = min(start, allSeconds[i][0])
+	}
+	numPoints := 0
+	for i := range allSeconds {
+		for _, s := range allSeconds[i] {
+			numPoints = max(numPoints, int(math.Round(float64(s-start)/float64(interval)))+1)
+		}
+	}
+	for i := range numPoints {
+		s := start + i*interval
+		if elapsed {
+			labels = append(labels, strconv.Itoa(s))
+		} else {
+			s = (s%secondsPerDay + secondsPerDay) % secondsPerDay // the time of day
+			labels = append(labels, fmt.Sprintf("%02d:%02d:%02d", s/3600, (s/60)%60, s%60))
+		}
+	}
+	for i, series := range allSeries {
+		points := make([]float64, numPoints)
+		counts := make([]int, numPoints)
+		for j, s := range allSeconds[i] {
+			point := int(math.Round(float64(s-start) / float64(interval)))
+			points[point] += series.values[j]
+			counts[point]++
+		}
+		for j := range points {
+			if counts[j] == 0 {
+				points[j] = math.NaN()
+			} else {
+				points[j] /= float64(counts[j])
+			}
+		}
+		data = append(data, points)
+	}
+	return
+}
+
+// telemetrySeriesOverlap returns true if each series overlaps at least one other series in time
+func telemetrySeriesOverlap(allSeconds [][]int) bool {
+	for i := range allSeconds {
+		if len(allSeconds[i]) == 0 {
+			continue
+		}
+		overlaps := false
+		others := 0
+		for j := range allSeconds {
+			if i == j || len(allSeconds[j]) == 0 {
+				continue
+			}
+			others++
+			if allSeconds[i][0] <= allSeconds[j][len(allSeconds[j])-1] && allSeconds[j][0] <= allSeconds[i][len(allSeconds[i])-1] {
+				overlaps = true
+				break
+			}
+		}
+		if others > 0 && !overlaps {
+			return false
+		}
+	}
+	return true
+}
+
+// telemetryMultiTargetHTMLRenderer renders a chart that overlays the targets' series of the statistic
+// and a table that compares the statistic's summary for each target, followed by each target's own
+// chart of the table
+func telemetryMultiTargetHTMLRenderer(allTableValues []TableValues, targetNames []string, statName string, yAxisText string, suggestedMax string, seriesFunc telemetrySeriesFunc) string {
+	var sb strings.Builder
+	var allSeries []telemetrySeries
+	var datasetNames []string
+	summaryHeaders := []string{"Target", "Start", "End", "Samples", "Mean", "Min", "Max"}
+	var summaryValues [][]string
+	for targetIdx, tableValues := range allTableValues {
+		targetName := htmltemplate.HTMLEscapeString(targetNames[targetIdx])
+		var series telemetrySeries
+		if len(tableValues.Fields) > 0 && len(tableValues.Fields[0].Values) > 0 {
+			var err error
+			if series, err = seriesFunc(tableValues); err != nil {
+				slog.Error("failed to get telemetry series", slog.String("table", tableValues.Name), slog.String("target", targetNames[targetIdx]), slog.String("error", err.Error()))
+			}
+		}
+		if len(series.values) == 0 {
+			summaryValues = append(summaryValues, []string{targetName, "", "", "0", "", "", ""})
+			continue
+		}
+		allSeries = append(allSeries, series)
+		datasetNames = append(datasetNames, targetName)
+		sum := 0.0
+		for _, value := range series.values {
+			sum += value
+		}
+		mean := sum / float64(len(series.values))
+		summaryValues = append(summaryValues, []string{
+			targetName,
+			series.times[0],
+			series.times[len(series.times)-1],
+			strconv.Itoa(len(series.values)),
+			strconv.FormatFloat(mean, 'f', 2, 64),
+			strconv.FormatFloat(slices.Min(series.values), 'f', 2, 64),
+			strconv.FormatFloat(slices.Max(series.values), 'f', 2, 64),
+		})
+	}
+	if len(allSeries) == 0 {
+		return "<p>" + noDataFound + "</p>\n"
+	}
+	labels, data, err := alignTelemetrySeries(allSeries)
+	if err != nil {
+		slog.Error("failed to align telemetry series", slog.String("table", allTableValues[0].Name), slog.String("error", err.Error()))
+		return ""
+	}
+	xAxisText := "Time"
+	if len(labels) > 0 && !strings.Contains(labels[0], ":") {
+		xAxisText = "Elapsed Time (seconds)"
+	} else if strings.Contains(allSeries[0].times[0], "T") {
+		xAxisText = "Time (UTC)"
+	}
+	chartConfig := chartTemplateStruct{
+		ID:            fmt.Sprintf("%s%d", strings.ReplaceAll(allTableValues[0].Name, " ", ""), util.RandUint(10000)),
+		XaxisText:     xAxisText,
+		YaxisText:     yAxisText,
+		TitleText:     statName,
+		DisplayTitle:  "true",
+		DisplayLegend: "true",
+		AspectRatio:   "2",
+		SuggestedMin:  "0",
+		SuggestedMax:  suggestedMax,
+	}
+	sb.WriteString(renderLineChart(labels, data, datasetNames, chartConfig))
+	sb.WriteString(fmt.Sprintf("<h3>%s Summary</h3>\n", htmltemplate.HTMLEscapeString(statName)))
+	sb.WriteString(renderHTMLTable(summaryHeaders, summaryValues, "pure-table pure-table-striped", [][]string{}))
+	// each target's own chart
+	for targetIdx, tableValues := range allTableValues {
+		if tableValues.HTMLTableRendererFunc == nil || len(tableValues.Fields) == 0 || len(tableValues.Fields[0].Values) == 0 {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("<h3>%s</h3>\n", htmltemplate.HTMLEscapeString(targetNames[targetIdx])))
+		sb.WriteString(tableValues.HTMLTableRendererFunc(tableValues, targetNames[targetIdx]))
+	}
+	return sb.String()
+}
+
+func cpuUtilizationTelemetryTableMultiTargetHTMLRenderer(allTableValues []TableValues, targetNames []string) string {
+	return telemetryMultiTargetHTMLRenderer(allTableValues, targetNames, "Average CPU Utilization", "% Utilization", "100", telemetryRowsSeries([]string{"%idle"}, true, true))
+}
+
+func utilizationCategoriesTelemetryTableMultiTargetHTMLRenderer(allTableValues []TableValues, targetNames []string) string {
+	return telemetryMultiTargetHTMLRenderer(allTableValues, targetNames, "User and System Utilization", "% Utilization", "100", telemetryRowsSeries([]string{"%usr", "%sys"}, false, true))
+}
+
+func ipcTelemetryTableMultiTargetHTMLRenderer(allTableValues []TableValues, targetNames []string) string {
+	return telemetryMultiTargetHTMLRenderer(allTableValues, targetNames, "Average Core IPC", "IPC", "0", telemetryFieldSeries("Core (Avg.)"))
+}
+
+func c6TelemetryTableMultiTargetHTMLRenderer(allTableValues []TableValues, targetNames []string) string {
+	return telemetryMultiTargetHTMLRenderer(allTableValues, targetNames, "Average Core C6 Residency", "% C6 Residency", "100", telemetryFieldSeries("Core (Avg.)"))
+}
+
+func frequencyTelemetryTableMultiTargetHTMLRenderer(allTableValues []TableValues, targetNames []string) string {
+	return telemetryMultiTargetHTMLRenderer(allTableValues, targetNames, "Average Core Frequency", "MHz", "0", telemetryFieldSeries("Core (Avg.)"))
+}
+
+func irqRateTelemetryTableMultiTargetHTMLRenderer(allTableValues []TableValues, targetNames []string) string {
+	return telemetryMultiTargetHTMLRenderer(allTableValues, targetNames, "Total IRQ Rate", "IRQs/s", "0", telemetryRowsSeries([]string{"HI/s", "TIMER/s", "NET_TX/s", "NET_RX/s", "BLOCK/s", "IRQ_POLL/s", "TASKLET/s", "SCHED/s", "HRTIMER/s", "RCU/s"}, false, false))
+}
+
+func driveTelemetryTableMultiTargetHTMLRenderer(allTableValues []TableValues, targetNames []string) string {
+	return telemetryMultiTargetHTMLRenderer(allTableValues, targetNames, "Total Drive Throughput (Read + Write)", "kB/s", "0", telemetryRowsSeries([]string{"kB_read/s", "kB_wrtn/s"}, false, false))
+}
+
+func networkTelemetryTableMultiTargetHTMLRenderer(allTableValues []TableValues, targetNames []string) string {
+	return telemetryMultiTargetHTMLRenderer(allTableValues, targetNames, "Total Network Throughput (Receive + Transmit)", "kB/s", "0", telemetryRowsSeries([]string{"rxkB/s", "txkB/s"}, false, false))
+}
+
+func memoryTelemetryTableMultiTargetHTMLRenderer(allTableValues []TableValues, targetNames []string) string {
+	return telemetryMultiTargetHTMLRenderer(allTableValues, targetNames, "Used Memory", "kilobytes", "0", telemetryFieldSeries("used"))
+}
+
+func powerTelemetryTableMultiTargetHTMLRenderer(allTableValues []TableValues, targetNames []string) string {
+	return telemetryMultiTargetHTMLRenderer(allTableValues, targetNames, "Total Package Power", "Watts", "0", telemetryFieldsSumSeries("Package "))
+}
+
+func temperatureTelemetryTableMultiTargetHTMLRenderer(allTableValues []TableValues, targetNames []string) string {
+	return telemetryMultiTargetHTMLRenderer(allTableValues, targetNames, "Average Core Temperature", "Celsius", "0", telemetryFieldSeries("Core (Avg.)"))
+}
@@ -0,0 +1,104 @@
+package report
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assertPoints compares chart points, NaN is a gap
+func assertPoints(t *testing.T, expected []float64, actual []float64) {
+	require.Len(t, actual, len(expected))
+	for i := range expected {
+		if math.IsNaN(expected[i]) {
+			assert.True(t, math.IsNaN(actual[i]), "point %d", i)
+		} else {
+			assert.InDelta(t, expected[i], actual[i], 0.001, "point %d", i)
+		}
+	}
+}
+
+func TestAlignTelemetrySeries(t *testing.T) {
+	// the second target starts later, misses a sample and samples a second later in each interval
+	labels, data, err := alignTelemetrySeries([]telemetrySeries{
+		{times: []string{"10:00:00", "10:00:02", "10:00:04", "10:00:06"}, values: []float64{1, 2, 3, 4}},
+		{times: []string{"10:00:03", "10:00:07"}, values: []float64{10, 30}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10:00:00", "10:00:02", "10:00:04", "10:00:06", "10:00:08"}, labels)
+	require.Len(t, data, 2)
+	assertPoints(t, []float64{1, 2, 3, 4, math.NaN()}, data[0])
+	assertPoints(t, []float64{math.NaN(), math.NaN(), 10, math.NaN(), 30}, data[1])
+}
+
+func TestAlignTelemetrySeriesMidnight(t *testing.T) {
+	labels, data, err := alignTelemetrySeries([]telemetrySeries{
+		{times: []string{"23:59:58", "23:59:59", "00:00:00"}, values: []float64{1, 2, 3}},
+		{times: []string{"00:00:00", "00:00:01"}, values: []float64{10, 20}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"23:59:58", "23:59:59", "00:00:00", "00:00:01"}, labels)
+	assertPoints(t, []float64{1, 2, 3, math.NaN()}, data[0])
+	assertPoints(t, []float64{math.NaN(), math.NaN(), 10, 20}, data[1])
+}
+
+func TestAlignTelemetrySeriesElapsed(t *testing.T) {
+	// series that were collected at different times are aligned by their elapsed times
+	labels, data, err := alignTelemetrySeries([]telemetrySeries{
+		{times: []string{"10:00:00", "10:00:01"}, values: []float64{1, 2}},
+		{times: []string{"14:30:00", "14:30:01", "14:30:02"}, values: []float64{10, 20, 30}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0", "1", "2"}, labels)
+	assertPoints(t, []float64{1, 2, math.NaN()}, data[0])
+	assertPoints(t, []float64{10, 20, 30}, data[1])
+}
+
+func TestAlignTelemetrySeriesNormalized(t *testing.T) {
+	// times on the controller's clock line up regardless of the targets' days
+	labels, data, err := alignTelemetrySeries([]telemetrySeries{
+		{times: []string{"2025-06-01T23:59:59Z", "2025-06-02T00:00:00Z"}, values: []float64{1, 2}},
+		{times: []string{"2025-06-02T00:00:00Z", "2025-06-02T00:00:01Z"}, values: []float64{10, 20}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"23:59:59", "00:00:00", "00:00:01"}, labels)
+	assertPoints(t, []float64{1, 2, math.NaN()}, data[0])
+	assertPoints(t, []float64{math.NaN(), 10, 20}, data[1])
+}
+
+func TestTelemetryRowsSeries(t *testing.T) {
+	tableValues := TableValues{Fields: []Field{
+		{Name: "Time", Values: []string{"10:00:00", "10:00:00", "10:00:01", "10:00:01"}},
+		{Name: "CPU", Values: []string{"0", "1", "0", "1"}},
+		{Name: "%idle", Values: []string{"90", "70", "50", "N/A"}},
+	}}
+	series, err := telemetryRowsSeries([]string{"%idle"}, true, true)(tableValues)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10:00:00", "10:00:01"}, series.times)
+	assert.Equal(t, []float64{20, 75}, series.values)
+	_, err = telemetryRowsSeries([]string{"%usr"}, false, false)(tableValues)
+	assert.Error(t, err)
+}
+
+func TestTelemetryMultiTargetHTMLRenderer(t *testing.T) {
+	allTableValues := []TableValues{
+		{TableDefinition: TableDefinition{Name: PowerTelemetryTableName}, Fields: []Field{
+			{Name: "Time", Values: []string{"10:00:00", "10:00:01"}},
+			{Name: "Package 0", Values: []string{"100", "110"}},
+			{Name: "DRAM 0", Values: []string{"10", "10"}},
+			{Name: "Package 1", Values: []string{"50", "60"}},
+		}},
+		{TableDefinition: TableDefinition{Name: PowerTelemetryTableName}},
+	}
+	out := powerTelemetryTableMultiTargetHTMLRenderer(allTableValues, []string{"host1", "host2"})
+	assert.Contains(t, out, "label: 'host1'")
+	assert.NotContains(t, out, "label: 'host2'")
+	// the summary of each target
+	assert.Contains(t, out, "<td>host1</td><td>10:00:00</td><td>10:00:01</td><td>2</td><td>160.00</td><td>150.00</td><td>170.00</td>")
+	assert.Contains(t, out, "<td>host2</td><td></td><td></td><td>0</td>")
+}
@@ -0,0 +1,214 @@
+package report
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+// profile formats export the call stacks of the Call Stack Frequency table to other profile viewers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/pprof/profile"
+)
+
+// callStackProfile is the native or java stacks of a Call Stack Frequency table
+type callStackProfile struct {
+	name   string // native or java
+	stacks ProcessStacks
+}
+
+// callStackProfilesFromTables returns the native and java stacks, and the collection mode, of the
+// Call Stack Frequency table
+func callStackProfilesFromTables(allTableValues []TableValues) (profiles []callStackProfile, mode string, err error) {
+	idx := slices.IndexFunc(allTableValues, func(tableValues TableValues) bool {
+		return tableValues.Name == CallStackFrequencyTableName
+	})
+	if idx == -1 {
+		err = fmt.Errorf("no %s table to export", CallStackFrequencyTableName)
+		return
+	}
+	tableValues := allTableValues[idx]
+	for _, field := range []string{"Native Stacks", "Java Stacks"} {
+		stacks := make(ProcessStacks)
+		if fieldIdx, fieldErr := getFieldIndex(field, tableValues); fieldErr == nil && len(tableValues.Fields[fieldIdx].Values) > 0 {
+			stacks.parseFolded(tableValues.Fields[fieldIdx].Values[0])
+		}
+		profiles = append(profiles, callStackProfile{name: strings.ToLower(strings.TrimSuffix(field, " Stacks")), stacks: stacks})
+	}
+	mode = callStackModeFromTable(tableValues)
+	return
+}
+
+// sortedStacks calls fn with the process name, frames and count of each stack, in order
+func (p *ProcessStacks) sortedStacks(fn func(processName string, frames []string, count int)) {
+	for _, processName := range slices.Sorted(maps.Keys(*p)) {
+		stacks := (*p)[processName]
+		for _, stack := range slices.Sorted(maps.Keys(stacks)) {
+			fn(processName, strings.Split(stack, ";"), stacks[stack])
+		}
+	}
+}
+
+// pprofSampleTypes are the pprof sample type and unit of each collection mode
+var pprofSampleTypes = map[string][2]string{
+	CallStackModeCPU:    {"samples", "count"},
+	CallStackModeOffCPU: {"off_cpu", "microseconds"},
+	CallStackModeWall:   {"wall_samples", "count"},
+	CallStackModeAlloc:  {"alloc_samples", "count"},
+	CallStackModeLock:   {"lock_samples", "count"},
+}
+
+// createPprofReport creates a gzipped pprof protobuf profile of the call stacks. The samples are
+// labeled with their process and whether they're native or java stacks.
+func createPprofReport(allTableValues []TableValues) (out []byte, err error) {
+	profiles, mode, err := callStackProfilesFromTables(allTableValues)
+	if err != nil {
+		return
+	}
+	sampleType, ok := pprofSampleTypes[mode]
+	if !ok {
+		sampleType = pprofSampleTypes[CallStackModeCPU]
+	}
+	prof := &profile.Profile{
+		SampleType:        []*profile.ValueType{{Type: sampleType[0], Unit: sampleType[1]}},
+		DefaultSampleType: sampleType[0],
+	}
+	// a location per function, pprof's frames are functions, not addresses
+	locations := make(map[string]*profile.Location)
+	for _, callStacks := range profiles {
+		callStacks.stacks.sortedStacks(func(processName string, frames []string, count int) {
+			sample := &profile.Sample{
+				Value: []int64{int64(count)},
+				Label: map[string][]string{"process": {processName}, "stacks": {callStacks.name}},
+			}
+			// pprof's stacks start with the innermost frame
+			for i := len(frames) - 1; i >= 0; i-- {
+				location, ok := locations[frames[i]]
+				if !ok {
+					function := &profile.Function{ID: uint64(len(prof.Function) + 1), Name: frames[i], SystemName: frames[i]}
+					prof.Function = append(prof.Function, function)
+					location = &profile.Location{ID: uint64(len(prof.Location) + 1), Line: []profile.Line{{Function: function}}}
+					prof.Location = append(prof.Location, location)
+					locations[frames[i]] = location
+				}
+				sample.Location = append(sample.Location, location)
+			}
+			prof.Sample = append(prof.Sample, sample)
+		})
+	}
+	if err = prof.CheckValid(); err != nil {
+		err = fmt.Errorf("invalid pprof profile: %w", err)
+		return
+	}
+	buf := new(bytes.Buffer)
+	if err = prof.Write(buf); err != nil { // gzipped
+		return
+	}
+	out = buf.Bytes()
+	return
+}
+
+// speedscope file format, https://www.speedscope.app/file-format-schema.json
+type speedscopeFile struct {
+	Schema             string              `json:"$schema"`
+	Shared             speedscopeShared    `json:"shared"`
+	Profiles           []speedscopeProfile `json:"profiles"`
+	Name               string              `json:"name"`
+	ActiveProfileIndex int                 `json:"activeProfileIndex"`
+	Exporter           string              `json:"exporter"`
+}
+
+type speedscopeShared struct {
+	Frames []speedscopeFrame `json:"frames"`
+}
+
+type speedscopeFrame struct {
+	Name string `json:"name"`
+}
+
+type speedscopeProfile struct {
+	Type       string  `json:"type"`
+	Name       string  `json:"name"`
+	Unit       string  `json:"unit"`
+	StartValue int     `json:"startValue"`
+	EndValue   int     `json:"endValue"`
+	Samples    [][]int `json:"samples"` // frame indices, outermost frame first
+	Weights    []int   `json:"weights"`
+}
+
+// createSpeedscopeReport creates a speedscope profile of the call stacks, with a profile for each
+// process's native and java stacks, the heaviest first
+func createSpeedscopeReport(allTableValues []TableValues, targetName string) (out []byte, err error) {
+	profiles, mode, err := callStackProfilesFromTables(allTableValues)
+	if err != nil {
+		return
+	}
+	unit := "none"
+	if mode == CallStackModeOffCPU {
+		unit = "microseconds"
+	}
+	file := speedscopeFile{
+		Schema:   "https://www.speedscope.app/file-format-schema.json",
+		Shared:   speedscopeShared{Frames: []speedscopeFrame{}},
+		Profiles: []speedscopeProfile{},
+		Name:     fmt.Sprintf("%s %s", targetName, mode),
+		Exporter: "perfspect",
+	}
+	frameIndices := make(map[string]int)
+	for _, callStacks := range profiles {
+		processProfiles := make(map[string]*speedscopeProfile)
+		callStacks.stacks.sortedStacks(func(processName string, frames []string, count int) {
+			processProfile, ok := processProfiles[processName]
+			if !ok {
+				processProfile = &speedscopeProfile{Type: "sampled", Name: fmt.Sprintf("%s (%s)", processName, callStacks.name), Unit: unit, Samples: [][]int{}, Weights: []int{}}
+				processProfiles[processName] = processProfile
+			}
+			sample := make([]int, 0, len(frames))
+			for _, frame := range frames {
+				idx, ok := frameIndices[frame]
+				if !ok {
+					idx = len(file.Shared.Frames)
+					file.Shared.Frames = append(file.Shared.Frames, speedscopeFrame{Name: frame})
+					frameIndices[frame] = idx
+				}
+				sample = append(sample, idx)
+			}
+			processProfile.Samples = append(processProfile.Samples, sample)
+			processProfile.Weights = append(processProfile.Weights, count)
+			processProfile.EndValue += count
+		})
+		for _, processName := range slices.Sorted(maps.Keys(processProfiles)) {
+			file.Profiles = append(file.Profiles, *processProfiles[processName])
+		}
+	}
+	slices.SortStableFunc(file.Profiles, func(a, b speedscopeProfile) int {
+		return b.EndValue - a.EndValue
+	})
+	return json.MarshalIndent(file, "", " ")
+}
+
+var foldedFileNameRe = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// CreateFoldedReports creates a folded stacks file for each process's native and java stacks, the
+// files are keyed by <native|java>_<process name>, with characters that aren't safe in file names
+// replaced
+func CreateFoldedReports(allTableValues []TableValues) (map[string][]byte, error) {
+	profiles, _, err := callStackProfilesFromTables(allTableValues)
+	if err != nil {
+		return nil, err
+	}
+	reports := make(map[string][]byte)
+	for _, callStacks := range profiles {
+		callStacks.stacks.sortedStacks(func(processName string, frames []string, count int) {
+			name := callStacks.name + "_" + strings.Trim(foldedFileNameRe.ReplaceAllString(processName, "_"), "_")
+			reports[name] = append(reports[name], fmt.Sprintf("%s %d\n", strings.Join(frames, ";"), count)...)
+		})
+	}
+	return reports, nil
+}
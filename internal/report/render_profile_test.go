@@ -0,0 +1,89 @@
+package report
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/pprof/profile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCallStackTables(mode string) []TableValues {
+	return []TableValues{
+		{TableDefinition: TableDefinition{Name: BriefSysSummaryTableName}},
+		{TableDefinition: TableDefinition{Name: CallStackFrequencyTableName}, Fields: []Field{
+			{Name: "Native Stacks", Values: []string{"app;main;compute 30\napp;main;parse;read 10\nkworker/0:1;worker 5\n"}},
+			{Name: "Java Stacks", Values: []string{"java (42);Main.run;Work.a 7\n"}},
+			{Name: "Maximum Render Depth", Values: []string{"0"}},
+			{Name: "Mode", Values: []string{mode}},
+		}},
+	}
+}
+
+func TestCreatePprofReport(t *testing.T) {
+	out, err := createPprofReport(testCallStackTables(CallStackModeOffCPU))
+	require.NoError(t, err)
+	prof, err := profile.Parse(bytes.NewReader(out))
+	require.NoError(t, err)
+	require.Len(t, prof.SampleType, 1)
+	assert.Equal(t, "off_cpu", prof.SampleType[0].Type)
+	assert.Equal(t, "microseconds", prof.SampleType[0].Unit)
+	require.Len(t, prof.Sample, 4)
+	var read *profile.Sample
+	for _, sample := range prof.Sample {
+		if sample.Location[0].Line[0].Function.Name == "read" {
+			read = sample
+		}
+	}
+	require.NotNil(t, read)
+	// innermost frame first
+	var frames []string
+	for _, location := range read.Location {
+		frames = append(frames, location.Line[0].Function.Name)
+	}
+	assert.Equal(t, []string{"read", "parse", "main"}, frames)
+	assert.Equal(t, []int64{10}, read.Value)
+	assert.Equal(t, map[string][]string{"process": {"app"}, "stacks": {"native"}}, read.Label)
+	// functions are shared by the stacks
+	assert.Len(t, prof.Function, 7)
+
+	_, err = createPprofReport([]TableValues{{TableDefinition: TableDefinition{Name: BriefSysSummaryTableName}}})
+	assert.Error(t, err)
+}
+
+func TestCreateSpeedscopeReport(t *testing.T) {
+	out, err := createSpeedscopeReport(testCallStackTables(CallStackModeCPU), "host")
+	require.NoError(t, err)
+	var file speedscopeFile
+	require.NoError(t, json.Unmarshal(out, &file))
+	assert.Equal(t, "host cpu", file.Name)
+	require.Len(t, file.Profiles, 3)
+	// the heaviest profile first
+	app := file.Profiles[0]
+	assert.Equal(t, "app (native)", app.Name)
+	assert.Equal(t, "none", app.Unit)
+	assert.Equal(t, 40, app.EndValue)
+	assert.Equal(t, []int{30, 10}, app.Weights)
+	var frames []string
+	for _, idx := range app.Samples[1] {
+		frames = append(frames, file.Shared.Frames[idx].Name)
+	}
+	assert.Equal(t, []string{"main", "parse", "read"}, frames)
+	assert.Equal(t, "java (42) (java)", file.Profiles[1].Name)
+	assert.Equal(t, "kworker/0:1 (native)", file.Profiles[2].Name)
+}
+
+func TestCreateFoldedReports(t *testing.T) {
+	reports, err := CreateFoldedReports(testCallStackTables(CallStackModeCPU))
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"native_app":         []byte("main;compute 30\nmain;parse;read 10\n"),
+		"native_kworker_0_1": []byte("worker 5\n"),
+		"java_java_42":       []byte("Main.run;Work.a 7\n"),
+	}, reports)
+}
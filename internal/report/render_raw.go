@@ -8,24 +8,29 @@ import (
 	"fmt"
 	"os"
 	"perfspect/internal/script"
+	"perfspect/internal/target"
 	"strings"
 )
 
-// RawReport represents a raw report containing the target name, table names, and script outputs.
+// RawReport represents a raw report containing the target name, table names, script outputs, and the
+// target's clock offset, if it was measured.
 type RawReport struct {
 	TargetName    string                         // json:"target_name"
 	TableNames    []string                       // json:"table_names"
 	ScriptOutputs map[string]script.ScriptOutput // json:"script_outputs"
+	ClockOffset   *target.ClockOffset            `json:",omitempty"`
 }
 
-// CreateRawReport creates a raw report with the specified table names, script outputs, and target name.
+// CreateRawReport creates a raw report with the specified table names, script outputs, target name, and
+// clock offset, which may be nil.
 // It marshals the report into a JSON format with indentation for readability.
 // The function returns the JSON byte slice and any error encountered during the process.
-func CreateRawReport(tableNames []string, scriptOutputs map[string]script.ScriptOutput, targetName string) (out []byte, err error) {
+func CreateRawReport(tableNames []string, scriptOutputs map[string]script.ScriptOutput, targetName string, clockOffset *target.ClockOffset) (out []byte, err error) {
 	report := RawReport{
 		TargetName:    targetName,
 		TableNames:    tableNames,
 		ScriptOutputs: scriptOutputs,
+		ClockOffset:   clockOffset,
 	}
 	out, err = json.MarshalIndent(report, "", " ")
 	return
@@ -18,15 +18,35 @@ const (
 	FormatTxt  = "txt"
 	FormatRaw  = "raw"
 	FormatAll  = "all"
+	// call stack profile formats
+	FormatPprof      = "pprof"
+	FormatSpeedscope = "speedscope"
+	FormatFolded     = "folded"
 )
 
 const noDataFound = "No data found."
 
 var FormatOptions = []string{FormatHtml, FormatXlsx, FormatJson, FormatTxt}
 
+// ProfileFormatOptions are the formats that export the call stacks of the Call Stack Frequency
+// table, they aren't included in FormatAll
+var ProfileFormatOptions = []string{FormatPprof, FormatSpeedscope, FormatFolded}
+
+// FileExtension returns the extension of a report file in the format, the viewers of some formats
+// recognize their files by extension
+func FileExtension(format string) string {
+	switch format {
+	case FormatPprof:
+		return "pb.gz"
+	case FormatSpeedscope:
+		return "speedscope.json"
+	}
+	return format
+}
+
 // Create generates a report in the specified format based on the provided tables, table values, and script outputs.
 // The function ensures that all fields have the same number of values before generating the report.
-// It supports formats such as txt, json, html, xlsx.
+// It supports formats such as txt, json, html, xlsx, and the pprof and speedscope call stack profile formats.
 // If the format is not supported, the function panics with an error message.
 //
 // Parameters:
@@ -62,6 +82,10 @@ func Create(format string, allTableValues []TableValues, scriptOutputs map[strin
 		return createHtmlReport(allTableValues, targetName)
 	case FormatXlsx:
 		return createXlsxReport(allTableValues)
+	case FormatPprof:
+		return createPprofReport(allTableValues)
+	case FormatSpeedscope:
+		return createSpeedscopeReport(allTableValues, targetName)
 	}
 	panic(fmt.Sprintf("expected one of %s, got %s", strings.Join(FormatOptions, ", "), format))
 }
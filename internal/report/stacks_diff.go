@@ -0,0 +1,149 @@
+package report
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// CallStackDiffTableName is the name of the table that compares the call stacks of two collections
+const CallStackDiffTableName = "Call Stack Differences"
+
+// functionChange is the change in a function's share of the samples of a profile
+type functionChange struct {
+	profile  string
+	function string
+	before   functionShare
+	after    functionShare
+}
+
+// magnitude is the larger of the changes in the inclusive and exclusive shares
+func (c functionChange) magnitude() float64 {
+	return max(math.Abs(c.after.inclusive-c.before.inclusive), math.Abs(c.after.exclusive-c.before.exclusive))
+}
+
+// DiffCallStacks compares the Call Stack Frequency tables of a collection before and after a
+// change. The stacks are normalized so that PIDs and unresolved addresses don't differ between the
+// collections, and the samples are compared as shares of each collection's total, so the
+// collections may differ in duration and frequency. The returned table lists the functions whose
+// inclusive or exclusive share changed most, at most top of them if top isn't 0. HTML reports
+// render red/blue differential flamegraphs of the native and java stacks before the table.
+func DiffCallStacks(before, after TableValues, top int) (TableValues, error) {
+	profiles := []struct {
+		name   string
+		field  string
+		before ProcessStacks
+		after  ProcessStacks
+	}{
+		{name: "Native", field: "Native Stacks"},
+		{name: "Java", field: "Java Stacks"},
+	}
+	for i := range profiles {
+		for _, side := range []struct {
+			tableValues TableValues
+			stacks      *ProcessStacks
+		}{{before, &profiles[i].before}, {after, &profiles[i].after}} {
+			fieldIdx, err := getFieldIndex(profiles[i].field, side.tableValues)
+			if err != nil || len(side.tableValues.Fields[fieldIdx].Values) == 0 {
+				return TableValues{}, fmt.Errorf("%s not found in the %s table", profiles[i].field, CallStackFrequencyTableName)
+			}
+			stacks := make(ProcessStacks)
+			stacks.parseFolded(side.tableValues.Fields[fieldIdx].Values[0])
+			*side.stacks = stacks.normalized()
+		}
+	}
+	// the frame widths of collections of different modes aren't comparable
+	mode := callStackModeFromTable(after)
+	if beforeMode := callStackModeFromTable(before); beforeMode != mode {
+		return TableValues{}, fmt.Errorf("the before collection's mode, %s, differs from the after collection's mode, %s", beforeMode, mode)
+	}
+	if profiles[0].before.totalSamples()+profiles[1].before.totalSamples() == 0 {
+		return TableValues{}, fmt.Errorf("no call stacks found in the before collection")
+	}
+	if profiles[0].after.totalSamples()+profiles[1].after.totalSamples() == 0 {
+		return TableValues{}, fmt.Errorf("no call stacks found in the after collection")
+	}
+	// the flamegraphs are trimmed to the after collection's maximum render depth
+	maxStackDepth := 0
+	if fieldIdx, err := getFieldIndex("Maximum Render Depth", after); err == nil && len(after.Fields[fieldIdx].Values) > 0 {
+		maxStackDepth, _ = strconv.Atoi(strings.TrimSpace(after.Fields[fieldIdx].Values[0]))
+	}
+	// the functions of profiles that have samples in both collections
+	var changes []functionChange
+	for _, profile := range profiles {
+		if profile.before.totalSamples() == 0 || profile.after.totalSamples() == 0 {
+			continue
+		}
+		beforeShares := profile.before.functionShares()
+		afterShares := profile.after.functionShares()
+		for function := range beforeShares {
+			if _, ok := afterShares[function]; !ok {
+				afterShares[function] = functionShare{}
+			}
+		}
+		for function, afterShare := range afterShares {
+			changes = append(changes, functionChange{profile: profile.name, function: function, before: beforeShares[function], after: afterShare})
+		}
+	}
+	slices.SortFunc(changes, func(a, b functionChange) int {
+		if a.magnitude() != b.magnitude() {
+			if a.magnitude() > b.magnitude() {
+				return -1
+			}
+			return 1
+		}
+		if a.profile != b.profile {
+			return strings.Compare(a.profile, b.profile)
+		}
+		return strings.Compare(a.function, b.function)
+	})
+	if top > 0 && len(changes) > top {
+		changes = changes[:top]
+	}
+	tableValues := TableValues{
+		TableDefinition: TableDefinition{
+			Name:        CallStackDiffTableName,
+			MenuLabel:   CallStackDiffTableName,
+			HasRows:     true,
+			NoDataFound: "No functions were sampled in both collections.",
+			HTMLTableRendererFunc: func(tableValues TableValues, targetName string) string {
+				out := flameGraphStyle
+				out += "<p>Frame widths are the samples of the collection, colors are the change in the frame's share of the samples: red frames have a larger share after, blue frames a smaller share.</p>\n"
+				for _, profile := range profiles {
+					out += renderDiffFlameGraphs(flameGraphHeader(profile.name, mode), profile.before, profile.after, maxStackDepth)
+				}
+				return out + DefaultHTMLTableRendererFunc(tableValues)
+			},
+		},
+		Fields: []Field{
+			{Name: "Profile"},
+			{Name: "Function"},
+			{Name: "Inclusive Before (%)"},
+			{Name: "Inclusive After (%)"},
+			{Name: "Inclusive Change"},
+			{Name: "Exclusive Before (%)"},
+			{Name: "Exclusive After (%)"},
+			{Name: "Exclusive Change"},
+		},
+	}
+	for _, change := range changes {
+		for i, value := range []string{
+			change.profile,
+			change.function,
+			fmt.Sprintf("%.2f", change.before.inclusive),
+			fmt.Sprintf("%.2f", change.after.inclusive),
+			fmt.Sprintf("%+.2f", change.after.inclusive-change.before.inclusive),
+			fmt.Sprintf("%.2f", change.before.exclusive),
+			fmt.Sprintf("%.2f", change.after.exclusive),
+			fmt.Sprintf("%+.2f", change.after.exclusive-change.before.exclusive),
+		} {
+			tableValues.Fields[i].Values = append(tableValues.Fields[i].Values, value)
+		}
+	}
+	return tableValues, nil
+}
@@ -0,0 +1,98 @@
+package report
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFolded(t *testing.T) {
+	stacks := make(ProcessStacks)
+	stacks.parseFolded("java (1234);main;0x7f00aa 3\njava (5678);main;0x7f00bb 2\nswapper;idle 5\nno count\n")
+	assert.Equal(t, ProcessStacks{
+		"java (1234)": Stacks{"main;0x7f00aa": 3},
+		"java (5678)": Stacks{"main;0x7f00bb": 2},
+		"swapper":     Stacks{"idle": 5},
+	}, stacks)
+	assert.Equal(t, ProcessStacks{
+		"java":    Stacks{"main;[unknown]": 5},
+		"swapper": Stacks{"idle": 5},
+	}, stacks.normalized())
+}
+
+func TestFunctionShares(t *testing.T) {
+	stacks := ProcessStacks{"app": Stacks{"main;work;work": 3, "main;idle": 1}}
+	shares := stacks.functionShares()
+	assert.InDelta(t, 100, shares["main"].inclusive, 0.001)
+	assert.InDelta(t, 0, shares["main"].exclusive, 0.001)
+	// recursion is counted once
+	assert.InDelta(t, 75, shares["work"].inclusive, 0.001)
+	assert.InDelta(t, 75, shares["work"].exclusive, 0.001)
+	assert.InDelta(t, 25, shares["idle"].exclusive, 0.001)
+	assert.NotContains(t, shares, "app")
+}
+
+func TestNewDiffTree(t *testing.T) {
+	before := ProcessStacks{"app": Stacks{"main;a": 50, "main;gone": 50}}
+	after := ProcessStacks{"app": Stacks{"main;a": 150, "main;b": 50}}
+	// the after profile has twice the samples, the before profile is scaled up
+	tree := newDiffTree(after, before, after, 0)
+	main := tree.Children["app"].Children["main"]
+	assert.Equal(t, 200, main.Value)
+	assert.InDelta(t, 0, main.Delta, 0.001)
+	assert.InDelta(t, 50, main.Children["a"].Delta, 0.001)
+	assert.InDelta(t, 50, main.Children["b"].Delta, 0.001)
+	assert.NotContains(t, main.Children, "gone")
+	assert.InDelta(t, 50, tree.maxAbsDelta(), 0.001)
+	// the before profile shows what's gone
+	tree = newDiffTree(before, before, after, 0)
+	main = tree.Children["app"].Children["main"]
+	assert.Equal(t, 100, main.Value)
+	assert.InDelta(t, -50, main.Children["gone"].Delta, 0.001)
+	assert.InDelta(t, 25, main.Children["a"].Delta, 0.001)
+	// trimmed stacks
+	tree = newDiffTree(after, before, after, 2)
+	assert.Empty(t, tree.Children["app"].Children["main"].Children)
+}
+
+func TestDiffCallStacks(t *testing.T) {
+	callStacks := func(native string, java string) TableValues {
+		return TableValues{Fields: []Field{
+			{Name: "Native Stacks", Values: []string{native}},
+			{Name: "Java Stacks", Values: []string{java}},
+			{Name: "Maximum Render Depth", Values: []string{"0"}},
+		}}
+	}
+	before := callStacks("app;main;a 50\napp;main;gone 50\n", "")
+	after := callStacks("app;main;a 150\napp;main;b 50\n", "java (42);run 10\n")
+	tableValues, err := DiffCallStacks(before, after, 3)
+	require.NoError(t, err)
+	assert.Equal(t, CallStackDiffTableName, tableValues.Name)
+	require.Len(t, tableValues.Fields, 8)
+	// the java profile wasn't in the before collection, and main's share didn't change
+	assert.Equal(t, []string{"Native", "Native", "Native"}, tableValues.Fields[0].Values)
+	assert.Equal(t, []string{"gone", "a", "b"}, tableValues.Fields[1].Values)
+	assert.Equal(t, []string{"50.00", "50.00", "0.00"}, tableValues.Fields[2].Values)
+	assert.Equal(t, []string{"0.00", "75.00", "25.00"}, tableValues.Fields[3].Values)
+	assert.Equal(t, []string{"-50.00", "+25.00", "+25.00"}, tableValues.Fields[4].Values)
+	assert.Equal(t, []string{"-50.00", "+25.00", "+25.00"}, tableValues.Fields[7].Values)
+	out := tableValues.HTMLTableRendererFunc(tableValues, "host")
+	assert.Contains(t, out, "Native: CPU Samples (after)")
+	assert.Contains(t, out, "Native: CPU Samples (before)")
+	assert.Contains(t, out, "setColorMapper")
+
+	// collections of different modes
+	offCPU := callStacks("app;main;read 100\n", "")
+	offCPU.Fields = append(offCPU.Fields, Field{Name: "Mode", Values: []string{CallStackModeOffCPU}})
+	_, err = DiffCallStacks(before, offCPU, 0)
+	assert.ErrorContains(t, err, "mode")
+
+	_, err = DiffCallStacks(callStacks("", ""), after, 0)
+	assert.Error(t, err)
+	_, err = DiffCallStacks(TableValues{}, after, 0)
+	assert.Error(t, err)
+}
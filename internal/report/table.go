@@ -11,6 +11,8 @@ import (
 	"perfspect/internal/script"
 	"perfspect/internal/target"
 	"slices"
+	"strings"
+	"time"
 )
 
 // GetTableByName retrieves a table definition by its name.
@@ -76,3 +78,57 @@ func ProcessTables(tableNames []string, scriptOutputs map[string]script.ScriptOu
 	}
 	return
 }
+
+// HasTimeSeriesTable returns true if any of the tables is a time series, i.e., its sample times need
+// the target's clock offset to be put on the controller's clock
+func HasTimeSeriesTable(tableNames []string) bool {
+	for _, tableName := range tableNames {
+		if GetTableByName(tableName).TimeSeries {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeTableTimes converts the sample times of the time-series tables, i.e., those whose first field
+// is the time of each sample as HH:MM:SS on the target's clock, to the controller's clock in UTC with
+// full dates, so that the timelines of multiple targets line up. The dates are counted from the clock
+// measurement, which is taken before the samples, and a time earlier than the previous sample's time is
+// on the next day.
+func NormalizeTableTimes(allTableValues []TableValues, clock target.ClockOffset) {
+	for i := range allTableValues {
+		if len(allTableValues[i].Fields) == 0 {
+			continue
+		}
+		field := &allTableValues[i].Fields[0]
+		if !strings.EqualFold(field.Name, "Time") && !strings.EqualFold(field.Name, "Timestamp") {
+			continue
+		}
+		if times, ok := normalizeTimes(field.Values, clock); ok {
+			field.Values = times
+		}
+	}
+}
+
+// normalizeTimes returns the times on the controller's clock, or false if the values aren't all times
+func normalizeTimes(values []string, clock target.ClockOffset) ([]string, bool) {
+	measured := clock.TargetTime
+	day := time.Date(measured.Year(), measured.Month(), measured.Day(), 0, 0, 0, 0, measured.Location())
+	var previous time.Time
+	normalized := make([]string, 0, len(values))
+	for i, value := range values {
+		timeOfDay, err := time.Parse("15:04:05", value)
+		if err != nil {
+			return nil, false
+		}
+		sampleTime := time.Date(day.Year(), day.Month(), day.Day(), timeOfDay.Hour(), timeOfDay.Minute(), timeOfDay.Second(), 0, day.Location())
+		// allow for the sample times being rounded down to the second
+		if (i == 0 && sampleTime.Before(measured.Add(-time.Minute))) || (i > 0 && sampleTime.Before(previous)) {
+			day = day.AddDate(0, 0, 1)
+			sampleTime = sampleTime.AddDate(0, 0, 1)
+		}
+		previous = sampleTime
+		normalized = append(normalized, sampleTime.Add(-clock.Offset).UTC().Round(time.Second).Format(time.RFC3339))
+	}
+	return normalized, true
+}
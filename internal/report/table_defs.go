@@ -58,6 +58,7 @@ type TableDefinition struct {
 	FieldsFunc  FieldsRetriever
 	MenuLabel   string // add to tables that will be displayed in the menu
 	HasRows     bool   // table is meant to be displayed in row form, i.e., a field may have multiple values
+	TimeSeries  bool   // table's first field is the time of each sample on the target's clock
 	NoDataFound string // message to display when no data is found
 	// render functions are used to override the default rendering behavior
 	HTMLTableRendererFunc            HTMLTableRenderer
@@ -125,6 +126,14 @@ const (
 	InstructionTelemetryTableName           = "Instruction Telemetry"
 	DriveTelemetryTableName                 = "Drive Telemetry"
 	NetworkTelemetryTableName               = "Network Telemetry"
+	ProcessTelemetryTableName               = "Process Telemetry"
+	ProcessSummaryTelemetryTableName        = "Process Summary Telemetry"
+	PressureTelemetryTableName              = "Pressure Telemetry"
+	CgroupPressureTelemetryTableName        = "Cgroup Pressure Telemetry"
+	SchedulerTelemetryTableName             = "Scheduler Telemetry"
+	NetworkStackTelemetryTableName          = "Network Stack Telemetry"
+	NetworkSoftirqTelemetryTableName        = "Network Softirq Telemetry"
+	NetworkQueueTelemetryTableName          = "Network Queue Telemetry"
 	MemoryTelemetryTableName                = "Memory Telemetry"
 	PowerTelemetryTableName                 = "Power Telemetry"
 	TemperatureTelemetryTableName           = "Temperature Telemetry"
@@ -133,6 +142,7 @@ const (
 	ConfigurationTableName = "Configuration"
 	// flamegraph table names
 	CallStackFrequencyTableName = "Call Stack Frequency"
+	CallStackTimelineTableName  = "Call Stack Timeline"
 	// lock table names
 	KernelLockAnalysisTableName = "Kernel Lock Analysis"
 	// common table names
@@ -151,6 +161,14 @@ const (
 	InstructionTelemetryMenuLabel           = "Instruction"
 	DriveTelemetryMenuLabel                 = "Drive"
 	NetworkTelemetryMenuLabel               = "Network"
+	ProcessTelemetryMenuLabel               = "Process"
+	ProcessSummaryTelemetryMenuLabel        = "Process Summary"
+	PressureTelemetryMenuLabel              = "Pressure"
+	CgroupPressureTelemetryMenuLabel        = "Cgroup Pressure"
+	SchedulerTelemetryMenuLabel             = "Scheduler"
+	NetworkStackTelemetryMenuLabel          = "Network Stack"
+	NetworkSoftirqTelemetryMenuLabel        = "Network Softirq"
+	NetworkQueueTelemetryMenuLabel          = "Network Queue"
 	MemoryTelemetryMenuLabel                = "Memory"
 	PowerTelemetryMenuLabel                 = "Power"
 	TemperatureTelemetryMenuLabel           = "Temperature"
@@ -660,117 +678,230 @@ var tableDefinitions = map[string]TableDefinition{
 	// telemetry tables
 	//
 	CPUUtilizationTelemetryTableName: {
-		Name:      CPUUtilizationTelemetryTableName,
-		MenuLabel: CPUUtilizationTelemetryMenuLabel,
-		HasRows:   true,
+		Name:       CPUUtilizationTelemetryTableName,
+		MenuLabel:  CPUUtilizationTelemetryMenuLabel,
+		HasRows:    true,
+		TimeSeries: true,
 		ScriptNames: []string{
 			script.MpstatTelemetryScriptName,
 		},
-		FieldsFunc:            cpuUtilizationTelemetryTableValues,
-		HTMLTableRendererFunc: cpuUtilizationTelemetryTableHTMLRenderer},
+		FieldsFunc:                       cpuUtilizationTelemetryTableValues,
+		HTMLTableRendererFunc:            cpuUtilizationTelemetryTableHTMLRenderer,
+		HTMLMultiTargetTableRendererFunc: cpuUtilizationTelemetryTableMultiTargetHTMLRenderer},
 	UtilizationCategoriesTelemetryTableName: {
-		Name:      UtilizationCategoriesTelemetryTableName,
-		MenuLabel: UtilizationCategoriesTelemetryMenuLabel,
-		HasRows:   true,
+		Name:       UtilizationCategoriesTelemetryTableName,
+		MenuLabel:  UtilizationCategoriesTelemetryMenuLabel,
+		HasRows:    true,
+		TimeSeries: true,
 		ScriptNames: []string{
 			script.MpstatTelemetryScriptName,
 		},
-		FieldsFunc:            utilizationCategoriesTelemetryTableValues,
-		HTMLTableRendererFunc: utilizationCategoriesTelemetryTableHTMLRenderer},
+		FieldsFunc:                       utilizationCategoriesTelemetryTableValues,
+		HTMLTableRendererFunc:            utilizationCategoriesTelemetryTableHTMLRenderer,
+		HTMLMultiTargetTableRendererFunc: utilizationCategoriesTelemetryTableMultiTargetHTMLRenderer},
 	IPCTelemetryTableName: {
-		Name:      IPCTelemetryTableName,
-		MenuLabel: IPCTelemetryMenuLabel,
-		HasRows:   true,
+		Name:       IPCTelemetryTableName,
+		MenuLabel:  IPCTelemetryMenuLabel,
+		HasRows:    true,
+		TimeSeries: true,
 		ScriptNames: []string{
 			script.TurbostatTelemetryScriptName,
 		},
-		FieldsFunc:            ipcTelemetryTableValues,
-		HTMLTableRendererFunc: ipcTelemetryTableHTMLRenderer},
+		FieldsFunc:                       ipcTelemetryTableValues,
+		HTMLTableRendererFunc:            ipcTelemetryTableHTMLRenderer,
+		HTMLMultiTargetTableRendererFunc: ipcTelemetryTableMultiTargetHTMLRenderer},
 	C6TelemetryTableName: {
-		Name:      C6TelemetryTableName,
-		MenuLabel: C6TelemetryMenuLabel,
-		HasRows:   true,
+		Name:       C6TelemetryTableName,
+		MenuLabel:  C6TelemetryMenuLabel,
+		HasRows:    true,
+		TimeSeries: true,
 		ScriptNames: []string{
 			script.TurbostatTelemetryScriptName,
 		},
-		FieldsFunc:            c6TelemetryTableValues,
-		HTMLTableRendererFunc: c6TelemetryTableHTMLRenderer},
+		FieldsFunc:                       c6TelemetryTableValues,
+		HTMLTableRendererFunc:            c6TelemetryTableHTMLRenderer,
+		HTMLMultiTargetTableRendererFunc: c6TelemetryTableMultiTargetHTMLRenderer},
 	FrequencyTelemetryTableName: {
-		Name:      FrequencyTelemetryTableName,
-		MenuLabel: FrequencyTelemetryMenuLabel,
-		HasRows:   true,
+		Name:       FrequencyTelemetryTableName,
+		MenuLabel:  FrequencyTelemetryMenuLabel,
+		HasRows:    true,
+		TimeSeries: true,
 		ScriptNames: []string{
 			script.TurbostatTelemetryScriptName,
 		},
-		FieldsFunc:            frequencyTelemetryTableValues,
-		HTMLTableRendererFunc: averageFrequencyTelemetryTableHTMLRenderer},
+		FieldsFunc:                       frequencyTelemetryTableValues,
+		HTMLTableRendererFunc:            averageFrequencyTelemetryTableHTMLRenderer,
+		HTMLMultiTargetTableRendererFunc: frequencyTelemetryTableMultiTargetHTMLRenderer},
 	IRQRateTelemetryTableName: {
-		Name:      IRQRateTelemetryTableName,
-		MenuLabel: IRQRateTelemetryMenuLabel,
-		HasRows:   true,
+		Name:       IRQRateTelemetryTableName,
+		MenuLabel:  IRQRateTelemetryMenuLabel,
+		HasRows:    true,
+		TimeSeries: true,
 		ScriptNames: []string{
 			script.MpstatTelemetryScriptName,
 		},
-		FieldsFunc:            irqRateTelemetryTableValues,
-		HTMLTableRendererFunc: irqRateTelemetryTableHTMLRenderer},
+		FieldsFunc:                       irqRateTelemetryTableValues,
+		HTMLTableRendererFunc:            irqRateTelemetryTableHTMLRenderer,
+		HTMLMultiTargetTableRendererFunc: irqRateTelemetryTableMultiTargetHTMLRenderer},
 	DriveTelemetryTableName: {
-		Name:      DriveTelemetryTableName,
-		MenuLabel: DriveTelemetryMenuLabel,
-		HasRows:   true,
+		Name:       DriveTelemetryTableName,
+		MenuLabel:  DriveTelemetryMenuLabel,
+		HasRows:    true,
+		TimeSeries: true,
 		ScriptNames: []string{
 			script.IostatTelemetryScriptName,
 		},
-		FieldsFunc:            driveTelemetryTableValues,
-		HTMLTableRendererFunc: driveTelemetryTableHTMLRenderer},
+		FieldsFunc:                       driveTelemetryTableValues,
+		HTMLTableRendererFunc:            driveTelemetryTableHTMLRenderer,
+		HTMLMultiTargetTableRendererFunc: driveTelemetryTableMultiTargetHTMLRenderer},
 	NetworkTelemetryTableName: {
-		Name:      NetworkTelemetryTableName,
-		MenuLabel: NetworkTelemetryMenuLabel,
-		HasRows:   true,
+		Name:       NetworkTelemetryTableName,
+		MenuLabel:  NetworkTelemetryMenuLabel,
+		HasRows:    true,
+		TimeSeries: true,
 		ScriptNames: []string{
 			script.NetworkTelemetryScriptName,
 		},
-		FieldsFunc:            networkTelemetryTableValues,
-		HTMLTableRendererFunc: networkTelemetryTableHTMLRenderer},
-	MemoryTelemetryTableName: {
-		Name:      MemoryTelemetryTableName,
-		MenuLabel: MemoryTelemetryMenuLabel,
+		FieldsFunc:                       networkTelemetryTableValues,
+		HTMLTableRendererFunc:            networkTelemetryTableHTMLRenderer,
+		HTMLMultiTargetTableRendererFunc: networkTelemetryTableMultiTargetHTMLRenderer},
+	ProcessTelemetryTableName: {
+		Name:       ProcessTelemetryTableName,
+		MenuLabel:  ProcessTelemetryMenuLabel,
+		HasRows:    true,
+		TimeSeries: true,
+		ScriptNames: []string{
+			script.ProcessTelemetryScriptName,
+		},
+		NoDataFound:           "No process telemetry found. The procstat collector is required to collect process telemetry.",
+		FieldsFunc:            processTelemetryTableValues,
+		HTMLTableRendererFunc: processTelemetryTableHTMLRenderer},
+	ProcessSummaryTelemetryTableName: {
+		Name:      ProcessSummaryTelemetryTableName,
+		MenuLabel: ProcessSummaryTelemetryMenuLabel,
 		HasRows:   true,
+		ScriptNames: []string{
+			script.ProcessTelemetryScriptName,
+		},
+		NoDataFound: "No process telemetry found. The procstat collector is required to collect process telemetry.",
+		FieldsFunc:  processSummaryTelemetryTableValues},
+	PressureTelemetryTableName: {
+		Name:       PressureTelemetryTableName,
+		MenuLabel:  PressureTelemetryMenuLabel,
+		HasRows:    true,
+		TimeSeries: true,
+		ScriptNames: []string{
+			script.PressureTelemetryScriptName,
+		},
+		NoDataFound:           "No pressure telemetry found. The procstat collector and a kernel with pressure stall information (CONFIG_PSI) are required to collect pressure telemetry.",
+		FieldsFunc:            pressureTelemetryTableValues,
+		HTMLTableRendererFunc: pressureTelemetryTableHTMLRenderer,
+		InsightsFunc:          pressureTelemetryTableInsights},
+	CgroupPressureTelemetryTableName: {
+		Name:       CgroupPressureTelemetryTableName,
+		MenuLabel:  CgroupPressureTelemetryMenuLabel,
+		HasRows:    true,
+		TimeSeries: true,
+		ScriptNames: []string{
+			script.PressureTelemetryScriptName,
+		},
+		NoDataFound:           "No cgroup pressure telemetry found. Use the --pressure-cgroups flag to select cgroups.",
+		FieldsFunc:            cgroupPressureTelemetryTableValues,
+		HTMLTableRendererFunc: cgroupPressureTelemetryTableHTMLRenderer},
+	SchedulerTelemetryTableName: {
+		Name:       SchedulerTelemetryTableName,
+		MenuLabel:  SchedulerTelemetryMenuLabel,
+		HasRows:    true,
+		TimeSeries: true,
+		ScriptNames: []string{
+			script.PressureTelemetryScriptName,
+		},
+		NoDataFound:           "No scheduler telemetry found. The procstat collector is required to collect scheduler telemetry.",
+		FieldsFunc:            schedulerTelemetryTableValues,
+		HTMLTableRendererFunc: schedulerTelemetryTableHTMLRenderer},
+	NetworkStackTelemetryTableName: {
+		Name:       NetworkStackTelemetryTableName,
+		MenuLabel:  NetworkStackTelemetryMenuLabel,
+		HasRows:    true,
+		TimeSeries: true,
+		ScriptNames: []string{
+			script.NetStackTelemetryScriptName,
+		},
+		NoDataFound:           "No network stack telemetry found. The procstat collector is required to collect network stack telemetry.",
+		FieldsFunc:            networkStackTelemetryTableValues,
+		HTMLTableRendererFunc: networkStackTelemetryTableHTMLRenderer,
+		InsightsFunc:          networkStackTelemetryTableInsights},
+	NetworkSoftirqTelemetryTableName: {
+		Name:       NetworkSoftirqTelemetryTableName,
+		MenuLabel:  NetworkSoftirqTelemetryMenuLabel,
+		HasRows:    true,
+		TimeSeries: true,
+		ScriptNames: []string{
+			script.MpstatTelemetryScriptName,
+		},
+		FieldsFunc:            networkSoftirqTelemetryTableValues,
+		HTMLTableRendererFunc: networkSoftirqTelemetryTableHTMLRenderer,
+		InsightsFunc:          networkSoftirqTelemetryTableInsights},
+	NetworkQueueTelemetryTableName: {
+		Name:       NetworkQueueTelemetryTableName,
+		MenuLabel:  NetworkQueueTelemetryMenuLabel,
+		HasRows:    true,
+		TimeSeries: true,
+		ScriptNames: []string{
+			script.NetQueueTelemetryScriptName,
+		},
+		NoDataFound:           "No network queue telemetry found. The network interface driver must report per-queue statistics (ethtool -S).",
+		FieldsFunc:            networkQueueTelemetryTableValues,
+		HTMLTableRendererFunc: networkQueueTelemetryTableHTMLRenderer,
+		InsightsFunc:          networkQueueTelemetryTableInsights},
+	MemoryTelemetryTableName: {
+		Name:       MemoryTelemetryTableName,
+		MenuLabel:  MemoryTelemetryMenuLabel,
+		HasRows:    true,
+		TimeSeries: true,
 		ScriptNames: []string{
 			script.MemoryTelemetryScriptName,
 		},
-		FieldsFunc:            memoryTelemetryTableValues,
-		HTMLTableRendererFunc: memoryTelemetryTableHTMLRenderer},
+		FieldsFunc:                       memoryTelemetryTableValues,
+		HTMLTableRendererFunc:            memoryTelemetryTableHTMLRenderer,
+		HTMLMultiTargetTableRendererFunc: memoryTelemetryTableMultiTargetHTMLRenderer},
 	PowerTelemetryTableName: {
-		Name:      PowerTelemetryTableName,
-		MenuLabel: PowerTelemetryMenuLabel,
-		HasRows:   true,
+		Name:       PowerTelemetryTableName,
+		MenuLabel:  PowerTelemetryMenuLabel,
+		HasRows:    true,
+		TimeSeries: true,
 		ScriptNames: []string{
 			script.TurbostatTelemetryScriptName,
 		},
-		FieldsFunc:            powerTelemetryTableValues,
-		HTMLTableRendererFunc: powerTelemetryTableHTMLRenderer},
+		FieldsFunc:                       powerTelemetryTableValues,
+		HTMLTableRendererFunc:            powerTelemetryTableHTMLRenderer,
+		HTMLMultiTargetTableRendererFunc: powerTelemetryTableMultiTargetHTMLRenderer},
 	TemperatureTelemetryTableName: {
-		Name:      TemperatureTelemetryTableName,
-		MenuLabel: TemperatureTelemetryMenuLabel,
-		HasRows:   true,
+		Name:       TemperatureTelemetryTableName,
+		MenuLabel:  TemperatureTelemetryMenuLabel,
+		HasRows:    true,
+		TimeSeries: true,
 		ScriptNames: []string{
 			script.TurbostatTelemetryScriptName,
 		},
-		FieldsFunc:            temperatureTelemetryTableValues,
-		HTMLTableRendererFunc: temperatureTelemetryTableHTMLRenderer},
+		FieldsFunc:                       temperatureTelemetryTableValues,
+		HTMLTableRendererFunc:            temperatureTelemetryTableHTMLRenderer,
+		HTMLMultiTargetTableRendererFunc: temperatureTelemetryTableMultiTargetHTMLRenderer},
 	InstructionTelemetryTableName: {
-		Name:      InstructionTelemetryTableName,
-		MenuLabel: InstructionTelemetryMenuLabel,
-		HasRows:   true,
+		Name:       InstructionTelemetryTableName,
+		MenuLabel:  InstructionTelemetryMenuLabel,
+		HasRows:    true,
+		TimeSeries: true,
 		ScriptNames: []string{
 			script.InstructionTelemetryScriptName,
 		},
 		FieldsFunc:            instructionTelemetryTableValues,
 		HTMLTableRendererFunc: instructionTelemetryTableHTMLRenderer},
 	GaudiTelemetryTableName: {
-		Name:      GaudiTelemetryTableName,
-		MenuLabel: GaudiTelemetryMenuLabel,
-		HasRows:   true,
+		Name:       GaudiTelemetryTableName,
+		MenuLabel:  GaudiTelemetryMenuLabel,
+		HasRows:    true,
+		TimeSeries: true,
 		ScriptNames: []string{
 			script.GaudiTelemetryScriptName,
 		},
@@ -788,6 +919,16 @@ var tableDefinitions = map[string]TableDefinition{
 		},
 		FieldsFunc:            callStackFrequencyTableValues,
 		HTMLTableRendererFunc: callStackFrequencyTableHTMLRenderer},
+	CallStackTimelineTableName: {
+		Name:      CallStackTimelineTableName,
+		MenuLabel: CallStackTimelineTableName,
+		ScriptNames: []string{
+			script.CollapsedCallStacksScriptName,
+		},
+		FieldsFunc:            callStackTimelineTableValues,
+		HTMLTableRendererFunc: callStackTimelineTableHTMLRenderer,
+		NoDataFound:           "No call stack timeline found. The timeline is collected from the native stacks in cpu mode.",
+	},
 	//
 	// kernel lock analysis tables
 	//
@@ -2202,6 +2343,9 @@ func cpuUtilizationTelemetryTableValues(outputs map[string]script.ScriptOutput)
 		{Name: "%gnice"},
 		{Name: "%idle"},
 	}
+	if procstatFieldValues(fields, outputs[script.MpstatTelemetryScriptName].Stdout, procstatCPUUtilizationRows) {
+		return fields
+	}
 	reStat := regexp.MustCompile(`^(\d\d:\d\d:\d\d)\s+(\d+)\s+(\d+)\s+(\d+)\s+(-*\d+)\s+(\d+\.\d+)\s+(\d+\.\d+)\s+(\d+\.\d+)\s+(\d+\.\d+)\s+(\d+\.\d+)\s+(\d+\.\d+)\s+(\d+\.\d+)\s+(\d+\.\d+)\s+(\d+\.\d+)\s+(\d+\.\d+)$`)
 	for line := range strings.SplitSeq(outputs[script.MpstatTelemetryScriptName].Stdout, "\n") {
 		match := reStat.FindStringSubmatch(line)
@@ -2229,6 +2373,9 @@ func utilizationCategoriesTelemetryTableValues(outputs map[string]script.ScriptO
 		{Name: "%gnice"},
 		{Name: "%idle"},
 	}
+	if procstatFieldValues(fields, outputs[script.MpstatTelemetryScriptName].Stdout, procstatUtilizationCategoriesRows) {
+		return fields
+	}
 	reStat := regexp.MustCompile(`^(\d\d:\d\d:\d\d)\s+all\s+(\d+\.\d+)\s+(\d+\.\d+)\s+(\d+\.\d+)\s+(\d+\.\d+)\s+(\d+\.\d+)\s+(\d+\.\d+)\s+(\d+\.\d+)\s+(\d+\.\d+)\s+(\d+\.\d+)\s+(\d+\.\d+)$`)
 	for line := range strings.SplitSeq(outputs[script.MpstatTelemetryScriptName].Stdout, "\n") {
 		match := reStat.FindStringSubmatch(line)
@@ -2257,6 +2404,9 @@ func irqRateTelemetryTableValues(outputs map[string]script.ScriptOutput) []Field
 		{Name: "HRTIMER/s"},
 		{Name: "RCU/s"},
 	}
+	if procstatFieldValues(fields, outputs[script.MpstatTelemetryScriptName].Stdout, procstatIRQRateRows) {
+		return fields
+	}
 	reStat := regexp.MustCompile(`^(\d\d:\d\d:\d\d)\s+(\d+)\s+(\d+\.\d+)\s+(\d+\.\d+)\s+(\d+\.\d+)\s+(\d+\.\d+)\s+(\d+\.\d+)\s+(\d+\.\d+)\s+(\d+\.\d+)\s+(\d+\.\d+)\s+(\d+\.\d+)\s+(\d+\.\d+)$`)
 	for line := range strings.SplitSeq(outputs[script.MpstatTelemetryScriptName].Stdout, "\n") {
 		match := reStat.FindStringSubmatch(line)
@@ -2279,6 +2429,9 @@ func driveTelemetryTableValues(outputs map[string]script.ScriptOutput) []Field {
 		{Name: "kB_wrtn/s"},
 		{Name: "kB_dscd/s"},
 	}
+	if procstatFieldValues(fields, outputs[script.IostatTelemetryScriptName].Stdout, procstatDriveRows) {
+		return fields
+	}
 	// the time is on its own line, so we need to keep track of it
 	reTime := regexp.MustCompile(`^\d\d\d\d-\d\d-\d\dT(\d\d:\d\d:\d\d)`)
 	// don't capture the last three vals: "kB_read","kB_wrtn","kB_dscd" -- they aren't the same scale as the others
@@ -2310,6 +2463,9 @@ func networkTelemetryTableValues(outputs map[string]script.ScriptOutput) []Field
 		{Name: "rxkB/s"},
 		{Name: "txkB/s"},
 	}
+	if procstatFieldValues(fields, outputs[script.NetworkTelemetryScriptName].Stdout, procstatNetworkRows) {
+		return fields
+	}
 	// don't capture the last four vals: "rxcmp/s","txcmp/s","rxcmt/s","%ifutil" -- obscure more important vals
 	reStat := regexp.MustCompile(`^(\d+:\d+:\d+)\s*(\w*)\s*(\d+.\d+)\s*(\d+.\d+)\s*(\d+.\d+)\s*(\d+.\d+)\s*\d+.\d+\s*\d+.\d+\s*\d+.\d+\s*\d+.\d+$`)
 	for line := range strings.SplitSeq(outputs[script.NetworkTelemetryScriptName].Stdout, "\n") {
@@ -2324,6 +2480,339 @@ func networkTelemetryTableValues(outputs map[string]script.ScriptOutput) []Field
 	return fields
 }
 
+func processTelemetryTableValues(outputs map[string]script.ScriptOutput) []Field {
+	fields := []Field{
+		{Name: "Time"},
+		{Name: "PID"},
+		{Name: "Command"},
+		{Name: "%CPU"},
+		{Name: "%usr"},
+		{Name: "%system"},
+		{Name: "RSS (kB)"},
+		{Name: "minflt/s"},
+		{Name: "majflt/s"},
+		{Name: "cswch/s"},
+		{Name: "nvcswch/s"},
+		{Name: "kB_rd/s"},
+		{Name: "kB_wr/s"},
+		{Name: "Threads"},
+	}
+	// there's no sysstat fallback, pidstat can't select the top processes
+	procstatFieldValues(fields, outputs[script.ProcessTelemetryScriptName].Stdout, procstatProcessRows)
+	return fields
+}
+
+func processSummaryTelemetryTableValues(outputs map[string]script.ScriptOutput) []Field {
+	fields := []Field{
+		{Name: "PID"},
+		{Name: "Command"},
+		{Name: "Avg %CPU"},
+		{Name: "Max %CPU"},
+		{Name: "Max RSS (kB)"},
+		{Name: "Avg majflt/s"},
+		{Name: "Avg cswch/s"},
+		{Name: "kB Read"},
+		{Name: "kB Written"},
+		{Name: "Samples"},
+	}
+	samples, ok := parseProcstatOutput(outputs[script.ProcessTelemetryScriptName].Stdout)
+	if !ok {
+		return fields
+	}
+	for _, row := range procstatProcessSummaryRows(samples) {
+		for i := range fields {
+			fields[i].Values = append(fields[i].Values, row[i])
+		}
+	}
+	return fields
+}
+
+func pressureTelemetryTableValues(outputs map[string]script.ScriptOutput) []Field {
+	fields := []Field{
+		{Name: "Time"},
+		{Name: "CPU Some %"},
+		{Name: "CPU Full %"},
+		{Name: "Memory Some %"},
+		{Name: "Memory Full %"},
+		{Name: "IO Some %"},
+		{Name: "IO Full %"},
+	}
+	procstatFieldValues(fields, outputs[script.PressureTelemetryScriptName].Stdout, procstatPressureRows)
+	return fields
+}
+
+// sustainedPressureThreshold is the stall percentage above which pressure is considered high,
+// pressure is sustained when it's high in at least half of the samples
+const sustainedPressureThreshold = 10.0
+
+func pressureTelemetryTableInsights(outputs map[string]script.ScriptOutput, tableValues TableValues) []Insight {
+	insights := []Insight{}
+	if len(tableValues.Fields) == 0 || len(tableValues.Fields[0].Values) == 0 {
+		return insights // pressure stall information isn't available
+	}
+	for _, check := range []struct {
+		fieldName      string
+		resource       string
+		recommendation string
+	}{
+		{"CPU Some %", "CPU", "Consider adding CPU capacity or reducing the load, runnable tasks waited for a CPU."},
+		{"Memory Some %", "memory", "Consider adding memory or reducing the memory footprint, tasks stalled on memory reclaim or swap."},
+		{"IO Some %", "IO", "Consider faster storage or reducing IO, tasks stalled waiting for IO."},
+	} {
+		fieldIndex, err := getFieldIndex(check.fieldName, tableValues)
+		if err != nil {
+			slog.Warn(err.Error())
+			continue
+		}
+		values := tableValues.Fields[fieldIndex].Values
+		var high int
+		var sum float64
+		for _, value := range values {
+			stall, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				slog.Warn("failed to parse pressure", slog.String("value", value), slog.String("error", err.Error()))
+				continue
+			}
+			sum += stall
+			if stall > sustainedPressureThreshold {
+				high++
+			}
+		}
+		if len(values) > 0 && high*2 >= len(values) {
+			insights = append(insights, Insight{
+				Recommendation: check.recommendation,
+				Justification:  fmt.Sprintf("Sustained %s pressure: some tasks were stalled more than %.0f%% of the time in %d of %d samples, %.2f%% on average.", check.resource, sustainedPressureThreshold, high, len(values), sum/float64(len(values))),
+			})
+		}
+	}
+	return insights
+}
+
+func cgroupPressureTelemetryTableValues(outputs map[string]script.ScriptOutput) []Field {
+	fields := []Field{
+		{Name: "Time"},
+		{Name: "Cgroup"},
+		{Name: "CPU Some %"},
+		{Name: "CPU Full %"},
+		{Name: "Memory Some %"},
+		{Name: "Memory Full %"},
+		{Name: "IO Some %"},
+		{Name: "IO Full %"},
+	}
+	procstatFieldValues(fields, outputs[script.PressureTelemetryScriptName].Stdout, procstatCgroupPressureRows)
+	return fields
+}
+
+func schedulerTelemetryTableValues(outputs map[string]script.ScriptOutput) []Field {
+	fields := []Field{
+		{Name: "Time"},
+		{Name: "runq-sz"},
+		{Name: "blocked"},
+		{Name: "cswch/s"},
+		{Name: "forks/s"},
+	}
+	procstatFieldValues(fields, outputs[script.PressureTelemetryScriptName].Stdout, procstatSchedulerRows)
+	return fields
+}
+
+func networkStackTelemetryTableValues(outputs map[string]script.ScriptOutput) []Field {
+	fields := []Field{{Name: "Time"}}
+	for _, counter := range procstatNetStackCounters {
+		fields = append(fields, Field{Name: counter + "/s"})
+		if counter == "TcpRetransSegs" {
+			fields = append(fields, Field{Name: "TcpRetrans%"})
+		}
+	}
+	fields = append(fields, Field{Name: "TcpCurrEstab"})
+	procstatFieldValues(fields, outputs[script.NetStackTelemetryScriptName].Stdout, procstatNetStackRows)
+	return fields
+}
+
+func networkStackTelemetryTableInsights(outputs map[string]script.ScriptOutput, tableValues TableValues) []Insight {
+	insights := []Insight{}
+	if len(tableValues.Fields) == 0 || len(tableValues.Fields[0].Values) == 0 {
+		return insights
+	}
+	// sum of the rates of the named fields across all samples
+	sum := func(fieldNames ...string) (total float64) {
+		for _, fieldName := range fieldNames {
+			fieldIndex, err := getFieldIndex(fieldName, tableValues)
+			if err != nil {
+				slog.Warn(err.Error())
+				continue
+			}
+			for _, value := range tableValues.Fields[fieldIndex].Values {
+				rate, err := strconv.ParseFloat(value, 64)
+				if err == nil {
+					total += rate
+				}
+			}
+		}
+		return
+	}
+	if outSegs := sum("TcpOutSegs/s"); outSegs > 0 {
+		if retransPercent := 100 * sum("TcpRetransSegs/s") / outSegs; retransPercent > 1 {
+			insights = append(insights, Insight{
+				Recommendation: "Consider investigating packet loss, e.g., NIC drops, congestion, or undersized buffers.",
+				Justification:  fmt.Sprintf("%.2f%% of the TCP segments sent were retransmitted.", retransPercent),
+			})
+		}
+	}
+	if sum("TcpExtListenOverflows/s", "TcpExtListenDrops/s") > 0 {
+		insights = append(insights, Insight{
+			Recommendation: "Consider increasing the listen backlog (net.core.somaxconn, net.ipv4.tcp_max_syn_backlog, and the application's backlog) or accepting connections faster.",
+			Justification:  "Incoming TCP connections were dropped because a listen queue was full.",
+		})
+	}
+	if sum("UdpRcvbufErrors/s") > 0 {
+		insights = append(insights, Insight{
+			Recommendation: "Consider increasing the UDP socket receive buffers (net.core.rmem_max and SO_RCVBUF) or reading from the sockets faster.",
+			Justification:  "UDP datagrams were dropped because a socket receive buffer was full.",
+		})
+	}
+	return insights
+}
+
+func networkSoftirqTelemetryTableValues(outputs map[string]script.ScriptOutput) []Field {
+	fields := []Field{
+		{Name: "Time"},
+		{Name: "CPU"},
+		{Name: "NET_RX/s"},
+		{Name: "NET_TX/s"},
+		{Name: "%soft"},
+	}
+	// join the network softirq rates with the softirq time of each CPU in each sample
+	irqFields := irqRateTelemetryTableValues(outputs)
+	cpuFields := cpuUtilizationTelemetryTableValues(outputs)
+	softTime := make(map[string]string) // time and cpu -> %soft
+	for i := range cpuFields[0].Values {
+		softTime[cpuFields[0].Values[i]+" "+cpuFields[1].Values[i]] = cpuFields[10].Values[i]
+	}
+	for i := range irqFields[0].Values {
+		timestamp, cpu := irqFields[0].Values[i], irqFields[1].Values[i]
+		soft, ok := softTime[timestamp+" "+cpu]
+		if !ok {
+			continue
+		}
+		for j, value := range []string{timestamp, cpu, irqFields[5].Values[i], irqFields[4].Values[i], soft} {
+			fields[j].Values = append(fields[j].Values, value)
+		}
+	}
+	return fields
+}
+
+// networkSoftirqTotals returns the total network softirq rate of each CPU across the samples,
+// and the number of samples
+func networkSoftirqTotals(tableValues TableValues) (totals map[string]float64, numSamples int) {
+	totals = make(map[string]float64)
+	var prevTime string
+	for i := range tableValues.Fields[0].Values {
+		if tableValues.Fields[0].Values[i] != prevTime {
+			prevTime = tableValues.Fields[0].Values[i]
+			numSamples++
+		}
+		for _, fieldIndex := range []int{2, 3} {
+			rate, err := strconv.ParseFloat(tableValues.Fields[fieldIndex].Values[i], 64)
+			if err == nil {
+				totals[tableValues.Fields[1].Values[i]] += rate
+			}
+		}
+	}
+	return
+}
+
+// concentratedSoftirqShare is the share of the network softirqs which, when handled by a quarter of
+// the CPUs or fewer, is reported as concentrated
+const concentratedSoftirqShare = 0.8
+
+func networkSoftirqTelemetryTableInsights(outputs map[string]script.ScriptOutput, tableValues TableValues) []Insight {
+	insights := []Insight{}
+	if len(tableValues.Fields) == 0 || len(tableValues.Fields[0].Values) == 0 {
+		return insights
+	}
+	totals, numSamples := networkSoftirqTotals(tableValues)
+	var total float64
+	cpus := make([]string, 0, len(totals))
+	for cpu, cpuTotal := range totals {
+		cpus = append(cpus, cpu)
+		total += cpuTotal
+	}
+	// ignore idle networks and small systems
+	if len(cpus) < 8 || total/float64(numSamples) < 1000 {
+		return insights
+	}
+	slices.SortFunc(cpus, func(a, b string) int {
+		if totals[a] != totals[b] {
+			if totals[a] > totals[b] {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a, b)
+	})
+	var share float64
+	var busiest []string
+	for _, cpu := range cpus {
+		busiest = append(busiest, cpu)
+		share += totals[cpu] / total
+		if share >= concentratedSoftirqShare {
+			break
+		}
+	}
+	if len(busiest)*4 <= len(cpus) {
+		insights = append(insights, Insight{
+			Recommendation: "Consider spreading network processing across more CPUs with RSS (more NIC queues), IRQ affinity, or RPS/RFS.",
+			Justification:  fmt.Sprintf("Network softirqs are concentrated on %d of %d CPUs, CPU(s) %s handled %.0f%% of them.", len(busiest), len(cpus), strings.Join(busiest, ", "), 100*share),
+		})
+	}
+	return insights
+}
+
+func networkQueueTelemetryTableValues(outputs map[string]script.ScriptOutput) []Field {
+	fields := []Field{
+		{Name: "Time"},
+		{Name: "Interface"},
+		{Name: "Queue"},
+		{Name: "rx packets/s"},
+		{Name: "tx packets/s"},
+		{Name: "rx drops/s"},
+		{Name: "tx drops/s"},
+	}
+	for _, row := range ethtoolQueueRows(outputs[script.NetQueueTelemetryScriptName].Stdout) {
+		for i := range fields {
+			fields[i].Values = append(fields[i].Values, row[i])
+		}
+	}
+	return fields
+}
+
+func networkQueueTelemetryTableInsights(outputs map[string]script.ScriptOutput, tableValues TableValues) []Insight {
+	insights := []Insight{}
+	// interface -> queues that dropped packets
+	dropQueues := make(map[string][]string)
+	var ifaces []string
+	for i := range tableValues.Fields[0].Values {
+		iface, queue := tableValues.Fields[1].Values[i], tableValues.Fields[2].Values[i]
+		for _, fieldIndex := range []int{5, 6} {
+			drops, err := strconv.ParseFloat(tableValues.Fields[fieldIndex].Values[i], 64)
+			if err != nil || drops == 0 || slices.Contains(dropQueues[iface], queue) {
+				continue
+			}
+			if _, ok := dropQueues[iface]; !ok {
+				ifaces = append(ifaces, iface)
+			}
+			dropQueues[iface] = append(dropQueues[iface], queue)
+		}
+	}
+	for _, iface := range ifaces {
+		insights = append(insights, Insight{
+			Recommendation: fmt.Sprintf("Consider increasing the ring sizes of %s (ethtool -G) or spreading its traffic across more queues.", iface),
+			Justification:  fmt.Sprintf("%s dropped packets on queue(s) %s.", iface, strings.Join(dropQueues[iface], ", ")),
+		})
+	}
+	return insights
+}
+
 func memoryTelemetryTableValues(outputs map[string]script.ScriptOutput) []Field {
 	fields := []Field{
 		{Name: "Time"},
@@ -2337,6 +2826,9 @@ func memoryTelemetryTableValues(outputs map[string]script.ScriptOutput) []Field
 		{Name: "inactive"},
 		{Name: "dirty"},
 	}
+	if procstatFieldValues(fields, outputs[script.MemoryTelemetryScriptName].Stdout, procstatMemoryRows) {
+		return fields
+	}
 	reStat := regexp.MustCompile(`^(\d+:\d+:\d+)\s*(\d+)\s*(\d+)\s*(\d+)\s*\d+\.\d+\s*(\d+)\s*(\d+)\s*(\d+)\s*\d+\.\d+\s*(\d+)\s*(\d+)\s*(\d+)$`)
 	for line := range strings.SplitSeq(outputs[script.MemoryTelemetryScriptName].Stdout, "\n") {
 		match := reStat.FindStringSubmatch(line)
@@ -2558,10 +3050,35 @@ func gaudiTelemetryTableValues(outputs map[string]script.ScriptOutput) []Field {
 }
 
 func callStackFrequencyTableValues(outputs map[string]script.ScriptOutput) []Field {
+	// the per-CPU native stacks are the frame pointer stacks of the timeline, they aren't merged with
+	// the dwarf stacks
+	nativeFolded := ""
+	if perCPUFromOutput(outputs) {
+		nativeFolded = perCPUFoldedFromOutput(outputs)
+	}
+	perCPU := nativeFolded != ""
+	if !perCPU {
+		nativeFolded = nativeFoldedFromOutput(outputs)
+	}
 	fields := []Field{
-		{Name: "Native Stacks", Values: []string{nativeFoldedFromOutput(outputs)}},
+		{Name: "Native Stacks", Values: []string{nativeFolded}},
 		{Name: "Java Stacks", Values: []string{javaFoldedFromOutput(outputs)}},
 		{Name: "Maximum Render Depth", Values: []string{maxRenderDepthFromOutput(outputs)}},
+		{Name: "Mode", Values: []string{callStackModeFromOutput(outputs)}},
+		{Name: "Per CPU", Values: []string{strconv.FormatBool(perCPU)}},
+	}
+	return fields
+}
+
+func callStackTimelineTableValues(outputs map[string]script.ScriptOutput) []Field {
+	samples, stacks := callStackTimelineFromOutput(outputs)
+	if samples == "" || stacks == "" {
+		return []Field{}
+	}
+	fields := []Field{
+		{Name: "Samples", Values: []string{samples}},
+		{Name: "Stacks", Values: []string{stacks}},
+		{Name: "Per CPU", Values: []string{strconv.FormatBool(perCPUFromOutput(outputs))}},
 	}
 	return fields
 }
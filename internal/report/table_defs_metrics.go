@@ -0,0 +1,65 @@
+package report
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+// table_defs_metrics.go defines the tables used for the metrics command's summary reports. The
+// metrics command computes their fields from the collected metrics, so the tables have no FieldsFunc.
+
+import "fmt"
+
+const (
+	// metrics summary table names
+	MetricStatisticsTableName = "Metric Statistics"
+	TMABreakdownTableName     = "TMA Breakdown"
+)
+
+const (
+	// metrics summary table field names
+	MetricsGroupFieldName       = "Group" // e.g., "SKT 0", present when metrics are summarized by socket, CPU, cgroup, or thread
+	MetricsMetricFieldName      = "Metric"
+	MetricsUnitFieldName        = "Unit"
+	MetricsCategoryFieldName    = "Category"
+	MetricsMeanFieldName        = "Mean"
+	MetricsMinFieldName         = "Min"
+	MetricsMaxFieldName         = "Max"
+	MetricsStddevFieldName      = "Stddev"
+	MetricsExceededFieldName    = "Threshold Exceeded" // "true" or "false", empty if the metric has no threshold
+	MetricsDescriptionFieldName = "Description"
+	MetricsLevelFieldName       = "Level" // TMA hierarchy level, 1 is the top level
+	MetricsParentFieldName      = "Parent"
+)
+
+var metricsTableDefinitions = map[string]TableDefinition{
+	SystemSummaryTableName: {
+		Name:      SystemSummaryTableName,
+		MenuLabel: SystemSummaryMenuLabel,
+	},
+	MetricStatisticsTableName: {
+		Name:                             MetricStatisticsTableName,
+		MenuLabel:                        MetricStatisticsTableName,
+		HasRows:                          true,
+		NoDataFound:                      "No metrics collected.",
+		HTMLTableRendererFunc:            metricStatisticsTableHTMLRenderer,
+		HTMLMultiTargetTableRendererFunc: metricStatisticsTableMultiTargetHTMLRenderer,
+		XlsxTableRendererFunc:            metricStatisticsTableXlsxRenderer,
+	},
+	TMABreakdownTableName: {
+		Name:                             TMABreakdownTableName,
+		MenuLabel:                        TMABreakdownTableName,
+		HasRows:                          true,
+		NoDataFound:                      "No TMA metrics collected.",
+		HTMLTableRendererFunc:            tmaBreakdownTableHTMLRenderer,
+		HTMLMultiTargetTableRendererFunc: tmaBreakdownTableMultiTargetHTMLRenderer,
+		XlsxTableRendererFunc:            tmaBreakdownTableXlsxRenderer,
+	},
+}
+
+// GetMetricsTableDefinition returns the definition of the metrics summary table with the given name
+func GetMetricsTableDefinition(name string) TableDefinition {
+	table, ok := metricsTableDefinitions[name]
+	if !ok {
+		panic(fmt.Sprintf("metrics table not found: %s", name))
+	}
+	return table
+}
@@ -2050,6 +2050,13 @@ func nativeFoldedFromOutput(outputs map[string]script.ScriptOutput) string {
 			dwarfFolded = content
 		} else if header == "perf_fp" {
 			fpFolded = content
+		} else if header == "perf_offcpu" {
+			// off-CPU stacks are collected with frame pointers only, there's nothing to merge
+			offCPUStacks := make(ProcessStacks)
+			if err := offCPUStacks.parsePerfFolded(content); err != nil {
+				slog.Error("failed to parse off-CPU stacks", slog.String("error", err.Error()))
+			}
+			return offCPUStacks.dumpFolded()
 		}
 	}
 	if dwarfFolded == "" && fpFolded == "" {
@@ -2062,6 +2069,45 @@ func nativeFoldedFromOutput(outputs map[string]script.ScriptOutput) string {
 	return folded
 }
 
+// callStackModeFromOutput returns the mode of the call stack collection, collections made before
+// the mode was added sampled on-CPU stacks
+func callStackModeFromOutput(outputs map[string]script.ScriptOutput) string {
+	mode := strings.TrimSpace(sectionValueFromOutput(outputs[script.CollapsedCallStacksScriptName].Stdout, "mode"))
+	if mode == "" {
+		return CallStackModeCPU
+	}
+	return mode
+}
+
+// callStackTimelineFromOutput returns the timeline of the frame pointer stacks, collections made
+// in modes other than cpu, or before the timeline was added, don't have one
+func callStackTimelineFromOutput(outputs map[string]script.ScriptOutput) (samples string, stacks string) {
+	sections := getSectionsFromOutput(outputs[script.CollapsedCallStacksScriptName].Stdout)
+	return sections["perf_timeline"], sections["perf_timeline_stacks"]
+}
+
+// perCPUFromOutput returns true if the native stacks are to be broken down by CPU
+func perCPUFromOutput(outputs map[string]script.ScriptOutput) bool {
+	sections := getSectionsFromOutput(outputs[script.CollapsedCallStacksScriptName].Stdout)
+	return strings.TrimSpace(sections["per cpu"]) == "true"
+}
+
+// perCPUFoldedFromOutput returns the native stacks of the timeline broken down by CPU
+func perCPUFoldedFromOutput(outputs map[string]script.ScriptOutput) string {
+	samples, stacks := callStackTimelineFromOutput(outputs)
+	if samples == "" || stacks == "" {
+		slog.Warn("no timeline in collapsed call stack output, native stacks aren't broken down by CPU")
+		return ""
+	}
+	timeline, err := parseCallStackTimeline(samples, stacks)
+	if err != nil {
+		slog.Error("failed to parse call stack timeline", slog.String("error", err.Error()))
+		return ""
+	}
+	perCPUStacks := timeline.perCPUStacks()
+	return perCPUStacks.dumpFolded()
+}
+
 func maxRenderDepthFromOutput(outputs map[string]script.ScriptOutput) string {
 	sections := getSectionsFromOutput(outputs[script.CollapsedCallStacksScriptName].Stdout)
 	if len(sections) == 0 {
@@ -0,0 +1,127 @@
+package report
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ethtoolQueueStatRe matches the per-queue packet and drop counters reported by ethtool -S. The
+// names are driver specific, e.g., rx_queue_0_packets (ice, ixgbe, virtio), rx-0.packets (i40e),
+// and rx0_packets (mlx5).
+var ethtoolQueueStatRe = regexp.MustCompile(`^(rx|tx)(?:_queue_|-|_)?(\d+)[_.](packets|drops|dropped)$`)
+
+// ethtoolSample is the per-queue counters of the interfaces at one point in time
+type ethtoolSample struct {
+	time      string
+	timestamp float64 // seconds since the epoch, 0 if not available
+	// interface -> queue -> counter, i.e., rx_packets, tx_packets, rx_drops, tx_drops
+	queues map[string]map[int]map[string]uint64
+}
+
+// parseEthtoolQueueOutput parses the output of the network queue telemetry script, i.e., the
+// ethtool -S output of each interface following TIME and IFACE lines
+func parseEthtoolQueueOutput(output string) (samples []ethtoolSample, interval float64) {
+	var iface string
+	for line := range strings.SplitSeq(output, "\n") {
+		line = strings.TrimSpace(line)
+		if value, found := strings.CutPrefix(line, "INTERVAL:"); found {
+			interval, _ = strconv.ParseFloat(strings.TrimSpace(value), 64)
+			continue
+		}
+		if value, found := strings.CutPrefix(line, "TIME:"); found {
+			fields := strings.Fields(value)
+			if len(fields) == 0 {
+				continue
+			}
+			sample := ethtoolSample{time: fields[0], queues: make(map[string]map[int]map[string]uint64)}
+			if len(fields) > 1 {
+				sample.timestamp, _ = strconv.ParseFloat(fields[1], 64)
+			}
+			samples = append(samples, sample)
+			continue
+		}
+		if value, found := strings.CutPrefix(line, "IFACE:"); found {
+			iface = strings.TrimSpace(value)
+			continue
+		}
+		if len(samples) == 0 || iface == "" {
+			continue
+		}
+		name, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		match := ethtoolQueueStatRe.FindStringSubmatch(strings.TrimSpace(name))
+		if match == nil {
+			continue
+		}
+		queue, err := strconv.Atoi(match[2])
+		if err != nil {
+			continue
+		}
+		count, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			continue
+		}
+		kind := "packets"
+		if match[3] != "packets" {
+			kind = "drops"
+		}
+		queues := samples[len(samples)-1].queues
+		if queues[iface] == nil {
+			queues[iface] = make(map[int]map[string]uint64)
+		}
+		if queues[iface][queue] == nil {
+			queues[iface][queue] = make(map[string]uint64)
+		}
+		queues[iface][queue][match[1]+"_"+kind] += count
+	}
+	return
+}
+
+// ethtoolQueueRows returns a row for each queue of each interface in each sample, except the
+// first: time, interface, queue, rx packets/s, tx packets/s, rx drops/s, tx drops/s
+func ethtoolQueueRows(output string) (rows [][]string) {
+	samples, interval := parseEthtoolQueueOutput(output)
+	for i := 1; i < len(samples); i++ {
+		previous, current := samples[i-1], samples[i]
+		seconds := interval
+		if current.timestamp > 0 && previous.timestamp > 0 {
+			seconds = current.timestamp - previous.timestamp
+		}
+		if seconds <= 0 {
+			continue
+		}
+		rate := func(cur, prev uint64) string {
+			if cur < prev { // counter reset
+				return formatRate(0)
+			}
+			return formatRate(float64(cur-prev) / seconds)
+		}
+		ifaces := make([]string, 0, len(current.queues))
+		for iface := range current.queues {
+			ifaces = append(ifaces, iface)
+		}
+		slices.Sort(ifaces)
+		for _, iface := range ifaces {
+			queues := make([]int, 0, len(current.queues[iface]))
+			for queue := range current.queues[iface] {
+				queues = append(queues, queue)
+			}
+			slices.Sort(queues)
+			for _, queue := range queues {
+				cur, prev := current.queues[iface][queue], previous.queues[iface][queue]
+				if prev == nil {
+					continue
+				}
+				rows = append(rows, []string{current.time, iface, strconv.Itoa(queue), rate(cur["rx_packets"], prev["rx_packets"]), rate(cur["tx_packets"], prev["tx_packets"]), rate(cur["rx_drops"], prev["rx_drops"]), rate(cur["tx_drops"], prev["tx_drops"])})
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,64 @@
+package report
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+import (
+	"testing"
+
+	"perfspect/internal/script"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ethtoolQueueOutput = `INTERVAL: 2
+TIME: 15:04:05 1700000000
+IFACE: eth0
+NIC statistics:
+     rx_packets: 1000
+     rx_queue_0_packets: 100
+     rx_queue_0_drops: 0
+     rx_queue_1_packets: 200
+     tx_queue_0_packets: 50
+IFACE: ens1
+NIC statistics:
+     rx0_packets: 10
+     rx0_dropped: 1
+TIME: 15:04:07 1700000004
+IFACE: eth0
+NIC statistics:
+     rx_packets: 2000
+     rx_queue_0_packets: 500
+     rx_queue_0_drops: 8
+     rx_queue_1_packets: 200
+     tx_queue_0_packets: 90
+IFACE: ens1
+NIC statistics:
+     rx0_packets: 30
+     rx0_dropped: 1
+`
+
+func TestEthtoolQueueRows(t *testing.T) {
+	rows := ethtoolQueueRows(ethtoolQueueOutput)
+	require.Len(t, rows, 3)
+	// interfaces are sorted, rates use the timestamps rather than the interval
+	assert.Equal(t, []string{"15:04:07", "ens1", "0", "5.00", "0.00", "0.00", "0.00"}, rows[0])
+	assert.Equal(t, []string{"15:04:07", "eth0", "0", "100.00", "10.00", "2.00", "0.00"}, rows[1])
+	assert.Equal(t, []string{"15:04:07", "eth0", "1", "0.00", "0.00", "0.00", "0.00"}, rows[2])
+	// the interval is used when the timestamps aren't available
+	rows = ethtoolQueueRows("INTERVAL: 2\nTIME: 15:04:05\nIFACE: eth0\nrx-0.packets: 10\nTIME: 15:04:07\nIFACE: eth0\nrx-0.packets: 30\n")
+	require.Len(t, rows, 1)
+	assert.Equal(t, "10.00", rows[0][3])
+}
+
+func TestNetworkQueueTelemetryTableInsights(t *testing.T) {
+	tableValues := TableValues{
+		TableDefinition: TableDefinition{Name: NetworkQueueTelemetryTableName},
+		Fields:          networkQueueTelemetryTableValues(map[string]script.ScriptOutput{script.NetQueueTelemetryScriptName: {Stdout: ethtoolQueueOutput}}),
+	}
+	insights := networkQueueTelemetryTableInsights(nil, tableValues)
+	require.Len(t, insights, 1)
+	assert.Equal(t, "eth0 dropped packets on queue(s) 0.", insights[0].Justification)
+	assert.Contains(t, networkQueueTelemetryTableHTMLRenderer(tableValues, ""), "label: 'queue 1'")
+}
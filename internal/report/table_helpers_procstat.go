@@ -0,0 +1,369 @@
+package report
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+// parsers for the JSON-lines output of the procstat collector (tools/procstat), the telemetry
+// scripts fall back to sysstat output when the collector isn't available
+
+import (
+	"cmp"
+	"encoding/json"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// procstatFormatVersion is the newest version of the procstat output format that can be parsed
+const procstatFormatVersion = 1
+
+// procstatSample is one line of procstat output
+type procstatSample struct {
+	Version    int                       `json:"version"`
+	Time       string                    `json:"time"`
+	Timestamp  int64                     `json:"timestamp"`
+	Interval   float64                   `json:"interval"`
+	CPU        []procstatCPUSample       `json:"cpu"`
+	SoftIRQ    []procstatSoftIRQSample   `json:"softirq"`
+	Interrupts []procstatInterruptSample `json:"interrupts"`
+	Disk       []procstatDiskSample      `json:"disk"`
+	Memory     *procstatMemorySample     `json:"memory"`
+	VMStat     map[string]float64        `json:"vmstat"`
+	Net        []procstatNetSample       `json:"net"`
+	Process    []procstatProcessSample   `json:"process"`
+	Pressure   *procstatPressureSample   `json:"pressure"`
+	Cgroups    []procstatCgroupSample    `json:"cgroups"`
+	Sched      *procstatSchedSample      `json:"sched"`
+	NetStack   *procstatNetStackSample   `json:"netstack"`
+}
+
+type procstatCPUSample struct {
+	CPU    string  `json:"cpu"`
+	Core   int     `json:"core"`
+	Socket int     `json:"socket"`
+	Node   int     `json:"node"`
+	User   float64 `json:"usr"`
+	Nice   float64 `json:"nice"`
+	System float64 `json:"sys"`
+	IOWait float64 `json:"iowait"`
+	IRQ    float64 `json:"irq"`
+	Soft   float64 `json:"soft"`
+	Steal  float64 `json:"steal"`
+	Guest  float64 `json:"guest"`
+	GNice  float64 `json:"gnice"`
+	Idle   float64 `json:"idle"`
+}
+
+type procstatSoftIRQSample struct {
+	CPU   string             `json:"cpu"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+type procstatInterruptSample struct {
+	CPU  string  `json:"cpu"`
+	Rate float64 `json:"rate"`
+}
+
+type procstatDiskSample struct {
+	Device      string  `json:"device"`
+	TPS         float64 `json:"tps"`
+	ReadKBps    float64 `json:"kB_read/s"`
+	WriteKBps   float64 `json:"kB_wrtn/s"`
+	DiscardKBps float64 `json:"kB_dscd/s"`
+}
+
+type procstatMemorySample struct {
+	Free     uint64 `json:"free"`
+	Avail    uint64 `json:"avail"`
+	Used     uint64 `json:"used"`
+	Buffers  uint64 `json:"buffers"`
+	Cache    uint64 `json:"cache"`
+	Commit   uint64 `json:"commit"`
+	Active   uint64 `json:"active"`
+	Inactive uint64 `json:"inactive"`
+	Dirty    uint64 `json:"dirty"`
+}
+
+type procstatNetSample struct {
+	Interface string  `json:"iface"`
+	RxPckps   float64 `json:"rxpck/s"`
+	TxPckps   float64 `json:"txpck/s"`
+	RxKBps    float64 `json:"rxkB/s"`
+	TxKBps    float64 `json:"txkB/s"`
+}
+
+type procstatProcessSample struct {
+	PID       int     `json:"pid"`
+	Command   string  `json:"command"`
+	CPU       float64 `json:"%CPU"`
+	User      float64 `json:"%usr"`
+	System    float64 `json:"%system"`
+	RSS       uint64  `json:"rss"`
+	MinFltps  float64 `json:"minflt/s"`
+	MajFltps  float64 `json:"majflt/s"`
+	CSwchps   float64 `json:"cswch/s"`
+	NvCSwchps float64 `json:"nvcswch/s"`
+	ReadKBps  float64 `json:"kB_rd/s"`
+	WriteKBps float64 `json:"kB_wr/s"`
+	Threads   int     `json:"threads"`
+}
+
+type procstatPressureSample struct {
+	CPUSome    float64 `json:"cpu_some"`
+	CPUFull    float64 `json:"cpu_full"`
+	MemorySome float64 `json:"memory_some"`
+	MemoryFull float64 `json:"memory_full"`
+	IOSome     float64 `json:"io_some"`
+	IOFull     float64 `json:"io_full"`
+}
+
+type procstatCgroupSample struct {
+	Cgroup string `json:"cgroup"`
+	procstatPressureSample
+}
+
+type procstatSchedSample struct {
+	RunQueue uint64  `json:"runq-sz"`
+	Blocked  uint64  `json:"blocked"`
+	CSwchps  float64 `json:"cswch/s"`
+	Forksps  float64 `json:"forks/s"`
+}
+
+type procstatNetStackSample struct {
+	Rates     map[string]float64 `json:"rates"`
+	CurrEstab uint64             `json:"TcpCurrEstab"`
+}
+
+// parseProcstatOutput returns the samples in the script output. It returns false if the output
+// isn't procstat output, i.e., the script fell back to a sysstat tool.
+func parseProcstatOutput(output string) (samples []procstatSample, ok bool) {
+	for line := range strings.SplitSeq(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "{") {
+			return nil, false
+		}
+		var sample procstatSample
+		if err := json.Unmarshal([]byte(line), &sample); err != nil {
+			slog.Warn("failed to parse procstat sample", slog.String("error", err.Error()))
+			continue
+		}
+		if sample.Version > procstatFormatVersion {
+			slog.Warn("unsupported procstat format version", slog.Int("version", sample.Version))
+			continue
+		}
+		samples = append(samples, sample)
+	}
+	return samples, len(samples) > 0
+}
+
+// procstatFieldValues appends the rows returned by rowsFunc for each sample to the fields. It
+// returns false, leaving the fields unchanged, if the output isn't procstat output.
+func procstatFieldValues(fields []Field, output string, rowsFunc func(procstatSample) [][]string) bool {
+	samples, ok := parseProcstatOutput(output)
+	if !ok {
+		return false
+	}
+	for _, sample := range samples {
+		for _, row := range rowsFunc(sample) {
+			for i := range fields {
+				fields[i].Values = append(fields[i].Values, row[i])
+			}
+		}
+	}
+	return true
+}
+
+// formatRate formats rates and percentages with two decimal places, like sysstat
+func formatRate(value float64) string {
+	return strconv.FormatFloat(value, 'f', 2, 64)
+}
+
+func procstatCPUUtilization(cpu procstatCPUSample) []string {
+	return []string{formatRate(cpu.User), formatRate(cpu.Nice), formatRate(cpu.System), formatRate(cpu.IOWait), formatRate(cpu.IRQ), formatRate(cpu.Soft), formatRate(cpu.Steal), formatRate(cpu.Guest), formatRate(cpu.GNice), formatRate(cpu.Idle)}
+}
+
+// procstatCPUUtilizationRows returns a row for each CPU: time, CPU, core, socket, node, utilization
+func procstatCPUUtilizationRows(sample procstatSample) (rows [][]string) {
+	for _, cpu := range sample.CPU {
+		if cpu.CPU == "all" {
+			continue
+		}
+		row := []string{sample.Time, cpu.CPU, strconv.Itoa(cpu.Core), strconv.Itoa(cpu.Socket), strconv.Itoa(cpu.Node)}
+		rows = append(rows, append(row, procstatCPUUtilization(cpu)...))
+	}
+	return
+}
+
+// procstatUtilizationCategoriesRows returns the utilization of all CPUs: time, utilization
+func procstatUtilizationCategoriesRows(sample procstatSample) (rows [][]string) {
+	for _, cpu := range sample.CPU {
+		if cpu.CPU == "all" {
+			rows = append(rows, append([]string{sample.Time}, procstatCPUUtilization(cpu)...))
+		}
+	}
+	return
+}
+
+// procstatSoftIRQNames are the soft IRQs in the order of the IRQ rate table's fields
+var procstatSoftIRQNames = []string{"HI", "TIMER", "NET_TX", "NET_RX", "BLOCK", "IRQ_POLL", "TASKLET", "SCHED", "HRTIMER", "RCU"}
+
+// procstatIRQRateRows returns a row for each CPU: time, CPU, rate of each soft IRQ
+func procstatIRQRateRows(sample procstatSample) (rows [][]string) {
+	for _, softIRQ := range sample.SoftIRQ {
+		row := []string{sample.Time, softIRQ.CPU}
+		for _, name := range procstatSoftIRQNames {
+			row = append(row, formatRate(softIRQ.Rates[name]))
+		}
+		rows = append(rows, row)
+	}
+	return
+}
+
+// procstatDriveRows returns a row for each disk: time, device, tps, kB_read/s, kB_wrtn/s, kB_dscd/s
+func procstatDriveRows(sample procstatSample) (rows [][]string) {
+	for _, disk := range sample.Disk {
+		rows = append(rows, []string{sample.Time, disk.Device, formatRate(disk.TPS), formatRate(disk.ReadKBps), formatRate(disk.WriteKBps), formatRate(disk.DiscardKBps)})
+	}
+	return
+}
+
+// procstatNetworkRows returns a row for each interface: time, interface, rxpck/s, txpck/s, rxkB/s, txkB/s
+func procstatNetworkRows(sample procstatSample) (rows [][]string) {
+	for _, net := range sample.Net {
+		rows = append(rows, []string{sample.Time, net.Interface, formatRate(net.RxPckps), formatRate(net.TxPckps), formatRate(net.RxKBps), formatRate(net.TxKBps)})
+	}
+	return
+}
+
+// procstatMemoryRows returns the memory usage in kB: time, free, avail, used, buffers, cache,
+// commit, active, inactive, dirty
+func procstatMemoryRows(sample procstatSample) [][]string {
+	m := sample.Memory
+	if m == nil {
+		return nil
+	}
+	row := []string{sample.Time}
+	for _, value := range []uint64{m.Free, m.Avail, m.Used, m.Buffers, m.Cache, m.Commit, m.Active, m.Inactive, m.Dirty} {
+		row = append(row, strconv.FormatUint(value, 10))
+	}
+	return [][]string{row}
+}
+
+func procstatPressure(p procstatPressureSample) []string {
+	return []string{formatRate(p.CPUSome), formatRate(p.CPUFull), formatRate(p.MemorySome), formatRate(p.MemoryFull), formatRate(p.IOSome), formatRate(p.IOFull)}
+}
+
+// procstatPressureRows returns the system-wide stall percentages: time, cpu some, cpu full,
+// memory some, memory full, io some, io full
+func procstatPressureRows(sample procstatSample) [][]string {
+	if sample.Pressure == nil {
+		return nil
+	}
+	return [][]string{append([]string{sample.Time}, procstatPressure(*sample.Pressure)...)}
+}
+
+// procstatCgroupPressureRows returns a row for each cgroup: time, cgroup, stall percentages
+func procstatCgroupPressureRows(sample procstatSample) (rows [][]string) {
+	for _, cgroup := range sample.Cgroups {
+		rows = append(rows, append([]string{sample.Time, cgroup.Cgroup}, procstatPressure(cgroup.procstatPressureSample)...))
+	}
+	return
+}
+
+// procstatSchedulerRows returns the scheduler activity: time, runq-sz, blocked, cswch/s, forks/s
+func procstatSchedulerRows(sample procstatSample) [][]string {
+	s := sample.Sched
+	if s == nil {
+		return nil
+	}
+	return [][]string{{sample.Time, strconv.FormatUint(s.RunQueue, 10), strconv.FormatUint(s.Blocked, 10), formatRate(s.CSwchps), formatRate(s.Forksps)}}
+}
+
+// procstatNetStackCounters are the network stack counters in the order of the network stack
+// table's fields, the retransmit percentage follows TcpRetransSegs
+var procstatNetStackCounters = []string{"TcpActiveOpens", "TcpPassiveOpens", "TcpInSegs", "TcpOutSegs", "TcpRetransSegs", "TcpInErrs", "TcpOutRsts", "TcpExtListenOverflows", "TcpExtListenDrops", "TcpExtTCPBacklogDrop", "TcpExtTCPTimeouts", "TcpExtTCPSynRetrans", "UdpInDatagrams", "UdpOutDatagrams", "UdpInErrors", "UdpRcvbufErrors", "UdpSndbufErrors", "UdpNoPorts"}
+
+// procstatNetStackRows returns the network stack activity: time, the rate of each counter, the
+// TCP retransmit percentage, established TCP connections
+func procstatNetStackRows(sample procstatSample) [][]string {
+	n := sample.NetStack
+	if n == nil {
+		return nil
+	}
+	row := []string{sample.Time}
+	for _, counter := range procstatNetStackCounters {
+		row = append(row, formatRate(n.Rates[counter]))
+		if counter == "TcpRetransSegs" {
+			var retransPercent float64
+			if n.Rates["TcpOutSegs"] > 0 {
+				retransPercent = 100 * n.Rates["TcpRetransSegs"] / n.Rates["TcpOutSegs"]
+			}
+			row = append(row, formatRate(retransPercent))
+		}
+	}
+	return [][]string{append(row, strconv.FormatUint(n.CurrEstab, 10))}
+}
+
+// procstatProcessRows returns a row for each process: time, pid, command, %CPU, %usr, %system,
+// RSS, minflt/s, majflt/s, cswch/s, nvcswch/s, kB_rd/s, kB_wr/s, threads
+func procstatProcessRows(sample procstatSample) (rows [][]string) {
+	for _, p := range sample.Process {
+		rows = append(rows, []string{sample.Time, strconv.Itoa(p.PID), p.Command, formatRate(p.CPU), formatRate(p.User), formatRate(p.System), strconv.FormatUint(p.RSS, 10), formatRate(p.MinFltps), formatRate(p.MajFltps), formatRate(p.CSwchps), formatRate(p.NvCSwchps), formatRate(p.ReadKBps), formatRate(p.WriteKBps), strconv.Itoa(p.Threads)})
+	}
+	return
+}
+
+// procstatProcessSummaryRows returns a row for each process that was sampled, heaviest CPU
+// consumer first: pid, command, avg %CPU, max %CPU, max RSS, avg majflt/s, avg cswch/s, kB read,
+// kB written, samples. Averages are over the whole run, a process counts as idle in the samples
+// that don't include it.
+func procstatProcessSummaryRows(samples []procstatSample) (rows [][]string) {
+	type processSummary struct {
+		pid        int
+		command    string
+		cpu        float64
+		maxCPU     float64
+		maxRSS     uint64
+		majFlt     float64
+		cswch      float64
+		readKB     float64
+		writeKB    float64
+		numSamples int
+	}
+	summaries := make(map[string]*processSummary)
+	for _, sample := range samples {
+		for _, p := range sample.Process {
+			// a pid may be reused by another command
+			key := strconv.Itoa(p.PID) + " " + p.Command
+			summary, ok := summaries[key]
+			if !ok {
+				summary = &processSummary{pid: p.PID, command: p.Command}
+				summaries[key] = summary
+			}
+			summary.cpu += p.CPU
+			summary.maxCPU = max(summary.maxCPU, p.CPU)
+			summary.maxRSS = max(summary.maxRSS, p.RSS)
+			summary.majFlt += p.MajFltps
+			summary.cswch += p.CSwchps
+			summary.readKB += p.ReadKBps * sample.Interval
+			summary.writeKB += p.WriteKBps * sample.Interval
+			summary.numSamples++
+		}
+	}
+	sorted := slices.SortedFunc(maps.Values(summaries), func(a, b *processSummary) int {
+		if c := cmp.Compare(b.cpu, a.cpu); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.pid, b.pid)
+	})
+	numSamples := float64(len(samples))
+	for _, s := range sorted {
+		rows = append(rows, []string{strconv.Itoa(s.pid), s.command, formatRate(s.cpu / numSamples), formatRate(s.maxCPU), strconv.FormatUint(s.maxRSS, 10), formatRate(s.majFlt / numSamples), formatRate(s.cswch / numSamples), strconv.FormatFloat(s.readKB, 'f', 0, 64), strconv.FormatFloat(s.writeKB, 'f', 0, 64), strconv.Itoa(s.numSamples)})
+	}
+	return
+}
@@ -0,0 +1,220 @@
+package report
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+import (
+	"strconv"
+	"testing"
+
+	"perfspect/internal/script"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const procstatOutput = `{"version":1,"time":"15:04:05","timestamp":1700000000,"cpu":[{"cpu":"all","core":-1,"socket":-1,"node":-1,"usr":10,"nice":0,"sys":5,"iowait":0,"irq":0,"soft":1,"steal":0,"guest":0,"gnice":0,"idle":84},{"cpu":"0","core":0,"socket":1,"node":1,"usr":20,"nice":0,"sys":10,"iowait":0,"irq":0,"soft":2,"steal":0,"guest":0,"gnice":0,"idle":68}],"softirq":[{"cpu":"0","rates":{"TIMER":100.5,"RCU":3}}],"disk":[{"device":"nvme0n1","tps":12.5,"kB_read/s":100,"kB_wrtn/s":200.25,"kB_dscd/s":0}],"memory":{"free":100,"avail":500,"used":600,"buffers":50,"cache":200,"commit":300,"active":10,"inactive":20,"dirty":5},"net":[{"iface":"eth0","rxpck/s":10,"txpck/s":5,"rxkB/s":1,"txkB/s":0.5}]}
+{"version":1,"time":"15:04:07","timestamp":1700000002,"cpu":[{"cpu":"all","core":-1,"socket":-1,"node":-1,"usr":12,"nice":0,"sys":5,"iowait":0,"irq":0,"soft":1,"steal":0,"guest":0,"gnice":0,"idle":82}]}
+`
+
+func TestParseProcstatOutput(t *testing.T) {
+	samples, ok := parseProcstatOutput(procstatOutput)
+	require.True(t, ok)
+	require.Len(t, samples, 2)
+	assert.Equal(t, "15:04:07", samples[1].Time)
+	// sysstat output isn't procstat output
+	_, ok = parseProcstatOutput("Linux 6.8.0 (host) \t01/01/2025 \t_x86_64_\t(2 CPU)\n")
+	assert.False(t, ok)
+	_, ok = parseProcstatOutput("")
+	assert.False(t, ok)
+	// samples in newer format versions are skipped
+	_, ok = parseProcstatOutput(`{"version":2,"time":"15:04:05"}`)
+	assert.False(t, ok)
+}
+
+func TestProcstatTelemetryTables(t *testing.T) {
+	outputs := map[string]script.ScriptOutput{
+		script.MpstatTelemetryScriptName:  {Stdout: procstatOutput},
+		script.IostatTelemetryScriptName:  {Stdout: procstatOutput},
+		script.MemoryTelemetryScriptName:  {Stdout: procstatOutput},
+		script.NetworkTelemetryScriptName: {Stdout: procstatOutput},
+	}
+	fields := cpuUtilizationTelemetryTableValues(outputs)
+	assert.Equal(t, []string{"15:04:05"}, fields[0].Values)
+	assert.Equal(t, []string{"0"}, fields[1].Values)
+	assert.Equal(t, []string{"1"}, fields[3].Values)
+	assert.Equal(t, []string{"20.00"}, fields[5].Values)
+	assert.Equal(t, []string{"68.00"}, fields[len(fields)-1].Values)
+
+	fields = utilizationCategoriesTelemetryTableValues(outputs)
+	assert.Equal(t, []string{"15:04:05", "15:04:07"}, fields[0].Values)
+	assert.Equal(t, []string{"10.00", "12.00"}, fields[1].Values)
+
+	fields = irqRateTelemetryTableValues(outputs)
+	assert.Equal(t, "TIMER/s", fields[3].Name)
+	assert.Equal(t, []string{"100.50"}, fields[3].Values)
+	assert.Equal(t, []string{"0.00"}, fields[2].Values)
+
+	fields = driveTelemetryTableValues(outputs)
+	assert.Equal(t, []string{"nvme0n1"}, fields[1].Values)
+	assert.Equal(t, []string{"200.25"}, fields[4].Values)
+
+	fields = memoryTelemetryTableValues(outputs)
+	assert.Equal(t, []string{"600"}, fields[3].Values)
+
+	fields = networkTelemetryTableValues(outputs)
+	assert.Equal(t, []string{"eth0"}, fields[1].Values)
+	assert.Equal(t, []string{"0.50"}, fields[5].Values)
+}
+
+func TestSysstatTelemetryFallback(t *testing.T) {
+	outputs := map[string]script.ScriptOutput{
+		script.MemoryTelemetryScriptName: {Stdout: `Linux 6.8.0 (host) 	01/01/2025 	_x86_64_	(2 CPU)
+
+15:04:05    kbmemfree   kbavail kbmemused  %memused kbbuffers  kbcached  kbcommit   %commit  kbactive   kbinact   kbdirty
+15:04:07       100       500       600     60.00        50       200       300     30.00        10        20         5
+`},
+	}
+	fields := memoryTelemetryTableValues(outputs)
+	assert.Equal(t, []string{"15:04:07"}, fields[0].Values)
+	assert.Equal(t, []string{"600"}, fields[3].Values)
+}
+
+const procstatProcessOutput = `{"version":1,"time":"15:04:05","timestamp":1700000000,"interval":2,"process":[{"pid":10,"command":"java","%CPU":150,"%usr":100,"%system":50,"rss":2048,"minflt/s":1,"majflt/s":2,"cswch/s":10,"nvcswch/s":1,"kB_rd/s":4,"kB_wr/s":8,"threads":20},{"pid":20,"command":"redis-server","%CPU":50,"%usr":40,"%system":10,"rss":1024,"minflt/s":0,"majflt/s":0,"cswch/s":4,"nvcswch/s":0,"kB_rd/s":0,"kB_wr/s":0,"threads":4}]}
+{"version":1,"time":"15:04:07","timestamp":1700000002,"interval":2,"process":[{"pid":20,"command":"redis-server","%CPU":250,"%usr":200,"%system":50,"rss":4096,"minflt/s":0,"majflt/s":0,"cswch/s":2,"nvcswch/s":0,"kB_rd/s":1,"kB_wr/s":0,"threads":4}]}
+`
+
+func TestProcessTelemetryTables(t *testing.T) {
+	outputs := map[string]script.ScriptOutput{
+		script.ProcessTelemetryScriptName: {Stdout: procstatProcessOutput},
+	}
+	fields := processTelemetryTableValues(outputs)
+	assert.Equal(t, []string{"15:04:05", "15:04:05", "15:04:07"}, fields[0].Values)
+	assert.Equal(t, []string{"java", "redis-server", "redis-server"}, fields[2].Values)
+	assert.Equal(t, "150.00", fields[3].Values[0])
+	assert.Equal(t, "2048", fields[6].Values[0])
+	assert.Equal(t, "20", fields[len(fields)-1].Values[0])
+
+	// redis-server used more CPU over the run, averages are over both samples
+	fields = processSummaryTelemetryTableValues(outputs)
+	assert.Equal(t, []string{"20", "10"}, fields[0].Values)
+	assert.Equal(t, []string{"150.00", "75.00"}, fields[2].Values)
+	assert.Equal(t, []string{"250.00", "150.00"}, fields[3].Values)
+	assert.Equal(t, []string{"4096", "2048"}, fields[4].Values)
+	assert.Equal(t, []string{"2", "8"}, fields[7].Values)
+	assert.Equal(t, []string{"0", "16"}, fields[8].Values)
+	assert.Equal(t, []string{"2", "1"}, fields[9].Values)
+
+	// no data without the collector
+	fields = processSummaryTelemetryTableValues(map[string]script.ScriptOutput{})
+	assert.Empty(t, fields[0].Values)
+}
+
+func TestProcessTelemetryTableHTMLRenderer(t *testing.T) {
+	tableValues := TableValues{
+		TableDefinition: TableDefinition{Name: ProcessTelemetryTableName},
+		Fields:          processTelemetryTableValues(map[string]script.ScriptOutput{script.ProcessTelemetryScriptName: {Stdout: procstatProcessOutput}}),
+	}
+	out := processTelemetryTableHTMLRenderer(tableValues, "")
+	assert.Contains(t, out, "stacked: true")
+	assert.Contains(t, out, "label: 'redis-server (20)'")
+	// java isn't in the second sample
+	assert.Contains(t, out, "data: [150.000000,0.000000]")
+}
+
+const procstatPressureOutput = `{"version":1,"time":"15:04:05","timestamp":1700000000,"interval":2,"pressure":{"cpu_some":5,"cpu_full":0,"memory_some":12.5,"memory_full":3,"io_some":1,"io_full":0.5},"cgroups":[{"cgroup":"system.slice","cpu_some":1,"cpu_full":0,"memory_some":20,"memory_full":10,"io_some":0,"io_full":0}],"sched":{"runq-sz":3,"blocked":1,"cswch/s":1000,"forks/s":2.5}}
+{"version":1,"time":"15:04:07","timestamp":1700000002,"interval":2,"pressure":{"cpu_some":6,"cpu_full":0,"memory_some":8,"memory_full":2,"io_some":0,"io_full":0},"sched":{"runq-sz":2,"blocked":0,"cswch/s":900,"forks/s":0}}
+`
+
+func TestPressureTelemetryTables(t *testing.T) {
+	outputs := map[string]script.ScriptOutput{
+		script.PressureTelemetryScriptName: {Stdout: procstatPressureOutput},
+	}
+	fields := pressureTelemetryTableValues(outputs)
+	assert.Equal(t, []string{"15:04:05", "15:04:07"}, fields[0].Values)
+	assert.Equal(t, []string{"12.50", "8.00"}, fields[3].Values)
+	assert.Equal(t, []string{"0.50", "0.00"}, fields[6].Values)
+
+	fields = cgroupPressureTelemetryTableValues(outputs)
+	assert.Equal(t, []string{"system.slice"}, fields[1].Values)
+	assert.Equal(t, []string{"20.00"}, fields[4].Values)
+
+	fields = schedulerTelemetryTableValues(outputs)
+	assert.Equal(t, []string{"3", "2"}, fields[1].Values)
+	assert.Equal(t, []string{"1000.00", "900.00"}, fields[3].Values)
+
+	tableValues := TableValues{
+		TableDefinition: TableDefinition{Name: SchedulerTelemetryTableName},
+		Fields:          fields,
+	}
+	out := schedulerTelemetryTableHTMLRenderer(tableValues, "")
+	assert.Contains(t, out, "label: 'runq-sz'")
+	assert.Contains(t, out, "text: \"per second\"")
+}
+
+func TestPressureTelemetryTableInsights(t *testing.T) {
+	tableValues := TableValues{
+		TableDefinition: TableDefinition{Name: PressureTelemetryTableName},
+		Fields:          pressureTelemetryTableValues(map[string]script.ScriptOutput{script.PressureTelemetryScriptName: {Stdout: procstatPressureOutput}}),
+	}
+	// memory pressure is high in one of two samples
+	insights := pressureTelemetryTableInsights(nil, tableValues)
+	require.Len(t, insights, 1)
+	assert.Contains(t, insights[0].Justification, "Sustained memory pressure")
+	assert.Contains(t, insights[0].Justification, "1 of 2 samples, 10.25% on average")
+	// no insights without pressure stall information
+	tableValues.Fields = pressureTelemetryTableValues(map[string]script.ScriptOutput{})
+	assert.Empty(t, pressureTelemetryTableInsights(nil, tableValues))
+}
+
+func TestNetworkStackTelemetryTable(t *testing.T) {
+	outputs := map[string]script.ScriptOutput{
+		script.NetStackTelemetryScriptName: {Stdout: `{"version":1,"time":"15:04:05","timestamp":1700000000,"interval":2,"netstack":{"rates":{"TcpOutSegs":1000,"TcpRetransSegs":50,"TcpExtListenDrops":1},"TcpCurrEstab":12}}` + "\n"},
+	}
+	tableValues := TableValues{
+		TableDefinition: TableDefinition{Name: NetworkStackTelemetryTableName},
+		Fields:          networkStackTelemetryTableValues(outputs),
+	}
+	fields := tableValues.Fields
+	require.Len(t, fields, len(procstatNetStackCounters)+3)
+	assert.Equal(t, "TcpRetransSegs/s", fields[5].Name)
+	assert.Equal(t, []string{"50.00"}, fields[5].Values)
+	assert.Equal(t, "TcpRetrans%", fields[6].Name)
+	assert.Equal(t, []string{"5.00"}, fields[6].Values)
+	assert.Equal(t, []string{"12"}, fields[len(fields)-1].Values)
+
+	insights := networkStackTelemetryTableInsights(nil, tableValues)
+	require.Len(t, insights, 2)
+	assert.Equal(t, "5.00% of the TCP segments sent were retransmitted.", insights[0].Justification)
+	assert.Contains(t, insights[1].Justification, "listen queue was full")
+}
+
+func TestNetworkSoftirqTelemetryTable(t *testing.T) {
+	// eight CPUs, network softirqs are handled by CPU 3
+	var output string
+	for _, timestamp := range []string{"15:04:05", "15:04:07"} {
+		var cpus, softirqs string
+		for cpu := range 8 {
+			id := strconv.Itoa(cpu)
+			netRX, soft := "0", "0"
+			if cpu == 3 {
+				netRX, soft = "5000", "5"
+			}
+			cpus += `,{"cpu":"` + id + `","core":0,"socket":0,"node":0,"soft":` + soft + `,"idle":90}`
+			softirqs += `,{"cpu":"` + id + `","rates":{"NET_RX":` + netRX + `,"NET_TX":1}}`
+		}
+		output += `{"version":1,"time":"` + timestamp + `","cpu":[` + cpus[1:] + `],"softirq":[` + softirqs[1:] + "]}\n"
+	}
+	tableValues := TableValues{
+		TableDefinition: TableDefinition{Name: NetworkSoftirqTelemetryTableName},
+		Fields:          networkSoftirqTelemetryTableValues(map[string]script.ScriptOutput{script.MpstatTelemetryScriptName: {Stdout: output}}),
+	}
+	fields := tableValues.Fields
+	require.Len(t, fields[0].Values, 16)
+	assert.Equal(t, []string{"15:04:05", "3", "5000.00", "1.00", "5.00"}, []string{fields[0].Values[3], fields[1].Values[3], fields[2].Values[3], fields[3].Values[3], fields[4].Values[3]})
+
+	insights := networkSoftirqTelemetryTableInsights(nil, tableValues)
+	require.Len(t, insights, 1)
+	assert.Contains(t, insights[0].Justification, "concentrated on 1 of 8 CPUs, CPU(s) 3 handled")
+	assert.Contains(t, networkSoftirqTelemetryTableHTMLRenderer(tableValues, ""), "label: 'CPU 3'")
+}
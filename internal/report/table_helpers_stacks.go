@@ -163,3 +163,213 @@ func mergeSystemFolded(perfFp string, perfDwarf string) (merged string, err erro
 	merged = mergedStacks.dumpFolded()
 	return
 }
+
+// parseFolded parses folded stacks that start with the process name, i.e., the native and java
+// stacks in the Call Stack Frequency table
+func (p *ProcessStacks) parseFolded(folded string) {
+	for line := range strings.SplitSeq(folded, "\n") {
+		splitAt := strings.LastIndex(line, " ")
+		if splitAt == -1 {
+			continue
+		}
+		count, err := strconv.Atoi(line[splitAt+1:])
+		if err != nil {
+			continue
+		}
+		processName, stack, found := strings.Cut(line[:splitAt], ";")
+		if !found {
+			continue
+		}
+		if _, ok := (*p)[processName]; !ok {
+			(*p)[processName] = make(Stacks)
+		}
+		(*p)[processName][stack] += count
+	}
+}
+
+// normalized returns the stacks with process names and frames normalized so that stacks from
+// different collections can be compared, the counts of stacks that are then the same are summed
+func (p *ProcessStacks) normalized() ProcessStacks {
+	normalized := make(ProcessStacks)
+	for processName, stacks := range *p {
+		processName = normalizeProcessName(processName)
+		if _, ok := normalized[processName]; !ok {
+			normalized[processName] = make(Stacks)
+		}
+		for stack, count := range stacks {
+			normalized[processName][normalizeStack(stack)] += count
+		}
+	}
+	return normalized
+}
+
+// pidSuffixRe matches the PID that is appended to the names of java processes, e.g., "java (1234)"
+var pidSuffixRe = regexp.MustCompile(`\s+\(\d+\)$`)
+
+// normalizeProcessName removes the PID from a process name
+func normalizeProcessName(processName string) string {
+	return pidSuffixRe.ReplaceAllString(processName, "")
+}
+
+var (
+	hexAddressRe = regexp.MustCompile(`\b0x[0-9a-fA-F]+\b`)
+	perfMapRe    = regexp.MustCompile(`perf-\d+\.map`)
+)
+
+// normalizeStack replaces the addresses of unresolved frames and the PIDs in JIT map names, which
+// differ between collections
+func normalizeStack(stack string) string {
+	stack = hexAddressRe.ReplaceAllString(stack, "[unknown]")
+	return perfMapRe.ReplaceAllString(stack, "perf-PID.map")
+}
+
+// functionShare is the percentage of samples in which a function is on the stack (inclusive) and
+// at the top of the stack (exclusive)
+type functionShare struct {
+	inclusive float64
+	exclusive float64
+}
+
+// functionShares returns the share of the samples of each function, process names are not
+// functions
+func (p *ProcessStacks) functionShares() map[string]functionShare {
+	shares := make(map[string]functionShare)
+	total := p.totalSamples()
+	if total == 0 {
+		return shares
+	}
+	for _, stacks := range *p {
+		for stack, count := range stacks {
+			percent := float64(count) / float64(total) * 100
+			frames := strings.Split(stack, ";")
+			seen := make(map[string]bool)
+			for _, frame := range frames {
+				if seen[frame] { // recursive functions are counted once per stack
+					continue
+				}
+				seen[frame] = true
+				share := shares[frame]
+				share.inclusive += percent
+				shares[frame] = share
+			}
+			leaf := shares[frames[len(frames)-1]]
+			leaf.exclusive += percent
+			shares[frames[len(frames)-1]] = leaf
+		}
+	}
+	return shares
+}
+
+// walkFrames calls fn with the frames of each stack, starting with the process name, and trimmed
+// to maxStackDepth frames if it isn't 0
+func (p *ProcessStacks) walkFrames(maxStackDepth int, fn func(frames []string, count int)) {
+	for processName, stacks := range *p {
+		for stack, count := range stacks {
+			frames := append([]string{processName}, strings.Split(stack, ";")...)
+			if maxStackDepth > 0 && len(frames) > maxStackDepth {
+				frames = frames[:maxStackDepth]
+			}
+			fn(frames, count)
+		}
+	}
+}
+
+// call stack collection modes
+const (
+	CallStackModeCPU    = "cpu"    // on-CPU samples of native and java stacks
+	CallStackModeOffCPU = "offcpu" // time native stacks are blocked
+	CallStackModeWall   = "wall"   // wall-clock samples of java stacks
+	CallStackModeAlloc  = "alloc"  // allocation samples of java stacks
+	CallStackModeLock   = "lock"   // lock contention samples of java stacks
+)
+
+// CallStackModes are the call stack collection modes
+var CallStackModes = []string{CallStackModeCPU, CallStackModeOffCPU, CallStackModeWall, CallStackModeAlloc, CallStackModeLock}
+
+// callStackModeLabels describe the frame widths of each mode's flamegraphs
+var callStackModeLabels = map[string]string{
+	CallStackModeCPU:    "CPU Samples",
+	CallStackModeOffCPU: "Off-CPU Time (microseconds)",
+	CallStackModeWall:   "Wall-Clock Samples",
+	CallStackModeAlloc:  "Allocation Samples",
+	CallStackModeLock:   "Lock Contention Samples",
+}
+
+// callStackModeCollects returns true if the mode collects the native or java stacks
+func callStackModeCollects(mode string, stacks string) bool {
+	switch mode {
+	case CallStackModeOffCPU:
+		return stacks == "Native"
+	case CallStackModeWall, CallStackModeAlloc, CallStackModeLock:
+		return stacks == "Java"
+	}
+	return true
+}
+
+// callStackModeFromTable returns the mode of a Call Stack Frequency table
+func callStackModeFromTable(tableValues TableValues) string {
+	fieldIdx, err := getFieldIndex("Mode", tableValues)
+	if err != nil || len(tableValues.Fields[fieldIdx].Values) == 0 || tableValues.Fields[fieldIdx].Values[0] == "" {
+		return CallStackModeCPU
+	}
+	return tableValues.Fields[fieldIdx].Values[0]
+}
+
+// callStackTimeline is the timestamped samples of the frame pointer stacks of an on-CPU collection
+type callStackTimeline struct {
+	Times    []float64 `json:"times"`    // seconds since the first sample
+	CPUs     []int     `json:"cpus"`     // CPU the sample was taken on
+	Stacks   []int     `json:"stacks"`   // index of the sample's stack in Names
+	Names    []string  `json:"names"`    // folded stacks, the first frame is the process
+	Duration float64   `json:"duration"` // seconds from the first to the last sample
+}
+
+// parseCallStackTimeline parses the timeline samples, "<seconds> <cpu> <stack index>" lines, and
+// the timeline stacks, one folded stack per line where the line number is the stack's index
+func parseCallStackTimeline(samples string, stacks string) (timeline callStackTimeline, err error) {
+	timeline.Names = strings.Split(strings.TrimRight(stacks, "\n"), "\n")
+	for line := range strings.SplitSeq(samples, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 {
+			err = fmt.Errorf("unexpected timeline sample: %s", line)
+			return
+		}
+		var sampleTime float64
+		var cpu, stack int
+		if sampleTime, err = strconv.ParseFloat(fields[0], 64); err != nil {
+			return
+		}
+		if cpu, err = strconv.Atoi(fields[1]); err != nil {
+			return
+		}
+		if stack, err = strconv.Atoi(fields[2]); err != nil {
+			return
+		}
+		if stack < 0 || stack >= len(timeline.Names) {
+			err = fmt.Errorf("timeline sample refers to unknown stack: %s", line)
+			return
+		}
+		timeline.Times = append(timeline.Times, sampleTime)
+		timeline.CPUs = append(timeline.CPUs, cpu)
+		timeline.Stacks = append(timeline.Stacks, stack)
+		timeline.Duration = max(timeline.Duration, sampleTime)
+	}
+	return
+}
+
+// perCPUStacks returns the timeline's samples counted per CPU, the CPUs take the place of the
+// processes, which become the first frame of the stacks
+func (t *callStackTimeline) perCPUStacks() ProcessStacks {
+	stacks := make(ProcessStacks)
+	for i, stack := range t.Stacks {
+		cpu := fmt.Sprintf("CPU %d", t.CPUs[i])
+		if _, ok := stacks[cpu]; !ok {
+			stacks[cpu] = make(Stacks)
+		}
+		stacks[cpu][t.Names[stack]]++
+	}
+	return stacks
+}
@@ -4,7 +4,10 @@ package report
 // SPDX-License-Identifier: BSD-3-Clause
 
 import (
+	"perfspect/internal/script"
 	"reflect"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -351,3 +354,109 @@ Content B1`,
 		})
 	}
 }
+
+func TestCallStackFrequencyTableValuesMode(t *testing.T) {
+	tests := []struct {
+		name       string
+		output     string
+		wantMode   string
+		wantNative string
+	}{
+		{
+			name:       "Off-CPU stacks",
+			output:     "########## mode ##########\noffcpu\n########## maximum depth ##########\n0\n########## perf_offcpu ##########\napp;main;read 1500\n",
+			wantMode:   "offcpu",
+			wantNative: "app;main;read 1500",
+		},
+		{
+			name:       "Collection without a mode",
+			output:     "########## maximum depth ##########\n0\n",
+			wantMode:   "cpu",
+			wantNative: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := callStackFrequencyTableValues(map[string]script.ScriptOutput{
+				script.CollapsedCallStacksScriptName: {Stdout: tt.output},
+			})
+			tableValues := TableValues{Fields: fields}
+			if got := callStackModeFromTable(tableValues); got != tt.wantMode {
+				t.Errorf("mode = %q, want %q", got, tt.wantMode)
+			}
+			if got := strings.TrimSpace(fields[0].Values[0]); got != tt.wantNative {
+				t.Errorf("native stacks = %q, want %q", got, tt.wantNative)
+			}
+		})
+	}
+}
+
+func TestParseCallStackTimeline(t *testing.T) {
+	timeline, err := parseCallStackTimeline("0.000 3 0\n0.101 1 1\n0.800 3 0\n", "app;main;work\nswapper;idle\n")
+	if err != nil {
+		t.Fatalf("parseCallStackTimeline() error = %v", err)
+	}
+	if !slices.Equal(timeline.Times, []float64{0, 0.101, 0.8}) || !slices.Equal(timeline.CPUs, []int{3, 1, 3}) || !slices.Equal(timeline.Stacks, []int{0, 1, 0}) {
+		t.Errorf("samples = %v %v %v", timeline.Times, timeline.CPUs, timeline.Stacks)
+	}
+	if timeline.Duration != 0.8 {
+		t.Errorf("duration = %v, want 0.8", timeline.Duration)
+	}
+	perCPU := timeline.perCPUStacks()
+	want := ProcessStacks{"CPU 3": Stacks{"app;main;work": 2}, "CPU 1": Stacks{"swapper;idle": 1}}
+	if !reflect.DeepEqual(perCPU, want) {
+		t.Errorf("perCPUStacks() = %v, want %v", perCPU, want)
+	}
+	for _, samples := range []string{"0.000 3 2\n", "0.000 3\n", "x 3 0\n"} {
+		if _, err := parseCallStackTimeline(samples, "app;main;work\nswapper;idle\n"); err == nil {
+			t.Errorf("parseCallStackTimeline(%q) expected an error", samples)
+		}
+	}
+}
+
+func TestCallStackFrequencyTableValuesPerCPU(t *testing.T) {
+	timeline := "########## perf_dwarf ##########\napp;main;work 2\n########## perf_fp ##########\napp;main;work 2\n########## perf_timeline_stacks ##########\napp;main;work\n########## perf_timeline ##########\n0.000 3 0\n0.500 3 0\n"
+	tests := []struct {
+		name       string
+		output     string
+		wantNative string
+		wantPerCPU string
+	}{
+		{
+			name:       "Per CPU",
+			output:     "########## per cpu ##########\ntrue\n" + timeline,
+			wantNative: "CPU 3;app;main;work 2",
+			wantPerCPU: "true",
+		},
+		{
+			name:       "Not per CPU",
+			output:     "########## per cpu ##########\nfalse\n" + timeline,
+			wantNative: "app;main;work 2",
+			wantPerCPU: "false",
+		},
+		{
+			name:       "Per CPU without a timeline",
+			output:     "########## per cpu ##########\ntrue\n########## perf_dwarf ##########\napp;main;work 2\n########## perf_fp ##########\napp;main;work 2\n",
+			wantNative: "app;main;work 2",
+			wantPerCPU: "false",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputs := map[string]script.ScriptOutput{
+				script.CollapsedCallStacksScriptName: {Stdout: tt.output},
+			}
+			fields := callStackFrequencyTableValues(outputs)
+			if got := strings.TrimSpace(fields[0].Values[0]); got != tt.wantNative {
+				t.Errorf("native stacks = %q, want %q", got, tt.wantNative)
+			}
+			if got := fields[len(fields)-1]; got.Name != "Per CPU" || got.Values[0] != tt.wantPerCPU {
+				t.Errorf("per cpu field = %v, want %q", got, tt.wantPerCPU)
+			}
+			timelineFields := callStackTimelineTableValues(outputs)
+			if strings.Contains(tt.output, "perf_timeline") != (len(timelineFields) == 3) {
+				t.Errorf("timeline fields = %v", timelineFields)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package report
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+import (
+	"testing"
+	"time"
+
+	"perfspect/internal/target"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeTableTimes(t *testing.T) {
+	// the target is in UTC+2 and its clock is 3 seconds ahead of the controller's
+	clock := target.ClockOffset{
+		Offset:     3 * time.Second,
+		TargetTime: time.Date(2025, 6, 1, 23, 59, 57, 0, time.FixedZone("+0200", 2*3600)),
+	}
+	allTableValues := []TableValues{
+		{Fields: []Field{
+			{Name: "Time", Values: []string{"23:59:58", "23:59:58", "00:00:00"}},
+			{Name: "CPU", Values: []string{"0", "1", "0"}},
+		}},
+		// not a time series
+		{Fields: []Field{
+			{Name: "Date", Values: []string{"06/01/2025"}},
+			{Name: "Time", Values: []string{"10:00:00"}},
+		}},
+		// not times
+		{Fields: []Field{
+			{Name: "Time", Values: []string{"Sun Jun  1 23:59:57 CEST 2025"}},
+		}},
+	}
+	NormalizeTableTimes(allTableValues, clock)
+	assert.Equal(t, []string{"2025-06-01T21:59:55Z", "2025-06-01T21:59:55Z", "2025-06-01T21:59:57Z"}, allTableValues[0].Fields[0].Values)
+	assert.Equal(t, []string{"10:00:00"}, allTableValues[1].Fields[1].Values)
+	assert.Equal(t, []string{"Sun Jun  1 23:59:57 CEST 2025"}, allTableValues[2].Fields[0].Values)
+}
+
+func TestNormalizeTableTimesNextDay(t *testing.T) {
+	// the first sample is after midnight on the target
+	clock := target.ClockOffset{TargetTime: time.Date(2025, 6, 1, 23, 59, 59, 0, time.UTC)}
+	allTableValues := []TableValues{{Fields: []Field{{Name: "Time", Values: []string{"00:00:01"}}}}}
+	NormalizeTableTimes(allTableValues, clock)
+	assert.Equal(t, []string{"2025-06-02T00:00:01Z"}, allTableValues[0].Fields[0].Values)
+}
+
+func TestHasTimeSeriesTable(t *testing.T) {
+	assert.True(t, HasTimeSeriesTable([]string{BriefSysSummaryTableName, CPUUtilizationTelemetryTableName}))
+	assert.False(t, HasTimeSeriesTable([]string{BriefSysSummaryTableName, CallStackFrequencyTableName}))
+	assert.False(t, HasTimeSeriesTable([]string{ProcessSummaryTelemetryTableName}))
+}
@@ -14,6 +14,7 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"perfspect/internal/target"
 	"perfspect/internal/util"
@@ -195,6 +196,125 @@ func RunScriptStream(myTarget target.Target, script ScriptDefinition, localTempD
 	errorChannel <- err
 }
 
+// streamDrainTime is how long to wait for the remaining output of a script after it exits
+const streamDrainTime = 100 * time.Millisecond
+
+// RunScriptsStream runs the scripts on the target in parallel and passes each line of their stdout to lineFunc as
+// it is received. lineFunc is called from multiple goroutines. The complete outputs of the scripts are returned
+// after all scripts exit.
+func RunScriptsStream(myTarget target.Target, scripts []ScriptDefinition, localTempDir string, lineFunc func(scriptName string, line string)) (map[string]ScriptOutput, error) {
+	canElevate := myTarget.CanElevatePrivileges()
+	var streamScripts []ScriptDefinition
+	for _, script := range scripts {
+		if !scriptForTarget(script, myTarget) {
+			slog.Debug("skipping script because it is not intended to run on the target processor", slog.String("target", myTarget.GetName()), slog.String("script", script.Name))
+			continue
+		}
+		if script.Superuser && !canElevate {
+			slog.Debug("skipping script because it requires superuser privileges and the user cannot elevate privileges on target", slog.String("script", script.Name))
+			continue
+		}
+		// the scripts aren't run by the master script, so they need their own SIGINT handler to stop the
+		// commands they start in the background
+		if script.NeedsKill {
+			script.ScriptTemplate = streamStopHandler(script.Name) + script.ScriptTemplate
+		}
+		streamScripts = append(streamScripts, script)
+	}
+	installedLkms, err := prepareTargetToRunScripts(myTarget, streamScripts, localTempDir, false)
+	if err != nil {
+		err = fmt.Errorf("error while preparing target to run scripts: %v", err)
+		return nil, err
+	}
+	if len(installedLkms) > 0 {
+		defer func() {
+			err := myTarget.UninstallLkms(installedLkms)
+			if err != nil {
+				slog.Error("error uninstalling LKMs", slog.String("lkms", strings.Join(installedLkms, ", ")), slog.String("error", err.Error()))
+			}
+		}()
+	}
+	outputChannel := make(chan ScriptOutput)
+	for _, script := range streamScripts {
+		scriptPath := path.Join(myTarget.GetTempDirectory(), scriptNameToFilename(script.Name))
+		var cmd *exec.Cmd
+		if script.Superuser && !myTarget.IsSuperUser() {
+			cmd = exec.Command("sudo", "-S", "bash", scriptPath) // #nosec G204
+		} else {
+			cmd = exec.Command("bash", scriptPath) // #nosec G204
+		}
+		go func(script ScriptDefinition, cmd *exec.Cmd) {
+			outputChannel <- streamScript(myTarget, script, cmd, lineFunc)
+		}(script, cmd)
+	}
+	scriptOutputs := make(map[string]ScriptOutput)
+	for range streamScripts {
+		scriptOutput := <-outputChannel
+		scriptOutputs[scriptOutput.Name] = scriptOutput
+	}
+	return scriptOutputs, nil
+}
+
+// streamScript runs the command that runs the script on the target and collects its output
+func streamScript(myTarget target.Target, script ScriptDefinition, cmd *exec.Cmd, lineFunc func(scriptName string, line string)) ScriptOutput {
+	stdoutChannel := make(chan string)
+	stderrChannel := make(chan string)
+	exitcodeChannel := make(chan int)
+	errorChannel := make(chan error)
+	cmdChannel := make(chan *exec.Cmd)
+	go func() {
+		errorChannel <- myTarget.RunCommandStream(cmd, 0, false, stdoutChannel, stderrChannel, exitcodeChannel, cmdChannel)
+	}()
+	<-cmdChannel
+	var stdout, stderr strings.Builder
+	scriptOutput := ScriptOutput{ScriptDefinition: script, Exitcode: -1}
+	receive := func(timeout <-chan time.Time) bool {
+		select {
+		case line := <-stdoutChannel:
+			stdout.WriteString(line + "\n")
+			lineFunc(script.Name, line)
+		case line := <-stderrChannel:
+			stderr.WriteString(line + "\n")
+		case exitcode := <-exitcodeChannel:
+			scriptOutput.Exitcode = exitcode
+		case err := <-errorChannel:
+			if err != nil {
+				slog.Error("error running script on target", slog.String("script", script.Name), slog.String("error", err.Error()))
+			}
+			return false
+		case <-timeout:
+			return false
+		}
+		return true
+	}
+	for receive(nil) {
+	}
+	// lines may still be in flight after the command exits
+	for receive(time.After(streamDrainTime)) {
+	}
+	scriptOutput.Stdout = stdout.String()
+	scriptOutput.Stderr = stderr.String()
+	return scriptOutput
+}
+
+// streamStopHandler returns the bash that stops the command that a script starts in the background when the
+// script receives SIGINT or SIGTERM, as the master script's handle_sigint does for the scripts it runs
+func streamStopHandler(scriptName string) string {
+	pidFile := sanitizeScriptName(scriptName) + "_cmd.pid"
+	return fmt.Sprintf(`stop_cmd() {
+	if [ -f %[1]s ] && ps -p $(cat %[1]s) > /dev/null; then
+		kill -SIGINT $(cat %[1]s)
+		sleep 0.5
+		if ps -p $(cat %[1]s) > /dev/null; then
+			kill -SIGKILL $(cat %[1]s)
+		fi
+	fi
+	exit 0
+}
+trap stop_cmd SIGINT SIGTERM
+`, pidFile)
+}
+
 // scriptForTarget checks if the script is intended for the target processor.
 func scriptForTarget(script ScriptDefinition, myTarget target.Target) bool {
 	if len(script.Architectures) > 0 {
@@ -22,8 +22,12 @@ type ScriptDefinition struct {
 	Superuser      bool     // requires sudo or root
 	Sequential     bool     // run script sequentially (not at the same time as others)
 	NeedsKill      bool     // process/script needs to be killed after run without a duration specified, i.e., it doesn't stop through SIGINT
+	Settings       []string // sysctl or sysfs files that the script changes and restores, recorded in the target's journal before the script runs
 }
 
+// perfSettings are the settings changed by scripts that need unrestricted access to perf events and kernel symbols
+var perfSettings = []string{"/proc/sys/kernel/perf_event_paranoid", "/proc/sys/kernel/kptr_restrict"}
+
 // script names, these must be unique
 const (
 	// report and configuration (reading) scripts
@@ -111,9 +115,14 @@ const (
 	IostatTelemetryScriptName      = "iostat telemetry"
 	MemoryTelemetryScriptName      = "memory telemetry"
 	NetworkTelemetryScriptName     = "network telemetry"
+	ProcessTelemetryScriptName     = "process telemetry"
+	PressureTelemetryScriptName    = "pressure telemetry"
+	NetStackTelemetryScriptName    = "network stack telemetry"
+	NetQueueTelemetryScriptName    = "network queue telemetry"
 	TurbostatTelemetryScriptName   = "turbostat telemetry"
 	InstructionTelemetryScriptName = "instruction telemetry"
 	GaudiTelemetryScriptName       = "gaudi telemetry"
+	TelemetryRecorderScriptName    = "telemetry recorder"
 	// flamegraph scripts
 	CollapsedCallStacksScriptName = "collapsed call stacks"
 	// lock scripts
@@ -1133,13 +1142,18 @@ duration={{.Duration}}
 if [ $duration -ne 0 ] && [ $interval -ne 0 ]; then
 	count=$((duration / interval))
 fi
-mpstat -u -T -I SCPU -P ALL $interval $count &
+# prefer the native collector, mpstat is the fallback
+if command -v procstat >/dev/null 2>&1; then
+	procstat -interval $interval -count ${count:-0} -sources cpu,softirq,interrupts &
+else
+	mpstat -u -T -I SCPU -P ALL $interval $count &
+fi
 echo $! > {{.ScriptName}}_cmd.pid
 wait
 `,
 		Superuser: true,
 		Lkms:      []string{},
-		Depends:   []string{"mpstat"},
+		Depends:   []string{"procstat", "mpstat"},
 		NeedsKill: true,
 	},
 	IostatTelemetryScriptName: {
@@ -1149,13 +1163,18 @@ duration={{.Duration}}
 if [ $duration -ne 0 ] && [ $interval -ne 0 ]; then
 	count=$((duration / interval))
 fi
-S_TIME_FORMAT=ISO iostat -d -t $interval $count | sed '/^loop/d' &
+# prefer the native collector, iostat is the fallback
+if command -v procstat >/dev/null 2>&1; then
+	procstat -interval $interval -count ${count:-0} -sources disk &
+else
+	S_TIME_FORMAT=ISO iostat -d -t $interval $count | sed '/^loop/d' &
+fi
 echo $! > {{.ScriptName}}_cmd.pid
 wait
 `,
 		Superuser: true,
 		Lkms:      []string{},
-		Depends:   []string{"iostat"},
+		Depends:   []string{"procstat", "iostat"},
 		NeedsKill: true,
 	},
 	MemoryTelemetryScriptName: {
@@ -1165,13 +1184,18 @@ duration={{.Duration}}
 if [ $duration -ne 0 ] && [ $interval -ne 0 ]; then
 	count=$((duration / interval))
 fi
-sar -r $interval $count &
+# prefer the native collector, sar is the fallback
+if command -v procstat >/dev/null 2>&1; then
+	procstat -interval $interval -count ${count:-0} -sources memory,vmstat &
+else
+	sar -r $interval $count &
+fi
 echo $! > {{.ScriptName}}_cmd.pid
 wait
 `,
 		Superuser: true,
 		Lkms:      []string{},
-		Depends:   []string{"sar", "sadc"},
+		Depends:   []string{"procstat", "sar", "sadc"},
 		NeedsKill: true,
 	},
 	NetworkTelemetryScriptName: {
@@ -1181,15 +1205,136 @@ duration={{.Duration}}
 if [ $duration -ne 0 ] && [ $interval -ne 0 ]; then
 	count=$((duration / interval))
 fi
-sar -n DEV $interval $count &
+# prefer the native collector, sar is the fallback
+if command -v procstat >/dev/null 2>&1; then
+	procstat -interval $interval -count ${count:-0} -sources net &
+else
+	sar -n DEV $interval $count &
+fi
+echo $! > {{.ScriptName}}_cmd.pid
+wait
+`,
+		Superuser: true,
+		Lkms:      []string{},
+		Depends:   []string{"procstat", "sar", "sadc"},
+		NeedsKill: true,
+	},
+	ProcessTelemetryScriptName: {
+		Name: ProcessTelemetryScriptName,
+		ScriptTemplate: `interval={{.Interval}}
+duration={{.Duration}}
+if [ $duration -ne 0 ] && [ $interval -ne 0 ]; then
+	count=$((duration / interval))
+fi
+# .ProcessPIDs and .ProcessNames are comma separated lists, the top processes by CPU utilization
+# are collected if both are empty
+procstat -interval $interval -count ${count:-0} -sources process -top {{.ProcessTop}} -pids "{{.ProcessPIDs}}" -names "{{.ProcessNames}}" &
+echo $! > {{.ScriptName}}_cmd.pid
+wait
+`,
+		Superuser: true,
+		Lkms:      []string{},
+		Depends:   []string{"procstat"},
+		NeedsKill: true,
+	},
+	PressureTelemetryScriptName: {
+		Name: PressureTelemetryScriptName,
+		ScriptTemplate: `interval={{.Interval}}
+duration={{.Duration}}
+if [ $duration -ne 0 ] && [ $interval -ne 0 ]; then
+	count=$((duration / interval))
+fi
+if [ ! -e /proc/pressure ]; then
+	echo "pressure stall information is not available, the kernel must be built with CONFIG_PSI and booted without psi=0" >&2
+fi
+# .PressureCgroups is a comma separated list of cgroup v2 paths relative to /sys/fs/cgroup
+procstat -interval $interval -count ${count:-0} -sources pressure,sched -cgroups "{{.PressureCgroups}}" &
+echo $! > {{.ScriptName}}_cmd.pid
+wait
+`,
+		Superuser: true,
+		Lkms:      []string{},
+		Depends:   []string{"procstat"},
+		NeedsKill: true,
+	},
+	NetStackTelemetryScriptName: {
+		Name: NetStackTelemetryScriptName,
+		ScriptTemplate: `interval={{.Interval}}
+duration={{.Duration}}
+if [ $duration -ne 0 ] && [ $interval -ne 0 ]; then
+	count=$((duration / interval))
+fi
+procstat -interval $interval -count ${count:-0} -sources netstack &
 echo $! > {{.ScriptName}}_cmd.pid
 wait
 `,
 		Superuser: true,
 		Lkms:      []string{},
-		Depends:   []string{"sar", "sadc"},
+		Depends:   []string{"procstat"},
 		NeedsKill: true,
 	},
+	NetQueueTelemetryScriptName: {
+		Name: NetQueueTelemetryScriptName,
+		ScriptTemplate: `interval={{.Interval}}
+duration={{.Duration}}
+if [ $duration -ne 0 ] && [ $interval -ne 0 ]; then
+	count=$((duration / interval))
+fi
+# .NetworkInterfaces is a comma separated list, the physical interfaces are monitored if it's empty
+ifaces=$(echo "{{.NetworkInterfaces}}" | tr ',' ' ')
+if [ -z "$ifaces" ]; then
+	for dev in /sys/class/net/*; do
+		if [ -e "$dev/device" ]; then
+			ifaces="$ifaces $(basename "$dev")"
+		fi
+	done
+fi
+if [ -z "$ifaces" ]; then
+	echo "no network interfaces found" >&2
+	exit 1
+fi
+echo INTERVAL: $interval
+# the counters are cumulative, rates are calculated from consecutive samples
+sample() {
+	echo TIME: $(date +"%H:%M:%S %s")
+	for iface in $ifaces; do
+		echo IFACE: $iface
+		ethtool -S $iface 2>/dev/null
+	done
+}
+(
+	sample
+	i=0
+	while [ -z "$count" ] || [ $i -lt $count ]; do
+		sleep $interval
+		sample
+		i=$((i + 1))
+	done
+) &
+echo $! > {{.ScriptName}}_cmd.pid
+wait
+`,
+		Superuser: true,
+		Lkms:      []string{},
+		Depends:   []string{"ethtool"},
+		NeedsKill: true,
+	},
+	TelemetryRecorderScriptName: {
+		Name: TelemetryRecorderScriptName,
+		ScriptTemplate: `interval={{.Interval}}
+duration={{.Duration}}
+if [ $duration -ne 0 ] && [ $interval -ne 0 ]; then
+	count=$((duration / interval))
+fi
+# the recorder writes rotating telemetry.<epoch>.jsonl files in the temporary directory, keeping the newest
+# {{.RecordKeep}} files. It runs in its own session so that it survives the loss of the connection to the target.
+setsid procstat -interval $interval -count ${count:-0} -sources {{.RecordSources}} -top {{.ProcessTop}} -pids "{{.ProcessPIDs}}" -names "{{.ProcessNames}}" -cgroups "{{.PressureCgroups}}" -output telemetry -rotate {{.RecordRotate}} -keep {{.RecordKeep}} > /dev/null 2> {{.ScriptName}}.stderr < /dev/null &
+echo $!
+`,
+		Superuser: true,
+		Lkms:      []string{},
+		Depends:   []string{"procstat"},
+	},
 	TurbostatTelemetryScriptName: {
 		Name: TurbostatTelemetryScriptName,
 		ScriptTemplate: `interval={{.Interval}}
@@ -1270,10 +1415,17 @@ fi
 	CollapsedCallStacksScriptName: {
 		Name: CollapsedCallStacksScriptName,
 		ScriptTemplate: `# Combined (perf record and async profiler) call stack collection
+# mode: cpu (on-CPU samples), offcpu (blocked time from sched_switch, native only),
+# wall, alloc, lock (async-profiler events, java only)
+# percpu: break the native stacks down by CPU, cpu mode only
+# timeline: write the timeline of the native samples, cpu mode only
 pids={{.PIDs}}
 duration={{.Duration}}
 frequency={{.Frequency}}
 maxdepth={{.MaxDepth}}
+mode={{.Mode}}
+percpu={{.PerCPU}}
+timeline={{.Timeline}}
 
 ap_interval=0
 if [ "$frequency" -ne 0 ]; then
@@ -1290,6 +1442,9 @@ restore_settings() {
     if [ -n "$perf_dwarf_pid" ]; then
         kill -0 $perf_dwarf_pid 2>/dev/null && kill -INT $perf_dwarf_pid
     fi
+    if [ -n "$perf_offcpu_pid" ]; then
+        kill -0 $perf_offcpu_pid 2>/dev/null && kill -INT $perf_offcpu_pid
+    fi
     for pid in "${java_pids[@]}"; do
         async-profiler/bin/asprof stop -o collapsed "$pid"
     done
@@ -1320,50 +1475,85 @@ if [ -n "$pids" ]; then
 else
     mapfile -t java_pids < <(pgrep java)
 fi
-
-# Frame pointer mode
-if [ -n "$pids" ]; then
-    perf record -F "$frequency" -p "$pids" -g -o perf_fp_data -m 129 &
-else
-    perf record -F "$frequency" -a -g -o perf_fp_data -m 129 &
-fi
-perf_fp_pid=$!
-if ! kill -0 $perf_fp_pid 2>/dev/null; then
-    echo "Failed to start perf record in frame pointer mode" >&2
-    exit 1
+# async-profiler doesn't profile off-CPU time
+if [ "$mode" = "offcpu" ]; then
+    java_pids=()
 fi
 
-# Dwarf mode
-if [ -n "$pids" ]; then
-    perf record -F "$frequency" -p "$pids" -g -o perf_dwarf_data -m 257 --call-graph dwarf,8192 &
-else
-    perf record -F "$frequency" -a -g -o perf_dwarf_data -m 257 --call-graph dwarf,8192 &
-fi
-perf_dwarf_pid=$!
-if ! kill -0 $perf_dwarf_pid 2>/dev/null; then
-    echo "Failed to start perf record in dwarf mode" >&2
-    exit 1
+if [ "$mode" = "cpu" ]; then
+    # Frame pointer mode
+    if [ -n "$pids" ]; then
+        perf record -F "$frequency" -p "$pids" -g -T --sample-cpu -o perf_fp_data -m 129 &
+    else
+        perf record -F "$frequency" -a -g -T --sample-cpu -o perf_fp_data -m 129 &
+    fi
+    perf_fp_pid=$!
+    if ! kill -0 $perf_fp_pid 2>/dev/null; then
+        echo "Failed to start perf record in frame pointer mode" >&2
+        exit 1
+    fi
+
+    # Dwarf mode
+    if [ -n "$pids" ]; then
+        perf record -F "$frequency" -p "$pids" -g -o perf_dwarf_data -m 257 --call-graph dwarf,8192 &
+    else
+        perf record -F "$frequency" -a -g -o perf_dwarf_data -m 257 --call-graph dwarf,8192 &
+    fi
+    perf_dwarf_pid=$!
+    if ! kill -0 $perf_dwarf_pid 2>/dev/null; then
+        echo "Failed to start perf record in dwarf mode" >&2
+        exit 1
+    fi
+elif [ "$mode" = "offcpu" ]; then
+    # Context switches of all tasks, a task's switch in is recorded on the CPU of the task that
+    # switches out, so the PIDs are filtered when the stacks are collapsed
+    perf record -e sched:sched_switch -a -g -o perf_offcpu_data -m 257 &
+    perf_offcpu_pid=$!
+    if ! kill -0 $perf_offcpu_pid 2>/dev/null; then
+        echo "Failed to start perf record for off-CPU time" >&2
+        exit 1
+    fi
 fi
 
+# async-profiler event and sampling interval of the mode, the alloc and lock intervals are in
+# bytes and nanoseconds so their defaults are used
+case "$mode" in
+    wall) ap_args=(-e wall -i "$ap_interval") ;;
+    alloc) ap_args=(-e alloc) ;;
+    lock) ap_args=(-e lock) ;;
+    *) ap_args=(-i "$ap_interval") ;;
+esac
+
 # Start Java profiling for each Java PID
 for pid in "${java_pids[@]}"; do
     java_cmds+=("$(tr '\000' ' ' < /proc/"$pid"/cmdline)")
-    async-profiler/bin/asprof start -i "$ap_interval" -F probesp+vtable "$pid"
+    async-profiler/bin/asprof start "${ap_args[@]}" -F probesp+vtable "$pid"
 done
 
 # Wait for the specified duration
 sleep "$duration"
 
 # Stop perf recording
-if ! kill -0 $perf_fp_pid 2>/dev/null; then
-    echo "Frame pointer mode already stopped" >&2
-else
-    kill -INT $perf_fp_pid
+if [ -n "$perf_fp_pid" ]; then
+    if ! kill -0 $perf_fp_pid 2>/dev/null; then
+        echo "Frame pointer mode already stopped" >&2
+    else
+        kill -INT $perf_fp_pid
+    fi
 fi
-if ! kill -0 $perf_dwarf_pid 2>/dev/null; then
-    echo "Dwarf mode already stopped" >&2
-else
-    kill -INT $perf_dwarf_pid
+if [ -n "$perf_dwarf_pid" ]; then
+    if ! kill -0 $perf_dwarf_pid 2>/dev/null; then
+        echo "Dwarf mode already stopped" >&2
+    else
+        kill -INT $perf_dwarf_pid
+    fi
+fi
+if [ -n "$perf_offcpu_pid" ]; then
+    if ! kill -0 $perf_offcpu_pid 2>/dev/null; then
+        echo "Off-CPU recording already stopped" >&2
+    else
+        kill -INT $perf_offcpu_pid
+    fi
 fi
 
 # Stop Java profiling, write output to ap_folded_<pid> files
@@ -1372,25 +1562,122 @@ for pid in "${java_pids[@]}"; do
 done
 
 # Wait for perf to finish
-wait ${perf_fp_pid} ${perf_dwarf_pid}
+wait ${perf_fp_pid} ${perf_dwarf_pid} ${perf_offcpu_pid}
 
 # Collapse perf data
-if [ -f perf_dwarf_data ]; then
-    perf script -i perf_dwarf_data > perf_dwarf_stacks
-    stackcollapse-perf perf_dwarf_stacks > perf_dwarf_folded
-else
-    echo "Error: perf_dwarf_data file not found" >&2
-fi
-if [ -f perf_fp_data ]; then
-    perf script -i perf_fp_data > perf_fp_stacks
-    stackcollapse-perf perf_fp_stacks > perf_fp_folded
-else
-    echo "Error: perf_fp_data file not found" >&2
+if [ "$mode" = "cpu" ]; then
+    if [ -f perf_dwarf_data ]; then
+        perf script -i perf_dwarf_data > perf_dwarf_stacks
+        stackcollapse-perf perf_dwarf_stacks > perf_dwarf_folded
+    else
+        echo "Error: perf_dwarf_data file not found" >&2
+    fi
+    if [ -f perf_fp_data ]; then
+        perf script -i perf_fp_data > perf_fp_stacks
+        stackcollapse-perf perf_fp_stacks > perf_fp_folded
+    else
+        echo "Error: perf_fp_data file not found" >&2
+    fi
+    if [ -f perf_fp_data ] && [ "$timeline" = "true" ]; then
+        # Timeline of the samples, each distinct stack is written once to perf_timeline_stacks
+        # and each sample is written as "<seconds since the first sample> <cpu> <stack index>"
+        perf script -i perf_fp_data -F comm,cpu,time,ip,sym 2>/dev/null | awk '
+            function emit() {
+                key = comm ";" (frames == "" ? "[unknown]" : frames)
+                if (!(key in ids)) {
+                    ids[key] = nstacks++
+                    print key > "perf_timeline_stacks"
+                }
+                if (!started) {
+                    t0 = t
+                    started = 1
+                }
+                printf "%.3f %d %d\n", t - t0, cpu, ids[key]
+                cur = 0
+            }
+            # <comm> [<cpu>] <time>:
+            match($0, / +\[[0-9]+\] +[0-9]+\.[0-9]+:/) {
+                if (cur) emit()
+                comm = substr($0, 1, RSTART - 1)
+                gsub(/^[ \t]+|[ \t]+$/, "", comm)
+                gsub(/[^A-Za-z0-9_.-]/, "_", comm)
+                header = substr($0, RSTART, RLENGTH)
+                match(header, /\[[0-9]+\]/)
+                cpu = substr(header, RSTART + 1, RLENGTH - 2)
+                t = substr(header, RSTART + RLENGTH) + 0
+                frames = ""
+                cur = 1
+                next
+            }
+            # the stack, innermost frame first
+            cur && /^[ \t]+[0-9a-f]+ / {
+                frame = $0
+                sub(/^[ \t]+[0-9a-f]+ /, "", frame)
+                gsub(/;/, ":", frame)
+                frames = (frames == "" ? frame : frame ";" frames)
+            }
+            END {
+                if (cur) emit()
+            }' > perf_timeline_samples
+    fi
+elif [ "$mode" = "offcpu" ]; then
+    # The stack of a task that blocks, i.e., switches out in a state other than runnable, is
+    # weighted by the microseconds until the task switches in again
+    if [ -f perf_offcpu_data ]; then
+        perf script -i perf_offcpu_data -F comm,pid,tid,time,event,trace,ip,sym 2>/dev/null | awk -v pids="$pids" '
+            BEGIN {
+                n = split(pids, p, ",")
+                for (i = 1; i <= n; i++) want[p[i]] = 1
+            }
+            # <comm> <pid>/<tid> <time>: sched:sched_switch: prev_comm=... prev_pid=... prev_state=... ==> next_comm=... next_pid=...
+            /sched:sched_switch:/ {
+                cur = ""
+                if (!match($0, / [0-9]+\/[0-9]+ /)) next
+                comm = substr($0, 1, RSTART - 1)
+                split(substr($0, RSTART + 1, RLENGTH - 2), ids, "/")
+                t = substr($0, RSTART + RLENGTH) + 0
+                match($0, /prev_pid=[0-9]+/); prev_tid = substr($0, RSTART + 9, RLENGTH - 9)
+                match($0, /prev_state=[^ ]+/); state = substr($0, RSTART + 11, RLENGTH - 11)
+                match($0, /next_pid=[0-9]+/); next_tid = substr($0, RSTART + 9, RLENGTH - 9)
+                if (next_tid in start) {
+                    key = comms[next_tid] ";" (frames[next_tid] == "" ? "[unknown]" : frames[next_tid])
+                    blocked[key] += (t - start[next_tid]) * 1000000
+                    delete start[next_tid]
+                }
+                if (prev_tid != 0 && state !~ /^R/ && (n == 0 || (ids[1] in want))) {
+                    gsub(/^[ \t]+|[ \t]+$/, "", comm)
+                    gsub(/[^A-Za-z0-9_.-]/, "_", comm)
+                    cur = prev_tid
+                    start[cur] = t
+                    comms[cur] = comm
+                    frames[cur] = ""
+                }
+                next
+            }
+            # the stack, innermost frame first
+            cur != "" && /^[ \t]+[0-9a-f]+ / {
+                frame = $0
+                sub(/^[ \t]+[0-9a-f]+ /, "", frame)
+                gsub(/;/, ":", frame)
+                frames[cur] = (frames[cur] == "" ? frame : frame ";" frames[cur])
+            }
+            END {
+                for (key in blocked) {
+                    if (blocked[key] >= 1) printf "%s %d\n", key, blocked[key]
+                }
+            }' > perf_offcpu_folded
+    else
+        echo "Error: perf_offcpu_data file not found" >&2
+    fi
 fi
 
 # Dump results to stdout
+echo "########## mode ##########"
+echo "$mode"
 echo "########## maximum depth ##########"
 echo "$maxdepth"
+echo "########## per cpu ##########"
+echo "$percpu"
 
 if [ -f perf_dwarf_folded ]; then
     echo "########## perf_dwarf ##########"
@@ -1400,6 +1687,16 @@ if [ -f perf_fp_folded ]; then
     echo "########## perf_fp ##########"
     cat perf_fp_folded
 fi
+if [ -f perf_offcpu_folded ]; then
+    echo "########## perf_offcpu ##########"
+    cat perf_offcpu_folded
+fi
+if [ -s perf_timeline_samples ] && [ -f perf_timeline_stacks ]; then
+    echo "########## perf_timeline_stacks ##########"
+    cat perf_timeline_stacks
+    echo "########## perf_timeline ##########"
+    cat perf_timeline_samples
+fi
 
 for idx in "${!java_pids[@]}"; do
     pid="${java_pids[$idx]}"
@@ -1415,6 +1712,7 @@ done
 		Superuser:  true,
 		Sequential: true,
 		Depends:    []string{"async-profiler", "perf", "stackcollapse-perf"},
+		Settings:   perfSettings,
 	},
 	// lock analysis scripts
 	ProfileKernelLockScriptName: {
@@ -1481,5 +1779,6 @@ fi
 `,
 		Superuser: true,
 		Depends:   []string{"perf", "perf-archive"},
+		Settings:  perfSettings,
 	},
 }
@@ -8,6 +8,7 @@ import (
 	"path"
 	"regexp"
 	"strings"
+	"sync"
 	"testing"
 
 	"perfspect/internal/target"
@@ -211,3 +212,50 @@ mpstat -u -T -I SCPU -P ALL 1 $count`,
 		}
 	}
 }
+
+func TestRunScriptsStream(t *testing.T) {
+	tgt := target.NewLocalTarget()
+	targetTempDir, err := tgt.CreateTempDirectory("/tmp")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() {
+		err := tgt.RemoveDirectory(targetTempDir)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}()
+	localTempDir, err := os.MkdirTemp(os.TempDir(), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(localTempDir)
+	err = os.MkdirAll(path.Join(localTempDir, tgt.GetName()), 0700)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	scripts := []ScriptDefinition{
+		{Name: "unittest count", ScriptTemplate: "for i in 1 2 3; do echo $i; done"},
+		{Name: "unittest background", ScriptTemplate: "(echo a; echo b >&2; exit 3) &\necho $! > unittest_background_cmd.pid\nwait $!", NeedsKill: true},
+	}
+	var mutex sync.Mutex
+	lines := make(map[string][]string)
+	scriptOutputs, err := RunScriptsStream(tgt, scripts, localTempDir, func(scriptName string, line string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		lines[scriptName] = append(lines[scriptName], line)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Join(lines["unittest count"], ","); got != "1,2,3" {
+		t.Errorf("unexpected streamed lines: got %q, want %q", got, "1,2,3")
+	}
+	if got := scriptOutputs["unittest count"].Stdout; got != "1\n2\n3\n" {
+		t.Errorf("unexpected stdout: got %q", got)
+	}
+	background := scriptOutputs["unittest background"]
+	if background.Stdout != "a\n" || background.Stderr != "b\n" || background.Exitcode != 3 {
+		t.Errorf("unexpected output: got %q, %q, %d", background.Stdout, background.Stderr, background.Exitcode)
+	}
+}
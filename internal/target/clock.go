@@ -0,0 +1,76 @@
+package target
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ClockOffset is a measurement of a target's clock relative to the controller's clock
+type ClockOffset struct {
+	Offset     time.Duration // the target's clock minus the controller's clock
+	RoundTrip  time.Duration // of the measurement, the offset is accurate to within half of it
+	TargetTime time.Time     // the target's clock at the measurement, in the target's time zone
+}
+
+// clockSamples is the number of round trips in a measurement, the one with the shortest round trip is used
+const clockSamples = 5
+
+// MeasureClockOffset measures the offset of the target's clock from the controller's clock. The
+// target's time, read with date, is compared to the midpoint of the controller's times before and
+// after the round trip.
+func MeasureClockOffset(t Target) (clock ClockOffset, err error) {
+	for i := range clockSamples {
+		before := time.Now()
+		stdout, stderr, _, runErr := t.RunCommand(exec.Command("date", "+%s.%N %z"), 10, true)
+		after := time.Now()
+		if runErr != nil {
+			err = fmt.Errorf("failed to read the clock: %v, %s", runErr, stderr)
+			return
+		}
+		targetTime, parseErr := parseDateOutput(stdout)
+		if parseErr != nil {
+			err = parseErr
+			return
+		}
+		roundTrip := after.Sub(before)
+		if i > 0 && roundTrip >= clock.RoundTrip {
+			continue
+		}
+		clock = ClockOffset{
+			Offset:     targetTime.Sub(before.Add(roundTrip / 2)),
+			RoundTrip:  roundTrip,
+			TargetTime: targetTime,
+		}
+	}
+	return
+}
+
+// parseDateOutput parses the output of date +"%s.%N %z", e.g., "1700000000.123456789 +0200". Some
+// versions of date don't support %N, the time is then to the second.
+func parseDateOutput(output string) (time.Time, error) {
+	fields := strings.Fields(output)
+	if len(fields) != 2 {
+		return time.Time{}, fmt.Errorf("unexpected date output: %s", output)
+	}
+	secondsStr, nanosStr, _ := strings.Cut(fields[0], ".")
+	seconds, err := strconv.ParseInt(secondsStr, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unexpected date output: %s", output)
+	}
+	nanos, err := strconv.ParseInt((nanosStr + "000000000")[:9], 10, 64)
+	if err != nil {
+		nanos = 0
+	}
+	zone, err := time.Parse("-0700", fields[1])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unexpected date output: %s", output)
+	}
+	_, zoneOffset := zone.Zone()
+	return time.Unix(seconds, nanos).In(time.FixedZone(fields[1], zoneOffset)), nil
+}
@@ -0,0 +1,57 @@
+package target
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDateOutput(t *testing.T) {
+	tests := []struct {
+		output   string
+		expected time.Time
+		offset   int
+		wantErr  bool
+	}{
+		{output: "1700000000.123456789 +0200\n", expected: time.Unix(1700000000, 123456789), offset: 2 * 3600},
+		{output: "1700000000.5 -0530", expected: time.Unix(1700000000, 500000000), offset: -(5*3600 + 30*60)},
+		{output: "1700000000.N +0000", expected: time.Unix(1700000000, 0)},
+		{output: "1700000000", wantErr: true},
+		{output: "now +0000", wantErr: true},
+	}
+	for _, tt := range tests {
+		parsed, err := parseDateOutput(tt.output)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseDateOutput(%q) expected an error", tt.output)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseDateOutput(%q) unexpected error: %v", tt.output, err)
+			continue
+		}
+		if !parsed.Equal(tt.expected) {
+			t.Errorf("parseDateOutput(%q) = %v, expected %v", tt.output, parsed, tt.expected)
+		}
+		if _, offset := parsed.Zone(); offset != tt.offset {
+			t.Errorf("parseDateOutput(%q) zone offset = %d, expected %d", tt.output, offset, tt.offset)
+		}
+	}
+}
+
+func TestMeasureClockOffset(t *testing.T) {
+	clock, err := MeasureClockOffset(NewLocalTarget())
+	if err != nil {
+		t.Fatalf("MeasureClockOffset() unexpected error: %v", err)
+	}
+	// the controller is the target
+	if clock.Offset.Abs() > clock.RoundTrip+time.Millisecond {
+		t.Errorf("MeasureClockOffset() offset = %v, expected less than the round trip, %v", clock.Offset, clock.RoundTrip)
+	}
+	if time.Since(clock.TargetTime) > time.Minute {
+		t.Errorf("MeasureClockOffset() target time = %v, expected about now", clock.TargetTime)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"log/slog"
 	"os/exec"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -161,18 +162,24 @@ func runLocalCommandWithInputWithTimeoutAsync(cmd *exec.Cmd, stdoutChannel chan
 		err = fmt.Errorf("failed to run command (%s): %v", cmd, err)
 		return
 	}
+	// Wait closes the pipes, so the output must be read before calling it
+	var readers sync.WaitGroup
+	readers.Add(2)
 	go func() {
+		defer readers.Done()
 		for stdoutScanner.Scan() {
 			text := stdoutScanner.Text()
 			stdoutChannel <- text
 		}
 	}()
 	go func() {
+		defer readers.Done()
 		for stderrScanner.Scan() {
 			text := stderrScanner.Text()
 			stderrChannel <- text
 		}
 	}()
+	readers.Wait()
 	err = cmd.Wait()
 	if err != nil {
 		if exitError, ok := err.(*exec.ExitError); ok {
@@ -0,0 +1,3 @@
+module intel.com/procstat
+
+go 1.24
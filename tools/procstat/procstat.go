@@ -0,0 +1,1102 @@
+// procstat samples /proc and /sys at an interval and writes one JSON object per sample to stdout, or
+// to rotating files when recording. It replaces the sysstat tools (mpstat, iostat, sar) for telemetry
+// collection.
+package main
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FormatVersion is incremented when the output format changes in a way that isn't backwards compatible
+const FormatVersion = 1
+
+// sources that can be sampled
+const (
+	SourceCPU        = "cpu"        // /proc/stat
+	SourceSoftIRQ    = "softirq"    // /proc/softirqs
+	SourceInterrupts = "interrupts" // /proc/interrupts
+	SourceDisk       = "disk"       // /proc/diskstats
+	SourceMemory     = "memory"     // /proc/meminfo
+	SourceVMStat     = "vmstat"     // /proc/vmstat
+	SourceNet        = "net"        // /proc/net/dev
+	SourceProcess    = "process"    // /proc/<pid>/stat, status, and io
+	SourcePressure   = "pressure"   // /proc/pressure/*, and <cgroup>/*.pressure for the requested cgroups
+	SourceSched      = "sched"      // /proc/stat
+	SourceNetStack   = "netstack"   // /proc/net/snmp and /proc/net/netstat
+)
+
+var sourceOptions = []string{SourceCPU, SourceSoftIRQ, SourceInterrupts, SourceDisk, SourceMemory, SourceVMStat, SourceNet, SourceProcess, SourcePressure, SourceSched, SourceNetStack}
+
+// netstackCounters are the network stack counters, named like nstat names them, included in
+// samples, i.e., TCP and UDP traffic, errors, retransmits, and drops
+var netstackCounters = []string{"TcpActiveOpens", "TcpPassiveOpens", "TcpInSegs", "TcpOutSegs", "TcpRetransSegs", "TcpInErrs", "TcpOutRsts", "TcpExtListenOverflows", "TcpExtListenDrops", "TcpExtTCPBacklogDrop", "TcpExtTCPTimeouts", "TcpExtTCPSynRetrans", "UdpInDatagrams", "UdpOutDatagrams", "UdpInErrors", "UdpRcvbufErrors", "UdpSndbufErrors", "UdpNoPorts"}
+
+// pressureResources are the resources with pressure stall information
+var pressureResources = []string{"cpu", "memory", "io"}
+
+// clockTicks is the unit of the CPU times in /proc/<pid>/stat, USER_HZ is 100 on all supported architectures
+const clockTicks = 100
+
+// vmstatCounters are the /proc/vmstat counters included in samples, i.e., paging, swapping,
+// reclaim, and NUMA placement
+var vmstatCounters = []string{"pgpgin", "pgpgout", "pswpin", "pswpout", "pgfault", "pgmajfault", "pgscan_kswapd", "pgscan_direct", "pgsteal_kswapd", "pgsteal_direct", "numa_hit", "numa_miss", "numa_foreign", "oom_kill"}
+
+// Sample is written as one line of JSON for each interval. Rates and percentages are calculated
+// over the interval. Sources that weren't requested are omitted.
+type Sample struct {
+	Version    int                `json:"version"`
+	Time       string             `json:"time"`      // local time, HH:MM:SS
+	Timestamp  int64              `json:"timestamp"` // seconds since the epoch
+	Interval   float64            `json:"interval"`  // seconds since the previous sample
+	CPU        []CPUSample        `json:"cpu,omitempty"`
+	SoftIRQ    []SoftIRQSample    `json:"softirq,omitempty"`
+	Interrupts []InterruptSample  `json:"interrupts,omitempty"`
+	Disk       []DiskSample       `json:"disk,omitempty"`
+	Memory     *MemorySample      `json:"memory,omitempty"`
+	VMStat     map[string]float64 `json:"vmstat,omitempty"` // per second rates of the selected vmstat counters
+	Net        []NetSample        `json:"net,omitempty"`
+	Process    []ProcessSample    `json:"process,omitempty"`
+	Pressure   *PressureSample    `json:"pressure,omitempty"`
+	Cgroups    []CgroupSample     `json:"cgroups,omitempty"`
+	Sched      *SchedSample       `json:"sched,omitempty"`
+	NetStack   *NetStackSample    `json:"netstack,omitempty"`
+}
+
+// CPUSample is the utilization of a CPU, or of all CPUs when CPU is "all", in percent of the
+// interval. Like mpstat, usr and nice exclude guest time. Core, socket, and node are -1 for "all".
+type CPUSample struct {
+	CPU    string  `json:"cpu"`
+	Core   int     `json:"core"`
+	Socket int     `json:"socket"`
+	Node   int     `json:"node"`
+	User   float64 `json:"usr"`
+	Nice   float64 `json:"nice"`
+	System float64 `json:"sys"`
+	IOWait float64 `json:"iowait"`
+	IRQ    float64 `json:"irq"`
+	Soft   float64 `json:"soft"`
+	Steal  float64 `json:"steal"`
+	Guest  float64 `json:"guest"`
+	GNice  float64 `json:"gnice"`
+	Idle   float64 `json:"idle"`
+}
+
+// SoftIRQSample is the per second rate of each type of soft IRQ on a CPU
+type SoftIRQSample struct {
+	CPU   string             `json:"cpu"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// InterruptSample is the per second rate of hardware interrupts on a CPU
+type InterruptSample struct {
+	CPU  string  `json:"cpu"`
+	Rate float64 `json:"rate"`
+}
+
+// DiskSample is the activity of a disk, partitions are not included
+type DiskSample struct {
+	Device       string  `json:"device"`
+	TPS          float64 `json:"tps"`
+	ReadKBps     float64 `json:"kB_read/s"`
+	WriteKBps    float64 `json:"kB_wrtn/s"`
+	DiscardKBps  float64 `json:"kB_dscd/s"`
+	UtilPercent  float64 `json:"util"`
+	QueueLength  float64 `json:"aqu-sz"`
+	InFlightIOPs uint64  `json:"in_flight"`
+}
+
+// MemorySample is the memory usage, in kB, like sar -r
+type MemorySample struct {
+	Free     uint64 `json:"free"`
+	Avail    uint64 `json:"avail"`
+	Used     uint64 `json:"used"`
+	Buffers  uint64 `json:"buffers"`
+	Cache    uint64 `json:"cache"`
+	Commit   uint64 `json:"commit"`
+	Active   uint64 `json:"active"`
+	Inactive uint64 `json:"inactive"`
+	Dirty    uint64 `json:"dirty"`
+}
+
+// NetSample is the traffic on a network interface, like sar -n DEV
+type NetSample struct {
+	Interface string  `json:"iface"`
+	RxPckps   float64 `json:"rxpck/s"`
+	TxPckps   float64 `json:"txpck/s"`
+	RxKBps    float64 `json:"rxkB/s"`
+	TxKBps    float64 `json:"txkB/s"`
+	RxErrps   float64 `json:"rxerr/s"`
+	TxErrps   float64 `json:"txerr/s"`
+	RxDropps  float64 `json:"rxdrop/s"`
+	TxDropps  float64 `json:"txdrop/s"`
+}
+
+// ProcessSample is the activity of a process, like pidstat -u -r -w -d. CPU percentages are of
+// one CPU, so they exceed 100 for processes running on more than one CPU.
+type ProcessSample struct {
+	PID       int     `json:"pid"`
+	Command   string  `json:"command"`
+	CPU       float64 `json:"%CPU"`
+	User      float64 `json:"%usr"`
+	System    float64 `json:"%system"`
+	RSS       uint64  `json:"rss"` // kB
+	MinFltps  float64 `json:"minflt/s"`
+	MajFltps  float64 `json:"majflt/s"`
+	CSwchps   float64 `json:"cswch/s"`
+	NvCSwchps float64 `json:"nvcswch/s"`
+	ReadKBps  float64 `json:"kB_rd/s"`
+	WriteKBps float64 `json:"kB_wr/s"`
+	Threads   int     `json:"threads"`
+}
+
+// PressureSample is the percentage of the interval in which some or all non-idle tasks were
+// stalled on a resource, calculated from the pressure stall information totals
+type PressureSample struct {
+	CPUSome    float64 `json:"cpu_some"`
+	CPUFull    float64 `json:"cpu_full"`
+	MemorySome float64 `json:"memory_some"`
+	MemoryFull float64 `json:"memory_full"`
+	IOSome     float64 `json:"io_some"`
+	IOFull     float64 `json:"io_full"`
+}
+
+// CgroupSample is the pressure stall information of a cgroup
+type CgroupSample struct {
+	Cgroup string `json:"cgroup"`
+	PressureSample
+}
+
+// SchedSample is the scheduler activity, like the r, b, and cs columns of vmstat
+type SchedSample struct {
+	RunQueue uint64  `json:"runq-sz"` // runnable tasks, including running tasks
+	Blocked  uint64  `json:"blocked"` // tasks blocked on IO
+	CSwchps  float64 `json:"cswch/s"`
+	Forksps  float64 `json:"forks/s"`
+}
+
+// NetStackSample is the activity of the network stack
+type NetStackSample struct {
+	Rates     map[string]float64 `json:"rates"` // per second rates of the selected counters
+	CurrEstab uint64             `json:"TcpCurrEstab"`
+}
+
+// counters read from the /proc files, rates are calculated from two snapshots
+type snapshot struct {
+	time       time.Time
+	cpu        map[string][]uint64          // cpu name -> user nice system idle iowait irq softirq steal guest guest_nice
+	cpuOrder   []string                     // cpu names in the order of /proc/stat
+	softIRQ    map[string]map[string]uint64 // cpu -> soft IRQ name -> count
+	softIRQs   []string                     // soft IRQ names in the order of /proc/softirqs
+	interrupts map[string]uint64            // cpu -> count
+	disk       map[string][]uint64          // device -> diskstats fields after the device name
+	diskOrder  []string                     // devices in the order of /proc/diskstats
+	memory     map[string]uint64            // meminfo field -> kB
+	vmstat     map[string]uint64            // vmstat counter -> count
+	net        map[string][]uint64          // interface -> /proc/net/dev fields
+	netOrder   []string                     // interfaces in the order of /proc/net/dev
+	process    map[int]processCounters      // pid -> counters
+	pressure   map[string]uint64            // "<resource> <some|full>" -> total stall time in microseconds
+	cgroups    map[string]map[string]uint64 // cgroup -> pressure totals
+	sched      map[string]uint64            // /proc/stat field -> value
+	netstack   map[string]uint64            // nstat name, e.g., TcpExtListenDrops -> value
+}
+
+// processCounters are read from /proc/<pid>/stat, status, and io
+type processCounters struct {
+	command      string
+	startTime    uint64 // distinguishes a process from an earlier one with the same pid
+	userTicks    uint64
+	systemTicks  uint64
+	minorFaults  uint64
+	majorFaults  uint64
+	threads      int
+	rssPages     uint64
+	voluntary    uint64 // context switches
+	nonVoluntary uint64
+	readBytes    uint64 // zero if /proc/<pid>/io isn't readable
+	writeBytes   uint64
+}
+
+// topology of a CPU read from /sys
+type topology struct {
+	core   int
+	socket int
+	node   int
+}
+
+// collector reads the /proc and /sys files below root, root is "/" except when testing
+type collector struct {
+	root     string
+	sources  []string
+	topology map[string]topology
+	// process selection, the top processes by CPU utilization unless pids or names are given
+	top   int
+	pids  []int
+	names []string // patterns matched against the command name
+	// cgroup v2 paths, relative to /sys/fs/cgroup, with pressure stall information
+	cgroups []string
+}
+
+func main() {
+	interval := flag.Int("interval", 2, "number of seconds between samples")
+	count := flag.Int("count", 0, "number of samples, 0 samples until interrupted")
+	sources := flag.String("sources", strings.Join(sourceOptions, ","), fmt.Sprintf("comma separated list of sources to sample, options: %s", strings.Join(sourceOptions, ", ")))
+	top := flag.Int("top", 10, "number of processes, by CPU utilization, included in process samples")
+	pids := flag.String("pids", "", "comma separated list of pids included in process samples, overrides -top")
+	cgroups := flag.String("cgroups", "", "comma separated list of cgroup v2 paths, relative to /sys/fs/cgroup, included in pressure samples")
+	names := flag.String("names", "", "comma separated list of command name patterns, e.g., java or redis*, included in process samples, overrides -top")
+	output := flag.String("output", "", "write the samples to files named <output>.<epoch>.jsonl instead of stdout, where epoch is the time the file was started")
+	rotate := flag.Int("rotate", 3600, "number of seconds between starting new output files")
+	keep := flag.Int("keep", 0, "number of output files to keep, the oldest files are removed when a new file is started, 0 keeps all files")
+	flag.Parse()
+	if *interval < 1 {
+		fmt.Fprintln(os.Stderr, "interval must be greater than 0")
+		os.Exit(1)
+	}
+	if *rotate < 1 {
+		fmt.Fprintln(os.Stderr, "rotate must be greater than 0")
+		os.Exit(1)
+	}
+	if *keep < 0 {
+		fmt.Fprintln(os.Stderr, "keep must be 0 or greater")
+		os.Exit(1)
+	}
+	if *top < 1 {
+		fmt.Fprintln(os.Stderr, "top must be greater than 0")
+		os.Exit(1)
+	}
+	c := &collector{root: "/", top: *top}
+	for pid := range strings.SplitSeq(*pids, ",") {
+		if pid == "" {
+			continue
+		}
+		value, err := strconv.Atoi(pid)
+		if err != nil || value < 1 {
+			fmt.Fprintf(os.Stderr, "invalid pid: %s\n", pid)
+			os.Exit(1)
+		}
+		c.pids = append(c.pids, value)
+	}
+	for name := range strings.SplitSeq(*names, ",") {
+		if name == "" {
+			continue
+		}
+		if _, err := path.Match(name, ""); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid name pattern: %s\n", name)
+			os.Exit(1)
+		}
+		c.names = append(c.names, name)
+	}
+	for cgroup := range strings.SplitSeq(*cgroups, ",") {
+		if cgroup = strings.Trim(cgroup, "/"); cgroup != "" {
+			c.cgroups = append(c.cgroups, cgroup)
+		}
+	}
+	for source := range strings.SplitSeq(*sources, ",") {
+		if !slices.Contains(sourceOptions, source) {
+			fmt.Fprintf(os.Stderr, "invalid source: %s, options: %s\n", source, strings.Join(sourceOptions, ", "))
+			os.Exit(1)
+		}
+		c.sources = append(c.sources, source)
+	}
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		rw := &rotatingWriter{prefix: *output, period: time.Duration(*rotate) * time.Second, keep: *keep, now: time.Now}
+		defer rw.Close()
+		w = rw
+	}
+	if err := c.run(w, time.Duration(*interval)*time.Second, *count); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// rotatingWriter writes to files named <prefix>.<epoch>.jsonl, starting a new file when the current
+// file is older than period. A sample is written with a single Write, so a file only ends with a
+// partial sample while it is being written. If keep is greater than 0, only the newest keep files
+// are kept, so that the files don't fill the disk when nobody collects them.
+type rotatingWriter struct {
+	prefix  string
+	period  time.Duration
+	keep    int
+	now     func() time.Time
+	file    *os.File
+	started time.Time
+	names   []string // the files started by the writer, oldest first
+}
+
+func (rw *rotatingWriter) Write(p []byte) (int, error) {
+	now := rw.now()
+	if rw.file == nil || now.Sub(rw.started) >= rw.period {
+		if err := rw.Close(); err != nil {
+			return 0, err
+		}
+		name := fmt.Sprintf("%s.%d.jsonl", rw.prefix, now.Unix())
+		file, err := os.OpenFile(name, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644) // #nosec G302 G304
+		if err != nil {
+			return 0, err
+		}
+		rw.file = file
+		rw.started = now
+		rw.names = append(rw.names, name)
+		for rw.keep > 0 && len(rw.names) > rw.keep {
+			// the file may have been removed by the collector already
+			if err := os.Remove(rw.names[0]); err != nil && !os.IsNotExist(err) {
+				return 0, err
+			}
+			rw.names = rw.names[1:]
+		}
+	}
+	return rw.file.Write(p)
+}
+
+func (rw *rotatingWriter) Close() error {
+	if rw.file == nil {
+		return nil
+	}
+	err := rw.file.Close()
+	rw.file = nil
+	return err
+}
+
+// run writes count samples, or samples until interrupted if count is 0
+func (c *collector) run(w io.Writer, interval time.Duration, count int) error {
+	if slices.Contains(c.sources, SourceCPU) {
+		c.topology = c.readTopology()
+	}
+	previous, err := c.snapshot()
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	for i := 0; count == 0 || i < count; i++ {
+		time.Sleep(interval)
+		current, err := c.snapshot()
+		if err != nil {
+			return err
+		}
+		if err = encoder.Encode(c.sample(previous, current)); err != nil {
+			return err
+		}
+		previous = current
+	}
+	return nil
+}
+
+// snapshot reads the counters of the requested sources
+func (c *collector) snapshot() (s snapshot, err error) {
+	s.time = time.Now()
+	for _, source := range c.sources {
+		var file *os.File
+		switch source {
+		case SourceCPU:
+			file, err = os.Open(filepath.Join(c.root, "proc/stat"))
+			if err == nil {
+				s.cpu, s.cpuOrder, err = parseStat(file)
+			}
+		case SourceSoftIRQ:
+			file, err = os.Open(filepath.Join(c.root, "proc/softirqs"))
+			if err == nil {
+				s.softIRQ, s.softIRQs, err = parseSoftIRQs(file)
+			}
+		case SourceInterrupts:
+			file, err = os.Open(filepath.Join(c.root, "proc/interrupts"))
+			if err == nil {
+				s.interrupts, err = parseInterrupts(file)
+			}
+		case SourceDisk:
+			file, err = os.Open(filepath.Join(c.root, "proc/diskstats"))
+			if err == nil {
+				s.disk, s.diskOrder, err = parseDiskStats(file, c.isPartition)
+			}
+		case SourceMemory:
+			file, err = os.Open(filepath.Join(c.root, "proc/meminfo"))
+			if err == nil {
+				s.memory, err = parseKeyValues(file, ":")
+			}
+		case SourceVMStat:
+			file, err = os.Open(filepath.Join(c.root, "proc/vmstat"))
+			if err == nil {
+				s.vmstat, err = parseKeyValues(file, " ")
+			}
+		case SourceNet:
+			file, err = os.Open(filepath.Join(c.root, "proc/net/dev"))
+			if err == nil {
+				s.net, s.netOrder, err = parseNetDev(file)
+			}
+		case SourceProcess:
+			s.process, err = c.readProcesses()
+		case SourcePressure:
+			s.pressure, err = readPressure(filepath.Join(c.root, "proc/pressure"), "")
+			if err == nil && len(c.cgroups) > 0 {
+				s.cgroups = make(map[string]map[string]uint64, len(c.cgroups))
+				for _, cgroup := range c.cgroups {
+					if s.cgroups[cgroup], err = readPressure(filepath.Join(c.root, "sys/fs/cgroup", cgroup), ".pressure"); err != nil {
+						break
+					}
+				}
+			}
+		case SourceNetStack:
+			s.netstack = make(map[string]uint64)
+			for _, name := range []string{"proc/net/snmp", "proc/net/netstat"} {
+				if file, err = os.Open(filepath.Join(c.root, name)); err != nil {
+					break
+				}
+				err = parseSNMP(file, s.netstack)
+				file.Close()
+				file = nil
+				if err != nil {
+					break
+				}
+			}
+		case SourceSched:
+			file, err = os.Open(filepath.Join(c.root, "proc/stat"))
+			if err == nil {
+				s.sched, err = parseKeyValues(file, " ")
+			}
+		}
+		if file != nil {
+			file.Close()
+		}
+		if err != nil {
+			err = fmt.Errorf("failed to read %s: %w", source, err)
+			return
+		}
+	}
+	return
+}
+
+// sample calculates the rates and percentages between two snapshots
+func (c *collector) sample(previous, current snapshot) Sample {
+	seconds := current.time.Sub(previous.time).Seconds()
+	rate := func(cur, prev uint64) float64 {
+		if cur < prev || seconds <= 0 { // counter reset
+			return 0
+		}
+		return float64(cur-prev) / seconds
+	}
+	sample := Sample{
+		Version:   FormatVersion,
+		Time:      current.time.Format("15:04:05"),
+		Timestamp: current.time.Unix(),
+		Interval:  seconds,
+	}
+	for _, cpu := range current.cpuOrder {
+		prev, ok := previous.cpu[cpu]
+		if !ok {
+			continue
+		}
+		sample.CPU = append(sample.CPU, c.cpuSample(cpu, prev, current.cpu[cpu]))
+	}
+	for _, cpu := range slices.SortedFunc(maps.Keys(current.softIRQ), compareCPUs) {
+		s := SoftIRQSample{CPU: cpu, Rates: make(map[string]float64)}
+		for _, name := range current.softIRQs {
+			s.Rates[name] = rate(current.softIRQ[cpu][name], previous.softIRQ[cpu][name])
+		}
+		sample.SoftIRQ = append(sample.SoftIRQ, s)
+	}
+	for _, cpu := range slices.SortedFunc(maps.Keys(current.interrupts), compareCPUs) {
+		sample.Interrupts = append(sample.Interrupts, InterruptSample{CPU: cpu, Rate: rate(current.interrupts[cpu], previous.interrupts[cpu])})
+	}
+	for _, device := range current.diskOrder {
+		prev, ok := previous.disk[device]
+		if !ok {
+			continue
+		}
+		sample.Disk = append(sample.Disk, diskSample(device, prev, current.disk[device], seconds))
+	}
+	if current.memory != nil {
+		sample.Memory = memorySample(current.memory)
+	}
+	if current.vmstat != nil {
+		sample.VMStat = make(map[string]float64, len(vmstatCounters))
+		for _, name := range vmstatCounters {
+			if value, ok := current.vmstat[name]; ok {
+				sample.VMStat[name] = rate(value, previous.vmstat[name])
+			}
+		}
+	}
+	for _, iface := range current.netOrder {
+		prev, ok := previous.net[iface]
+		if !ok {
+			continue
+		}
+		cur := current.net[iface]
+		// fields: rx bytes packets errs drop fifo frame compressed multicast, tx bytes packets errs drop ...
+		sample.Net = append(sample.Net, NetSample{
+			Interface: iface,
+			RxPckps:   rate(cur[1], prev[1]),
+			TxPckps:   rate(cur[9], prev[9]),
+			RxKBps:    rate(cur[0], prev[0]) / 1024,
+			TxKBps:    rate(cur[8], prev[8]) / 1024,
+			RxErrps:   rate(cur[2], prev[2]),
+			TxErrps:   rate(cur[10], prev[10]),
+			RxDropps:  rate(cur[3], prev[3]),
+			TxDropps:  rate(cur[11], prev[11]),
+		})
+	}
+	sample.Process = c.processSamples(previous.process, current.process, seconds)
+	if len(current.pressure) > 0 {
+		sample.Pressure = pressureSample(previous.pressure, current.pressure, seconds)
+	}
+	for _, cgroup := range c.cgroups {
+		cur, prev := current.cgroups[cgroup], previous.cgroups[cgroup]
+		if len(cur) == 0 || len(prev) == 0 {
+			continue
+		}
+		sample.Cgroups = append(sample.Cgroups, CgroupSample{Cgroup: cgroup, PressureSample: *pressureSample(prev, cur, seconds)})
+	}
+	if current.sched != nil {
+		sample.Sched = &SchedSample{
+			RunQueue: current.sched["procs_running"],
+			Blocked:  current.sched["procs_blocked"],
+			CSwchps:  rate(current.sched["ctxt"], previous.sched["ctxt"]),
+			Forksps:  rate(current.sched["processes"], previous.sched["processes"]),
+		}
+	}
+	if current.netstack != nil {
+		sample.NetStack = &NetStackSample{
+			Rates:     make(map[string]float64, len(netstackCounters)),
+			CurrEstab: current.netstack["TcpCurrEstab"],
+		}
+		for _, name := range netstackCounters {
+			if value, ok := current.netstack[name]; ok {
+				sample.NetStack.Rates[name] = rate(value, previous.netstack[name])
+			}
+		}
+	}
+	return sample
+}
+
+// pressureSample calculates the percentage of the interval spent stalled from the stall totals
+func pressureSample(previous, current map[string]uint64, seconds float64) *PressureSample {
+	percent := func(key string) float64 {
+		cur, prev := current[key], previous[key]
+		if cur < prev || seconds <= 0 {
+			return 0
+		}
+		return min(100*float64(cur-prev)/(seconds*1e6), 100) // totals are in microseconds
+	}
+	return &PressureSample{
+		CPUSome:    percent("cpu some"),
+		CPUFull:    percent("cpu full"),
+		MemorySome: percent("memory some"),
+		MemoryFull: percent("memory full"),
+		IOSome:     percent("io some"),
+		IOFull:     percent("io full"),
+	}
+}
+
+// processSamples calculates the activity of the selected processes that were running during the
+// whole interval
+func (c *collector) processSamples(previous, current map[int]processCounters, seconds float64) []ProcessSample {
+	if current == nil || seconds <= 0 {
+		return nil
+	}
+	rate := func(cur, prev uint64) float64 {
+		if cur < prev {
+			return 0
+		}
+		return float64(cur-prev) / seconds
+	}
+	pageKB := uint64(os.Getpagesize() / 1024) // #nosec G115
+	var samples []ProcessSample
+	for pid, cur := range current {
+		prev, ok := previous[pid]
+		if !ok || prev.startTime != cur.startTime {
+			continue
+		}
+		if !c.selected(pid, cur.command) {
+			continue
+		}
+		user := rate(cur.userTicks, prev.userTicks) * 100 / clockTicks
+		system := rate(cur.systemTicks, prev.systemTicks) * 100 / clockTicks
+		samples = append(samples, ProcessSample{
+			PID:       pid,
+			Command:   cur.command,
+			CPU:       user + system,
+			User:      user,
+			System:    system,
+			RSS:       cur.rssPages * pageKB,
+			MinFltps:  rate(cur.minorFaults, prev.minorFaults),
+			MajFltps:  rate(cur.majorFaults, prev.majorFaults),
+			CSwchps:   rate(cur.voluntary, prev.voluntary),
+			NvCSwchps: rate(cur.nonVoluntary, prev.nonVoluntary),
+			ReadKBps:  rate(cur.readBytes, prev.readBytes) / 1024,
+			WriteKBps: rate(cur.writeBytes, prev.writeBytes) / 1024,
+			Threads:   cur.threads,
+		})
+	}
+	// busiest first, ties by pid so that the output is stable
+	slices.SortFunc(samples, func(a, b ProcessSample) int {
+		if a.CPU != b.CPU {
+			if a.CPU > b.CPU {
+				return -1
+			}
+			return 1
+		}
+		return a.PID - b.PID
+	})
+	if len(c.pids) == 0 && len(c.names) == 0 {
+		// like pidstat, idle processes aren't reported
+		idle := slices.IndexFunc(samples, func(s ProcessSample) bool { return s.CPU == 0 })
+		if idle >= 0 {
+			samples = samples[:idle]
+		}
+		samples = samples[:min(len(samples), c.top)]
+	}
+	return samples
+}
+
+// selected returns true if the process is included in samples, all processes are candidates for
+// the top processes when no pids or names are given
+func (c *collector) selected(pid int, command string) bool {
+	if len(c.pids) == 0 && len(c.names) == 0 {
+		return true
+	}
+	if slices.Contains(c.pids, pid) {
+		return true
+	}
+	for _, name := range c.names {
+		if match, _ := path.Match(name, command); match {
+			return true
+		}
+	}
+	return false
+}
+
+// cpuSample calculates the utilization of a CPU like mpstat
+func (c *collector) cpuSample(cpu string, prev, cur []uint64) CPUSample {
+	delta := make([]float64, 10)
+	for i := range delta {
+		if i < len(cur) && i < len(prev) && cur[i] >= prev[i] {
+			delta[i] = float64(cur[i] - prev[i])
+		}
+	}
+	user, nice, system, idle, iowait, irq, softirq, steal, guest, guestNice := delta[0], delta[1], delta[2], delta[3], delta[4], delta[5], delta[6], delta[7], delta[8], delta[9]
+	// guest time is included in user and nice time
+	total := user + nice + system + idle + iowait + irq + softirq + steal
+	percent := func(v float64) float64 {
+		if total == 0 {
+			return 0
+		}
+		return 100 * v / total
+	}
+	s := CPUSample{
+		CPU:    strings.TrimPrefix(cpu, "cpu"),
+		Core:   -1,
+		Socket: -1,
+		Node:   -1,
+		User:   percent(max(user-guest, 0)),
+		Nice:   percent(max(nice-guestNice, 0)),
+		System: percent(system),
+		IOWait: percent(iowait),
+		IRQ:    percent(irq),
+		Soft:   percent(softirq),
+		Steal:  percent(steal),
+		Guest:  percent(guest),
+		GNice:  percent(guestNice),
+		Idle:   percent(idle),
+	}
+	if cpu == "cpu" {
+		s.CPU = "all"
+	} else if t, ok := c.topology[s.CPU]; ok {
+		s.Core, s.Socket, s.Node = t.core, t.socket, t.node
+	}
+	return s
+}
+
+// diskSample calculates the activity of a disk like iostat
+func diskSample(device string, prev, cur []uint64, seconds float64) DiskSample {
+	// fields: reads merged sectors_read ms_reading writes merged sectors_written ms_writing in_flight
+	// ms_io weighted_ms [discards merged sectors_discarded ms_discarding [flushes ms_flushing]]
+	field := func(values []uint64, i int) uint64 {
+		if i < len(values) {
+			return values[i]
+		}
+		return 0
+	}
+	delta := func(i int) float64 {
+		cur, prev := field(cur, i), field(prev, i)
+		if cur < prev || seconds <= 0 {
+			return 0
+		}
+		return float64(cur-prev) / seconds
+	}
+	return DiskSample{
+		Device:       device,
+		TPS:          delta(0) + delta(4) + delta(11),
+		ReadKBps:     delta(2) / 2, // sectors are 512 bytes
+		WriteKBps:    delta(6) / 2,
+		DiscardKBps:  delta(13) / 2,
+		UtilPercent:  min(delta(9)/10, 100), // ms per second to percent
+		QueueLength:  delta(10) / 1000,
+		InFlightIOPs: field(cur, 8),
+	}
+}
+
+// memorySample calculates the memory usage like sar -r
+func memorySample(meminfo map[string]uint64) *MemorySample {
+	used := int64(meminfo["MemTotal"]) - int64(meminfo["MemFree"]) - int64(meminfo["Buffers"]) - int64(meminfo["Cached"]) - int64(meminfo["Slab"]) // #nosec G115
+	return &MemorySample{
+		Free:     meminfo["MemFree"],
+		Avail:    meminfo["MemAvailable"],
+		Used:     uint64(max(used, 0)), // #nosec G115
+		Buffers:  meminfo["Buffers"],
+		Cache:    meminfo["Cached"],
+		Commit:   meminfo["Committed_AS"],
+		Active:   meminfo["Active"],
+		Inactive: meminfo["Inactive"],
+		Dirty:    meminfo["Dirty"],
+	}
+}
+
+// readTopology reads the core, socket, and NUMA node of each CPU from /sys
+func (c *collector) readTopology() map[string]topology {
+	topologies := make(map[string]topology)
+	cpuDirs, _ := filepath.Glob(filepath.Join(c.root, "sys/devices/system/cpu/cpu[0-9]*"))
+	for _, cpuDir := range cpuDirs {
+		cpu := strings.TrimPrefix(filepath.Base(cpuDir), "cpu")
+		t := topology{core: -1, socket: -1, node: -1}
+		t.core = readInt(filepath.Join(cpuDir, "topology/core_id"))
+		t.socket = readInt(filepath.Join(cpuDir, "topology/physical_package_id"))
+		if nodes, _ := filepath.Glob(filepath.Join(cpuDir, "node[0-9]*")); len(nodes) > 0 {
+			if node, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(nodes[0]), "node")); err == nil {
+				t.node = node
+			}
+		}
+		topologies[cpu] = t
+	}
+	return topologies
+}
+
+// isPartition returns true if the block device is a partition
+func (c *collector) isPartition(device string) bool {
+	_, err := os.Stat(filepath.Join(c.root, "sys/class/block", device, "partition"))
+	return err == nil
+}
+
+// readProcesses reads the counters of all processes, processes that exit while being read are
+// skipped
+func (c *collector) readProcesses() (map[int]processCounters, error) {
+	pidDirs, err := filepath.Glob(filepath.Join(c.root, "proc/[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+	processes := make(map[int]processCounters, len(pidDirs))
+	for _, pidDir := range pidDirs {
+		pid, err := strconv.Atoi(filepath.Base(pidDir))
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(pidDir, "stat")) // #nosec G304
+		if err != nil {
+			continue
+		}
+		counters, err := parseProcessStat(string(data))
+		if err != nil {
+			continue
+		}
+		if file, err := os.Open(filepath.Join(pidDir, "status")); err == nil { // #nosec G304
+			status, _ := parseKeyValues(file, ":")
+			file.Close()
+			counters.voluntary = status["voluntary_ctxt_switches"]
+			counters.nonVoluntary = status["nonvoluntary_ctxt_switches"]
+		}
+		// reading another user's io file requires privileges
+		if file, err := os.Open(filepath.Join(pidDir, "io")); err == nil { // #nosec G304
+			ioCounters, _ := parseKeyValues(file, ":")
+			file.Close()
+			counters.readBytes = ioCounters["read_bytes"]
+			counters.writeBytes = ioCounters["write_bytes"]
+		}
+		processes[pid] = counters
+	}
+	return processes, nil
+}
+
+// parseProcessStat parses /proc/<pid>/stat, the command name is in parentheses and may contain
+// spaces and parentheses
+func parseProcessStat(stat string) (counters processCounters, err error) {
+	start := strings.Index(stat, "(")
+	end := strings.LastIndex(stat, ")")
+	if start < 0 || end < start {
+		err = fmt.Errorf("unexpected format: %s", stat)
+		return
+	}
+	counters.command = stat[start+1 : end]
+	// fields after the command, starting with the state (field 3)
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 22 {
+		err = fmt.Errorf("unexpected number of fields: %d", len(fields))
+		return
+	}
+	values := parseUints(fields)
+	counters.minorFaults = values[7]   // field 10
+	counters.majorFaults = values[9]   // field 12
+	counters.userTicks = values[11]    // field 14
+	counters.systemTicks = values[12]  // field 15
+	counters.threads = int(values[17]) // field 20 #nosec G115
+	counters.startTime = values[19]    // field 22
+	counters.rssPages = values[21]     // field 24
+	return
+}
+
+// readPressure reads the stall totals of each resource from the <resource><suffix> files in the
+// directory. Missing files aren't an error, pressure stall information may be disabled.
+func readPressure(dir string, suffix string) (totals map[string]uint64, err error) {
+	totals = make(map[string]uint64)
+	for _, resource := range pressureResources {
+		file, err := os.Open(filepath.Join(dir, resource+suffix)) // #nosec G304
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		resourceTotals, err := parsePressure(file)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+		for kind, total := range resourceTotals {
+			totals[resource+" "+kind] = total
+		}
+	}
+	return totals, nil
+}
+
+// parsePressure parses a pressure file, e.g., /proc/pressure/memory, and returns the total stall
+// time, in microseconds, of "some" and "full"
+func parsePressure(r io.Reader) (totals map[string]uint64, err error) {
+	totals = make(map[string]uint64)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		for _, field := range fields[1:] {
+			if value, found := strings.CutPrefix(field, "total="); found {
+				totals[fields[0]], _ = strconv.ParseUint(value, 10, 64)
+			}
+		}
+	}
+	err = scanner.Err()
+	return
+}
+
+// readInt reads an integer from a file, it returns -1 if the file can't be read
+func readInt(path string) int {
+	data, err := os.ReadFile(path) // #nosec G304
+	if err != nil {
+		return -1
+	}
+	value, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return -1
+	}
+	return value
+}
+
+// parseStat parses the cpu lines of /proc/stat
+func parseStat(r io.Reader) (cpus map[string][]uint64, order []string, err error) {
+	cpus = make(map[string][]uint64)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || !strings.HasPrefix(fields[0], "cpu") {
+			continue
+		}
+		cpus[fields[0]] = parseUints(fields[1:])
+		order = append(order, fields[0])
+	}
+	err = scanner.Err()
+	return
+}
+
+// parseSoftIRQs parses /proc/softirqs, the header lists the CPUs and each line is a soft IRQ
+func parseSoftIRQs(r io.Reader) (counts map[string]map[string]uint64, names []string, err error) {
+	counts = make(map[string]map[string]uint64)
+	scanner := bufio.NewScanner(r)
+	var cpus []string
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if cpus == nil {
+			for _, field := range fields {
+				cpu := strings.TrimPrefix(field, "CPU")
+				cpus = append(cpus, cpu)
+				counts[cpu] = make(map[string]uint64)
+			}
+			continue
+		}
+		name := strings.TrimSuffix(fields[0], ":")
+		names = append(names, name)
+		for i, value := range parseUints(fields[1:]) {
+			if i < len(cpus) {
+				counts[cpus[i]][name] = value
+			}
+		}
+	}
+	err = scanner.Err()
+	return
+}
+
+// parseInterrupts parses /proc/interrupts and returns the total interrupt count of each CPU
+func parseInterrupts(r io.Reader) (counts map[string]uint64, err error) {
+	counts = make(map[string]uint64)
+	scanner := bufio.NewScanner(r)
+	var cpus []string
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if cpus == nil {
+			for _, field := range fields {
+				cpus = append(cpus, strings.TrimPrefix(field, "CPU"))
+			}
+			continue
+		}
+		// ERR and MIS lines have a single count that isn't per CPU
+		if fields[0] == "ERR:" || fields[0] == "MIS:" {
+			continue
+		}
+		for i := 1; i < len(fields) && i <= len(cpus); i++ {
+			value, err := strconv.ParseUint(fields[i], 10, 64)
+			if err != nil {
+				break // description follows the counts
+			}
+			counts[cpus[i-1]] += value
+		}
+	}
+	err = scanner.Err()
+	return
+}
+
+// parseDiskStats parses /proc/diskstats, excluding partitions and loop and ram devices
+func parseDiskStats(r io.Reader, isPartition func(string) bool) (disks map[string][]uint64, order []string, err error) {
+	disks = make(map[string][]uint64)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 14 {
+			continue
+		}
+		device := fields[2]
+		if strings.HasPrefix(device, "loop") || strings.HasPrefix(device, "ram") || isPartition(device) {
+			continue
+		}
+		disks[device] = parseUints(fields[3:])
+		order = append(order, device)
+	}
+	err = scanner.Err()
+	return
+}
+
+// parseNetDev parses /proc/net/dev, the first two lines are headers
+func parseNetDev(r io.Reader) (ifaces map[string][]uint64, order []string, err error) {
+	ifaces = make(map[string][]uint64)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		iface, counters, found := strings.Cut(scanner.Text(), ":")
+		if !found {
+			continue
+		}
+		values := parseUints(strings.Fields(counters))
+		if len(values) < 16 {
+			continue
+		}
+		iface = strings.TrimSpace(iface)
+		ifaces[iface] = values
+		order = append(order, iface)
+	}
+	err = scanner.Err()
+	return
+}
+
+// parseSNMP parses /proc/net/snmp or /proc/net/netstat into values, named like nstat names them.
+// Each protocol has a line of names followed by a line of values, e.g., "Tcp: RtoAlgorithm RtoMin"
+// and "Tcp: 1 200". Negative values, e.g., Tcp MaxConn, are 0.
+func parseSNMP(r io.Reader, values map[string]uint64) error {
+	scanner := bufio.NewScanner(r)
+	var names []string
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || !strings.HasSuffix(fields[0], ":") {
+			continue
+		}
+		if names == nil || names[0] != fields[0] {
+			names = fields
+			continue
+		}
+		protocol := strings.TrimSuffix(fields[0], ":")
+		for i, value := range parseUints(fields[1:]) {
+			if i+1 < len(names) {
+				values[protocol+names[i+1]] = value
+			}
+		}
+		names = nil
+	}
+	return scanner.Err()
+}
+
+// parseKeyValues parses files with one "key<separator> value [unit]" per line, e.g., /proc/meminfo
+func parseKeyValues(r io.Reader, separator string) (values map[string]uint64, err error) {
+	values = make(map[string]uint64)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, rest, found := strings.Cut(scanner.Text(), separator)
+		if !found {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		values[strings.TrimSpace(key)] = value
+	}
+	err = scanner.Err()
+	return
+}
+
+// parseUints parses the fields as unsigned integers, fields that aren't integers are 0
+func parseUints(fields []string) []uint64 {
+	values := make([]uint64, len(fields))
+	for i, field := range fields {
+		values[i], _ = strconv.ParseUint(field, 10, 64)
+	}
+	return values
+}
+
+// compareCPUs orders CPU numbers numerically
+func compareCPUs(a, b string) int {
+	ai, aErr := strconv.Atoi(a)
+	bi, bErr := strconv.Atoi(b)
+	if aErr != nil || bErr != nil {
+		return strings.Compare(a, b)
+	}
+	return ai - bi
+}
@@ -0,0 +1,336 @@
+package main
+
+// Copyright (C) 2021-2025 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, root string, name string, content string) {
+	t.Helper()
+	path := filepath.Join(root, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertClose(t *testing.T, name string, expected, actual float64) {
+	t.Helper()
+	if math.Abs(expected-actual) > 1e-9 {
+		t.Errorf("%s: expected %f, got %f", name, expected, actual)
+	}
+}
+
+func TestParseSoftIRQs(t *testing.T) {
+	counts, names, err := parseSoftIRQs(strings.NewReader(`                    CPU0       CPU1
+          HI:          1          2
+       TIMER:        100        200
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(names, ",") != "HI,TIMER" {
+		t.Errorf("unexpected names: %v", names)
+	}
+	if counts["1"]["TIMER"] != 200 || counts["0"]["HI"] != 1 {
+		t.Errorf("unexpected counts: %v", counts)
+	}
+}
+
+func TestParseInterrupts(t *testing.T) {
+	counts, err := parseInterrupts(strings.NewReader(`           CPU0       CPU1
+  0:         10          0   IO-APIC   2-edge      timer
+  8:          1          2   IO-APIC   8-edge      rtc0
+NMI:          3          4   Non-maskable interrupts
+ERR:          5
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts["0"] != 14 || counts["1"] != 6 {
+		t.Errorf("unexpected counts: %v", counts)
+	}
+}
+
+func TestSample(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "sys/devices/system/cpu/cpu0/topology/core_id", "3\n")
+	writeFile(t, root, "sys/devices/system/cpu/cpu0/topology/physical_package_id", "1\n")
+	writeFile(t, root, "sys/devices/system/cpu/cpu0/node1/cpumap", "1\n")
+	writeFile(t, root, "sys/class/block/sda1/partition", "1\n")
+	c := &collector{root: root, sources: sourceOptions}
+	c.topology = c.readTopology()
+
+	writeSnapshot := func(stat, diskstats, netdev string, outSegs int) snapshot {
+		writeFile(t, root, "proc/stat", stat)
+		writeFile(t, root, "proc/softirqs", "  CPU0\nTIMER: 10\n")
+		writeFile(t, root, "proc/interrupts", "  CPU0\n0: 10 timer\n")
+		writeFile(t, root, "proc/diskstats", diskstats)
+		writeFile(t, root, "proc/meminfo", "MemTotal: 1000 kB\nMemFree: 100 kB\nMemAvailable: 500 kB\nBuffers: 50 kB\nCached: 200 kB\nSlab: 50 kB\nCommitted_AS: 300 kB\n")
+		writeFile(t, root, "proc/vmstat", "pgfault 100\nnr_free_pages 10\n")
+		writeFile(t, root, "proc/net/dev", "Inter-|   Receive\n face |bytes    packets\n"+netdev)
+		writeFile(t, root, "proc/net/snmp", "Tcp: OutSegs CurrEstab\nTcp: "+strconv.Itoa(outSegs)+" 5\n")
+		writeFile(t, root, "proc/net/netstat", "TcpExt: ListenDrops\nTcpExt: 0\n")
+		s, err := c.snapshot()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	previous := writeSnapshot(
+		"cpu  100 0 100 800 0 0 0 0 0 0\ncpu0 100 0 100 800 0 0 0 0 0 0\nintr 0\n",
+		"   8       0 sda 10 0 100 0 10 0 100 0 0 0 0 0 0 0 0\n   8       1 sda1 10 0 100 0 10 0 100 0 0 0 0 0 0 0 0\n",
+		"  eth0: 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0\n", 100)
+	current := writeSnapshot(
+		"cpu  160 0 120 820 0 0 0 0 20 0\ncpu0 160 0 120 820 0 0 0 0 20 0\nintr 0\n",
+		"   8       0 sda 30 0 300 0 20 0 500 0 1 1000 2000 0 0 0 0\n   8       1 sda1 30 0 300 0 20 0 500 0 0 0 0 0 0 0 0\n",
+		"  eth0: 2048 20 0 0 0 0 0 0 1024 10 0 0 0 0 0 0\n", 300)
+	current.time = previous.time.Add(2 * time.Second)
+
+	sample := c.sample(previous, current)
+	if sample.Version != FormatVersion {
+		t.Errorf("unexpected version: %d", sample.Version)
+	}
+	if len(sample.CPU) != 2 || sample.CPU[0].CPU != "all" || sample.CPU[1].CPU != "0" {
+		t.Fatalf("unexpected CPUs: %+v", sample.CPU)
+	}
+	// 100 jiffies in total, 60 user of which 20 are guest
+	cpu := sample.CPU[1]
+	assertClose(t, "usr", 40, cpu.User)
+	assertClose(t, "guest", 20, cpu.Guest)
+	assertClose(t, "sys", 20, cpu.System)
+	assertClose(t, "idle", 20, cpu.Idle)
+	if cpu.Core != 3 || cpu.Socket != 1 || cpu.Node != 1 {
+		t.Errorf("unexpected topology: %+v", cpu)
+	}
+	if sample.CPU[0].Socket != -1 {
+		t.Errorf("unexpected topology for all CPUs: %+v", sample.CPU[0])
+	}
+	// partitions are excluded
+	if len(sample.Disk) != 1 {
+		t.Fatalf("unexpected disks: %+v", sample.Disk)
+	}
+	disk := sample.Disk[0]
+	assertClose(t, "tps", 15, disk.TPS)
+	assertClose(t, "kB_read/s", 50, disk.ReadKBps)
+	assertClose(t, "kB_wrtn/s", 100, disk.WriteKBps)
+	assertClose(t, "util", 50, disk.UtilPercent)
+	if sample.Memory == nil || sample.Memory.Used != 600 || sample.Memory.Avail != 500 {
+		t.Errorf("unexpected memory: %+v", sample.Memory)
+	}
+	if _, ok := sample.VMStat["nr_free_pages"]; ok {
+		t.Errorf("unexpected vmstat counter: %v", sample.VMStat)
+	}
+	assertClose(t, "pgfault", 0, sample.VMStat["pgfault"])
+	if len(sample.Net) != 1 {
+		t.Fatalf("unexpected interfaces: %+v", sample.Net)
+	}
+	assertClose(t, "rxpck/s", 10, sample.Net[0].RxPckps)
+	assertClose(t, "rxkB/s", 1, sample.Net[0].RxKBps)
+	assertClose(t, "txkB/s", 0.5, sample.Net[0].TxKBps)
+	if sample.NetStack == nil || sample.NetStack.CurrEstab != 5 {
+		t.Fatalf("unexpected network stack: %+v", sample.NetStack)
+	}
+	assertClose(t, "TcpOutSegs", 100, sample.NetStack.Rates["TcpOutSegs"])
+	if _, ok := sample.NetStack.Rates["TcpInSegs"]; ok {
+		t.Errorf("unexpected counter: %v", sample.NetStack.Rates)
+	}
+}
+
+func TestParseProcessStat(t *testing.T) {
+	counters, err := parseProcessStat("42 (my (odd) cmd) S 1 42 42 0 -1 4194560 150 0 3 0 70 30 0 0 20 0 4 0 1234 10000000 256 18446744073709551615\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counters.command != "my (odd) cmd" {
+		t.Errorf("unexpected command: %s", counters.command)
+	}
+	if counters.minorFaults != 150 || counters.majorFaults != 3 || counters.userTicks != 70 || counters.systemTicks != 30 {
+		t.Errorf("unexpected counters: %+v", counters)
+	}
+	if counters.threads != 4 || counters.startTime != 1234 || counters.rssPages != 256 {
+		t.Errorf("unexpected counters: %+v", counters)
+	}
+	if _, err := parseProcessStat("42 cmd S 1"); err == nil {
+		t.Error("expected error for malformed stat")
+	}
+}
+
+func TestProcessSamples(t *testing.T) {
+	root := t.TempDir()
+	c := &collector{root: root, sources: []string{SourceProcess}, top: 1}
+	writeProcess := func(pid, command string, startTime, userTicks, systemTicks, readBytes int) {
+		writeFile(t, root, "proc/"+pid+"/stat", pid+" ("+command+") S 1 1 1 0 -1 0 10 0 1 0 "+
+			strconv.Itoa(userTicks)+" "+strconv.Itoa(systemTicks)+" 0 0 20 0 2 0 "+strconv.Itoa(startTime)+" 0 100\n")
+		writeFile(t, root, "proc/"+pid+"/status", "Name:\t"+command+"\nvoluntary_ctxt_switches:\t10\nnonvoluntary_ctxt_switches:\t2\n")
+		writeFile(t, root, "proc/"+pid+"/io", "read_bytes: "+strconv.Itoa(readBytes)+"\nwrite_bytes: 0\n")
+	}
+	writeProcess("1", "init", 1, 0, 0, 0)
+	writeProcess("10", "java", 5, 100, 50, 0)
+	writeProcess("20", "redis-server", 6, 100, 0, 0)
+	previous, err := c.snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeProcess("10", "java", 5, 200, 100, 2048)
+	writeProcess("20", "redis-server", 6, 150, 0, 0)
+	current, err := c.snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	current.time = previous.time.Add(time.Second)
+
+	// the busiest process
+	samples := c.processSamples(previous.process, current.process, 1)
+	if len(samples) != 1 || samples[0].Command != "java" {
+		t.Fatalf("unexpected samples: %+v", samples)
+	}
+	assertClose(t, "%usr", 100, samples[0].User)
+	assertClose(t, "%system", 50, samples[0].System)
+	assertClose(t, "%CPU", 150, samples[0].CPU)
+	assertClose(t, "kB_rd/s", 2, samples[0].ReadKBps)
+	if samples[0].Threads != 2 || samples[0].RSS != 100*uint64(os.Getpagesize()/1024) {
+		t.Errorf("unexpected sample: %+v", samples[0])
+	}
+	// idle processes aren't among the top processes
+	c.top = 10
+	if samples = c.processSamples(previous.process, current.process, 1); len(samples) != 2 {
+		t.Errorf("unexpected samples: %+v", samples)
+	}
+	// selected by name, including idle processes
+	c.names = []string{"redis*", "ini?"}
+	samples = c.processSamples(previous.process, current.process, 1)
+	if len(samples) != 2 || samples[0].Command != "redis-server" || samples[1].PID != 1 {
+		t.Errorf("unexpected samples: %+v", samples)
+	}
+	// a new process with a reused pid
+	writeProcess("10", "java", 7, 200, 100, 0)
+	current, err = c.snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.names, c.pids = nil, []int{10}
+	if samples = c.processSamples(previous.process, current.process, 1); len(samples) != 0 {
+		t.Errorf("unexpected samples: %+v", samples)
+	}
+}
+
+func TestPressureAndSched(t *testing.T) {
+	root := t.TempDir()
+	c := &collector{root: root, sources: []string{SourcePressure, SourceSched}, cgroups: []string{"system.slice", "missing.slice"}}
+	writeSnapshot := func(memoryTotal, ioTotal, ctxt int) snapshot {
+		memory := "some avg10=0.00 avg60=0.00 avg300=0.00 total=" + strconv.Itoa(memoryTotal) + "\nfull avg10=0.00 avg60=0.00 avg300=0.00 total=" + strconv.Itoa(memoryTotal/2) + "\n"
+		writeFile(t, root, "proc/pressure/memory", memory)
+		writeFile(t, root, "proc/pressure/io", "some avg10=0.00 avg60=0.00 avg300=0.00 total="+strconv.Itoa(ioTotal)+"\n")
+		writeFile(t, root, "sys/fs/cgroup/system.slice/memory.pressure", memory)
+		writeFile(t, root, "proc/stat", "cpu  1 2 3\nctxt "+strconv.Itoa(ctxt)+"\nprocesses 10\nprocs_running 3\nprocs_blocked 1\n")
+		s, err := c.snapshot()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	previous := writeSnapshot(1000000, 0, 100)
+	current := writeSnapshot(1500000, 4000000, 300)
+	current.time = previous.time.Add(2 * time.Second)
+
+	sample := c.sample(previous, current)
+	// cpu pressure isn't available, io is capped at 100%
+	if sample.Pressure == nil {
+		t.Fatal("expected pressure sample")
+	}
+	assertClose(t, "memory some", 25, sample.Pressure.MemorySome)
+	assertClose(t, "memory full", 12.5, sample.Pressure.MemoryFull)
+	assertClose(t, "io some", 100, sample.Pressure.IOSome)
+	assertClose(t, "cpu some", 0, sample.Pressure.CPUSome)
+	if len(sample.Cgroups) != 1 || sample.Cgroups[0].Cgroup != "system.slice" {
+		t.Fatalf("unexpected cgroups: %+v", sample.Cgroups)
+	}
+	assertClose(t, "cgroup memory some", 25, sample.Cgroups[0].MemorySome)
+	if sample.Sched == nil || sample.Sched.RunQueue != 3 || sample.Sched.Blocked != 1 {
+		t.Fatalf("unexpected sched: %+v", sample.Sched)
+	}
+	assertClose(t, "cswch/s", 100, sample.Sched.CSwchps)
+	assertClose(t, "forks/s", 0, sample.Sched.Forksps)
+}
+
+func TestParseSNMP(t *testing.T) {
+	values := make(map[string]uint64)
+	err := parseSNMP(strings.NewReader(`Ip: Forwarding DefaultTTL
+Ip: 1 64
+Tcp: RtoAlgorithm MaxConn RetransSegs CurrEstab
+Tcp: 1 -1 42 7
+TcpExt: ListenOverflows ListenDrops
+TcpExt: 3 4
+`), values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["TcpRetransSegs"] != 42 || values["TcpCurrEstab"] != 7 || values["TcpMaxConn"] != 0 {
+		t.Errorf("unexpected tcp values: %v", values)
+	}
+	if values["TcpExtListenDrops"] != 4 || values["IpDefaultTTL"] != 64 {
+		t.Errorf("unexpected values: %v", values)
+	}
+}
+
+func TestRotatingWriter(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Unix(1700000000, 0)
+	rw := &rotatingWriter{prefix: filepath.Join(dir, "telemetry"), period: time.Minute, now: func() time.Time { return now }}
+	for i := range 4 {
+		if _, err := rw.Write([]byte(strconv.Itoa(i) + "\n")); err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(30 * time.Second)
+	}
+	if err := rw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for name, expected := range map[string]string{"telemetry.1700000000.jsonl": "0\n1\n", "telemetry.1700000060.jsonl": "2\n3\n"} {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != expected {
+			t.Errorf("%s: expected %q, got %q", name, expected, string(content))
+		}
+	}
+}
+
+func TestRotatingWriterKeep(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Unix(1700000000, 0)
+	rw := &rotatingWriter{prefix: filepath.Join(dir, "telemetry"), period: time.Minute, keep: 2, now: func() time.Time { return now }}
+	for i := range 4 {
+		if _, err := rw.Write([]byte(strconv.Itoa(i) + "\n")); err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(time.Minute)
+	}
+	if err := rw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if !slices.Equal(names, []string{"telemetry.1700000120.jsonl", "telemetry.1700000180.jsonl"}) {
+		t.Errorf("unexpected files: %v", names)
+	}
+}